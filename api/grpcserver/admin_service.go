@@ -2,12 +2,17 @@ package grpcserver
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
+	"net/http"
 	"os"
+	"path/filepath"
+	"time"
 
 	"github.com/golang/protobuf/ptypes/empty"
+	"github.com/libp2p/go-libp2p/core/peer"
 	pb "github.com/spacemeshos/api/release/go/spacemesh/v1"
 	"github.com/spf13/afero"
 	"google.golang.org/grpc/codes"
@@ -18,7 +23,10 @@ import (
 	"github.com/spacemeshos/go-spacemesh/common/types"
 	"github.com/spacemeshos/go-spacemesh/events"
 	"github.com/spacemeshos/go-spacemesh/log"
+	"github.com/spacemeshos/go-spacemesh/p2p"
+	"github.com/spacemeshos/go-spacemesh/p2p/book"
 	"github.com/spacemeshos/go-spacemesh/sql"
+	"github.com/spacemeshos/go-spacemesh/sql/backup"
 )
 
 const (
@@ -26,19 +34,106 @@ const (
 	defaultNumAtxs = 4
 )
 
+// PeersPath is the path of the peer management endpoints on the json gateway.
+// They are served together with AdminService until the api defines rpcs for them:
+//   - GET PeersPath lists peers in the peer book;
+//   - POST PeersPath adds a peer with AddPeerRequest body;
+//   - DELETE PeersPath/{id} removes a peer;
+//   - POST and DELETE PeersPath/{id}/pin pin and unpin a peer;
+//   - POST and DELETE PeersPath/{id}/ban ban and unban a peer. Ban is permanent
+//     unless the "duration" query parameter is set.
+const PeersPath = "/v1/admin/peers"
+
+// PeerResponse is a record about a peer served on PeersPath.
+type PeerResponse struct {
+	book.Peer
+	Reachability float64 `json:"reachability"`
+}
+
+// AddPeerRequest is the body of the request to add a peer.
+type AddPeerRequest struct {
+	// Address is a multiaddr of the peer that includes peer id.
+	Address string `json:"address"`
+	Pin     bool   `json:"pin"`
+}
+
+// BackupPath is the path of the database backup endpoint on the json gateway.
+// It is served together with AdminService until the api defines an rpc for it.
+// POST BackupPath writes a backup into the "backups" directory under the data directory,
+// and serves BackupResponse. Name of the backup is set with BackupRequest body,
+// it defaults to the current time.
+const BackupPath = "/v1/admin/backup"
+
+// backupsDir is the directory for backups under the data directory.
+const backupsDir = "backups"
+
+// BackupRequest is the body of the request to create a backup.
+type BackupRequest struct {
+	Name string `json:"name"`
+}
+
+// BackupResponse is served on BackupPath.
+type BackupResponse struct {
+	// Dir is the directory with the backup.
+	Dir      string          `json:"dir"`
+	Manifest backup.Manifest `json:"manifest"`
+}
+
+// MempoolPath is the path of the mempool inspection endpoint on the json gateway.
+// It is served together with AdminService until the api defines an rpc for it.
+// GET MempoolPath serves MempoolResponse, optionally filtered by the "principal" query parameter.
+const MempoolPath = "/v1/admin/mempool"
+
+// MempoolResponse is served on MempoolPath.
+type MempoolResponse struct {
+	// Transactions in the order they would be selected into a proposal.
+	Transactions []PendingTXResponse `json:"transactions"`
+	// Projections of the principals of the transactions.
+	Projections map[string]ProjectionResponse `json:"projections"`
+}
+
+// PendingTXResponse is a transaction in the mempool.
+type PendingTXResponse struct {
+	ID        string    `json:"id"`
+	Principal string    `json:"principal"`
+	Nonce     uint64    `json:"nonce"`
+	MaxGas    uint64    `json:"max_gas"`
+	GasPrice  uint64    `json:"gas_price"`
+	MaxSpend  uint64    `json:"max_spend"`
+	Size      int       `json:"size"`
+	Received  time.Time `json:"received"`
+	Position  int       `json:"position"`
+}
+
+// ProjectionResponse is the nonce and balance of the account after applying pending transactions.
+type ProjectionResponse struct {
+	Nonce   uint64 `json:"nonce"`
+	Balance uint64 `json:"balance"`
+}
+
 // AdminService exposes endpoints for node administration.
 type AdminService struct {
 	logger  log.Logger
 	db      *sql.Database
 	dataDir string
+	peers   peerManager
+	mempool mempoolInspector
 }
 
 // NewAdminService creates a new admin grpc service.
-func NewAdminService(db *sql.Database, dataDir string, lg log.Logger) *AdminService {
+func NewAdminService(
+	db *sql.Database,
+	dataDir string,
+	peers peerManager,
+	mempool mempoolInspector,
+	lg log.Logger,
+) *AdminService {
 	return &AdminService{
 		logger:  lg,
 		db:      db,
 		dataDir: dataDir,
+		peers:   peers,
+		mempool: mempool,
 	}
 }
 
@@ -128,3 +223,187 @@ func (a AdminService) EventsStream(req *pb.EventStreamRequest, stream pb.AdminSe
 		}
 	}
 }
+
+func (a AdminService) peerError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, book.ErrNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, p2p.ErrPeerBanned):
+		http.Error(w, err.Error(), http.StatusConflict)
+	default:
+		a.logger.With().Warning("peer management request failed", log.Err(err))
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+func parsePeerID(w http.ResponseWriter, params map[string]string) (peer.ID, bool) {
+	pid, err := peer.Decode(params["id"])
+	if err != nil {
+		http.Error(w, fmt.Sprintf("invalid peer id %q", params["id"]), http.StatusBadRequest)
+		return "", false
+	}
+	return pid, true
+}
+
+// ListPeers serves records of the peers in the peer book, the most recently seen first.
+func (a AdminService) ListPeers(w http.ResponseWriter, _ *http.Request, _ map[string]string) {
+	peers, err := a.peers.Peers()
+	if err != nil {
+		a.peerError(w, err)
+		return
+	}
+	rst := make([]PeerResponse, 0, len(peers))
+	for _, p := range peers {
+		rst = append(rst, PeerResponse{Peer: p, Reachability: p.Reachability()})
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(rst); err != nil {
+		a.logger.With().Warning("failed to write peers", log.Err(err))
+	}
+}
+
+// AddPeer adds a peer to the peer book and connects to it.
+func (a AdminService) AddPeer(w http.ResponseWriter, r *http.Request, _ map[string]string) {
+	var req AddPeerRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, fmt.Sprintf("invalid request: %s", err), http.StatusBadRequest)
+		return
+	}
+	info, err := peer.AddrInfoFromString(req.Address)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("invalid address %q: %s", req.Address, err), http.StatusBadRequest)
+		return
+	}
+	if err := a.peers.AddPeer(r.Context(), *info, req.Pin); err != nil {
+		a.peerError(w, err)
+	}
+}
+
+// RemovePeer removes a peer from the peer book, including its pin and ban.
+func (a AdminService) RemovePeer(w http.ResponseWriter, _ *http.Request, params map[string]string) {
+	if pid, ok := parsePeerID(w, params); ok {
+		if err := a.peers.RemovePeer(pid); err != nil {
+			a.peerError(w, err)
+		}
+	}
+}
+
+// PinPeer pins a peer.
+func (a AdminService) PinPeer(w http.ResponseWriter, _ *http.Request, params map[string]string) {
+	if pid, ok := parsePeerID(w, params); ok {
+		if err := a.peers.PinPeer(pid, true); err != nil {
+			a.peerError(w, err)
+		}
+	}
+}
+
+// UnpinPeer unpins a peer.
+func (a AdminService) UnpinPeer(w http.ResponseWriter, _ *http.Request, params map[string]string) {
+	if pid, ok := parsePeerID(w, params); ok {
+		if err := a.peers.PinPeer(pid, false); err != nil {
+			a.peerError(w, err)
+		}
+	}
+}
+
+// BanPeer bans a peer for the duration set with the "duration" query parameter, or permanently.
+func (a AdminService) BanPeer(w http.ResponseWriter, r *http.Request, params map[string]string) {
+	pid, ok := parsePeerID(w, params)
+	if !ok {
+		return
+	}
+	var duration time.Duration
+	if value := r.URL.Query().Get("duration"); value != "" {
+		parsed, err := time.ParseDuration(value)
+		if err != nil || parsed < 0 {
+			http.Error(w, fmt.Sprintf("invalid duration %q", value), http.StatusBadRequest)
+			return
+		}
+		duration = parsed
+	}
+	if err := a.peers.BanPeer(pid, duration); err != nil {
+		a.peerError(w, err)
+	}
+}
+
+// UnbanPeer lifts the ban from a peer.
+func (a AdminService) UnbanPeer(w http.ResponseWriter, _ *http.Request, params map[string]string) {
+	if pid, ok := parsePeerID(w, params); ok {
+		if err := a.peers.UnbanPeer(pid); err != nil {
+			a.peerError(w, err)
+		}
+	}
+}
+
+// Mempool serves transactions in the mempool in the order they would be selected into a proposal.
+func (a AdminService) Mempool(w http.ResponseWriter, r *http.Request, _ map[string]string) {
+	var principal types.Address
+	if value := r.URL.Query().Get("principal"); value != "" {
+		addr, err := types.StringToAddress(value)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("invalid principal %q: %s", value, err), http.StatusBadRequest)
+			return
+		}
+		principal = addr
+	}
+	pending, projections := a.mempool.GetPendingTXs(principal)
+	rst := MempoolResponse{
+		Transactions: make([]PendingTXResponse, 0, len(pending)),
+		Projections:  make(map[string]ProjectionResponse, len(projections)),
+	}
+	for _, ptx := range pending {
+		rst.Transactions = append(rst.Transactions, PendingTXResponse{
+			ID:        ptx.ID.String(),
+			Principal: ptx.Principal.String(),
+			Nonce:     ptx.Nonce,
+			MaxGas:    ptx.MaxGas,
+			GasPrice:  ptx.GasPrice,
+			MaxSpend:  ptx.MaxSpend,
+			Size:      ptx.Size,
+			Received:  ptx.Received,
+			Position:  ptx.Position,
+		})
+	}
+	for addr, projection := range projections {
+		rst.Projections[addr.String()] = ProjectionResponse{Nonce: projection.Nonce, Balance: projection.Balance}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(rst); err != nil {
+		a.logger.With().Warning("failed to write mempool", log.Err(err))
+	}
+}
+
+// Backup writes a consistent copy of the database and its manifest, see BackupPath.
+func (a AdminService) Backup(w http.ResponseWriter, r *http.Request, _ map[string]string) {
+	var req BackupRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		http.Error(w, fmt.Sprintf("invalid request: %s", err), http.StatusBadRequest)
+		return
+	}
+	if req.Name == "" {
+		req.Name = time.Now().UTC().Format("20060102T150405Z")
+	}
+	if req.Name != filepath.Base(req.Name) || req.Name == "." || req.Name == ".." {
+		http.Error(w, fmt.Sprintf("invalid name %q", req.Name), http.StatusBadRequest)
+		return
+	}
+	dir := filepath.Join(a.dataDir, backupsDir, req.Name)
+	if _, err := os.Stat(dir); err == nil {
+		http.Error(w, fmt.Sprintf("backup %s already exists", req.Name), http.StatusConflict)
+		return
+	}
+	manifest, err := backup.Backup(r.Context(), a.db, dir)
+	if err != nil {
+		a.logger.With().Warning("failed to backup database", log.String("dir", dir), log.Err(err))
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	a.logger.With().Info("database backup created",
+		log.String("dir", dir),
+		log.Stringer("layer", manifest.Layer),
+	)
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(BackupResponse{Dir: dir, Manifest: *manifest}); err != nil {
+		a.logger.With().Warning("failed to write backup response", log.Err(err))
+	}
+}
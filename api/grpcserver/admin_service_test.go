@@ -2,19 +2,32 @@ package grpcserver
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
 	"io"
+	"net/http"
+	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/golang/mock/gomock"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/libp2p/go-libp2p/core/test"
 	pb "github.com/spacemeshos/api/release/go/spacemesh/v1"
 	"github.com/stretchr/testify/require"
 
 	"github.com/spacemeshos/go-spacemesh/common/types"
 	"github.com/spacemeshos/go-spacemesh/log/logtest"
+	"github.com/spacemeshos/go-spacemesh/p2p"
+	"github.com/spacemeshos/go-spacemesh/p2p/book"
 	"github.com/spacemeshos/go-spacemesh/sql"
 	"github.com/spacemeshos/go-spacemesh/sql/accounts"
 	"github.com/spacemeshos/go-spacemesh/sql/atxs"
+	"github.com/spacemeshos/go-spacemesh/sql/backup"
+	"github.com/spacemeshos/go-spacemesh/sql/layers"
+	"github.com/spacemeshos/go-spacemesh/txs"
 )
 
 const snapshot uint32 = 15
@@ -56,7 +69,7 @@ func createMesh(tb testing.TB, db *sql.Database) {
 func TestAdminService_Checkpoint(t *testing.T) {
 	db := sql.InMemory()
 	createMesh(t, db)
-	svc := NewAdminService(db, t.TempDir(), logtest.New(t))
+	svc := NewAdminService(db, t.TempDir(), nil, nil, logtest.New(t))
 	t.Cleanup(launchServer(t, cfg, svc))
 
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
@@ -91,7 +104,7 @@ func TestAdminService_Checkpoint(t *testing.T) {
 
 func TestAdminService_CheckpointError(t *testing.T) {
 	db := sql.InMemory()
-	svc := NewAdminService(db, t.TempDir(), logtest.New(t))
+	svc := NewAdminService(db, t.TempDir(), nil, nil, logtest.New(t))
 	t.Cleanup(launchServer(t, cfg, svc))
 
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
@@ -104,3 +117,152 @@ func TestAdminService_CheckpointError(t *testing.T) {
 	_, err = stream.Recv()
 	require.ErrorContains(t, err, sql.ErrNotFound.Error())
 }
+
+func TestAdminService_Peers(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	peers := NewMockpeerManager(ctrl)
+	svc := NewAdminService(sql.InMemory(), t.TempDir(), peers, nil, logtest.New(t))
+	t.Cleanup(launchServer(t, cfg, svc))
+
+	pid := test.RandPeerIDFatal(t)
+	send := func(method, path, body string) *http.Response {
+		req, err := http.NewRequest(method, fmt.Sprintf("http://%s%s", cfg.JSONListener, path), strings.NewReader(body))
+		require.NoError(t, err)
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		t.Cleanup(func() { resp.Body.Close() })
+		return resp
+	}
+	peerPath := PeersPath + "/" + pid.String()
+
+	t.Run("list", func(t *testing.T) {
+		record := book.Peer{ID: pid.String(), Address: "/ip4/1.1.1.1/tcp/7513/p2p/" + pid.String(), Dials: 4, Outbound: 3}
+		peers.EXPECT().Peers().Return([]book.Peer{record}, nil)
+		resp := send(http.MethodGet, PeersPath, "")
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		var rst []PeerResponse
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&rst))
+		require.Equal(t, []PeerResponse{{Peer: record, Reachability: 0.75}}, rst)
+	})
+	t.Run("add", func(t *testing.T) {
+		address := "/ip4/1.1.1.1/tcp/7513/p2p/" + pid.String()
+		info, err := peer.AddrInfoFromString(address)
+		require.NoError(t, err)
+		peers.EXPECT().AddPeer(gomock.Any(), *info, true)
+		resp := send(http.MethodPost, PeersPath, fmt.Sprintf(`{"address": %q, "pin": true}`, address))
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		resp = send(http.MethodPost, PeersPath, `{"address": "/ip4/1.1.1.1/tcp/7513"}`)
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	})
+	t.Run("remove", func(t *testing.T) {
+		peers.EXPECT().RemovePeer(pid).Return(fmt.Errorf("%w: %s", book.ErrNotFound, pid))
+		resp := send(http.MethodDelete, peerPath, "")
+		require.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+		resp = send(http.MethodDelete, PeersPath+"/invalid", "")
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	})
+	t.Run("pin", func(t *testing.T) {
+		peers.EXPECT().PinPeer(pid, true).Return(p2p.ErrPeerBanned)
+		resp := send(http.MethodPost, peerPath+"/pin", "")
+		require.Equal(t, http.StatusConflict, resp.StatusCode)
+
+		peers.EXPECT().PinPeer(pid, false)
+		resp = send(http.MethodDelete, peerPath+"/pin", "")
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+	})
+	t.Run("ban", func(t *testing.T) {
+		peers.EXPECT().BanPeer(pid, time.Hour)
+		resp := send(http.MethodPost, peerPath+"/ban?duration=1h", "")
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		peers.EXPECT().BanPeer(pid, time.Duration(0))
+		resp = send(http.MethodPost, peerPath+"/ban", "")
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		resp = send(http.MethodPost, peerPath+"/ban?duration=x", "")
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+		peers.EXPECT().UnbanPeer(pid)
+		resp = send(http.MethodDelete, peerPath+"/ban", "")
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+	})
+}
+
+func TestAdminService_Mempool(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mempool := NewMockmempoolInspector(ctrl)
+	svc := NewAdminService(sql.InMemory(), t.TempDir(), nil, mempool, logtest.New(t))
+	t.Cleanup(launchServer(t, cfg, svc))
+
+	principal := types.GenerateAddress([]byte{1})
+	ptx := txs.PendingTX{
+		NanoTX: txs.NanoTX{
+			TxHeader: types.TxHeader{Principal: principal, Nonce: 2, MaxGas: 10, GasPrice: 1, MaxSpend: 100},
+			ID:       types.TransactionID{1},
+			Size:     120,
+			Received: time.Unix(100, 0).UTC(),
+		},
+		Position: 3,
+	}
+	mempool.EXPECT().GetPendingTXs(principal).Return(
+		[]txs.PendingTX{ptx},
+		map[types.Address]txs.Projection{principal: {Nonce: 3, Balance: 900}},
+	)
+	resp, err := http.Get(fmt.Sprintf("http://%s%s?principal=%s", cfg.JSONListener, MempoolPath, principal.String()))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var rst MempoolResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&rst))
+	require.Equal(t, MempoolResponse{
+		Transactions: []PendingTXResponse{{
+			ID:        ptx.ID.String(),
+			Principal: principal.String(),
+			Nonce:     2,
+			MaxGas:    10,
+			GasPrice:  1,
+			MaxSpend:  100,
+			Size:      120,
+			Received:  ptx.Received,
+			Position:  3,
+		}},
+		Projections: map[string]ProjectionResponse{principal.String(): {Nonce: 3, Balance: 900}},
+	}, rst)
+
+	resp, err = http.Get(fmt.Sprintf("http://%s%s?principal=invalid", cfg.JSONListener, MempoolPath))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
+
+func TestAdminService_Backup(t *testing.T) {
+	db, err := sql.Open("file:" + filepath.Join(t.TempDir(), "state.sql"))
+	require.NoError(t, err)
+	t.Cleanup(func() { require.NoError(t, db.Close()) })
+	lid := types.LayerID(10)
+	require.NoError(t, layers.SetApplied(db, lid, types.BlockID{1}))
+	dataDir := t.TempDir()
+	svc := NewAdminService(db, dataDir, nil, nil, logtest.New(t))
+	t.Cleanup(launchServer(t, cfg, svc))
+
+	send := func(body string) *http.Response {
+		resp, err := http.Post(fmt.Sprintf("http://%s%s", cfg.JSONListener, BackupPath), "application/json",
+			strings.NewReader(body))
+		require.NoError(t, err)
+		t.Cleanup(func() { resp.Body.Close() })
+		return resp
+	}
+	resp := send(`{"name": "first"}`)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var rst BackupResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&rst))
+	require.Equal(t, filepath.Join(dataDir, backupsDir, "first"), rst.Dir)
+	require.Equal(t, lid, rst.Manifest.Layer)
+	require.FileExists(t, filepath.Join(rst.Dir, backup.DatabaseFile))
+
+	require.Equal(t, http.StatusConflict, send(`{"name": "first"}`).StatusCode)
+	require.Equal(t, http.StatusBadRequest, send(`{"name": "../first"}`).StatusCode)
+	require.Equal(t, http.StatusOK, send("").StatusCode, "name defaults to the current time")
+}
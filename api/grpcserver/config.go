@@ -13,6 +13,8 @@ type Config struct {
 	GrpcSendMsgSize int       `mapstructure:"grpc-send-msg-size"`
 	GrpcRecvMsgSize int       `mapstructure:"grpc-recv-msg-size"`
 	JSONListener    string    `mapstructure:"grpc-json-listener"`
+	// PrivateJSONListener serves the json gateway for private services.
+	PrivateJSONListener string `mapstructure:"grpc-private-json-listener"`
 
 	SmesherStreamInterval time.Duration
 }
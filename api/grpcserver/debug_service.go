@@ -2,7 +2,11 @@ package grpcserver
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"net/http"
+	"path"
+	"strconv"
 
 	"github.com/golang/protobuf/ptypes/empty"
 	pb "github.com/spacemeshos/api/release/go/spacemesh/v1"
@@ -14,10 +18,30 @@ import (
 	"github.com/spacemeshos/go-spacemesh/common/types"
 	"github.com/spacemeshos/go-spacemesh/events"
 	"github.com/spacemeshos/go-spacemesh/log"
+	"github.com/spacemeshos/go-spacemesh/p2p/pubsub"
 	"github.com/spacemeshos/go-spacemesh/sql"
 	"github.com/spacemeshos/go-spacemesh/sql/accounts"
 )
 
+// SlowQueriesPath is the path of the slow query log on the json gateway.
+// It is served together with DebugService until the api defines an rpc for it.
+const SlowQueriesPath = "/v1/debug/slowqueries"
+
+// SlowQueriesResponse is served on SlowQueriesPath.
+type SlowQueriesResponse struct {
+	// Recent slow queries, newest first.
+	Recent []sql.SlowQuery `json:"recent"`
+	// Top queries by total duration of slow executions, with query plans.
+	Top []sql.QueryStats `json:"top"`
+}
+
+// GossipTracesPath is the path of the stream of gossip message traces on the json gateway.
+// It is served together with DebugService until the api defines an rpc for it.
+const GossipTracesPath = "/v1/debug/gossiptraces"
+
+// gossipTracesBuffer is the number of traces buffered for a slow client before they are dropped.
+const gossipTracesBuffer = 1024
+
 // DebugService exposes global state data, output from the STF.
 type DebugService struct {
 	db       *sql.Database
@@ -25,6 +49,7 @@ type DebugService struct {
 	conState conservativeState
 	identity networkIdentity
 	oracle   oracle
+	tracer   *pubsub.Tracer
 }
 
 // RegisterService registers this service with a grpc server instance.
@@ -33,13 +58,22 @@ func (d DebugService) RegisterService(server *Server) {
 }
 
 // NewDebugService creates a new grpc service using config data.
-func NewDebugService(db *sql.Database, conState conservativeState, host networkIdentity, oracle oracle, lg log.Logger) *DebugService {
+// Tracer is optional, gossip traces are not served if it is nil.
+func NewDebugService(
+	db *sql.Database,
+	conState conservativeState,
+	host networkIdentity,
+	oracle oracle,
+	tracer *pubsub.Tracer,
+	lg log.Logger,
+) *DebugService {
 	return &DebugService{
 		db:       db,
 		logger:   lg,
 		conState: conState,
 		identity: host,
 		oracle:   oracle,
+		tracer:   tracer,
 	}
 }
 
@@ -149,3 +183,67 @@ func castEventProposal(ev *events.EventProposal) *pb.Proposal {
 	}
 	return proposal
 }
+
+// SlowQueries serves the slow query log of the database.
+// Number of top queries is set with the "top" query parameter, 10 by default.
+func (d DebugService) SlowQueries(w http.ResponseWriter, r *http.Request, _ map[string]string) {
+	top := 10
+	if value := r.URL.Query().Get("top"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 0 {
+			http.Error(w, fmt.Sprintf("invalid top %q", value), http.StatusBadRequest)
+			return
+		}
+		top = parsed
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(SlowQueriesResponse{
+		Recent: d.db.SlowQueries(),
+		Top:    d.db.TopSlowQueries(top),
+	}); err != nil {
+		d.logger.With().Warning("failed to write slow queries", log.Err(err))
+	}
+}
+
+// GossipTraces streams traces of gossip messages as JSON lines until the client disconnects.
+// Topics are filtered with the optional "topic" query parameter, a pattern as understood by path.Match.
+func (d DebugService) GossipTraces(w http.ResponseWriter, r *http.Request, _ map[string]string) {
+	if d.tracer == nil {
+		http.Error(w, "gossip tracing is disabled", http.StatusNotFound)
+		return
+	}
+	topic := r.URL.Query().Get("topic")
+	if _, err := path.Match(topic, ""); err != nil {
+		http.Error(w, fmt.Sprintf("invalid topic %q", topic), http.StatusBadRequest)
+		return
+	}
+	traces, unsubscribe := d.tracer.Subscribe(gossipTracesBuffer)
+	defer unsubscribe()
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.WriteHeader(http.StatusOK)
+	flusher, _ := w.(http.Flusher)
+	if flusher != nil {
+		flusher.Flush()
+	}
+	enc := json.NewEncoder(w)
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case trace, ok := <-traces:
+			if !ok {
+				return
+			}
+			if matched, _ := path.Match(topic, trace.Topic); topic != "" && !matched {
+				continue
+			}
+			if err := enc.Encode(trace); err != nil {
+				d.logger.With().Debug("failed to write gossip trace", log.Err(err))
+				return
+			}
+			if flusher != nil {
+				flusher.Flush()
+			}
+		}
+	}
+}
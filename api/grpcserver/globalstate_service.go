@@ -2,7 +2,12 @@ package grpcserver
 
 import (
 	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"net/http"
+	"strconv"
 
 	pb "github.com/spacemeshos/api/release/go/spacemesh/v1"
 	"google.golang.org/grpc/codes"
@@ -12,8 +17,67 @@ import (
 	"github.com/spacemeshos/go-spacemesh/common/types"
 	"github.com/spacemeshos/go-spacemesh/events"
 	"github.com/spacemeshos/go-spacemesh/log"
+	"github.com/spacemeshos/go-spacemesh/sql"
 )
 
+// AccountsPath is the path of the account state endpoints on the json gateway.
+// They are served together with GlobalStateService until the api defines rpcs for them:
+//   - GET AccountsPath/{address} serves AccountStateResponse with the state that was valid
+//     at the layer set by the "layer" query parameter, the latest state if it is not set;
+//   - GET AccountsPath/{address}/history serves AccountHistoryResponse with states updated
+//     at the layer set by the "from" query parameter or later, up to "limit" states per page;
+//   - GET AccountsPath/{address}/proof serves AccountProofResponse with the proof that the state
+//     is the latest state of the account at the layer set by the "layer" query parameter,
+//     the latest layer with accounts root if it is not set.
+const AccountsPath = "/v1/globalstate/accounts"
+
+const (
+	defaultPageLimit = 100
+	maxPageLimit     = 1000
+)
+
+// AccountStateResponse is the state of the account, updated at the layer.
+type AccountStateResponse struct {
+	Address   string `json:"address"`
+	Layer     uint32 `json:"layer"`
+	Balance   uint64 `json:"balance"`
+	NextNonce uint64 `json:"next_nonce"`
+	Template  string `json:"template,omitempty"`
+	// State is the hex encoded state of the template.
+	State string `json:"state,omitempty"`
+}
+
+// AccountHistoryResponse is a page of the account states served on AccountsPath/{address}/history.
+type AccountHistoryResponse struct {
+	States []AccountStateResponse `json:"states"`
+	// Next is the "from" parameter for the next page, zero if there are no more states.
+	Next uint32 `json:"next,omitempty"`
+}
+
+// AccountProofResponse links the state of the account to the accounts root of the layer,
+// see vm.AccountProof. Root and nodes are hex encoded.
+type AccountProofResponse struct {
+	Layer   uint32               `json:"layer"`
+	Root    string               `json:"root"`
+	Account AccountStateResponse `json:"account"`
+	Index   uint64               `json:"index"`
+	Nodes   []string             `json:"nodes"`
+}
+
+func accountStateResponse(account *types.Account) AccountStateResponse {
+	resp := AccountStateResponse{
+		Address:   account.Address.String(),
+		Layer:     account.Layer.Uint32(),
+		Balance:   account.Balance,
+		NextNonce: account.NextNonce,
+		State:     hex.EncodeToString(account.State),
+	}
+	if account.TemplateAddress != nil {
+		resp.Template = account.TemplateAddress.String()
+	}
+	return resp
+}
+
 // GlobalStateService exposes global state data, output from the STF.
 type GlobalStateService struct {
 	logger   log.Logger
@@ -443,3 +507,134 @@ func (s GlobalStateService) GlobalStateStream(in *pb.GlobalStateStreamRequest, s
 		// See https://github.com/spacemeshos/go-spacemesh/issues/2075
 	}
 }
+
+func parseUint32(w http.ResponseWriter, r *http.Request, name string, dst *uint32) bool {
+	value := r.URL.Query().Get(name)
+	if value == "" {
+		return true
+	}
+	parsed, err := strconv.ParseUint(value, 10, 32)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("invalid %s %q", name, value), http.StatusBadRequest)
+		return false
+	}
+	*dst = uint32(parsed)
+	return true
+}
+
+func parseAddress(w http.ResponseWriter, params map[string]string) (types.Address, bool) {
+	addr, err := types.StringToAddress(params["address"])
+	if err != nil {
+		http.Error(w, fmt.Sprintf("invalid address %q: %s", params["address"], err), http.StatusBadRequest)
+		return addr, false
+	}
+	return addr, true
+}
+
+// parseLimit parses the "limit" query parameter of a paged request.
+func parseLimit(w http.ResponseWriter, r *http.Request, dst *uint32) bool {
+	*dst = defaultPageLimit
+	if !parseUint32(w, r, "limit", dst) {
+		return false
+	}
+	if *dst == 0 || *dst > maxPageLimit {
+		http.Error(w, fmt.Sprintf("limit must be in range [1, %d]", maxPageLimit), http.StatusBadRequest)
+		return false
+	}
+	return true
+}
+
+// AccountState serves the state of the account at the layer, see AccountsPath.
+func (s GlobalStateService) AccountState(w http.ResponseWriter, r *http.Request, params map[string]string) {
+	addr, ok := parseAddress(w, params)
+	if !ok {
+		return
+	}
+	var lid uint32
+	if !parseUint32(w, r, "layer", &lid) {
+		return
+	}
+	account, err := s.conState.GetAccount(addr, types.LayerID(lid))
+	if err != nil {
+		s.logger.With().Warning("failed to load account", addr, log.Err(err))
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(accountStateResponse(&account)); err != nil {
+		s.logger.With().Warning("failed to write account state", log.Err(err))
+	}
+}
+
+// AccountHistory serves a page of the account states, see AccountsPath.
+func (s GlobalStateService) AccountHistory(w http.ResponseWriter, r *http.Request, params map[string]string) {
+	addr, ok := parseAddress(w, params)
+	if !ok {
+		return
+	}
+	var from, limit uint32
+	if !parseUint32(w, r, "from", &from) || !parseLimit(w, r, &limit) {
+		return
+	}
+	// one more state is requested to find out if there is a next page
+	history, err := s.conState.GetAccountHistory(addr, types.LayerID(from), int(limit)+1)
+	if err != nil {
+		s.logger.With().Warning("failed to load account history", addr, log.Err(err))
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	var rst AccountHistoryResponse
+	if len(history) > int(limit) {
+		rst.Next = history[limit].Layer.Uint32()
+		history = history[:limit]
+	}
+	rst.States = make([]AccountStateResponse, 0, len(history))
+	for _, account := range history {
+		rst.States = append(rst.States, accountStateResponse(account))
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(rst); err != nil {
+		s.logger.With().Warning("failed to write account history", log.Err(err))
+	}
+}
+
+// AccountProof serves the proof of the account state at the layer, see AccountsPath.
+func (s GlobalStateService) AccountProof(w http.ResponseWriter, r *http.Request, params map[string]string) {
+	addr, ok := parseAddress(w, params)
+	if !ok {
+		return
+	}
+	var lid uint32
+	if !parseUint32(w, r, "layer", &lid) {
+		return
+	}
+	proof, err := s.conState.GetAccountProof(addr, types.LayerID(lid))
+	if errors.Is(err, sql.ErrNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	} else if err != nil {
+		s.logger.With().Warning("failed to prove account state", addr, log.Err(err))
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	_, root, err := s.conState.GetAccountsRoot(proof.Layer)
+	if err != nil {
+		s.logger.With().Warning("failed to load accounts root", proof.Layer, log.Err(err))
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	rst := AccountProofResponse{
+		Layer:   proof.Layer.Uint32(),
+		Root:    root.Hex(),
+		Account: accountStateResponse(&proof.Account),
+		Index:   proof.Index,
+		Nodes:   make([]string, 0, len(proof.Nodes)),
+	}
+	for _, node := range proof.Nodes {
+		rst.Nodes = append(rst.Nodes, node.Hex())
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(rst); err != nil {
+		s.logger.With().Warning("failed to write account proof", log.Err(err))
+	}
+}
@@ -4,6 +4,8 @@ package grpcserver
 import (
 	"bytes"
 	"context"
+	"encoding/hex"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
@@ -24,6 +26,9 @@ import (
 	"github.com/golang/protobuf/jsonpb"
 	"github.com/golang/protobuf/proto"
 	"github.com/golang/protobuf/ptypes/empty"
+	lp2ppubsub "github.com/libp2p/go-libp2p-pubsub"
+	pubsubpb "github.com/libp2p/go-libp2p-pubsub/pb"
+	lp2ppeer "github.com/libp2p/go-libp2p/core/peer"
 	pb "github.com/spacemeshos/api/release/go/spacemesh/v1"
 	"github.com/spacemeshos/merkle-tree"
 	"github.com/spacemeshos/poet/shared"
@@ -33,6 +38,7 @@ import (
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/status"
 
 	"github.com/spacemeshos/go-spacemesh/activation"
@@ -45,6 +51,7 @@ import (
 	"github.com/spacemeshos/go-spacemesh/genvm/sdk/wallet"
 	"github.com/spacemeshos/go-spacemesh/log/logtest"
 	"github.com/spacemeshos/go-spacemesh/p2p"
+	"github.com/spacemeshos/go-spacemesh/p2p/pubsub"
 	pubsubmocks "github.com/spacemeshos/go-spacemesh/p2p/pubsub/mocks"
 	"github.com/spacemeshos/go-spacemesh/rand"
 	"github.com/spacemeshos/go-spacemesh/signing"
@@ -52,6 +59,7 @@ import (
 	"github.com/spacemeshos/go-spacemesh/sql/accounts"
 	"github.com/spacemeshos/go-spacemesh/sql/atxs"
 	"github.com/spacemeshos/go-spacemesh/sql/identities"
+	"github.com/spacemeshos/go-spacemesh/sql/layers"
 	"github.com/spacemeshos/go-spacemesh/system"
 	"github.com/spacemeshos/go-spacemesh/txs"
 )
@@ -411,6 +419,30 @@ func (t *ConStateAPIMock) Validation(raw types.RawTx) system.ValidationRequest {
 	panic("dont use this")
 }
 
+func (t *ConStateAPIMock) Simulate(types.LayerID, types.RawTx, bool) (*types.TransactionWithResult, error) {
+	panic("dont use this")
+}
+
+func (t *ConStateAPIMock) EstimateGas([]byte) (*vm.GasEstimate, error) {
+	panic("dont use this")
+}
+
+func (t *ConStateAPIMock) GetAccount(types.Address, types.LayerID) (types.Account, error) {
+	panic("dont use this")
+}
+
+func (t *ConStateAPIMock) GetAccountHistory(types.Address, types.LayerID, int) ([]*types.Account, error) {
+	panic("dont use this")
+}
+
+func (t *ConStateAPIMock) GetAccountsRoot(types.LayerID) (types.LayerID, types.Hash32, error) {
+	panic("dont use this")
+}
+
+func (t *ConStateAPIMock) GetAccountProof(types.Address, types.LayerID) (*vm.AccountProof, error) {
+	panic("dont use this")
+}
+
 func NewTx(nonce uint64, recipient types.Address, signer *signing.EdSigner) *types.Transaction {
 	tx := types.Transaction{TxHeader: &types.TxHeader{}}
 	tx.Principal = wallet.Address(signer.PublicKey().Bytes())
@@ -562,7 +594,7 @@ func TestNodeService(t *testing.T) {
 
 	version := "v0.0.0"
 	build := "cafebabe"
-	grpcService := NewNodeService(peerCounter, meshAPIMock, genTime, syncer, version, build, logtest.New(t).WithName("grpc.Node"))
+	grpcService := NewNodeService(peerCounter, meshAPIMock, genTime, syncer, version, build, func() (int, error) { return 4, nil }, logtest.New(t).WithName("grpc.Node"))
 	t.Cleanup(launchServer(t, cfg, grpcService))
 
 	conn := dialGrpc(ctx, t, cfg.PublicListener)
@@ -597,9 +629,11 @@ func TestNodeService(t *testing.T) {
 			require.Equal(t, "Must include `Msg`", grpcStatus.Message())
 		}},
 		{"Version", func(t *testing.T) {
-			res, err := c.Version(context.Background(), &empty.Empty{})
+			var header metadata.MD
+			res, err := c.Version(context.Background(), &empty.Empty{}, grpc.Header(&header))
 			require.NoError(t, err)
 			require.Equal(t, version, res.VersionString.Value)
+			require.Equal(t, []string{"4"}, header.Get(SchemaVersionHeader))
 		}},
 		{"Build", func(t *testing.T) {
 			res, err := c.Build(context.Background(), &empty.Empty{})
@@ -2311,7 +2345,7 @@ func TestMultiService(t *testing.T) {
 	genTime := NewMockgenesisTimeAPI(ctrl)
 	genesis := time.Unix(genTimeUnix, 0)
 	genTime.EXPECT().GenesisTime().Return(genesis)
-	svc1 := NewNodeService(peerCounter, meshAPIMock, genTime, syncer, "v0.0.0", "cafebabe", logtest.New(t).WithName("grpc.Node"))
+	svc1 := NewNodeService(peerCounter, meshAPIMock, genTime, syncer, "v0.0.0", "cafebabe", func() (int, error) { return 4, nil }, logtest.New(t).WithName("grpc.Node"))
 	svc2 := NewMeshService(datastore.NewCachedDB(sql.InMemory(), logtest.New(t)), meshAPIMock, conStateAPI, genTime, layersPerEpoch, types.Hash20{}, layerDuration, layerAvgSize, txsPerProposal, logtest.New(t).WithName("grpc.Mesh"))
 	shutDown := launchServer(t, cfg, svc1, svc2)
 	t.Cleanup(shutDown)
@@ -2366,7 +2400,7 @@ func TestJsonApi(t *testing.T) {
 	genTime := NewMockgenesisTimeAPI(ctrl)
 	genesis := time.Unix(genTimeUnix, 0)
 	genTime.EXPECT().GenesisTime().Return(genesis)
-	svc1 := NewNodeService(peerCounter, meshAPIMock, genTime, syncer, "v0.0.0", "cafebabe", logtest.New(t).WithName("grpc.Node"))
+	svc1 := NewNodeService(peerCounter, meshAPIMock, genTime, syncer, "v0.0.0", "cafebabe", func() (int, error) { return 4, nil }, logtest.New(t).WithName("grpc.Node"))
 	svc2 := NewMeshService(datastore.NewCachedDB(sql.InMemory(), logtest.New(t)), meshAPIMock, conStateAPI, genTime, layersPerEpoch, types.Hash20{}, layerDuration, layerAvgSize, txsPerProposal, logtest.New(t).WithName("grpc.Mesh"))
 	t.Cleanup(launchServer(t, cfg, svc1, svc2))
 	time.Sleep(time.Second)
@@ -2391,8 +2425,10 @@ func TestDebugService(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	identity := NewMocknetworkIdentity(ctrl)
 	mOracle := NewMockoracle(ctrl)
-	db := sql.InMemory()
-	svc := NewDebugService(db, conStateAPI, identity, mOracle, logtest.New(t).WithName("grpc.Debug"))
+	db := sql.InMemory(sql.WithSlowQueryLog(time.Nanosecond))
+	tracer, err := pubsub.NewTracer(logtest.New(t), t.TempDir(), pubsub.TraceConfig{Topics: []string{"ax1", "hr1"}})
+	require.NoError(t, err)
+	svc := NewDebugService(db, conStateAPI, identity, mOracle, tracer, logtest.New(t).WithName("grpc.Debug"))
 	t.Cleanup(launchServer(t, cfg, svc))
 
 	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
@@ -2487,6 +2523,52 @@ func TestDebugService(t *testing.T) {
 		require.NoError(t, err)
 		require.Equal(t, pb.Proposal_Included, msg.Status)
 	})
+	t.Run("SlowQueries", func(t *testing.T) {
+		url := fmt.Sprintf("http://%s%s?top=1", cfg.JSONListener, SlowQueriesPath)
+		resp, err := http.Get(url)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		var rst SlowQueriesResponse
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&rst))
+		require.NotEmpty(t, rst.Recent)
+		require.NotEmpty(t, rst.Recent[0].Query)
+		require.Len(t, rst.Top, 1)
+
+		resp, err = http.Get(fmt.Sprintf("http://%s%s?top=x", cfg.JSONListener, SlowQueriesPath))
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	})
+	t.Run("GossipTraces", func(t *testing.T) {
+		url := fmt.Sprintf("http://%s%s?topic=hr1", cfg.JSONListener, GossipTracesPath)
+		resp, err := http.Get(url)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		for _, topic := range []string{"ax1", "hr1"} {
+			tracer.ValidateMessage(&lp2ppubsub.Message{
+				Message:      &pubsubpb.Message{Topic: &topic, Data: []byte(topic)},
+				ID:           topic,
+				ReceivedFrom: "peer",
+			})
+		}
+		// publishes remaining traces and ends the stream
+		require.NoError(t, tracer.Close())
+		dec := json.NewDecoder(resp.Body)
+		var trace pubsub.MessageTrace
+		require.NoError(t, dec.Decode(&trace))
+		require.Equal(t, "hr1", trace.Topic)
+		require.Equal(t, hex.EncodeToString([]byte("hr1")), trace.ID)
+		require.Equal(t, lp2ppeer.ID("peer").String(), trace.Peer)
+		require.ErrorIs(t, dec.Decode(&trace), io.EOF)
+
+		resp, err = http.Get(fmt.Sprintf("http://%s%s?topic=[", cfg.JSONListener, GossipTracesPath))
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	})
 }
 
 func TestEventsReceived(t *testing.T) {
@@ -2766,3 +2848,188 @@ func TestMeshService_EpochStream(t *testing.T) {
 	}
 	require.ElementsMatch(t, expected, got)
 }
+
+func TestGlobalStateService_AccountHistory(t *testing.T) {
+	db := sql.InMemory()
+	svm := vm.New(db, vm.WithLogger(logtest.New(t)))
+	t.Cleanup(launchServer(t, cfg, NewGlobalStateService(nil, txs.NewConservativeState(svm, db), logtest.New(t).WithName("grpc.GlobalState"))))
+
+	address := types.GenerateAddress([]byte{1, 2, 3})
+	for i := 1; i <= 5; i++ {
+		require.NoError(t, accounts.Update(db, &types.Account{
+			Layer:     types.LayerID(uint32(i * 10)),
+			Address:   address,
+			NextNonce: uint64(i),
+			Balance:   uint64(i * 100),
+		}))
+	}
+	get := func(t *testing.T, path string, rst any) int {
+		resp, err := http.Get(fmt.Sprintf("http://%s%s", cfg.JSONListener, path))
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		if resp.StatusCode == http.StatusOK {
+			require.NoError(t, json.NewDecoder(resp.Body).Decode(rst))
+		}
+		return resp.StatusCode
+	}
+	t.Run("state", func(t *testing.T) {
+		var state AccountStateResponse
+		require.Equal(t, http.StatusOK, get(t, fmt.Sprintf("%s/%s?layer=35", AccountsPath, address.String()), &state))
+		require.Equal(t, AccountStateResponse{
+			Address:   address.String(),
+			Layer:     30,
+			Balance:   300,
+			NextNonce: 3,
+		}, state)
+		require.Equal(t, http.StatusOK, get(t, fmt.Sprintf("%s/%s", AccountsPath, address.String()), &state))
+		require.EqualValues(t, 50, state.Layer)
+		require.Equal(t, http.StatusBadRequest, get(t, AccountsPath+"/invalid", &state))
+	})
+	t.Run("history", func(t *testing.T) {
+		var (
+			layers []uint32
+			from   uint32 = 20
+		)
+		for {
+			var page AccountHistoryResponse
+			require.Equal(t, http.StatusOK,
+				get(t, fmt.Sprintf("%s/%s/history?from=%d&limit=2", AccountsPath, address.String(), from), &page))
+			require.LessOrEqual(t, len(page.States), 2)
+			for _, state := range page.States {
+				layers = append(layers, state.Layer)
+			}
+			if page.Next == 0 {
+				break
+			}
+			from = page.Next
+		}
+		require.Equal(t, []uint32{20, 30, 40, 50}, layers)
+
+		var page AccountHistoryResponse
+		for _, limit := range []int{0, maxPageLimit + 1} {
+			require.Equal(t, http.StatusBadRequest,
+				get(t, fmt.Sprintf("%s/%s/history?limit=%d", AccountsPath, address.String(), limit), &page))
+		}
+	})
+	t.Run("proof", func(t *testing.T) {
+		other := types.GenerateAddress([]byte{4, 5, 6})
+		require.NoError(t, accounts.Update(db, &types.Account{Layer: 40, Address: other, Balance: 1}))
+		var proof AccountProofResponse
+		require.Equal(t, http.StatusNotFound, get(t, fmt.Sprintf("%s/%s/proof", AccountsPath, address.String()), &proof))
+
+		require.NoError(t, layers.UpdateStateHash(db, types.LayerID(40), types.Hash32{40}))
+		root, err := svm.ComputeAccountsRoot(types.LayerID(40))
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, get(t, fmt.Sprintf("%s/%s/proof", AccountsPath, address.String()), &proof))
+		require.EqualValues(t, 40, proof.Layer)
+		require.Equal(t, root.Hex(), proof.Root)
+		require.EqualValues(t, 40, proof.Account.Layer)
+		require.EqualValues(t, 400, proof.Account.Balance)
+
+		parsed := vm.AccountProof{
+			Layer: types.LayerID(proof.Layer),
+			Account: types.Account{
+				Layer:     types.LayerID(proof.Account.Layer),
+				Address:   address,
+				NextNonce: proof.Account.NextNonce,
+				Balance:   proof.Account.Balance,
+			},
+			Index: proof.Index,
+		}
+		for _, node := range proof.Nodes {
+			parsed.Nodes = append(parsed.Nodes, types.HexToHash32(node))
+		}
+		require.NoError(t, parsed.Verify(root))
+
+		require.Equal(t, http.StatusNotFound,
+			get(t, fmt.Sprintf("%s/%s/proof", AccountsPath, types.GenerateAddress([]byte{7}).String()), &proof))
+		require.Equal(t, http.StatusNotFound,
+			get(t, fmt.Sprintf("%s/%s/proof?layer=30", AccountsPath, address.String()), &proof))
+	})
+}
+
+func TestMeshService_Index(t *testing.T) {
+	db := sql.InMemory()
+	svc := NewMeshService(datastore.NewCachedDB(db, logtest.New(t)), nil, nil, nil, layersPerEpoch, types.Hash20{}, layerDuration, layerAvgSize, txsPerProposal, logtest.New(t).WithName("grpc.Mesh"))
+	t.Cleanup(launchServer(t, cfg, svc))
+
+	var (
+		principal = types.GenerateAddress([]byte{1})
+		spawned   = types.GenerateAddress([]byte{2})
+		smesher   = types.RandomNodeID()
+		tids      = []types.TransactionID{{1}, {2}, {3}}
+	)
+	exec := func(query string, args ...any) {
+		_, err := db.Exec(query, func(stmt *sql.Statement) {
+			for i, arg := range args {
+				switch typed := arg.(type) {
+				case []byte:
+					stmt.BindBytes(i+1, typed)
+				case int:
+					stmt.BindInt64(i+1, int64(typed))
+				}
+			}
+		}, nil)
+		require.NoError(t, err)
+	}
+	exec("insert into indexer_layers (layer, block) values (?1, ?2)", 30, types.EmptyBlockID[:])
+	for i, tid := range tids {
+		exec("insert into indexer_activity (address, layer, tid) values (?1, ?2, ?3)", principal[:], (i+1)*10, tid[:])
+	}
+	exec("insert into indexer_spawns (account, spawner, tid, layer) values (?1, ?1, ?2, 10)", principal[:], tids[0][:])
+	exec("insert into indexer_spawns (account, spawner, tid, layer) values (?1, ?2, ?3, 20)", spawned[:], principal[:], tids[1][:])
+	exec(`insert into indexer_coinbase_epoch_rewards (coinbase, epoch, total_reward, layer_reward, count)
+		values (?1, 2, 100, 50, 1)`, principal[:])
+	exec(`insert into indexer_smesher_epoch_rewards (smesher, epoch, total_reward, layer_reward, count)
+		values (?1, 3, 200, 60, 2)`, smesher[:])
+
+	get := func(t *testing.T, path string, rst any) int {
+		resp, err := http.Get(fmt.Sprintf("http://%s%s%s", cfg.JSONListener, IndexPath, path))
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		if resp.StatusCode == http.StatusOK {
+			require.NoError(t, json.NewDecoder(resp.Body).Decode(rst))
+		}
+		return resp.StatusCode
+	}
+	t.Run("activity", func(t *testing.T) {
+		var rst IndexActivityResponse
+		require.Equal(t, http.StatusOK, get(t, "/activity/"+principal.String()+"?limit=2&offset=1", &rst))
+		require.Equal(t, IndexActivityResponse{
+			Indexed: 30,
+			Activity: []ActivityResponse{
+				{Layer: 20, ID: hex.EncodeToString(tids[1][:])},
+				{Layer: 10, ID: hex.EncodeToString(tids[0][:])},
+			},
+		}, rst)
+		require.Equal(t, http.StatusOK, get(t, "/activity/"+principal.String()+"?start=30", &rst))
+		require.Len(t, rst.Activity, 1)
+		require.Equal(t, http.StatusBadRequest, get(t, "/activity/"+principal.String()+"?limit=0", &rst))
+	})
+	t.Run("rewards", func(t *testing.T) {
+		var rst IndexRewardsResponse
+		require.Equal(t, http.StatusOK, get(t, "/rewards/coinbase/"+principal.String(), &rst))
+		require.Equal(t, []EpochRewardsResponse{{Epoch: 2, TotalReward: 100, LayerReward: 50, Count: 1}}, rst.Rewards)
+		require.Equal(t, http.StatusOK, get(t, "/rewards/coinbase/"+principal.String()+"?from=3", &rst))
+		require.Empty(t, rst.Rewards)
+		require.Equal(t, http.StatusOK, get(t, "/rewards/smesher/"+smesher.String()+"?from=1&to=3", &rst))
+		require.Equal(t, []EpochRewardsResponse{{Epoch: 3, TotalReward: 200, LayerReward: 60, Count: 2}}, rst.Rewards)
+		require.Equal(t, http.StatusBadRequest, get(t, "/rewards/smesher/invalid", &rst))
+	})
+	t.Run("spawns", func(t *testing.T) {
+		var rst IndexSpawnsResponse
+		require.Equal(t, http.StatusOK, get(t, "/lineage/"+spawned.String(), &rst))
+		require.Len(t, rst.Spawns, 2)
+		require.Equal(t, spawned.String(), rst.Spawns[0].Account)
+		require.Equal(t, principal.String(), rst.Spawns[1].Spawner)
+		require.Equal(t, http.StatusNotFound, get(t, "/lineage/"+types.GenerateAddress([]byte{3}).String(), &rst))
+
+		require.Equal(t, http.StatusOK, get(t, "/spawned/"+principal.String(), &rst))
+		require.Equal(t, []SpawnResponse{{
+			Account: spawned.String(),
+			Spawner: principal.String(),
+			ID:      hex.EncodeToString(tids[1][:]),
+			Layer:   20,
+		}}, rst.Spawns)
+	})
+}
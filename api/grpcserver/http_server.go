@@ -81,18 +81,77 @@ func (s *JSONHTTPServer) startInternal(
 	for _, svc := range services {
 		var err error
 		switch typed := svc.(type) {
+		case *AdminService:
+			for _, route := range []struct {
+				method  string
+				path    string
+				handler runtime.HandlerFunc
+			}{
+				{http.MethodGet, PeersPath, typed.ListPeers},
+				{http.MethodPost, PeersPath, typed.AddPeer},
+				{http.MethodDelete, PeersPath + "/{id}", typed.RemovePeer},
+				{http.MethodPost, PeersPath + "/{id}/pin", typed.PinPeer},
+				{http.MethodDelete, PeersPath + "/{id}/pin", typed.UnpinPeer},
+				{http.MethodPost, PeersPath + "/{id}/ban", typed.BanPeer},
+				{http.MethodDelete, PeersPath + "/{id}/ban", typed.UnbanPeer},
+				{http.MethodGet, MempoolPath, typed.Mempool},
+				{http.MethodPost, BackupPath, typed.Backup},
+			} {
+				if err = mux.HandlePath(route.method, route.path, route.handler); err != nil {
+					break
+				}
+			}
 		case *GlobalStateService:
 			err = pb.RegisterGlobalStateServiceHandlerServer(ctx, mux, typed)
+			if err == nil {
+				err = mux.HandlePath(http.MethodGet, AccountsPath+"/{address}", typed.AccountState)
+			}
+			if err == nil {
+				err = mux.HandlePath(http.MethodGet, AccountsPath+"/{address}/history", typed.AccountHistory)
+			}
+			if err == nil {
+				err = mux.HandlePath(http.MethodGet, AccountsPath+"/{address}/proof", typed.AccountProof)
+			}
 		case *MeshService:
 			err = pb.RegisterMeshServiceHandlerServer(ctx, mux, typed)
+			for _, route := range []struct {
+				path    string
+				handler runtime.HandlerFunc
+			}{
+				{IndexPath + "/activity/{address}", typed.IndexActivity},
+				{IndexPath + "/rewards/coinbase/{address}", typed.IndexCoinbaseRewards},
+				{IndexPath + "/rewards/smesher/{id}", typed.IndexSmesherRewards},
+				{IndexPath + "/lineage/{address}", typed.IndexLineage},
+				{IndexPath + "/spawned/{address}", typed.IndexSpawned},
+			} {
+				if err != nil {
+					break
+				}
+				err = mux.HandlePath(http.MethodGet, route.path, route.handler)
+			}
 		case *NodeService:
 			err = pb.RegisterNodeServiceHandlerServer(ctx, mux, typed)
 		case *SmesherService:
 			err = pb.RegisterSmesherServiceHandlerServer(ctx, mux, typed)
 		case *TransactionService:
 			err = pb.RegisterTransactionServiceHandlerServer(ctx, mux, typed)
+			if err == nil {
+				err = mux.HandlePath(http.MethodGet, TransactionResultsPath, typed.Results)
+			}
+			if err == nil {
+				err = mux.HandlePath(http.MethodPost, SimulatePath, typed.Simulate)
+			}
+			if err == nil {
+				err = mux.HandlePath(http.MethodPost, EstimateGasPath, typed.EstimateGas)
+			}
 		case *DebugService:
 			err = pb.RegisterDebugServiceHandlerServer(ctx, mux, typed)
+			if err == nil {
+				err = mux.HandlePath(http.MethodGet, SlowQueriesPath, typed.SlowQueries)
+			}
+			if err == nil {
+				err = mux.HandlePath(http.MethodGet, GossipTracesPath, typed.GossipTraces)
+			}
 		}
 		if err != nil {
 			s.logger.Error("registering %T with grpc gateway failed with %v", svc, err)
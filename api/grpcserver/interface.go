@@ -4,10 +4,15 @@ import (
 	"context"
 	"time"
 
+	"github.com/libp2p/go-libp2p/core/peer"
+
 	"github.com/spacemeshos/go-spacemesh/activation"
 	"github.com/spacemeshos/go-spacemesh/common/types"
+	vm "github.com/spacemeshos/go-spacemesh/genvm"
 	"github.com/spacemeshos/go-spacemesh/p2p"
+	"github.com/spacemeshos/go-spacemesh/p2p/book"
 	"github.com/spacemeshos/go-spacemesh/system"
+	"github.com/spacemeshos/go-spacemesh/txs"
 )
 
 //go:generate mockgen -package=grpcserver -destination=./mocks.go -source=./interface.go
@@ -29,6 +34,12 @@ type conservativeState interface {
 	GetMeshTransactions([]types.TransactionID) ([]*types.MeshTransaction, map[types.TransactionID]struct{})
 	GetTransactionsByAddress(types.LayerID, types.LayerID, types.Address) ([]*types.MeshTransaction, error)
 	Validation(raw types.RawTx) system.ValidationRequest
+	Simulate(types.LayerID, types.RawTx, bool) (*types.TransactionWithResult, error)
+	EstimateGas([]byte) (*vm.GasEstimate, error)
+	GetAccount(types.Address, types.LayerID) (types.Account, error)
+	GetAccountHistory(types.Address, types.LayerID, int) ([]*types.Account, error)
+	GetAccountsRoot(types.LayerID) (types.LayerID, types.Hash32, error)
+	GetAccountProof(types.Address, types.LayerID) (*vm.AccountProof, error)
 }
 
 // syncer is the API to get sync status.
@@ -54,6 +65,21 @@ type postSetupProvider interface {
 	Config() activation.PostConfig
 }
 
+// peerManager is an api to list and manage peers at runtime.
+type peerManager interface {
+	Peers() ([]book.Peer, error)
+	AddPeer(context.Context, peer.AddrInfo, bool) error
+	RemovePeer(peer.ID) error
+	PinPeer(peer.ID, bool) error
+	BanPeer(peer.ID, time.Duration) error
+	UnbanPeer(peer.ID) error
+}
+
+// mempoolInspector is an api to list transactions in the mempool.
+type mempoolInspector interface {
+	GetPendingTXs(types.Address) ([]txs.PendingTX, map[types.Address]txs.Projection)
+}
+
 // peerCounter is an api to get amount of connected peers.
 type peerCounter interface {
 	PeerCount() uint64
@@ -2,7 +2,11 @@ package grpcserver
 
 import (
 	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"net/http"
 	"time"
 
 	pb "github.com/spacemeshos/api/release/go/spacemesh/v1"
@@ -12,9 +16,66 @@ import (
 	"github.com/spacemeshos/go-spacemesh/common/types"
 	"github.com/spacemeshos/go-spacemesh/datastore"
 	"github.com/spacemeshos/go-spacemesh/events"
+	"github.com/spacemeshos/go-spacemesh/indexer"
 	"github.com/spacemeshos/go-spacemesh/log"
+	"github.com/spacemeshos/go-spacemesh/sql"
 )
 
+// IndexPath is the path of the queries to the tables maintained by the indexer on the json gateway.
+// They are served together with MeshService until the api defines rpcs for them:
+//   - GET IndexPath/activity/{address} serves IndexActivityResponse with transactions that updated
+//     the address, newest first. Layers are bounded with the "start" and "end" query parameters;
+//   - GET IndexPath/rewards/coinbase/{address} and IndexPath/rewards/smesher/{id} serve
+//     IndexRewardsResponse with rewards aggregated per epoch, epochs are bounded with the "from"
+//     and "to" query parameters. Smesher id is hex encoded;
+//   - GET IndexPath/lineage/{address} serves IndexSpawnsResponse with spawns from the account
+//     up to the first self-spawned account;
+//   - GET IndexPath/spawned/{address} serves IndexSpawnsResponse with accounts spawned by the address.
+//
+// Lists of activity and spawned accounts are paged with the "offset" and "limit" query parameters.
+// Tables are updated only if the indexer is enabled, Indexed in the responses is the last indexed layer.
+const IndexPath = "/v1/mesh/index"
+
+// IndexActivityResponse is served on IndexPath/activity/{address}.
+type IndexActivityResponse struct {
+	Indexed  uint32             `json:"indexed"`
+	Activity []ActivityResponse `json:"activity"`
+}
+
+// ActivityResponse is a transaction that updated the address.
+type ActivityResponse struct {
+	Layer uint32 `json:"layer"`
+	ID    string `json:"id"`
+}
+
+// IndexRewardsResponse is served on IndexPath/rewards.
+type IndexRewardsResponse struct {
+	Indexed uint32                 `json:"indexed"`
+	Rewards []EpochRewardsResponse `json:"rewards"`
+}
+
+// EpochRewardsResponse is the sum of rewards received within the epoch.
+type EpochRewardsResponse struct {
+	Epoch       uint32 `json:"epoch"`
+	TotalReward uint64 `json:"total_reward"`
+	LayerReward uint64 `json:"layer_reward"`
+	Count       uint64 `json:"count"`
+}
+
+// IndexSpawnsResponse is served on IndexPath/lineage/{address} and IndexPath/spawned/{address}.
+type IndexSpawnsResponse struct {
+	Indexed uint32          `json:"indexed"`
+	Spawns  []SpawnResponse `json:"spawns"`
+}
+
+// SpawnResponse links the spawned account with the principal that spawned it.
+type SpawnResponse struct {
+	Account string `json:"account"`
+	Spawner string `json:"spawner"`
+	ID      string `json:"id"`
+	Layer   uint32 `json:"layer"`
+}
+
 // MeshService exposes mesh data such as accounts, blocks, and transactions.
 type MeshService struct {
 	logger         log.Logger
@@ -602,3 +663,162 @@ func (s MeshService) EpochStream(req *pb.EpochStreamRequest, stream pb.MeshServi
 	}
 	return nil
 }
+
+// indexQuery runs the query with the last indexed layer and writes the response.
+func (s MeshService) indexQuery(w http.ResponseWriter, r *http.Request, query func(indexed types.LayerID) (any, error)) {
+	indexed, err := indexer.LastIndexed(s.cdb)
+	if err != nil {
+		s.logger.With().Warning("failed to read last indexed layer", log.Err(err))
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	rst, err := query(indexed)
+	if errors.Is(err, sql.ErrNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	} else if err != nil {
+		s.logger.With().Warning("failed to query index", log.String("path", r.URL.Path), log.Err(err))
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(rst); err != nil {
+		s.logger.With().Warning("failed to write index query", log.Err(err))
+	}
+}
+
+// IndexActivity serves transactions that updated the address, see IndexPath.
+func (s MeshService) IndexActivity(w http.ResponseWriter, r *http.Request, params map[string]string) {
+	addr, ok := parseAddress(w, params)
+	if !ok {
+		return
+	}
+	var start, end, offset, limit uint32
+	if !parseUint32(w, r, "start", &start) || !parseUint32(w, r, "end", &end) ||
+		!parseUint32(w, r, "offset", &offset) || !parseLimit(w, r, &limit) {
+		return
+	}
+	s.indexQuery(w, r, func(indexed types.LayerID) (any, error) {
+		activity, err := indexer.GetActivity(s.cdb, indexer.ActivityFilter{
+			Address: addr,
+			Start:   types.LayerID(start),
+			End:     types.LayerID(end),
+			Offset:  uint64(offset),
+			Limit:   uint64(limit),
+		})
+		if err != nil {
+			return nil, err
+		}
+		rst := IndexActivityResponse{
+			Indexed:  indexed.Uint32(),
+			Activity: make([]ActivityResponse, 0, len(activity)),
+		}
+		for _, a := range activity {
+			rst.Activity = append(rst.Activity, ActivityResponse{Layer: a.Layer.Uint32(), ID: hex.EncodeToString(a.TID[:])})
+		}
+		return rst, nil
+	})
+}
+
+func (s MeshService) indexRewards(w http.ResponseWriter, r *http.Request, query func(from, to types.EpochID) ([]indexer.EpochRewards, error)) {
+	var from, to uint32
+	if !parseUint32(w, r, "from", &from) || !parseUint32(w, r, "to", &to) {
+		return
+	}
+	if to == 0 {
+		to = 1<<32 - 1
+	}
+	s.indexQuery(w, r, func(indexed types.LayerID) (any, error) {
+		rewards, err := query(types.EpochID(from), types.EpochID(to))
+		if err != nil {
+			return nil, err
+		}
+		rst := IndexRewardsResponse{
+			Indexed: indexed.Uint32(),
+			Rewards: make([]EpochRewardsResponse, 0, len(rewards)),
+		}
+		for _, reward := range rewards {
+			rst.Rewards = append(rst.Rewards, EpochRewardsResponse{
+				Epoch:       reward.Epoch.Uint32(),
+				TotalReward: reward.TotalReward,
+				LayerReward: reward.LayerReward,
+				Count:       reward.Count,
+			})
+		}
+		return rst, nil
+	})
+}
+
+// IndexCoinbaseRewards serves rewards of the coinbase aggregated per epoch, see IndexPath.
+func (s MeshService) IndexCoinbaseRewards(w http.ResponseWriter, r *http.Request, params map[string]string) {
+	addr, ok := parseAddress(w, params)
+	if !ok {
+		return
+	}
+	s.indexRewards(w, r, func(from, to types.EpochID) ([]indexer.EpochRewards, error) {
+		return indexer.GetCoinbaseRewards(s.cdb, addr, from, to)
+	})
+}
+
+// IndexSmesherRewards serves rewards of the smesher aggregated per epoch, see IndexPath.
+func (s MeshService) IndexSmesherRewards(w http.ResponseWriter, r *http.Request, params map[string]string) {
+	raw, err := hex.DecodeString(params["id"])
+	if err != nil || len(raw) != len(types.NodeID{}) {
+		http.Error(w, fmt.Sprintf("invalid smesher id %q", params["id"]), http.StatusBadRequest)
+		return
+	}
+	smesher := types.BytesToNodeID(raw)
+	s.indexRewards(w, r, func(from, to types.EpochID) ([]indexer.EpochRewards, error) {
+		return indexer.GetSmesherRewards(s.cdb, smesher, from, to)
+	})
+}
+
+func spawnsResponse(indexed types.LayerID, spawns []*indexer.Spawn) IndexSpawnsResponse {
+	rst := IndexSpawnsResponse{
+		Indexed: indexed.Uint32(),
+		Spawns:  make([]SpawnResponse, 0, len(spawns)),
+	}
+	for _, spawn := range spawns {
+		rst.Spawns = append(rst.Spawns, SpawnResponse{
+			Account: spawn.Account.String(),
+			Spawner: spawn.Spawner.String(),
+			ID:      hex.EncodeToString(spawn.TID[:]),
+			Layer:   spawn.Layer.Uint32(),
+		})
+	}
+	return rst
+}
+
+// IndexLineage serves spawns from the account up to the first self-spawned account, see IndexPath.
+func (s MeshService) IndexLineage(w http.ResponseWriter, r *http.Request, params map[string]string) {
+	addr, ok := parseAddress(w, params)
+	if !ok {
+		return
+	}
+	s.indexQuery(w, r, func(indexed types.LayerID) (any, error) {
+		lineage, err := indexer.GetLineage(s.cdb, addr)
+		if err != nil {
+			return nil, err
+		}
+		return spawnsResponse(indexed, lineage), nil
+	})
+}
+
+// IndexSpawned serves accounts spawned by the address, see IndexPath.
+func (s MeshService) IndexSpawned(w http.ResponseWriter, r *http.Request, params map[string]string) {
+	addr, ok := parseAddress(w, params)
+	if !ok {
+		return
+	}
+	var offset, limit uint32
+	if !parseUint32(w, r, "offset", &offset) || !parseLimit(w, r, &limit) {
+		return
+	}
+	s.indexQuery(w, r, func(indexed types.LayerID) (any, error) {
+		spawned, err := indexer.GetSpawned(s.cdb, addr, uint64(offset), uint64(limit))
+		if err != nil {
+			return nil, err
+		}
+		return spawnsResponse(indexed, spawned), nil
+	})
+}
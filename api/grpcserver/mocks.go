@@ -10,10 +10,14 @@ import (
 	time "time"
 
 	gomock "github.com/golang/mock/gomock"
+	peer "github.com/libp2p/go-libp2p/core/peer"
 	activation "github.com/spacemeshos/go-spacemesh/activation"
 	types "github.com/spacemeshos/go-spacemesh/common/types"
+	vm "github.com/spacemeshos/go-spacemesh/genvm"
 	p2p "github.com/spacemeshos/go-spacemesh/p2p"
+	book "github.com/spacemeshos/go-spacemesh/p2p/book"
 	system "github.com/spacemeshos/go-spacemesh/system"
+	txs "github.com/spacemeshos/go-spacemesh/txs"
 )
 
 // MocknetworkIdentity is a mock of networkIdentity interface.
@@ -76,6 +80,82 @@ func (m *MockconservativeState) EXPECT() *MockconservativeStateMockRecorder {
 	return m.recorder
 }
 
+// EstimateGas mocks base method.
+func (m *MockconservativeState) EstimateGas(arg0 []byte) (*vm.GasEstimate, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "EstimateGas", arg0)
+	ret0, _ := ret[0].(*vm.GasEstimate)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// EstimateGas indicates an expected call of EstimateGas.
+func (mr *MockconservativeStateMockRecorder) EstimateGas(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EstimateGas", reflect.TypeOf((*MockconservativeState)(nil).EstimateGas), arg0)
+}
+
+// GetAccount mocks base method.
+func (m *MockconservativeState) GetAccount(arg0 types.Address, arg1 types.LayerID) (types.Account, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAccount", arg0, arg1)
+	ret0, _ := ret[0].(types.Account)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAccount indicates an expected call of GetAccount.
+func (mr *MockconservativeStateMockRecorder) GetAccount(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAccount", reflect.TypeOf((*MockconservativeState)(nil).GetAccount), arg0, arg1)
+}
+
+// GetAccountHistory mocks base method.
+func (m *MockconservativeState) GetAccountHistory(arg0 types.Address, arg1 types.LayerID, arg2 int) ([]*types.Account, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAccountHistory", arg0, arg1, arg2)
+	ret0, _ := ret[0].([]*types.Account)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAccountHistory indicates an expected call of GetAccountHistory.
+func (mr *MockconservativeStateMockRecorder) GetAccountHistory(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAccountHistory", reflect.TypeOf((*MockconservativeState)(nil).GetAccountHistory), arg0, arg1, arg2)
+}
+
+// GetAccountProof mocks base method.
+func (m *MockconservativeState) GetAccountProof(arg0 types.Address, arg1 types.LayerID) (*vm.AccountProof, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAccountProof", arg0, arg1)
+	ret0, _ := ret[0].(*vm.AccountProof)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAccountProof indicates an expected call of GetAccountProof.
+func (mr *MockconservativeStateMockRecorder) GetAccountProof(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAccountProof", reflect.TypeOf((*MockconservativeState)(nil).GetAccountProof), arg0, arg1)
+}
+
+// GetAccountsRoot mocks base method.
+func (m *MockconservativeState) GetAccountsRoot(arg0 types.LayerID) (types.LayerID, types.Hash32, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAccountsRoot", arg0)
+	ret0, _ := ret[0].(types.LayerID)
+	ret1, _ := ret[1].(types.Hash32)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// GetAccountsRoot indicates an expected call of GetAccountsRoot.
+func (mr *MockconservativeStateMockRecorder) GetAccountsRoot(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAccountsRoot", reflect.TypeOf((*MockconservativeState)(nil).GetAccountsRoot), arg0)
+}
+
 // GetAllAccounts mocks base method.
 func (m *MockconservativeState) GetAllAccounts() ([]*types.Account, error) {
 	m.ctrl.T.Helper()
@@ -211,6 +291,21 @@ func (mr *MockconservativeStateMockRecorder) GetTransactionsByAddress(arg0, arg1
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTransactionsByAddress", reflect.TypeOf((*MockconservativeState)(nil).GetTransactionsByAddress), arg0, arg1, arg2)
 }
 
+// Simulate mocks base method.
+func (m *MockconservativeState) Simulate(arg0 types.LayerID, arg1 types.RawTx, arg2 bool) (*types.TransactionWithResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Simulate", arg0, arg1, arg2)
+	ret0, _ := ret[0].(*types.TransactionWithResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Simulate indicates an expected call of Simulate.
+func (mr *MockconservativeStateMockRecorder) Simulate(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Simulate", reflect.TypeOf((*MockconservativeState)(nil).Simulate), arg0, arg1, arg2)
+}
+
 // Validation mocks base method.
 func (m *MockconservativeState) Validation(raw types.RawTx) system.ValidationRequest {
 	m.ctrl.T.Helper()
@@ -433,6 +528,152 @@ func (mr *MockpostSetupProviderMockRecorder) Status() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Status", reflect.TypeOf((*MockpostSetupProvider)(nil).Status))
 }
 
+// MockpeerManager is a mock of peerManager interface.
+type MockpeerManager struct {
+	ctrl     *gomock.Controller
+	recorder *MockpeerManagerMockRecorder
+}
+
+// MockpeerManagerMockRecorder is the mock recorder for MockpeerManager.
+type MockpeerManagerMockRecorder struct {
+	mock *MockpeerManager
+}
+
+// NewMockpeerManager creates a new mock instance.
+func NewMockpeerManager(ctrl *gomock.Controller) *MockpeerManager {
+	mock := &MockpeerManager{ctrl: ctrl}
+	mock.recorder = &MockpeerManagerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockpeerManager) EXPECT() *MockpeerManagerMockRecorder {
+	return m.recorder
+}
+
+// AddPeer mocks base method.
+func (m *MockpeerManager) AddPeer(arg0 context.Context, arg1 peer.AddrInfo, arg2 bool) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddPeer", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddPeer indicates an expected call of AddPeer.
+func (mr *MockpeerManagerMockRecorder) AddPeer(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddPeer", reflect.TypeOf((*MockpeerManager)(nil).AddPeer), arg0, arg1, arg2)
+}
+
+// BanPeer mocks base method.
+func (m *MockpeerManager) BanPeer(arg0 peer.ID, arg1 time.Duration) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BanPeer", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// BanPeer indicates an expected call of BanPeer.
+func (mr *MockpeerManagerMockRecorder) BanPeer(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BanPeer", reflect.TypeOf((*MockpeerManager)(nil).BanPeer), arg0, arg1)
+}
+
+// Peers mocks base method.
+func (m *MockpeerManager) Peers() ([]book.Peer, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Peers")
+	ret0, _ := ret[0].([]book.Peer)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Peers indicates an expected call of Peers.
+func (mr *MockpeerManagerMockRecorder) Peers() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Peers", reflect.TypeOf((*MockpeerManager)(nil).Peers))
+}
+
+// PinPeer mocks base method.
+func (m *MockpeerManager) PinPeer(arg0 peer.ID, arg1 bool) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PinPeer", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// PinPeer indicates an expected call of PinPeer.
+func (mr *MockpeerManagerMockRecorder) PinPeer(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PinPeer", reflect.TypeOf((*MockpeerManager)(nil).PinPeer), arg0, arg1)
+}
+
+// RemovePeer mocks base method.
+func (m *MockpeerManager) RemovePeer(arg0 peer.ID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RemovePeer", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RemovePeer indicates an expected call of RemovePeer.
+func (mr *MockpeerManagerMockRecorder) RemovePeer(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemovePeer", reflect.TypeOf((*MockpeerManager)(nil).RemovePeer), arg0)
+}
+
+// UnbanPeer mocks base method.
+func (m *MockpeerManager) UnbanPeer(arg0 peer.ID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UnbanPeer", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UnbanPeer indicates an expected call of UnbanPeer.
+func (mr *MockpeerManagerMockRecorder) UnbanPeer(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UnbanPeer", reflect.TypeOf((*MockpeerManager)(nil).UnbanPeer), arg0)
+}
+
+// MockmempoolInspector is a mock of mempoolInspector interface.
+type MockmempoolInspector struct {
+	ctrl     *gomock.Controller
+	recorder *MockmempoolInspectorMockRecorder
+}
+
+// MockmempoolInspectorMockRecorder is the mock recorder for MockmempoolInspector.
+type MockmempoolInspectorMockRecorder struct {
+	mock *MockmempoolInspector
+}
+
+// NewMockmempoolInspector creates a new mock instance.
+func NewMockmempoolInspector(ctrl *gomock.Controller) *MockmempoolInspector {
+	mock := &MockmempoolInspector{ctrl: ctrl}
+	mock.recorder = &MockmempoolInspectorMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockmempoolInspector) EXPECT() *MockmempoolInspectorMockRecorder {
+	return m.recorder
+}
+
+// GetPendingTXs mocks base method.
+func (m *MockmempoolInspector) GetPendingTXs(arg0 types.Address) ([]txs.PendingTX, map[types.Address]txs.Projection) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPendingTXs", arg0)
+	ret0, _ := ret[0].([]txs.PendingTX)
+	ret1, _ := ret[1].(map[types.Address]txs.Projection)
+	return ret0, ret1
+}
+
+// GetPendingTXs indicates an expected call of GetPendingTXs.
+func (mr *MockmempoolInspectorMockRecorder) GetPendingTXs(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPendingTXs", reflect.TypeOf((*MockmempoolInspector)(nil).GetPendingTXs), arg0)
+}
+
 // MockpeerCounter is a mock of peerCounter interface.
 type MockpeerCounter struct {
 	ctrl     *gomock.Controller
@@ -3,10 +3,12 @@ package grpcserver
 import (
 	"context"
 	"fmt"
+	"strconv"
 
 	"github.com/golang/protobuf/ptypes/empty"
 	pb "github.com/spacemeshos/api/release/go/spacemesh/v1"
 	"go.uber.org/zap/zapcore"
+	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/status"
@@ -16,6 +18,9 @@ import (
 	"github.com/spacemeshos/go-spacemesh/log"
 )
 
+// SchemaVersionHeader is a header in the Version response with the version of the database schema.
+const SchemaVersionHeader = "schema-version"
+
 // NodeService is a grpc server that provides the NodeService, which exposes node-related
 // data such as node status, software version, errors, etc. It can also be used to start
 // the sync process, or to shut down the node.
@@ -27,6 +32,7 @@ type NodeService struct {
 	syncer      syncer
 	appVersion  string
 	appCommit   string
+	dbVersion   func() (int, error)
 }
 
 // RegisterService registers this service with a grpc server instance.
@@ -35,6 +41,7 @@ func (s NodeService) RegisterService(server *Server) {
 }
 
 // NewNodeService creates a new grpc service using config data.
+// dbVersion is called on every Version request, error means that the version is not reported.
 func NewNodeService(
 	peers peerCounter,
 	msh meshAPI,
@@ -42,6 +49,7 @@ func NewNodeService(
 	syncer syncer,
 	appVersion string,
 	appCommit string,
+	dbVersion func() (int, error),
 	lg log.Logger,
 ) *NodeService {
 	return &NodeService{
@@ -52,6 +60,7 @@ func NewNodeService(
 		syncer:      syncer,
 		appVersion:  appVersion,
 		appCommit:   appCommit,
+		dbVersion:   dbVersion,
 	}
 }
 
@@ -65,8 +74,14 @@ func (s NodeService) Echo(_ context.Context, in *pb.EchoRequest) (*pb.EchoRespon
 }
 
 // Version returns the version of the node software as a semver string.
-func (s NodeService) Version(context.Context, *empty.Empty) (*pb.VersionResponse, error) {
+// Version of the database schema is sent in the SchemaVersionHeader if the database is open.
+func (s NodeService) Version(ctx context.Context, _ *empty.Empty) (*pb.VersionResponse, error) {
 	s.logger.Info("GRPC NodeService.Version")
+	if version, err := s.dbVersion(); err != nil {
+		s.logger.With().Warning("schema version is not available", log.Err(err))
+	} else if err := grpc.SetHeader(ctx, metadata.Pairs(SchemaVersionHeader, strconv.Itoa(version))); err != nil {
+		return nil, status.Errorf(codes.Internal, "set header: %v", err)
+	}
 	return &pb.VersionResponse{
 		VersionString: &pb.SimpleString{Value: s.appVersion},
 	}, nil
@@ -3,9 +3,13 @@ package grpcserver
 import (
 	"bytes"
 	"context"
+	"encoding/hex"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
+	"net/http"
+	"strconv"
 
 	pb "github.com/spacemeshos/api/release/go/spacemesh/v1"
 	"google.golang.org/genproto/googleapis/rpc/code"
@@ -81,6 +85,106 @@ func (s TransactionService) ParseTransaction(ctx context.Context, in *pb.ParseTr
 	return &pb.ParseTransactionResponse{Tx: castTransaction(&tx)}, nil
 }
 
+// SimulatePath is the path of the transaction simulation on the json gateway.
+// It is served together with TransactionService until the api defines an rpc for it.
+const SimulatePath = "/v1/transactions/simulate"
+
+// SimulateRequest is the body of the request to SimulatePath.
+type SimulateRequest struct {
+	// Transaction is the encoded transaction, base64 in json.
+	Transaction []byte `json:"transaction"`
+	// Layer selects the state the transaction is executed on, the latest applied state if zero.
+	Layer uint32 `json:"layer"`
+	// Verify enables signature verification.
+	Verify bool `json:"verify"`
+}
+
+// Simulate executes the transaction without persisting changes and responds with
+// the result it would have, see TransactionResultResponse.
+func (s TransactionService) Simulate(w http.ResponseWriter, r *http.Request, _ map[string]string) {
+	var req SimulateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, fmt.Sprintf("invalid request: %s", err), http.StatusBadRequest)
+		return
+	}
+	if len(req.Transaction) == 0 {
+		http.Error(w, "empty transaction", http.StatusBadRequest)
+		return
+	}
+	rst, err := s.conState.Simulate(types.LayerID(req.Layer), types.NewRawTx(req.Transaction), req.Verify)
+	switch {
+	case errors.Is(err, core.ErrNotSpawned):
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	case errors.Is(err, core.ErrMalformed), errors.Is(err, core.ErrTxLimit), errors.Is(err, core.ErrIneffective):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	case err != nil:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(resultResponse(rst)); err != nil {
+		s.logger.With().Warning("failed to encode simulation result", log.Err(err))
+	}
+}
+
+// EstimateGasPath is the path of the gas estimation on the json gateway.
+// It is served together with TransactionService until the api defines an rpc for it.
+const EstimateGasPath = "/v1/transactions/estimategas"
+
+// EstimateGasRequest is the body of the request to EstimateGasPath.
+type EstimateGasRequest struct {
+	// Transaction is the encoded transaction, base64 in json. Signatures are not verified,
+	// but they must be present or replaced with placeholders of the same size.
+	Transaction []byte `json:"transaction"`
+}
+
+// EstimateGasResponse is the breakdown of the gas that will be charged for the transaction.
+type EstimateGasResponse struct {
+	BaseGas      uint64 `json:"base_gas"`
+	ExecGas      uint64 `json:"exec_gas"`
+	TxDataGas    uint64 `json:"tx_data_gas"`
+	IntrinsicGas uint64 `json:"intrinsic_gas"`
+	MaxGas       uint64 `json:"max_gas"`
+}
+
+// EstimateGas responds with the gas that will be charged for the transaction
+// if it is included into the next layer, see EstimateGasResponse.
+func (s TransactionService) EstimateGas(w http.ResponseWriter, r *http.Request, _ map[string]string) {
+	var req EstimateGasRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, fmt.Sprintf("invalid request: %s", err), http.StatusBadRequest)
+		return
+	}
+	if len(req.Transaction) == 0 {
+		http.Error(w, "empty transaction", http.StatusBadRequest)
+		return
+	}
+	estimate, err := s.conState.EstimateGas(req.Transaction)
+	switch {
+	case errors.Is(err, core.ErrNotSpawned):
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	case errors.Is(err, core.ErrMalformed), errors.Is(err, core.ErrTxLimit):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	case err != nil:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(EstimateGasResponse{
+		BaseGas:      estimate.BaseGas,
+		ExecGas:      estimate.ExecGas,
+		TxDataGas:    estimate.TxDataGas,
+		IntrinsicGas: estimate.IntrinsicGas,
+		MaxGas:       estimate.MaxGas,
+	}); err != nil {
+		s.logger.With().Warning("failed to encode gas estimate", log.Err(err))
+	}
+}
+
 // SubmitTransaction allows a new tx to be submitted.
 func (s TransactionService) SubmitTransaction(ctx context.Context, in *pb.SubmitTransactionRequest) (*pb.SubmitTransactionResponse, error) {
 	if len(in.Transaction) == 0 {
@@ -300,18 +404,64 @@ func (s TransactionService) TransactionsStateStream(in *pb.TransactionsStateStre
 	}
 }
 
-// StreamResults allows to query historical results and subscribe to live data using the same filter.
-func (s TransactionService) StreamResults(in *pb.TransactionResultsRequest, stream pb.TransactionService_StreamResultsServer) error {
-	var (
-		filter    transactions.ResultsFilter
-		sub       *events.BufferedSubscription[types.TransactionWithResult]
-		err       error
-		persisted types.LayerID
-	)
+// TransactionResultsPath is the path of the stream of transaction results with events on the json gateway.
+// It accepts the same filter as StreamResults in the query parameters: address, id (hex), start, end and watch.
+// It is served together with TransactionService until TransactionResult in the api has a field for events.
+const TransactionResultsPath = "/v1/transactions/results"
+
+// TransactionResultResponse is a line in the stream served on TransactionResultsPath.
+type TransactionResultResponse struct {
+	ID        string                     `json:"id"`
+	Status    string                     `json:"status"`
+	Message   string                     `json:"message,omitempty"`
+	Gas       uint64                     `json:"gas"`
+	Fee       uint64                     `json:"fee"`
+	Block     string                     `json:"block"`
+	Layer     uint32                     `json:"layer"`
+	Addresses []string                   `json:"addresses"`
+	Events    []TransactionEventResponse `json:"events"`
+}
+
+// TransactionEventResponse is a state change made by the transaction.
+type TransactionEventResponse struct {
+	Type   string `json:"type"`
+	From   string `json:"from"`
+	To     string `json:"to"`
+	Amount uint64 `json:"amount"`
+}
+
+func resultResponse(rst *types.TransactionWithResult) *TransactionResultResponse {
+	resp := &TransactionResultResponse{
+		ID:        hex.EncodeToString(rst.ID[:]),
+		Status:    pb.TransactionResult_Status(rst.Status).String(),
+		Message:   rst.Message,
+		Gas:       rst.Gas,
+		Fee:       rst.Fee,
+		Block:     hex.EncodeToString(rst.Block[:]),
+		Layer:     rst.Layer.Uint32(),
+		Addresses: make([]string, 0, len(rst.Addresses)),
+		Events:    make([]TransactionEventResponse, 0, len(rst.Events)),
+	}
+	for i := range rst.Addresses {
+		resp.Addresses = append(resp.Addresses, rst.Addresses[i].String())
+	}
+	for _, ev := range rst.Events {
+		resp.Events = append(resp.Events, TransactionEventResponse{
+			Type:   ev.Type.String(),
+			From:   ev.From.String(),
+			To:     ev.To.String(),
+			Amount: ev.Amount,
+		})
+	}
+	return resp
+}
+
+func resultsFilter(in *pb.TransactionResultsRequest) (transactions.ResultsFilter, error) {
+	var filter transactions.ResultsFilter
 	if len(in.Address) > 0 {
 		addr, err := types.StringToAddress(in.Address)
 		if err != nil {
-			return fmt.Errorf("failed to parse in.Address `%s`: %w", in.Address, err)
+			return filter, fmt.Errorf("failed to parse in.Address `%s`: %w", in.Address, err)
 		}
 		filter.Address = &addr
 	}
@@ -326,20 +476,56 @@ func (s TransactionService) StreamResults(in *pb.TransactionResultsRequest, stre
 	}
 	if in.End > 0 {
 		if in.Watch {
-			return status.Error(codes.InvalidArgument, "watch stream should have an empty End argument")
+			return filter, status.Error(codes.InvalidArgument, "watch stream should have an empty End argument")
 		}
 		lid := types.LayerID(in.End)
 		filter.End = &lid
 	}
+	return filter, nil
+}
+
+// StreamResults allows to query historical results and subscribe to live data using the same filter.
+// Events of the results are served on TransactionResultsPath.
+func (s TransactionService) StreamResults(in *pb.TransactionResultsRequest, stream pb.TransactionService_StreamResultsServer) error {
+	filter, err := resultsFilter(in)
+	if err != nil {
+		return err
+	}
+	return s.streamResults(stream.Context(), filter, in.Watch,
+		func() error {
+			if err := stream.SendHeader(metadata.MD{}); err != nil {
+				return status.Errorf(codes.Unavailable, "can't send header")
+			}
+			return nil
+		},
+		func(rst *types.TransactionWithResult) error {
+			return stream.Send(castResult(rst))
+		},
+	)
+}
 
-	if in.Watch {
+// streamResults sends persisted results matching the filter, and if watch is true the results
+// received after the subscription. subscribed is called once the subscription is created.
+func (s TransactionService) streamResults(
+	ctx context.Context,
+	filter transactions.ResultsFilter,
+	watch bool,
+	subscribed func() error,
+	send func(*types.TransactionWithResult) error,
+) error {
+	var (
+		sub       *events.BufferedSubscription[types.TransactionWithResult]
+		err       error
+		persisted types.LayerID
+	)
+	if watch {
 		sub, err = events.SubscribeMatched(resultsMatcher(filter).match)
 		if err != nil {
 			return status.Error(codes.Internal, err.Error())
 		}
 		defer sub.Close()
-		if err := stream.SendHeader(metadata.MD{}); err != nil {
-			return status.Errorf(codes.Unavailable, "can't send header")
+		if err := subscribed(); err != nil {
+			return err
 		}
 	}
 
@@ -348,7 +534,7 @@ func (s TransactionService) StreamResults(in *pb.TransactionResultsRequest, stre
 		if rst.Layer.After(persisted) {
 			persisted = rst.Layer
 		}
-		ierr = stream.Send(castResult(rst))
+		ierr = send(rst)
 		return ierr == nil
 	})
 	if err == nil {
@@ -365,7 +551,7 @@ func (s TransactionService) StreamResults(in *pb.TransactionResultsRequest, stre
 	}
 	for {
 		select {
-		case <-stream.Context().Done():
+		case <-ctx.Done():
 			return nil
 		case <-sub.Full():
 			return status.Error(codes.Canceled, "buffer overflow")
@@ -373,7 +559,7 @@ func (s TransactionService) StreamResults(in *pb.TransactionResultsRequest, stre
 			if !rst.Layer.After(persisted) {
 				break
 			}
-			if err := stream.Send(castResult(&rst)); err != nil {
+			if err := send(&rst); err != nil {
 				if errors.Is(err, io.EOF) {
 					return nil
 				}
@@ -383,6 +569,65 @@ func (s TransactionService) StreamResults(in *pb.TransactionResultsRequest, stre
 	}
 }
 
+// Results streams transaction results with events as JSON lines, see TransactionResultsPath.
+func (s TransactionService) Results(w http.ResponseWriter, r *http.Request, _ map[string]string) {
+	query := r.URL.Query()
+	in := &pb.TransactionResultsRequest{Address: query.Get("address")}
+	if value := query.Get("id"); value != "" {
+		id, err := hex.DecodeString(value)
+		if err != nil || len(id) != len(types.TransactionID{}) {
+			http.Error(w, fmt.Sprintf("invalid id %q", value), http.StatusBadRequest)
+			return
+		}
+		in.Id = id
+	}
+	for name, dst := range map[string]*uint32{"start": &in.Start, "end": &in.End} {
+		value := query.Get(name)
+		if value == "" {
+			continue
+		}
+		parsed, err := strconv.ParseUint(value, 10, 32)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("invalid %s %q", name, value), http.StatusBadRequest)
+			return
+		}
+		*dst = uint32(parsed)
+	}
+	if value := query.Get("watch"); value != "" {
+		watch, err := strconv.ParseBool(value)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("invalid watch %q", value), http.StatusBadRequest)
+			return
+		}
+		in.Watch = watch
+	}
+	filter, err := resultsFilter(in)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.WriteHeader(http.StatusOK)
+	flusher, _ := w.(http.Flusher)
+	flush := func() error {
+		if flusher != nil {
+			flusher.Flush()
+		}
+		return nil
+	}
+	enc := json.NewEncoder(w)
+	if err := s.streamResults(r.Context(), filter, in.Watch, flush,
+		func(rst *types.TransactionWithResult) error {
+			if err := enc.Encode(resultResponse(rst)); err != nil {
+				return err
+			}
+			return flush()
+		},
+	); err != nil {
+		s.logger.With().Debug("failed to stream transaction results", log.Err(err))
+	}
+}
+
 func castResult(rst *types.TransactionWithResult) *pb.TransactionResult {
 	casted := &pb.TransactionResult{
 		Tx:          castTransaction(&rst.Transaction),
@@ -1,10 +1,14 @@
 package grpcserver
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
 	"io"
 	"math/rand"
+	"net/http"
 	"runtime"
 	"testing"
 	"time"
@@ -35,7 +39,7 @@ func TestTransactionService_StreamResults(t *testing.T) {
 	defer cancel()
 
 	gen := fixture.NewTransactionResultGenerator().
-		WithAddresses(2)
+		WithAddresses(3)
 	txs := make([]types.TransactionWithResult, 100)
 	require.NoError(t, db.WithTx(ctx, func(dtx *sql.Tx) error {
 		for i := range txs {
@@ -43,6 +47,7 @@ func TestTransactionService_StreamResults(t *testing.T) {
 
 			require.NoError(t, transactions.Add(dtx, &tx.Transaction, time.Time{}))
 			require.NoError(t, transactions.AddResult(dtx, tx.ID, &tx.TransactionResult))
+			require.NoError(t, transactions.AddEvents(dtx, tx.ID, tx.Events))
 			txs[i] = *tx
 		}
 		return nil
@@ -67,6 +72,32 @@ func TestTransactionService_StreamResults(t *testing.T) {
 		}
 		require.Equal(t, len(txs), i)
 	})
+	t.Run("JSON", func(t *testing.T) {
+		var expect *types.TransactionWithResult
+		for i := range txs {
+			if len(txs[i].Events) > 0 {
+				expect = &txs[i]
+				break
+			}
+		}
+		require.NotNil(t, expect)
+		url := fmt.Sprintf("http://%s%s?id=%x", cfg.JSONListener, TransactionResultsPath, expect.ID[:])
+		resp, err := http.Get(url)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		dec := json.NewDecoder(resp.Body)
+		var rst TransactionResultResponse
+		require.NoError(t, dec.Decode(&rst))
+		require.Equal(t, resultResponse(expect), &rst)
+		require.Equal(t, "transfer", rst.Events[0].Type)
+		require.ErrorIs(t, dec.Decode(&rst), io.EOF)
+
+		resp, err = http.Get(fmt.Sprintf("http://%s%s?end=10&watch=true", cfg.JSONListener, TransactionResultsPath))
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	})
 	t.Run("Watch", func(t *testing.T) {
 		events.InitializeReporter()
 		t.Cleanup(events.CloseEventReporter)
@@ -294,3 +325,114 @@ func TestParseTransactions(t *testing.T) {
 		})
 	}
 }
+
+func TestTransactionService_Simulate(t *testing.T) {
+	db := sql.InMemory()
+	vminst := vm.New(db)
+	t.Cleanup(launchServer(t, cfg, NewTransactionService(db, nil, nil, txs.NewConservativeState(vminst, db), nil, nil, logtest.New(t).WithName("grpc.Transactions"))))
+	var (
+		keys     = make([]signing.PrivateKey, 3)
+		accounts = make([]types.Account, len(keys))
+		rng      = rand.New(rand.NewSource(10101))
+	)
+	for i := range keys {
+		pub, priv, err := ed25519.GenerateKey(rng)
+		require.NoError(t, err)
+		keys[i] = signing.PrivateKey(priv)
+		accounts[i] = types.Account{Address: wallet.Address(pub), Balance: 1e12}
+	}
+	require.NoError(t, vminst.ApplyGenesis(accounts))
+	_, _, err := vminst.Apply(vm.ApplyContext{Layer: types.GetEffectiveGenesis().Add(1)},
+		[]types.Transaction{{RawTx: types.NewRawTx(wallet.SelfSpawn(keys[0], 0))}}, nil)
+	require.NoError(t, err)
+	mangled := wallet.Spend(keys[0], accounts[2].Address, 100, 1)
+	mangled[len(mangled)-1] -= 1
+
+	simulate := func(t *testing.T, req SimulateRequest) *http.Response {
+		body, err := json.Marshal(req)
+		require.NoError(t, err)
+		resp, err := http.Post(fmt.Sprintf("http://%s%s", cfg.JSONListener, SimulatePath), "application/json",
+			bytes.NewReader(body))
+		require.NoError(t, err)
+		t.Cleanup(func() { resp.Body.Close() })
+		return resp
+	}
+	t.Run("success", func(t *testing.T) {
+		tx := wallet.Spend(keys[0], accounts[2].Address, 100, 1)
+		resp := simulate(t, SimulateRequest{Transaction: tx, Verify: true})
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		var rst TransactionResultResponse
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&rst))
+		require.Equal(t, pb.TransactionResult_SUCCESS.String(), rst.Status)
+		require.NotZero(t, rst.Gas)
+		require.NotZero(t, rst.Fee)
+		require.Contains(t, rst.Addresses, accounts[2].Address.String())
+
+		balance, err := vminst.GetBalance(accounts[2].Address)
+		require.NoError(t, err)
+		require.Equal(t, accounts[2].Balance, balance, "state is not changed")
+	})
+	for _, tc := range []struct {
+		desc   string
+		req    SimulateRequest
+		status int
+	}{
+		{"empty", SimulateRequest{}, http.StatusBadRequest},
+		{"malformed", SimulateRequest{Transaction: []byte("something")}, http.StatusBadRequest},
+		{
+			"not spawned",
+			SimulateRequest{Transaction: wallet.Spend(keys[1], accounts[2].Address, 100, 0)},
+			http.StatusNotFound,
+		},
+		{
+			"nonce too low",
+			SimulateRequest{Transaction: wallet.Spend(keys[0], accounts[2].Address, 100, 0)},
+			http.StatusBadRequest,
+		},
+		{"mangled signature", SimulateRequest{Transaction: mangled, Verify: true}, http.StatusBadRequest},
+	} {
+		tc := tc
+		t.Run(tc.desc, func(t *testing.T) {
+			require.Equal(t, tc.status, simulate(t, tc.req).StatusCode)
+		})
+	}
+}
+
+func TestTransactionService_EstimateGas(t *testing.T) {
+	db := sql.InMemory()
+	vminst := vm.New(db)
+	t.Cleanup(launchServer(t, cfg, NewTransactionService(db, nil, nil, txs.NewConservativeState(vminst, db), nil, nil, logtest.New(t).WithName("grpc.Transactions"))))
+	pub, priv, err := ed25519.GenerateKey(rand.New(rand.NewSource(10101)))
+	require.NoError(t, err)
+	key := signing.PrivateKey(priv)
+	require.NoError(t, vminst.ApplyGenesis([]types.Account{{Address: wallet.Address(pub), Balance: 1e12}}))
+
+	estimate := func(t *testing.T, tx []byte) *http.Response {
+		body, err := json.Marshal(EstimateGasRequest{Transaction: tx})
+		require.NoError(t, err)
+		resp, err := http.Post(fmt.Sprintf("http://%s%s", cfg.JSONListener, EstimateGasPath), "application/json",
+			bytes.NewReader(body))
+		require.NoError(t, err)
+		t.Cleanup(func() { resp.Body.Close() })
+		return resp
+	}
+	spawn := wallet.SelfSpawn(key, 0)
+	expected, err := vminst.EstimateGas(spawn)
+	require.NoError(t, err)
+	resp := estimate(t, spawn)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var rst EstimateGasResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&rst))
+	require.Equal(t, EstimateGasResponse{
+		BaseGas:      expected.BaseGas,
+		ExecGas:      expected.ExecGas,
+		TxDataGas:    expected.TxDataGas,
+		IntrinsicGas: expected.IntrinsicGas,
+		MaxGas:       expected.MaxGas,
+	}, rst)
+	require.NotZero(t, rst.MaxGas)
+
+	require.Equal(t, http.StatusBadRequest, estimate(t, nil).StatusCode)
+	require.Equal(t, http.StatusBadRequest, estimate(t, []byte("something")).StatusCode)
+	require.Equal(t, http.StatusNotFound, estimate(t, wallet.Spend(key, types.Address{1}, 100, 1)).StatusCode)
+}
@@ -68,6 +68,10 @@ func AddCommands(cmd *cobra.Command) {
 		cfg.TxsPerProposal, "the number of transactions to select per proposal")
 	cmd.PersistentFlags().Uint64Var(&cfg.BlockGasLimit, "block-gas-limit",
 		cfg.BlockGasLimit, "max gas allowed per block")
+	cmd.PersistentFlags().Uint32Var(&cfg.EscrowLayer, "escrow-layer",
+		cfg.EscrowLayer, "first layer when escrow template is enabled, disabled if zero")
+	cmd.PersistentFlags().Uint32Var(&cfg.SpendManyLayer, "spend-many-layer",
+		cfg.SpendManyLayer, "first layer when spend many method is enabled, disabled if zero")
 	cmd.PersistentFlags().IntVar(&cfg.OptFilterThreshold, "optimistic-filtering-threshold",
 		cfg.OptFilterThreshold, "threshold for optimistic filtering in percentage")
 
@@ -78,6 +82,10 @@ func AddCommands(cmd *cobra.Command) {
 		cfg.DatabaseConnections, "configure number of active connections to enable parallel read requests")
 	cmd.PersistentFlags().BoolVar(&cfg.DatabaseLatencyMetering, "db-latency-metering",
 		cfg.DatabaseLatencyMetering, "if enabled collect latency histogram for every database query")
+	cmd.PersistentFlags().DurationVar(&cfg.DatabaseSlowQueryThreshold, "db-slow-query-threshold",
+		cfg.DatabaseSlowQueryThreshold, "log database queries that take longer than the threshold, disabled if zero")
+	cmd.PersistentFlags().DurationVar(&cfg.DatabaseSlowQueryExplainInterval, "db-slow-query-explain-interval",
+		cfg.DatabaseSlowQueryExplainInterval, "interval for logging query plans of the slowest queries")
 
 	/** ======================== P2P Flags ========================== **/
 
@@ -149,6 +157,8 @@ func AddCommands(cmd *cobra.Command) {
 		cfg.API.GrpcSendMsgSize, "GRPC api send message size")
 	cmd.PersistentFlags().StringVar(&cfg.API.JSONListener, "grpc-json-listener",
 		cfg.API.JSONListener, "Socket for the grpc gateway for the list of services in grpc-public-services. If left empty - grpc gateway won't be enabled.")
+	cmd.PersistentFlags().StringVar(&cfg.API.PrivateJSONListener, "grpc-private-json-listener",
+		cfg.API.PrivateJSONListener, "Socket for the grpc gateway for the list of services in grpc-private-services. If left empty - private grpc gateway won't be enabled.")
 	/**======================== Hare Flags ========================== **/
 
 	// N determines the size of the hare committee
@@ -0,0 +1,220 @@
+package main
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"strings"
+
+	"github.com/spacemeshos/go-scale"
+	"github.com/spf13/cobra"
+
+	"github.com/spacemeshos/go-spacemesh/common/types"
+	"github.com/spacemeshos/go-spacemesh/genvm/core"
+	"github.com/spacemeshos/go-spacemesh/genvm/registry"
+	"github.com/spacemeshos/go-spacemesh/genvm/templates/escrow"
+	"github.com/spacemeshos/go-spacemesh/genvm/templates/multisig"
+	"github.com/spacemeshos/go-spacemesh/genvm/templates/vault"
+	"github.com/spacemeshos/go-spacemesh/genvm/templates/vesting"
+	"github.com/spacemeshos/go-spacemesh/genvm/templates/wallet"
+	"github.com/spacemeshos/go-spacemesh/signing"
+)
+
+var (
+	addressKey      string
+	addressTemplate string
+	addressRequired uint8
+	addressPubkeys  []string
+
+	inspectTemplate string
+)
+
+var templates = map[string]types.Address{
+	"wallet":         wallet.TemplateAddress,
+	templateMultisig: multisig.TemplateAddress,
+	templateVesting:  vesting.TemplateAddress,
+	"vault":          vault.TemplateAddress,
+	"escrow":         escrow.TemplateAddress,
+}
+
+func init() {
+	addressCmd.Flags().StringVar(&addressKey, "key", "", "path to the private key file of the wallet")
+	addressCmd.Flags().StringVar(&addressTemplate, "template", templateMultisig,
+		"template of the account without key. supported values: multisig, vesting")
+	addressCmd.Flags().Uint8Var(&addressRequired, "required", 0, "number of signatures required to authorize a transaction")
+	addressCmd.Flags().StringArrayVar(&addressPubkeys, "pubkey", nil, "hex encoded public key. can be repeated")
+
+	inspectCmd.Flags().StringVar(&inspectTemplate, "template", "wallet",
+		"template of the principal, ignored for spawn transactions. "+
+			"supported values: wallet, multisig, vesting, vault, escrow")
+
+	cmd.AddCommand(addressCmd, inspectCmd)
+}
+
+var addressCmd = &cobra.Command{
+	Use:   "address",
+	Short: "print address of the wallet from --key, or of the multisig account from --pubkey and --required",
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		if len(addressKey) > 0 {
+			pk, err := loadKey(addressKey)
+			if err != nil {
+				return err
+			}
+			args := wallet.SpawnArguments{}
+			copy(args.PublicKey[:], signing.Public(pk))
+			fmt.Fprintf(cmd.OutOrStdout(), "address: %s\npublic key: %s\n",
+				core.ComputePrincipal(wallet.TemplateAddress, &args).String(), hex.EncodeToString(args.PublicKey[:]))
+			return nil
+		}
+		template, err := multisigTemplateAddress(addressTemplate)
+		if err != nil {
+			return err
+		}
+		args, err := multisigSpawnArgs(addressRequired, addressPubkeys)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(cmd.OutOrStdout(), "address: %s\n", core.ComputePrincipal(template, args).String())
+		return nil
+	},
+}
+
+var inspectCmd = &cobra.Command{
+	Use:   "inspect [encoded tx]",
+	Short: "decode raw transaction. reads from stdin if transaction is not provided",
+	Args:  cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		var encoded []byte
+		if len(args) == 1 {
+			encoded = []byte(args[0])
+		} else {
+			data, err := io.ReadAll(os.Stdin)
+			if err != nil {
+				return err
+			}
+			encoded = data
+		}
+		raw, err := decodeTx(string(encoded))
+		if err != nil {
+			return fmt.Errorf("decode transaction: %w", err)
+		}
+		return inspect(cmd.OutOrStdout(), raw, inspectTemplate)
+	},
+}
+
+// inspect decodes transaction fields and arguments and prints them to w.
+//
+// Principal template can be found only in spawn transaction, for other methods
+// template name is used to decode arguments.
+func inspect(w io.Writer, raw []byte, name string) error {
+	reg := registry.New()
+	wallet.Register(reg)
+	multisig.Register(reg)
+	vesting.Register(reg)
+	vault.Register(reg)
+	escrow.Register(reg)
+
+	var (
+		reader    = bytes.NewReader(raw)
+		dec       = scale.NewDecoder(reader)
+		version   scale.U8
+		principal core.Address
+		method    scale.U8
+		template  core.Address
+		payload   core.Payload
+	)
+	if _, err := version.DecodeScale(dec); err != nil {
+		return fmt.Errorf("decode version: %w", err)
+	}
+	if _, err := principal.DecodeScale(dec); err != nil {
+		return fmt.Errorf("decode principal: %w", err)
+	}
+	if _, err := method.DecodeScale(dec); err != nil {
+		return fmt.Errorf("decode method: %w", err)
+	}
+	if method == core.MethodSpawn {
+		if _, err := template.DecodeScale(dec); err != nil {
+			return fmt.Errorf("decode template: %w", err)
+		}
+	} else {
+		address, exists := templates[name]
+		if !exists {
+			return fmt.Errorf("unknown template %q", name)
+		}
+		template = address
+	}
+	handler := reg.Get(template)
+	if handler == nil {
+		return fmt.Errorf("unknown template address %s", template.String())
+	}
+	if _, err := payload.DecodeScale(dec); err != nil {
+		return fmt.Errorf("decode payload: %w", err)
+	}
+	txargs := handler.Args(uint8(method))
+	if txargs == nil {
+		return fmt.Errorf("method %d is not supported by template %s", method, template.String())
+	}
+	if _, err := txargs.DecodeScale(dec); err != nil {
+		return fmt.Errorf("decode arguments: %w", err)
+	}
+	// decoder doesn't buffer, the rest is a signature
+	sig, err := io.ReadAll(reader)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(w, "id: %s\n", types.NewRawTx(raw).ID)
+	fmt.Fprintf(w, "version: %d\n", version)
+	fmt.Fprintf(w, "principal: %s\n", principal.String())
+	fmt.Fprintf(w, "method: %d\n", method)
+	fmt.Fprintf(w, "template: %s\n", template.String())
+	fmt.Fprintf(w, "nonce: %d\n", payload.Nonce)
+	fmt.Fprintf(w, "gas price: %d\n", payload.GasPrice)
+	fmt.Fprintf(w, "args: %s\n", formatValue(reflect.ValueOf(txargs)))
+	fmt.Fprintf(w, "signature: %s\n", hex.EncodeToString(sig))
+	return nil
+}
+
+// formatValue prints arguments in the human readable form. Addresses and hashes are printed
+// with their String method, as their Format method prints raw bytes.
+func formatValue(v reflect.Value) string {
+	if v.Kind() == reflect.Pointer {
+		return formatValue(v.Elem())
+	}
+	if v.Kind() != reflect.Struct && v.CanInterface() {
+		if stringer, ok := v.Interface().(fmt.Stringer); ok {
+			return stringer.String()
+		}
+	}
+	switch v.Kind() {
+	case reflect.Struct:
+		return "{" + strings.Join(formatFields(v), " ") + "}"
+	case reflect.Slice, reflect.Array:
+		elems := make([]string, 0, v.Len())
+		for i := 0; i < v.Len(); i++ {
+			elems = append(elems, formatValue(v.Index(i)))
+		}
+		return "[" + strings.Join(elems, " ") + "]"
+	}
+	return fmt.Sprintf("%v", v.Interface())
+}
+
+// formatFields returns name:value pairs, fields of embedded structs are inlined.
+func formatFields(v reflect.Value) []string {
+	var fields []string
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Type().Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		if field.Anonymous && field.Type.Kind() == reflect.Struct {
+			fields = append(fields, formatFields(v.Field(i))...)
+			continue
+		}
+		fields = append(fields, field.Name+":"+formatValue(v.Field(i)))
+	}
+	return fields
+}
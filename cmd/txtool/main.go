@@ -0,0 +1,156 @@
+// txtool builds, signs, inspects and encodes transactions without connecting to a node.
+//
+// Keys are read from files in the format written by the node (hex encoded ed25519 private key).
+// Multisig transactions are signed by every party separately into partial signature files,
+// which are merged and encoded once enough signatures are collected.
+package main
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/spacemeshos/go-spacemesh/common/types"
+	"github.com/spacemeshos/go-spacemesh/genvm/sdk"
+	"github.com/spacemeshos/go-spacemesh/signing"
+)
+
+const (
+	encodingHex    = "hex"
+	encodingBase64 = "base64"
+)
+
+var (
+	genesisID string
+	gasPrice  uint64
+	hrp       string
+	encoding  string
+)
+
+func init() {
+	cmd.PersistentFlags().StringVar(&genesisID, "genesis-id", "",
+		"hex encoded genesis id of the network, transactions are signed over it")
+	cmd.PersistentFlags().Uint64Var(&gasPrice, "gas-price", 1, "gas price for the transaction")
+	cmd.PersistentFlags().StringVar(&hrp, "hrp", types.NetworkHRP(), "human readable prefix of the network addresses")
+	cmd.PersistentFlags().StringVar(&encoding, "encoding", encodingHex,
+		"encoding of raw transactions. supported values: hex, base64")
+}
+
+var cmd = &cobra.Command{
+	Use:   "txtool",
+	Short: "build, sign and inspect transactions offline",
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		if encoding != encodingHex && encoding != encodingBase64 {
+			return fmt.Errorf("unknown encoding %q", encoding)
+		}
+		types.SetNetworkHRP(hrp)
+		return nil
+	},
+	SilenceUsage: true,
+}
+
+func main() {
+	if err := cmd.Execute(); err != nil {
+		os.Exit(1)
+	}
+}
+
+// options returns sdk options from the global flags.
+func options() ([]sdk.Opt, error) {
+	id, err := parseGenesisID(genesisID)
+	if err != nil {
+		return nil, err
+	}
+	return []sdk.Opt{sdk.WithGenesisID(id), sdk.WithGasPrice(gasPrice)}, nil
+}
+
+func parseGenesisID(value string) (types.Hash20, error) {
+	var id types.Hash20
+	if len(value) == 0 {
+		return id, fmt.Errorf("genesis id is required")
+	}
+	decoded, err := hex.DecodeString(strings.TrimPrefix(value, "0x"))
+	if err != nil {
+		return id, fmt.Errorf("decode genesis id %s: %w", value, err)
+	}
+	if len(decoded) != len(id) {
+		return id, fmt.Errorf("genesis id must be %d bytes, got %d", len(id), len(decoded))
+	}
+	copy(id[:], decoded)
+	return id, nil
+}
+
+// loadKey reads private key from the file created by signing.EdSigner.
+func loadKey(path string) (signing.PrivateKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read key file %s: %w", path, err)
+	}
+	key, err := hex.DecodeString(strings.TrimSpace(string(data)))
+	if err != nil {
+		return nil, fmt.Errorf("decode key file %s: %w", path, err)
+	}
+	signer, err := signing.NewEdSigner(signing.WithPrivateKey(key))
+	if err != nil {
+		return nil, fmt.Errorf("load key file %s: %w", path, err)
+	}
+	return signer.PrivateKey(), nil
+}
+
+func parsePublicKey(value string) (types.Hash32, error) {
+	var pub types.Hash32
+	decoded, err := hex.DecodeString(strings.TrimPrefix(value, "0x"))
+	if err != nil {
+		return pub, fmt.Errorf("decode public key %s: %w", value, err)
+	}
+	if len(decoded) != len(pub) {
+		return pub, fmt.Errorf("public key must be %d bytes, got %d", len(pub), len(decoded))
+	}
+	copy(pub[:], decoded)
+	return pub, nil
+}
+
+func parseAddress(value string) (types.Address, error) {
+	address, err := types.StringToAddress(value)
+	if err != nil {
+		return address, fmt.Errorf("parse address %s: %w", value, err)
+	}
+	return address, nil
+}
+
+// parseRecipient parses recipient in the <address>=<amount> format.
+func parseRecipient(value string) (types.Address, uint64, error) {
+	parts := strings.Split(value, "=")
+	if len(parts) != 2 {
+		return types.Address{}, 0, fmt.Errorf("recipient %s is not in <address>=<amount> format", value)
+	}
+	address, err := parseAddress(parts[0])
+	if err != nil {
+		return types.Address{}, 0, err
+	}
+	amount, err := strconv.ParseUint(parts[1], 10, 64)
+	if err != nil {
+		return types.Address{}, 0, fmt.Errorf("parse amount %s: %w", parts[1], err)
+	}
+	return address, amount, nil
+}
+
+func encodeTx(raw []byte) string {
+	if encoding == encodingBase64 {
+		return base64.StdEncoding.EncodeToString(raw)
+	}
+	return hex.EncodeToString(raw)
+}
+
+func decodeTx(value string) ([]byte, error) {
+	value = strings.TrimSpace(value)
+	if encoding == encodingBase64 {
+		return base64.StdEncoding.DecodeString(value)
+	}
+	return hex.DecodeString(strings.TrimPrefix(value, "0x"))
+}
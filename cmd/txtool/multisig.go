@@ -0,0 +1,377 @@
+package main
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+
+	"github.com/oasisprotocol/curve25519-voi/primitives/ed25519"
+	"github.com/spacemeshos/go-scale"
+	"github.com/spf13/cobra"
+
+	"github.com/spacemeshos/go-spacemesh/common/types"
+	"github.com/spacemeshos/go-spacemesh/genvm/core"
+	"github.com/spacemeshos/go-spacemesh/genvm/sdk"
+	sdkmultisig "github.com/spacemeshos/go-spacemesh/genvm/sdk/multisig"
+	"github.com/spacemeshos/go-spacemesh/genvm/templates/multisig"
+	"github.com/spacemeshos/go-spacemesh/genvm/templates/vesting"
+)
+
+const (
+	templateMultisig = "multisig"
+	templateVesting  = "vesting"
+)
+
+var (
+	multisigKey       string
+	multisigRef       uint8
+	multisigNonce     uint64
+	multisigOut       string
+	multisigPrincipal string
+	multisigTemplate  string
+	multisigRequired  uint8
+	multisigPubkeys   []string
+	multisigTo        string
+	multisigAmount    uint64
+	multisigMany      []string
+	multisigVault     string
+	multisigIn        string
+)
+
+func init() {
+	for _, c := range []*cobra.Command{msSpawnCmd, msSpendCmd, msSpendManyCmd, msDrainCmd, msSignCmd} {
+		c.Flags().StringVar(&multisigKey, "key", "", "path to the private key file")
+		c.Flags().Uint8Var(&multisigRef, "ref", 0, "index of the public key in the multisig account")
+		c.MarkFlagRequired("key")
+	}
+	for _, c := range []*cobra.Command{msSpawnCmd, msSpendCmd, msSpendManyCmd, msDrainCmd} {
+		c.Flags().Uint64Var(&multisigNonce, "nonce", 0, "nonce of the transaction")
+	}
+	for _, c := range []*cobra.Command{msSpendCmd, msSpendManyCmd, msDrainCmd} {
+		c.Flags().StringVar(&multisigPrincipal, "principal", "", "address of the multisig account")
+		c.MarkFlagRequired("principal")
+	}
+	for _, c := range []*cobra.Command{msSpendCmd, msDrainCmd} {
+		c.Flags().StringVar(&multisigTo, "to", "", "recipient address")
+		c.Flags().Uint64Var(&multisigAmount, "amount", 0, "amount in smidge")
+	}
+	for _, c := range []*cobra.Command{msSpawnCmd, msSpendCmd, msSpendManyCmd, msDrainCmd, msSignCmd, msMergeCmd} {
+		c.Flags().StringVar(&multisigOut, "out", "", "path to the partial signature file. printed if empty")
+	}
+	msSpawnCmd.Flags().StringVar(&multisigTemplate, "template", templateMultisig,
+		"template of the account. supported values: multisig, vesting")
+	msSpawnCmd.Flags().Uint8Var(&multisigRequired, "required", 0, "number of signatures required to authorize a transaction")
+	msSpawnCmd.Flags().StringArrayVar(&multisigPubkeys, "pubkey", nil, "hex encoded public key. can be repeated")
+	msSpendManyCmd.Flags().StringArrayVar(&multisigMany, "to", nil,
+		"recipient in the <address>=<amount> format. can be repeated")
+	msDrainCmd.Flags().StringVar(&multisigVault, "vault", "", "address of the vault")
+	msSignCmd.Flags().StringVar(&multisigIn, "in", "", "path to the partial signature file")
+	msSignCmd.MarkFlagRequired("in")
+
+	multisigCmd.AddCommand(msSpawnCmd, msSpendCmd, msSpendManyCmd, msDrainCmd, msSignCmd, msMergeCmd, msEncodeCmd)
+	cmd.AddCommand(multisigCmd)
+}
+
+// partial is a multisig transaction with a subset of signatures.
+//
+// Every party signs the same unsigned transaction into its own partial file,
+// files are merged and encoded into the raw transaction when threshold is reached.
+type partial struct {
+	Tx    string        `json:"tx"`
+	Parts []partialPart `json:"parts"`
+}
+
+type partialPart struct {
+	Ref       uint8  `json:"ref"`
+	Signature string `json:"signature"`
+}
+
+func (p *partial) unsigned() ([]byte, error) {
+	tx, err := hex.DecodeString(p.Tx)
+	if err != nil {
+		return nil, fmt.Errorf("decode unsigned tx: %w", err)
+	}
+	return tx, nil
+}
+
+// sign adds signature from pk, existing signature for ref is replaced.
+func (p *partial) sign(genesis types.Hash20, ref uint8, pk ed25519.PrivateKey) error {
+	tx, err := p.unsigned()
+	if err != nil {
+		return err
+	}
+	sig := ed25519.Sign(pk, core.SigningBody(genesis[:], tx))
+	return p.add(partialPart{Ref: ref, Signature: hex.EncodeToString(sig)}, true)
+}
+
+func (p *partial) add(part partialPart, replace bool) error {
+	for i := range p.Parts {
+		if p.Parts[i].Ref != part.Ref {
+			continue
+		}
+		if p.Parts[i].Signature != part.Signature && !replace {
+			return fmt.Errorf("conflicting signatures for ref %d", part.Ref)
+		}
+		p.Parts[i] = part
+		return nil
+	}
+	p.Parts = append(p.Parts, part)
+	sort.Slice(p.Parts, func(i, j int) bool {
+		return p.Parts[i].Ref < p.Parts[j].Ref
+	})
+	return nil
+}
+
+// merge parts from other partial file that signs the same transaction.
+func (p *partial) merge(other *partial) error {
+	if p.Tx != other.Tx {
+		return fmt.Errorf("partial files sign different transactions")
+	}
+	for _, part := range other.Parts {
+		if err := p.add(part, false); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// raw encodes transaction with all collected signatures.
+func (p *partial) raw() ([]byte, error) {
+	if len(p.Parts) == 0 {
+		return nil, fmt.Errorf("transaction is not signed")
+	}
+	tx, err := p.unsigned()
+	if err != nil {
+		return nil, err
+	}
+	aggregator := sdkmultisig.NewAggregator(tx)
+	for _, part := range p.Parts {
+		sig, err := hex.DecodeString(part.Signature)
+		if err != nil {
+			return nil, fmt.Errorf("decode signature for ref %d: %w", part.Ref, err)
+		}
+		if len(sig) != len(core.Signature{}) {
+			return nil, fmt.Errorf("signature for ref %d has invalid length %d", part.Ref, len(sig))
+		}
+		mpart := multisig.Part{Ref: part.Ref}
+		copy(mpart.Sig[:], sig)
+		aggregator.Add(mpart)
+	}
+	return aggregator.Raw(), nil
+}
+
+func readPartial(path string) (*partial, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read partial file %s: %w", path, err)
+	}
+	var p partial
+	if err := json.Unmarshal(data, &p); err != nil {
+		return nil, fmt.Errorf("decode partial file %s: %w", path, err)
+	}
+	return &p, nil
+}
+
+func writePartial(w io.Writer, path string, p *partial) error {
+	data, err := json.MarshalIndent(p, "", "  ")
+	if err != nil {
+		return err
+	}
+	if len(path) == 0 {
+		_, err = fmt.Fprintln(w, string(data))
+		return err
+	}
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		return fmt.Errorf("write partial file %s: %w", path, err)
+	}
+	return nil
+}
+
+// signUnsigned creates partial file for the unsigned transaction and signs it with the key from flags.
+func signUnsigned(cmd *cobra.Command, tx []byte) error {
+	p := &partial{Tx: hex.EncodeToString(tx)}
+	if err := signPartial(p); err != nil {
+		return err
+	}
+	return writePartial(cmd.OutOrStdout(), multisigOut, p)
+}
+
+func signPartial(p *partial) error {
+	pk, err := loadKey(multisigKey)
+	if err != nil {
+		return err
+	}
+	genesis, err := parseGenesisID(genesisID)
+	if err != nil {
+		return err
+	}
+	return p.sign(genesis, multisigRef, ed25519.PrivateKey(pk))
+}
+
+func payload() *core.Payload {
+	return &core.Payload{Nonce: core.Nonce(multisigNonce), GasPrice: gasPrice}
+}
+
+func multisigTemplateAddress(name string) (types.Address, error) {
+	switch name {
+	case templateMultisig:
+		return multisig.TemplateAddress, nil
+	case templateVesting:
+		return vesting.TemplateAddress, nil
+	}
+	return types.Address{}, fmt.Errorf("unknown multisig template %q", name)
+}
+
+func multisigSpawnArgs(required uint8, pubkeys []string) (*multisig.SpawnArguments, error) {
+	if required == 0 || int(required) > len(pubkeys) {
+		return nil, fmt.Errorf("required signatures %d must be between 1 and the number of public keys %d",
+			required, len(pubkeys))
+	}
+	args := &multisig.SpawnArguments{Required: required}
+	for _, value := range pubkeys {
+		pub, err := parsePublicKey(value)
+		if err != nil {
+			return nil, err
+		}
+		args.PublicKeys = append(args.PublicKeys, pub)
+	}
+	return args, nil
+}
+
+var multisigCmd = &cobra.Command{
+	Use:   "multisig",
+	Short: "build and partially sign transactions for multisig and vesting accounts",
+}
+
+var msSpawnCmd = &cobra.Command{
+	Use:   "spawn",
+	Short: "self-spawn multisig or vesting account",
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		template, err := multisigTemplateAddress(multisigTemplate)
+		if err != nil {
+			return err
+		}
+		args, err := multisigSpawnArgs(multisigRequired, multisigPubkeys)
+		if err != nil {
+			return err
+		}
+		principal := core.ComputePrincipal(template, args)
+		return signUnsigned(cmd, sdk.Encode(&sdk.TxVersion, &principal, &sdk.MethodSpawn, &template, payload(), args))
+	},
+}
+
+var msSpendCmd = &cobra.Command{
+	Use:   "spend",
+	Short: "spend to a single recipient",
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		principal, err := parseAddress(multisigPrincipal)
+		if err != nil {
+			return err
+		}
+		to, err := parseAddress(multisigTo)
+		if err != nil {
+			return err
+		}
+		args := &multisig.SpendArguments{Destination: to, Amount: multisigAmount}
+		return signUnsigned(cmd, sdk.Encode(&sdk.TxVersion, &principal, &sdk.MethodSpend, payload(), args))
+	},
+}
+
+var msSpendManyCmd = &cobra.Command{
+	Use:   "spend-many",
+	Short: "spend to several recipients in one transaction",
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		principal, err := parseAddress(multisigPrincipal)
+		if err != nil {
+			return err
+		}
+		recipients, err := parseRecipients(multisigMany)
+		if err != nil {
+			return err
+		}
+		args := &multisig.SpendManyArguments{Recipients: recipients}
+		return signUnsigned(cmd, sdk.Encode(&sdk.TxVersion, &principal, &sdk.MethodSpendMany, payload(), args))
+	},
+}
+
+var msDrainCmd = &cobra.Command{
+	Use:   "drain",
+	Short: "drain vault owned by the vesting account",
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		principal, err := parseAddress(multisigPrincipal)
+		if err != nil {
+			return err
+		}
+		vault, err := parseAddress(multisigVault)
+		if err != nil {
+			return err
+		}
+		to, err := parseAddress(multisigTo)
+		if err != nil {
+			return err
+		}
+		args := &vesting.DrainVaultArguments{Vault: vault}
+		args.Destination = to
+		args.Amount = multisigAmount
+		method := scale.U8(vesting.MethodDrainVault)
+		return signUnsigned(cmd, sdk.Encode(&sdk.TxVersion, &principal, &method, payload(), args))
+	},
+}
+
+var msSignCmd = &cobra.Command{
+	Use:   "sign",
+	Short: "add signature to the partial signature file",
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		p, err := readPartial(multisigIn)
+		if err != nil {
+			return err
+		}
+		if err := signPartial(p); err != nil {
+			return err
+		}
+		return writePartial(cmd.OutOrStdout(), multisigOut, p)
+	},
+}
+
+var msMergeCmd = &cobra.Command{
+	Use:   "merge <partial file>...",
+	Short: "merge signatures from partial signature files",
+	Args:  cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		merged, err := readPartial(args[0])
+		if err != nil {
+			return err
+		}
+		for _, path := range args[1:] {
+			p, err := readPartial(path)
+			if err != nil {
+				return err
+			}
+			if err := merged.merge(p); err != nil {
+				return fmt.Errorf("merge %s: %w", path, err)
+			}
+		}
+		return writePartial(cmd.OutOrStdout(), multisigOut, merged)
+	},
+}
+
+var msEncodeCmd = &cobra.Command{
+	Use:   "encode <partial file>",
+	Short: "encode raw transaction with all signatures from the partial signature file",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		p, err := readPartial(args[0])
+		if err != nil {
+			return err
+		}
+		raw, err := p.raw()
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(cmd.OutOrStdout(), encodeTx(raw))
+		return nil
+	},
+}
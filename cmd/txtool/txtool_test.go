@@ -0,0 +1,148 @@
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/stretchr/testify/require"
+
+	"github.com/spacemeshos/go-spacemesh/common/types"
+	vm "github.com/spacemeshos/go-spacemesh/genvm"
+	"github.com/spacemeshos/go-spacemesh/genvm/core"
+	"github.com/spacemeshos/go-spacemesh/genvm/templates/multisig"
+	"github.com/spacemeshos/go-spacemesh/signing"
+	"github.com/spacemeshos/go-spacemesh/sql"
+)
+
+var testGenesis = types.Hash20{1, 2, 3}
+
+// resetFlags restores defaults, flags are bound to the package variables
+// and otherwise leak between executions.
+func resetFlags(tb testing.TB, c *cobra.Command) {
+	set := func(f *pflag.Flag) {
+		if slice, ok := f.Value.(pflag.SliceValue); ok {
+			require.NoError(tb, slice.Replace(nil))
+		} else {
+			require.NoError(tb, f.Value.Set(f.DefValue))
+		}
+		f.Changed = false
+	}
+	c.Flags().VisitAll(set)
+	c.PersistentFlags().VisitAll(set)
+	for _, sub := range c.Commands() {
+		resetFlags(tb, sub)
+	}
+}
+
+func run(tb testing.TB, args ...string) string {
+	tb.Helper()
+	resetFlags(tb, cmd)
+	buf := bytes.NewBuffer(nil)
+	cmd.SetOut(buf)
+	cmd.SetArgs(append([]string{"--genesis-id", hex.EncodeToString(testGenesis[:])}, args...))
+	require.NoError(tb, cmd.Execute())
+	return strings.TrimSpace(buf.String())
+}
+
+func writeKey(tb testing.TB, dir string) (string, *signing.EdSigner) {
+	tb.Helper()
+	signer, err := signing.NewEdSigner()
+	require.NoError(tb, err)
+	path := filepath.Join(dir, hex.EncodeToString(signer.PublicKey().Bytes()[:4])+".key")
+	require.NoError(tb, os.WriteFile(path, []byte(hex.EncodeToString(signer.PrivateKey())), 0o600))
+	return path, signer
+}
+
+func verify(tb testing.TB, raw []byte) bool {
+	tb.Helper()
+	v := vm.New(sql.InMemory(), vm.WithConfig(vm.Config{GasLimit: 100_000_000, GenesisID: testGenesis}))
+	req := v.Validation(types.NewRawTx(raw))
+	_, err := req.Parse()
+	require.NoError(tb, err)
+	return req.Verify()
+}
+
+func TestWalletSpawn(t *testing.T) {
+	key, signer := writeKey(t, t.TempDir())
+
+	raw, err := hex.DecodeString(run(t, "--encoding", "hex", "wallet", "spawn", "--key", key))
+	require.NoError(t, err)
+	require.True(t, verify(t, raw))
+
+	encoded := run(t, "--encoding", "base64", "wallet", "spawn", "--key", key)
+	decoded, err := base64.StdEncoding.DecodeString(encoded)
+	require.NoError(t, err)
+	require.Equal(t, raw, decoded)
+
+	out := run(t, "--encoding", "hex", "address", "--key", key)
+	require.Contains(t, out, hex.EncodeToString(signer.PublicKey().Bytes()))
+
+	address := strings.TrimPrefix(strings.Split(out, "\n")[0], "address: ")
+	out = run(t, "--encoding", "hex", "inspect", hex.EncodeToString(raw))
+	require.Contains(t, out, "principal: "+address)
+	require.Contains(t, out, "method: 0")
+}
+
+func TestMultisigPartialSigning(t *testing.T) {
+	dir := t.TempDir()
+	key0, signer0 := writeKey(t, dir)
+	key1, signer1 := writeKey(t, dir)
+	spawn := []string{
+		"--encoding", "hex", "multisig", "spawn", "--required", "2",
+		"--pubkey", hex.EncodeToString(signer0.PublicKey().Bytes()),
+		"--pubkey", hex.EncodeToString(signer1.PublicKey().Bytes()),
+	}
+	part0 := filepath.Join(dir, "part0.json")
+	part1 := filepath.Join(dir, "part1.json")
+	merged := filepath.Join(dir, "merged.json")
+	run(t, append(spawn, "--key", key0, "--ref", "0", "--out", part0)...)
+	run(t, append(spawn, "--key", key1, "--ref", "1", "--out", part1)...)
+
+	raw, err := hex.DecodeString(run(t, "--encoding", "hex", "multisig", "encode", part0))
+	require.NoError(t, err)
+	require.False(t, verify(t, raw), "single signature is below threshold")
+
+	run(t, "--encoding", "hex", "multisig", "merge", "--out", merged, part0, part1)
+	raw, err = hex.DecodeString(run(t, "--encoding", "hex", "multisig", "encode", merged))
+	require.NoError(t, err)
+	require.True(t, verify(t, raw))
+
+	signed := filepath.Join(dir, "signed.json")
+	run(t, "--encoding", "hex", "multisig", "sign", "--key", key1, "--ref", "1", "--in", part0, "--out", signed)
+	encoded := run(t, "--encoding", "hex", "multisig", "encode", signed)
+	require.Equal(t, hex.EncodeToString(raw), encoded)
+
+	out := run(t, "--encoding", "hex", "inspect", encoded)
+	require.Contains(t, out, "template: "+multisig.TemplateAddress.String())
+	require.Contains(t, out, "Required:2")
+	require.Contains(t, out, signer1.PublicKey().String())
+}
+
+func TestPartialMerge(t *testing.T) {
+	p := &partial{Tx: "01", Parts: []partialPart{{Ref: 1, Signature: "aa"}}}
+	require.NoError(t, p.merge(&partial{Tx: "01", Parts: []partialPart{{Ref: 0, Signature: "bb"}, {Ref: 1, Signature: "aa"}}}))
+	require.Equal(t, []partialPart{{Ref: 0, Signature: "bb"}, {Ref: 1, Signature: "aa"}}, p.Parts)
+
+	require.ErrorContains(t, p.merge(&partial{Tx: "02"}), "different transactions")
+	require.ErrorContains(t, p.merge(&partial{Tx: "01", Parts: []partialPart{{Ref: 1, Signature: "cc"}}}), "conflicting")
+}
+
+func TestParseRecipient(t *testing.T) {
+	address := core.Address{4: 1}
+	to, amount, err := parseRecipient(address.String() + "=100")
+	require.NoError(t, err)
+	require.Equal(t, address, to)
+	require.EqualValues(t, 100, amount)
+
+	_, _, err = parseRecipient(address.String())
+	require.Error(t, err)
+	_, _, err = parseRecipient(address.String() + "=-1")
+	require.Error(t, err)
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/spacemeshos/go-spacemesh/genvm/core"
+	"github.com/spacemeshos/go-spacemesh/genvm/sdk"
+	sdkwallet "github.com/spacemeshos/go-spacemesh/genvm/sdk/wallet"
+	"github.com/spacemeshos/go-spacemesh/genvm/templates/wallet"
+	"github.com/spacemeshos/go-spacemesh/signing"
+)
+
+var (
+	walletKey    string
+	walletNonce  uint64
+	walletTo     string
+	walletAmount uint64
+	walletMany   []string
+)
+
+func init() {
+	walletCmd.PersistentFlags().StringVar(&walletKey, "key", "", "path to the private key file")
+	walletCmd.PersistentFlags().Uint64Var(&walletNonce, "nonce", 0, "nonce of the transaction")
+	walletCmd.MarkPersistentFlagRequired("key")
+
+	spendCmd.Flags().StringVar(&walletTo, "to", "", "recipient address")
+	spendCmd.Flags().Uint64Var(&walletAmount, "amount", 0, "amount in smidge")
+	spendManyCmd.Flags().StringArrayVar(&walletMany, "to", nil,
+		"recipient in the <address>=<amount> format. can be repeated")
+
+	walletCmd.AddCommand(spawnCmd, spendCmd, spendManyCmd)
+	cmd.AddCommand(walletCmd)
+}
+
+var walletCmd = &cobra.Command{
+	Use:   "wallet",
+	Short: "build and sign transactions for the single signature wallet",
+}
+
+var spawnCmd = &cobra.Command{
+	Use:   "spawn",
+	Short: "self-spawn wallet",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		pk, opts, err := walletParams()
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(cmd.OutOrStdout(), encodeTx(sdkwallet.SelfSpawn(pk, core.Nonce(walletNonce), opts...)))
+		return nil
+	},
+}
+
+var spendCmd = &cobra.Command{
+	Use:   "spend",
+	Short: "spend to a single recipient",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		pk, opts, err := walletParams()
+		if err != nil {
+			return err
+		}
+		to, err := parseAddress(walletTo)
+		if err != nil {
+			return err
+		}
+		raw := sdkwallet.Spend(pk, to, walletAmount, core.Nonce(walletNonce), opts...)
+		fmt.Fprintln(cmd.OutOrStdout(), encodeTx(raw))
+		return nil
+	},
+}
+
+var spendManyCmd = &cobra.Command{
+	Use:   "spend-many",
+	Short: "spend to several recipients in one transaction",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		pk, opts, err := walletParams()
+		if err != nil {
+			return err
+		}
+		recipients, err := parseRecipients(walletMany)
+		if err != nil {
+			return err
+		}
+		raw := sdkwallet.SpendMany(pk, recipients, core.Nonce(walletNonce), opts...)
+		fmt.Fprintln(cmd.OutOrStdout(), encodeTx(raw))
+		return nil
+	},
+}
+
+func walletParams() (signing.PrivateKey, []sdk.Opt, error) {
+	pk, err := loadKey(walletKey)
+	if err != nil {
+		return nil, nil, err
+	}
+	opts, err := options()
+	if err != nil {
+		return nil, nil, err
+	}
+	return pk, opts, nil
+}
+
+func parseRecipients(values []string) ([]wallet.SpendArguments, error) {
+	if len(values) == 0 {
+		return nil, fmt.Errorf("at least one recipient is required")
+	}
+	recipients := make([]wallet.SpendArguments, 0, len(values))
+	for _, value := range values {
+		to, amount, err := parseRecipient(value)
+		if err != nil {
+			return nil, err
+		}
+		recipients = append(recipients, wallet.SpendArguments{Destination: to, Amount: amount})
+	}
+	return recipients, nil
+}
@@ -77,6 +77,14 @@ func (g *TransactionResultGenerator) Next() *types.TransactionWithResult {
 			g.Addrs[i], g.Addrs[j] = g.Addrs[j], g.Addrs[i]
 		})
 		copy(tx.Addresses, g.Addrs)
+		for _, to := range tx.Addresses[1:] {
+			tx.Events = append(tx.Events, types.TransactionEvent{
+				Type:   types.EventTransfer,
+				From:   tx.Addresses[0],
+				To:     to,
+				Amount: uint64(g.rng.Intn(1000) + 1),
+			})
+		}
 	}
 	return &tx
 }
@@ -0,0 +1,49 @@
+package types
+
+import (
+	"github.com/spacemeshos/go-spacemesh/log"
+)
+
+//go:generate scalegen
+
+// TransactionEventType is a type of the event emitted during transaction execution.
+type TransactionEventType uint8
+
+const (
+	// EventTransfer is emitted when coins are moved From one account To another.
+	EventTransfer TransactionEventType = iota
+	// EventSpawn is emitted when principal (From) spawns account (To).
+	EventSpawn
+	// EventDrain is emitted when principal drains Amount from the vault (From) to the account (To).
+	EventDrain
+)
+
+// String implements human readable representation of the event type.
+func (t TransactionEventType) String() string {
+	switch t {
+	case EventTransfer:
+		return "transfer"
+	case EventSpawn:
+		return "spawn"
+	case EventDrain:
+		return "drain"
+	}
+	panic("unknown event type")
+}
+
+// TransactionEvent describes state change made by the transaction.
+type TransactionEvent struct {
+	Type   TransactionEventType
+	From   Address
+	To     Address
+	Amount uint64
+}
+
+// MarshalLogObject implements encoding for the transaction event.
+func (e *TransactionEvent) MarshalLogObject(encoder log.ObjectEncoder) error {
+	encoder.AddString("type", e.Type.String())
+	encoder.AddString("from", e.From.String())
+	encoder.AddString("to", e.To.String())
+	encoder.AddUint64("amount", e.Amount)
+	return nil
+}
@@ -38,7 +38,14 @@ type TransactionResult struct {
 	Block   BlockID
 	Layer   LayerID
 	// Addresses contains all updated addresses.
-	Addresses []Address `scale:"max=10"` // we expect 1-3 addresses to be updated in a transaction
+	//
+	// Breaking change: the limit was raised from 10 to 32 for SpendMany, which updates the principal
+	// and up to 20 recipients. Results with at most 10 addresses are encoded as before. Results are
+	// stored only in the local database and are not exchanged between nodes, and results with more
+	// than 10 addresses are created only by SpendMany, so only after the SpendManyLayer fork.
+	// Migration note: a node that applied such layers can't be downgraded to a version with the old
+	// limit without resyncing the database, as the old version fails to decode these results.
+	Addresses []Address `scale:"max=32"` // 1-3 addresses for most transactions, up to 21 for SpendMany
 }
 
 // MarshalLogObject implements encoding for the tx result.
@@ -64,4 +71,6 @@ func (h *TransactionResult) MarshalLogObject(encoder log.ObjectEncoder) error {
 type TransactionWithResult struct {
 	Transaction
 	TransactionResult
+	// Events emitted during execution, in the order of state changes.
+	Events []TransactionEvent `scale:"max=64"`
 }
@@ -51,7 +51,7 @@ func (t *TransactionResult) EncodeScale(enc *scale.Encoder) (total int, err erro
 		total += n
 	}
 	{
-		n, err := scale.EncodeStructSliceWithLimit(enc, t.Addresses, 10)
+		n, err := scale.EncodeStructSliceWithLimit(enc, t.Addresses, 32)
 		if err != nil {
 			return total, err
 		}
@@ -109,7 +109,7 @@ func (t *TransactionResult) DecodeScale(dec *scale.Decoder) (total int, err erro
 		t.Layer = LayerID(field)
 	}
 	{
-		field, n, err := scale.DecodeStructSliceWithLimit[Address](dec, 10)
+		field, n, err := scale.DecodeStructSliceWithLimit[Address](dec, 32)
 		if err != nil {
 			return total, err
 		}
@@ -134,6 +134,13 @@ func (t *TransactionWithResult) EncodeScale(enc *scale.Encoder) (total int, err
 		}
 		total += n
 	}
+	{
+		n, err := scale.EncodeStructSliceWithLimit(enc, t.Events, 64)
+		if err != nil {
+			return total, err
+		}
+		total += n
+	}
 	return total, nil
 }
 
@@ -152,5 +159,13 @@ func (t *TransactionWithResult) DecodeScale(dec *scale.Decoder) (total int, err
 		}
 		total += n
 	}
+	{
+		field, n, err := scale.DecodeStructSliceWithLimit[TransactionEvent](dec, 64)
+		if err != nil {
+			return total, err
+		}
+		total += n
+		t.Events = field
+	}
 	return total, nil
 }
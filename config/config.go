@@ -19,10 +19,13 @@ import (
 	vm "github.com/spacemeshos/go-spacemesh/genvm"
 	hareConfig "github.com/spacemeshos/go-spacemesh/hare/config"
 	eligConfig "github.com/spacemeshos/go-spacemesh/hare/eligibility/config"
+	"github.com/spacemeshos/go-spacemesh/indexer"
 	"github.com/spacemeshos/go-spacemesh/p2p"
+	"github.com/spacemeshos/go-spacemesh/prune"
 	"github.com/spacemeshos/go-spacemesh/syncer"
 	timeConfig "github.com/spacemeshos/go-spacemesh/timesync/config"
 	"github.com/spacemeshos/go-spacemesh/tortoise"
+	"github.com/spacemeshos/go-spacemesh/txs"
 )
 
 const (
@@ -61,6 +64,9 @@ type Config struct {
 	Bootstrap       bootstrap.Config      `mapstructure:"bootstrap"`
 	Sync            syncer.Config         `mapstructure:"syncer"`
 	Recovery        checkpoint.Config     `mapstructure:"recovery"`
+	Mempool         txs.MempoolConfig     `mapstructure:"mempool"`
+	Prune           prune.Config          `mapstructure:"prune"`
+	Indexer         indexer.Config        `mapstructure:"indexer"`
 }
 
 // DataDir returns the absolute path to use for the node's data. This is the tilde-expanded path given in the config
@@ -99,6 +105,10 @@ type BaseConfig struct {
 
 	TxsPerProposal int    `mapstructure:"txs-per-proposal"`
 	BlockGasLimit  uint64 `mapstructure:"block-gas-limit"`
+	// EscrowLayer is the first layer when escrow template is enabled, it is disabled if zero.
+	EscrowLayer uint32 `mapstructure:"escrow-layer"`
+	// SpendManyLayer is the first layer when SpendMany method is enabled, it is disabled if zero.
+	SpendManyLayer uint32 `mapstructure:"spend-many-layer"`
 	// if the number of proposals with the same mesh state crosses this threshold (in percentage),
 	// then we optimistically filter out infeasible transactions before constructing the block.
 	OptFilterThreshold int    `mapstructure:"optimistic-filtering-threshold"`
@@ -106,6 +116,10 @@ type BaseConfig struct {
 
 	DatabaseConnections     int  `mapstructure:"db-connections"`
 	DatabaseLatencyMetering bool `mapstructure:"db-latency-metering"`
+	// DatabaseSlowQueryThreshold enables slow query log if not zero.
+	DatabaseSlowQueryThreshold time.Duration `mapstructure:"db-slow-query-threshold"`
+	// DatabaseSlowQueryExplainInterval is the interval for explaining and logging the top slow queries.
+	DatabaseSlowQueryExplainInterval time.Duration `mapstructure:"db-slow-query-explain-interval"`
 
 	NetworkHRP string `mapstructure:"network-hrp"`
 }
@@ -148,6 +162,9 @@ func DefaultConfig() Config {
 		Bootstrap:       bootstrap.DefaultConfig(),
 		Sync:            syncer.DefaultConfig(),
 		Recovery:        checkpoint.DefaultConfig(),
+		Mempool:         txs.DefaultMempoolConfig(),
+		Prune:           prune.DefaultConfig(),
+		Indexer:         indexer.DefaultConfig(),
 	}
 }
 
@@ -177,6 +194,8 @@ func defaultBaseConfig() BaseConfig {
 		TickSize:            100,
 		DatabaseConnections: 16,
 		NetworkHRP:          "sm",
+
+		DatabaseSlowQueryExplainInterval: 10 * time.Minute,
 	}
 }
 
@@ -16,10 +16,13 @@ import (
 	"github.com/spacemeshos/go-spacemesh/fetch"
 	hareConfig "github.com/spacemeshos/go-spacemesh/hare/config"
 	eligConfig "github.com/spacemeshos/go-spacemesh/hare/eligibility/config"
+	"github.com/spacemeshos/go-spacemesh/indexer"
 	"github.com/spacemeshos/go-spacemesh/p2p"
+	"github.com/spacemeshos/go-spacemesh/prune"
 	"github.com/spacemeshos/go-spacemesh/syncer"
 	timeConfig "github.com/spacemeshos/go-spacemesh/timesync/config"
 	"github.com/spacemeshos/go-spacemesh/tortoise"
+	"github.com/spacemeshos/go-spacemesh/txs"
 )
 
 func MainnetConfig() Config {
@@ -44,6 +47,8 @@ func MainnetConfig() Config {
 			DatabaseConnections: 16,
 			NetworkHRP:          "sm",
 
+			DatabaseSlowQueryExplainInterval: 10 * time.Minute,
+
 			LayerDuration:  5 * time.Minute,
 			LayersPerEpoch: 4032,
 
@@ -130,5 +135,8 @@ func MainnetConfig() Config {
 		LOGGING:  defaultLoggingConfig(),
 		Sync:     syncer.DefaultConfig(),
 		Recovery: checkpoint.DefaultConfig(),
+		Mempool:  txs.DefaultMempoolConfig(),
+		Prune:    prune.DefaultConfig(),
+		Indexer:  indexer.DefaultConfig(),
 	}
 }
@@ -41,10 +41,24 @@ type CachedDB struct {
 	// used to coordinate db update and cache
 	mu               sync.Mutex
 	malfeasanceCache *lru.Cache[types.NodeID, *types.MalfeasanceProof]
+	// noMalfeasanceCache is set if the database is written by another process,
+	// and cached absence of the proof may become stale.
+	noMalfeasanceCache bool
+}
+
+// Opt for configuring CachedDB.
+type Opt func(*CachedDB)
+
+// WithoutMalfeasanceCache disables caching of malfeasance proofs. It should be used if the database
+// is written by another process, otherwise the node that became malicious may be reported as honest.
+func WithoutMalfeasanceCache() Opt {
+	return func(db *CachedDB) {
+		db.noMalfeasanceCache = true
+	}
 }
 
 // NewCachedDB create an instance of a CachedDB.
-func NewCachedDB(db *sql.Database, lg log.Log) *CachedDB {
+func NewCachedDB(db *sql.Database, lg log.Log, opts ...Opt) *CachedDB {
 	atxHdrCache, err := lru.New[types.ATXID, *types.ActivationTxHeader](atxHdrCacheSize)
 	if err != nil {
 		lg.Fatal("failed to create atx cache", err)
@@ -60,13 +74,17 @@ func NewCachedDB(db *sql.Database, lg log.Log) *CachedDB {
 		lg.Fatal("failed to create vrf nonce cache", err)
 	}
 
-	return &CachedDB{
+	cdb := &CachedDB{
 		Database:         db,
 		logger:           lg,
 		atxHdrCache:      atxHdrCache,
 		malfeasanceCache: malfeasanceCache,
 		vrfNonceCache:    vrfNonceCache,
 	}
+	for _, opt := range opts {
+		opt(cdb)
+	}
+	return cdb
 }
 
 func (db *CachedDB) MalfeasanceCacheSize() int {
@@ -78,6 +96,9 @@ func (db *CachedDB) IsMalicious(id types.NodeID) (bool, error) {
 	if id == types.EmptyNodeID {
 		db.logger.Fatal("invalid argument to IsMalicious")
 	}
+	if db.noMalfeasanceCache {
+		return identities.IsMalicious(db, id)
+	}
 
 	db.mu.Lock()
 	defer db.mu.Unlock()
@@ -104,6 +125,9 @@ func (db *CachedDB) GetMalfeasanceProof(id types.NodeID) (*types.MalfeasanceProo
 	if id == types.EmptyNodeID {
 		db.logger.Fatal("invalid argument to GetMalfeasanceProof")
 	}
+	if db.noMalfeasanceCache {
+		return identities.GetMalfeasanceProof(db.Database, id)
+	}
 
 	db.mu.Lock()
 	defer db.mu.Unlock()
@@ -126,6 +150,9 @@ func (db *CachedDB) CacheMalfeasanceProof(id types.NodeID, proof *types.Malfeasa
 	if id == types.EmptyNodeID {
 		db.logger.Fatal("invalid argument to CacheMalfeasanceProof")
 	}
+	if db.noMalfeasanceCache {
+		return
+	}
 
 	db.mu.Lock()
 	defer db.mu.Unlock()
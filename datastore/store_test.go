@@ -120,6 +120,25 @@ func TestMalfeasanceProof_Dishonest(t *testing.T) {
 	require.EqualValues(t, proof, got)
 }
 
+func TestMalfeasanceProof_WithoutCache(t *testing.T) {
+	db := sql.InMemory()
+	cdb := datastore.NewCachedDB(db, logtest.New(t), datastore.WithoutMalfeasanceCache())
+
+	nodeID1 := types.NodeID{1}
+	bad, err := cdb.IsMalicious(nodeID1)
+	require.NoError(t, err)
+	require.False(t, bad)
+	_, err = cdb.GetMalfeasanceProof(nodeID1)
+	require.ErrorIs(t, err, sql.ErrNotFound)
+
+	// proof saved by another process is visible immediately
+	require.NoError(t, identities.SetMalicious(db, nodeID1, []byte("bad"), time.Now()))
+	bad, err = cdb.IsMalicious(nodeID1)
+	require.NoError(t, err)
+	require.True(t, bad)
+	require.Zero(t, cdb.MalfeasanceCacheSize())
+}
+
 func TestIdentityExists(t *testing.T) {
 	cdb := datastore.NewCachedDB(sql.InMemory(), logtest.New(t))
 
@@ -0,0 +1,73 @@
+package events
+
+import (
+	"fmt"
+
+	"github.com/spacemeshos/go-spacemesh/common/types"
+	"github.com/spacemeshos/go-spacemesh/log"
+)
+
+// EvictionReason is a reason why transaction was evicted from the mempool.
+type EvictionReason int
+
+func (r EvictionReason) String() string {
+	switch r {
+	case EvictedReplaced:
+		return "replaced"
+	case EvictedExpired:
+		return "expired"
+	case EvictedPoolFull:
+		return "pool_full"
+	case EvictedNonceGap:
+		return "nonce_gap"
+	default:
+		return fmt.Sprintf("unknown(%d)", int(r))
+	}
+}
+
+const (
+	// EvictedReplaced is a reason for transaction that was replaced by a transaction
+	// with the same nonce and higher fee.
+	EvictedReplaced EvictionReason = iota
+	// EvictedExpired is a reason for transaction that wasn't included into any proposal or block
+	// for the configured time.
+	EvictedExpired
+	// EvictedPoolFull is a reason for transaction that was evicted to keep the mempool within limits.
+	EvictedPoolFull
+	// EvictedNonceGap is a reason for transaction that was evicted because transaction with lower nonce
+	// of the same principal was evicted. It is loaded back when the gap is filled.
+	EvictedNonceGap
+)
+
+// EventEvicted describes transaction evicted from the mempool.
+type EventEvicted struct {
+	ID        types.TransactionID
+	Principal types.Address
+	Nonce     uint64
+	Reason    EvictionReason
+}
+
+// ReportEvicted reports a transaction evicted from the mempool.
+func ReportEvicted(ev EventEvicted) {
+	mu.RLock()
+	defer mu.RUnlock()
+	if reporter != nil {
+		if err := reporter.evictedEmitter.Emit(ev); err != nil {
+			log.With().Error("failed to emit evicted transaction", ev.ID, log.Err(err))
+		}
+	}
+}
+
+// SubscribeEvicted subscribes to the transactions evicted from the mempool.
+func SubscribeEvicted() Subscription {
+	mu.RLock()
+	defer mu.RUnlock()
+	if reporter != nil {
+		sub, err := reporter.bus.Subscribe(new(EventEvicted))
+		if err != nil {
+			log.With().Panic("Failed to subscribe to evicted transactions")
+		}
+		return sub
+	}
+	return nil
+}
@@ -420,6 +420,7 @@ type EventReporter struct {
 	rewardEmitter      event.Emitter
 	resultsEmitter     event.Emitter
 	proposalsEmitter   event.Emitter
+	evictedEmitter     event.Emitter
 	events             struct {
 		sync.Mutex
 		buf     *Ring[UserEvent]
@@ -484,6 +485,10 @@ func newEventReporter() *EventReporter {
 	if err != nil {
 		log.With().Panic("failed to to create proposal emitter", log.Err(err))
 	}
+	evictedEmitter, err := bus.Emitter(new(EventEvicted))
+	if err != nil {
+		log.With().Panic("failed to create evicted emitter", log.Err(err))
+	}
 	eventsEmitter, err := bus.Emitter(new(UserEvent))
 	if err != nil {
 		log.With().Panic("failed to to create proposal emitter", log.Err(err))
@@ -500,6 +505,7 @@ func newEventReporter() *EventReporter {
 		resultsEmitter:     resultsEmitter,
 		errorEmitter:       errorEmitter,
 		proposalsEmitter:   proposalsEmitter,
+		evictedEmitter:     evictedEmitter,
 		stopChan:           make(chan struct{}),
 	}
 	reporter.events.buf = newRing[UserEvent](100)
@@ -539,6 +545,9 @@ func CloseEventReporter() {
 		if err := reporter.proposalsEmitter.Close(); err != nil {
 			log.With().Panic("failed to close propoposalsEmitter", log.Err(err))
 		}
+		if err := reporter.evictedEmitter.Close(); err != nil {
+			log.With().Panic("failed to close evictedEmitter", log.Err(err))
+		}
 
 		close(reporter.stopChan)
 		reporter = nil
@@ -16,6 +16,7 @@ import (
 	"github.com/spacemeshos/go-spacemesh/datastore"
 	"github.com/spacemeshos/go-spacemesh/log"
 	"github.com/spacemeshos/go-spacemesh/p2p"
+	"github.com/spacemeshos/go-spacemesh/p2p/pubsub"
 	"github.com/spacemeshos/go-spacemesh/p2p/server"
 	"github.com/spacemeshos/go-spacemesh/system"
 )
@@ -28,6 +29,12 @@ const (
 	meshHashProtocol = "mh/1"
 	malProtocol      = "ml/1"
 
+	// streaming versions of the protocols, responses are streamed in chunks
+	// and can be resumed after the last received id.
+	atxStreamProtocol     = "ax/2"
+	lyrDataStreamProtocol = "ld/2"
+	malStreamProtocol     = "ml/2"
+
 	cacheSize = 1000
 )
 
@@ -56,6 +63,7 @@ type promise struct {
 type batchInfo struct {
 	RequestBatch
 	peer p2p.Peer
+	sent time.Time
 }
 
 // setID calculates the hash of all requests and sets it as this batches ID.
@@ -82,6 +90,13 @@ type Config struct {
 	BatchSize, QueueSize int
 	RequestTimeout       time.Duration // in seconds
 	MaxRetriesForRequest int
+	// PeerBanThreshold is the number of responses that failed validation
+	// after which the peer is not selected for requests for PeerBanDuration.
+	PeerBanThreshold int
+	PeerBanDuration  time.Duration
+	// ServerLimits are the limits on the requests served for every protocol.
+	// Protocols without limits are served without rate and concurrency limits.
+	ServerLimits map[string]server.Limits
 }
 
 // DefaultConfig is the default config for the fetch component.
@@ -93,6 +108,17 @@ func DefaultConfig() Config {
 		BatchSize:            20,
 		RequestTimeout:       time.Second * time.Duration(10),
 		MaxRetriesForRequest: 100,
+		PeerBanThreshold:     5,
+		PeerBanDuration:      10 * time.Minute,
+		ServerLimits: map[string]server.Limits{
+			hashProtocol:          {PeerRate: 100, PeerBurst: 200},
+			atxProtocol:           {PeerRate: 1, PeerBurst: 10, Concurrency: 4},
+			atxStreamProtocol:     {PeerRate: 1, PeerBurst: 10, Concurrency: 4},
+			lyrOpnsProtocol:       {PeerRate: 10, PeerBurst: 50, Concurrency: 16},
+			lyrDataProtocol:       {PeerRate: 10, PeerBurst: 50},
+			lyrDataStreamProtocol: {PeerRate: 10, PeerBurst: 50},
+			malStreamProtocol:     {Concurrency: 4},
+		},
 	}
 }
 
@@ -157,6 +183,7 @@ type Fetch struct {
 	mu           sync.Mutex
 	onlyOnce     sync.Once
 	hashToPeers  *HashPeersCache
+	scores       *peerScores
 
 	shutdownCtx context.Context
 	cancel      context.CancelFunc
@@ -181,23 +208,39 @@ func NewFetch(cdb *datastore.CachedDB, msh meshProvider, b system.BeaconGetter,
 		opt(f)
 	}
 
+	f.scores = newPeerScores(f.logger, f.cfg.PeerBanThreshold, f.cfg.PeerBanDuration)
 	f.batchTimeout = time.NewTicker(f.cfg.BatchTimeout)
-	srvOpts := []server.Opt{
-		server.WithTimeout(f.cfg.RequestTimeout),
-		server.WithLog(f.logger),
-	}
 	if len(f.servers) == 0 {
 		h := newHandler(cdb, f.cfg, bs, msh, b, f.logger)
-		f.servers[atxProtocol] = server.New(host, atxProtocol, h.handleEpochInfoReq, srvOpts...)
-		f.servers[lyrDataProtocol] = server.New(host, lyrDataProtocol, h.handleLayerDataReq, srvOpts...)
-		f.servers[lyrOpnsProtocol] = server.New(host, lyrOpnsProtocol, h.handleLayerOpinionsReq, srvOpts...)
-		f.servers[hashProtocol] = server.New(host, hashProtocol, h.handleHashReq, srvOpts...)
-		f.servers[meshHashProtocol] = server.New(host, meshHashProtocol, h.handleMeshHashReq, srvOpts...)
-		f.servers[malProtocol] = server.New(host, malProtocol, h.handleMaliciousIDsReq, srvOpts...)
+		f.registerServer(host, atxProtocol, h.handleEpochInfoReq)
+		f.registerServer(host, lyrDataProtocol, h.handleLayerDataReq)
+		f.registerServer(host, lyrOpnsProtocol, h.handleLayerOpinionsReq)
+		f.registerServer(host, hashProtocol, h.handleHashReq)
+		f.registerServer(host, meshHashProtocol, h.handleMeshHashReq)
+		f.registerServer(host, malProtocol, h.handleMaliciousIDsReq)
+		f.registerStreamServer(host, atxStreamProtocol, h.handleEpochInfoStream)
+		f.registerStreamServer(host, lyrDataStreamProtocol, h.handleLayerDataStream)
+		f.registerStreamServer(host, malStreamProtocol, h.handleMaliciousIDsStream)
 	}
 	return f
 }
 
+func (f *Fetch) registerServer(host *p2p.Host, proto string, handler server.Handler) {
+	f.servers[proto] = server.New(host, proto, handler,
+		server.WithTimeout(f.cfg.RequestTimeout),
+		server.WithLog(f.logger),
+		server.WithLimits(f.cfg.ServerLimits[proto]),
+	)
+}
+
+func (f *Fetch) registerStreamServer(host *p2p.Host, proto string, handler server.StreamHandler) {
+	f.servers[proto] = server.NewStreaming(host, proto, handler,
+		server.WithTimeout(f.cfg.RequestTimeout),
+		server.WithLog(f.logger),
+		server.WithLimits(f.cfg.ServerLimits[proto]),
+	)
+}
+
 type dataValidators struct {
 	atx         SyncValidator
 	poet        SyncValidator
@@ -321,6 +364,12 @@ func (f *Fetch) receiveResponse(data []byte) {
 		return
 	}
 
+	if len(response.Responses) == 0 && len(batch.Requests) > 0 {
+		f.scores.onFailure(batch.peer)
+	} else {
+		f.scores.onSuccess(batch.peer, time.Since(batch.sent))
+	}
+
 	batchMap := batch.toMap()
 	// iterate all hash Responses
 	for _, resp := range response.Responses {
@@ -338,7 +387,11 @@ func (f *Fetch) receiveResponse(data []byte) {
 		rsp := resp
 		f.eg.Go(func() error {
 			// validation fetch data recursively. offload to another goroutine
-			f.hashValidationDone(rsp.Hash, req.validator(req.ctx, batch.peer, rsp.Data))
+			err := req.validator(req.ctx, batch.peer, rsp.Data)
+			if errors.Is(err, pubsub.ErrValidationReject) {
+				f.scores.onInvalid(batch.peer)
+			}
+			f.hashValidationDone(rsp.Hash, err)
 			return nil
 		})
 		delete(batchMap, resp.Hash)
@@ -463,9 +516,9 @@ func (f *Fetch) organizeRequests(requests []RequestMessage) map[p2p.Peer][][]Req
 	}
 
 	for _, req := range requests {
-		p, exists := f.hashToPeers.GetRandom(req.Hash, req.Hint, rng)
-		if !exists {
-			p = randomPeer(peers)
+		p := f.scores.selectPeer(f.hashToPeers.GetPeers(req.Hash, req.Hint), rng)
+		if p2p.IsNoPeer(p) {
+			p = f.scores.selectPeer(peers, rng)
 		}
 
 		_, ok := peer2requests[p]
@@ -510,6 +563,7 @@ func (f *Fetch) sendBatch(p p2p.Peer, batch *batchInfo) error {
 		f.logger.With().Warning("failed to send batch",
 			log.Stringer("batch_hash", batch.ID),
 			log.Err(err))
+		f.scores.onFailure(p)
 		f.handleHashError(batch.ID, err)
 	}
 
@@ -530,11 +584,15 @@ func (f *Fetch) sendBatch(p p2p.Peer, batch *batchInfo) error {
 			log.Int("num_requests", len(batch.Requests)),
 			log.Stringer("peer", p))
 
+		f.mu.Lock()
+		batch.sent = time.Now()
+		f.mu.Unlock()
 		err = f.servers[hashProtocol].Request(f.shutdownCtx, p, bytes, f.receiveResponse, errorFunc)
 		if err == nil {
 			break
 		}
 
+		f.scores.onFailure(p)
 		retries++
 		if retries > f.cfg.MaxRetriesForPeer {
 			f.handleHashError(batch.ID, fmt.Errorf("batched request failed w retries: %w", err))
@@ -635,3 +693,14 @@ func (f *Fetch) RegisterPeerHashes(peer p2p.Peer, hashes []types.Hash32) {
 func (f *Fetch) GetPeers() []p2p.Peer {
 	return f.host.GetPeers()
 }
+
+// RankedPeers returns connected peers ordered by their score, without peers
+// that are banned for serving invalid data.
+func (f *Fetch) RankedPeers() []p2p.Peer {
+	return f.scores.rank(f.host.GetPeers())
+}
+
+// ReportInvalid is called when the peer served data that failed validation outside of fetch.
+func (f *Fetch) ReportInvalid(peer p2p.Peer) {
+	f.scores.onInvalid(peer)
+}
@@ -69,6 +69,9 @@ func createFetch(tb testing.TB) *testFetch {
 		1000,
 		time.Second * time.Duration(3),
 		3,
+		5,
+		time.Minute,
+		nil,
 	}
 	lg := logtest.New(tb)
 	tf.Fetch = NewFetch(datastore.NewCachedDB(sql.InMemory(), lg), tf.mMesh, nil, nil,
@@ -76,12 +79,15 @@ func createFetch(tb testing.TB) *testFetch {
 		WithConfig(cfg),
 		WithLogger(lg),
 		withServers(map[string]requester{
-			malProtocol:      tf.mMalS,
-			atxProtocol:      tf.mAtxS,
-			lyrDataProtocol:  tf.mLyrS,
-			lyrOpnsProtocol:  tf.mOpnS,
-			hashProtocol:     tf.mHashS,
-			meshHashProtocol: tf.mMHashS,
+			malProtocol:           tf.mMalS,
+			malStreamProtocol:     tf.mMalS,
+			atxProtocol:           tf.mAtxS,
+			atxStreamProtocol:     tf.mAtxS,
+			lyrDataProtocol:       tf.mLyrS,
+			lyrDataStreamProtocol: tf.mLyrS,
+			lyrOpnsProtocol:       tf.mOpnS,
+			hashProtocol:          tf.mHashS,
+			meshHashProtocol:      tf.mMHashS,
 		}),
 		withHost(tf.mh))
 	tf.Fetch.SetValidators(tf.mAtxH, tf.mPoetH, tf.mBallotH, tf.mBlocksH, tf.mProposalH, tf.mTxBlocksH, tf.mTxProposalH, tf.mMalH)
@@ -320,6 +326,9 @@ func TestFetch_PeerDroppedWhenMessageResultsInValidationReject(t *testing.T) {
 		1000,
 		time.Second * time.Duration(3),
 		3,
+		5,
+		time.Minute,
+		nil,
 	}
 	p2pconf := p2p.DefaultConfig()
 	p2pconf.Listen = "/ip4/127.0.0.1/tcp/0"
@@ -14,9 +14,18 @@ import (
 	"github.com/spacemeshos/go-spacemesh/sql/certificates"
 	"github.com/spacemeshos/go-spacemesh/sql/identities"
 	"github.com/spacemeshos/go-spacemesh/sql/layers"
+	"github.com/spacemeshos/go-spacemesh/sql/proposals"
 	"github.com/spacemeshos/go-spacemesh/system"
 )
 
+// streamChunkSize is the number of ids in a chunk of the streamed response.
+// It is within the limits of EpochData, MaliciousIDs and LayerData.
+const streamChunkSize = 500
+
+// errPruned is returned to peers that request data that was removed by pruning,
+// so that they don't mistake it for the absence of data and ask other peers.
+var errPruned = errors.New("data is pruned")
+
 type handler struct {
 	logger log.Log
 	cdb    *datastore.CachedDB
@@ -55,6 +64,28 @@ func (h *handler) handleMaliciousIDsReq(ctx context.Context, _ []byte) ([]byte,
 	return data, nil
 }
 
+// handleMaliciousIDsStream streams the IDs of known malicious nodes in chunks of MaliciousIDs.
+func (h *handler) handleMaliciousIDsStream(ctx context.Context, msg []byte, send func([]byte) error) error {
+	var req MaliciousIDsRequest
+	if err := codec.Decode(msg, &req); err != nil {
+		return err
+	}
+	n, err := streamIDs(send, req.After,
+		func(after types.NodeID) ([]types.NodeID, error) {
+			return identities.GetMaliciousAfter(h.cdb, after, streamChunkSize)
+		},
+		func(ids []types.NodeID) ([]byte, error) {
+			return codec.Encode(&MaliciousIDs{NodeIDs: ids})
+		},
+	)
+	if err != nil {
+		h.logger.WithContext(ctx).With().Debug("failed to stream malicious IDs", log.Err(err))
+		return err
+	}
+	h.logger.WithContext(ctx).With().Debug("streamed malicious IDs", log.Int("num_malicious", n))
+	return nil
+}
+
 // handleEpochInfoReq returns the ATXs published in the specified epoch.
 func (h *handler) handleEpochInfoReq(ctx context.Context, msg []byte) ([]byte, error) {
 	var epoch types.EpochID
@@ -79,6 +110,28 @@ func (h *handler) handleEpochInfoReq(ctx context.Context, msg []byte) ([]byte, e
 	return bts, nil
 }
 
+// handleEpochInfoStream streams the ATXs published in the specified epoch in chunks of EpochData.
+func (h *handler) handleEpochInfoStream(ctx context.Context, msg []byte, send func([]byte) error) error {
+	var req EpochInfoRequest
+	if err := codec.Decode(msg, &req); err != nil {
+		return err
+	}
+	n, err := streamIDs(send, req.After,
+		func(after types.ATXID) ([]types.ATXID, error) {
+			return atxs.GetIDsByEpochAfter(h.cdb, req.Epoch, after, streamChunkSize)
+		},
+		func(ids []types.ATXID) ([]byte, error) {
+			return codec.Encode(&EpochData{AtxIDs: ids})
+		},
+	)
+	if err != nil {
+		h.logger.WithContext(ctx).With().Debug("failed to stream epoch atx IDs", req.Epoch, log.Err(err))
+		return err
+	}
+	h.logger.WithContext(ctx).With().Debug("streamed epoch info", req.Epoch, log.Int("atx_count", n))
+	return nil
+}
+
 // handleLayerDataReq returns all data in a layer, described in LayerData.
 func (h *handler) handleLayerDataReq(ctx context.Context, req []byte) ([]byte, error) {
 	var (
@@ -102,6 +155,76 @@ func (h *handler) handleLayerDataReq(ctx context.Context, req []byte) ([]byte, e
 	return out, nil
 }
 
+// handleLayerDataStream streams the ballots in the layer in chunks of LayerData.
+func (h *handler) handleLayerDataStream(ctx context.Context, msg []byte, send func([]byte) error) error {
+	var req LayerDataRequest
+	if err := codec.Decode(msg, &req); err != nil {
+		return err
+	}
+	_, err := streamIDs(send, req.After,
+		func(after types.BallotID) ([]types.BallotID, error) {
+			return ballots.IDsInLayerAfter(h.cdb, req.Layer, after, streamChunkSize)
+		},
+		func(ids []types.BallotID) ([]byte, error) {
+			return codec.Encode(&LayerData{Ballots: ids})
+		},
+	)
+	if err != nil {
+		h.logger.WithContext(ctx).With().Debug("failed to stream layer ballots", req.Layer, log.Err(err))
+	}
+	return err
+}
+
+// streamIDs sends ids that are greater than after, page by page.
+// The next page is read from the database only after the previous one is accepted by the peer,
+// so that slow peers don't hold the database.
+func streamIDs[T any](send func([]byte) error, after T, page func(T) ([]T, error), encode func([]T) ([]byte, error)) (int, error) {
+	total := 0
+	for {
+		ids, err := page(after)
+		if err != nil {
+			return total, err
+		}
+		if len(ids) == 0 {
+			return total, nil
+		}
+		data, err := encode(ids)
+		if err != nil {
+			return total, err
+		}
+		if err := send(data); err != nil {
+			return total, err
+		}
+		total += len(ids)
+		if len(ids) < streamChunkSize {
+			return total, nil
+		}
+		after = ids[len(ids)-1]
+	}
+}
+
+// checkPruned returns errPruned if certificates in the layer were pruned.
+func (h *handler) checkPruned(ctx context.Context, lid types.LayerID) error {
+	pruned, err := layers.GetPruned(h.cdb)
+	if err != nil {
+		h.logger.WithContext(ctx).With().Warning("failed to get pruned layer", lid, log.Err(err))
+		return err
+	}
+	if lid.Before(pruned) {
+		h.logger.WithContext(ctx).With().Debug("remote peer requested pruned layer", lid)
+		return errPruned
+	}
+	return nil
+}
+
+// isPruned returns true if the requested proposal was pruned.
+func (h *handler) isPruned(hint datastore.Hint, hash types.Hash32) (bool, error) {
+	if hint != datastore.ProposalDB {
+		return false, nil
+	}
+	return proposals.IsPruned(h.cdb, types.ProposalID(hash.ToHash20()))
+}
+
 // handleLayerOpinionsReq returns the opinions on data in the specified layer, described in LayerOpinion.
 func (h *handler) handleLayerOpinionsReq(ctx context.Context, req []byte) ([]byte, error) {
 	var (
@@ -113,6 +236,9 @@ func (h *handler) handleLayerOpinionsReq(ctx context.Context, req []byte) ([]byt
 	if err := codec.Decode(req, &lid); err != nil {
 		return nil, err
 	}
+	if err := h.checkPruned(ctx, lid); err != nil {
+		return nil, err
+	}
 	lo.PrevAggHash, err = layers.GetAggregatedHash(h.cdb, lid.Sub(1))
 	if err != nil && !errors.Is(err, sql.ErrNotFound) {
 		h.logger.WithContext(ctx).With().Warning("failed to get prev agg hash", lid, log.Err(err))
@@ -161,6 +287,20 @@ func (h *handler) handleHashReq(ctx context.Context, data []byte) ([]byte, error
 	for _, r := range requestBatch.Requests {
 		totalHashReqs.WithLabelValues(string(r.Hint)).Add(1)
 		res, err := h.bs.Get(r.Hint, r.Hash.Bytes())
+		// responses can't carry an error for a single item, so pruned item fails the whole batch
+		if errors.Is(err, sql.ErrNotFound) {
+			pruned, perr := h.isPruned(r.Hint, r.Hash)
+			if perr != nil {
+				h.logger.WithContext(ctx).With().Warning("failed to check pruned hash", log.Err(perr))
+				return nil, perr
+			}
+			if pruned {
+				h.logger.WithContext(ctx).With().Debug("remote peer requested pruned hash",
+					log.String("hash", r.Hash.ShortString()),
+					log.String("hint", string(r.Hint)))
+				return nil, errPruned
+			}
+		}
 		if err != nil {
 			h.logger.WithContext(ctx).With().Debug("remote peer requested nonexistent hash",
 				log.String("hash", r.Hash.ShortString()),
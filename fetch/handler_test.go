@@ -1,7 +1,9 @@
 package fetch
 
 import (
+	"bytes"
 	"context"
+	"sort"
 	"testing"
 	"time"
 
@@ -22,6 +24,7 @@ import (
 	"github.com/spacemeshos/go-spacemesh/sql/certificates"
 	"github.com/spacemeshos/go-spacemesh/sql/identities"
 	"github.com/spacemeshos/go-spacemesh/sql/layers"
+	"github.com/spacemeshos/go-spacemesh/sql/proposals"
 	smocks "github.com/spacemeshos/go-spacemesh/system/mocks"
 )
 
@@ -122,6 +125,7 @@ func TestHandleLayerOpinionsReq(t *testing.T) {
 	tt := []struct {
 		name                       string
 		missingCert, multipleCerts bool
+		pruned                     bool
 	}{
 		{
 			name: "all good",
@@ -134,6 +138,10 @@ func TestHandleLayerOpinionsReq(t *testing.T) {
 			name:          "multiple certs",
 			multipleCerts: true,
 		},
+		{
+			name:   "pruned",
+			pruned: true,
+		},
 	}
 
 	for _, tc := range tt {
@@ -150,10 +158,18 @@ func TestHandleLayerOpinionsReq(t *testing.T) {
 				}))
 			}
 
+			if tc.pruned {
+				require.NoError(t, layers.SetPruned(th.cdb, lid.Add(1)))
+			}
+
 			lidBytes, err := codec.Encode(&lid)
 			require.NoError(t, err)
 
 			out, err := th.handleLayerOpinionsReq(context.Background(), lidBytes)
+			if tc.pruned {
+				require.ErrorIs(t, err, errPruned)
+				return
+			}
 			require.NoError(t, err)
 
 			var got LayerOpinion
@@ -335,3 +351,115 @@ func TestHandleMaliciousIDsReq(t *testing.T) {
 		})
 	}
 }
+
+// streamChunks collects chunks streamed by the handler.
+func streamChunks(tb testing.TB, handler func(context.Context, []byte, func([]byte) error) error, req codec.Encodable) [][]byte {
+	tb.Helper()
+	data, err := codec.Encode(req)
+	require.NoError(tb, err)
+	var chunks [][]byte
+	require.NoError(tb, handler(context.Background(), data, func(chunk []byte) error {
+		chunks = append(chunks, chunk)
+		return nil
+	}))
+	return chunks
+}
+
+func TestHandleEpochInfoStream(t *testing.T) {
+	th := createTestHandler(t)
+	epoch := types.EpochID(11)
+	var expected []types.ATXID
+	for i := 0; i < 10; i++ {
+		vatx := newAtx(t, epoch)
+		require.NoError(t, atxs.Add(th.cdb, vatx))
+		expected = append(expected, vatx.ID())
+	}
+	sort.Slice(expected, func(i, j int) bool {
+		return bytes.Compare(expected[i].Bytes(), expected[j].Bytes()) < 0
+	})
+
+	chunks := streamChunks(t, th.handleEpochInfoStream, &EpochInfoRequest{Epoch: epoch})
+	require.Len(t, chunks, 1)
+	var got EpochData
+	require.NoError(t, codec.Decode(chunks[0], &got))
+	require.Equal(t, expected, got.AtxIDs)
+
+	chunks = streamChunks(t, th.handleEpochInfoStream, &EpochInfoRequest{Epoch: epoch, After: expected[4]})
+	require.Len(t, chunks, 1)
+	require.NoError(t, codec.Decode(chunks[0], &got))
+	require.Equal(t, expected[5:], got.AtxIDs)
+
+	require.Empty(t, streamChunks(t, th.handleEpochInfoStream, &EpochInfoRequest{Epoch: epoch + 1}))
+}
+
+func TestHandleMaliciousIDsStream(t *testing.T) {
+	th := createTestHandler(t)
+	var bad []types.NodeID
+	for i := 0; i < 2*streamChunkSize+10; i++ {
+		nid := types.NodeID{byte(i>>8 + 1), byte(i)}
+		bad = append(bad, nid)
+		require.NoError(t, identities.SetMalicious(th.cdb, nid, types.RandomBytes(11), time.Now()))
+	}
+
+	chunks := streamChunks(t, th.handleMaliciousIDsStream, &MaliciousIDsRequest{})
+	require.Len(t, chunks, 3)
+	var all []types.NodeID
+	for _, chunk := range chunks {
+		var got MaliciousIDs
+		require.NoError(t, codec.Decode(chunk, &got))
+		require.LessOrEqual(t, len(got.NodeIDs), streamChunkSize)
+		all = append(all, got.NodeIDs...)
+	}
+	require.Equal(t, bad, all)
+}
+
+func TestHandleLayerDataStream(t *testing.T) {
+	lid := types.LayerID(111)
+	th := createTestHandler(t)
+	blts, _ := createLayer(t, th.cdb, lid)
+
+	chunks := streamChunks(t, th.handleLayerDataStream, &LayerDataRequest{Layer: lid})
+	require.Len(t, chunks, 1)
+	var got LayerData
+	require.NoError(t, codec.Decode(chunks[0], &got))
+	require.ElementsMatch(t, blts, got.Ballots)
+}
+
+func TestHandleHashReqPruned(t *testing.T) {
+	th := createTestHandler(t)
+	lid := types.LayerID(111)
+	createProposal := func(lid types.LayerID) types.ProposalID {
+		ballot := types.NewExistingBallot(types.RandomBallotID(), types.RandomEdSignature(), types.RandomNodeID(), lid)
+		require.NoError(t, ballots.Add(th.cdb, &ballot))
+		proposal := &types.Proposal{
+			InnerProposal: types.InnerProposal{Ballot: ballot},
+			Signature:     types.RandomEdSignature(),
+		}
+		proposal.SetID(types.RandomProposalID())
+		require.NoError(t, proposals.Add(th.cdb, proposal))
+		return proposal.ID()
+	}
+	old := createProposal(lid)
+	kept := createProposal(lid.Add(1))
+	require.NoError(t, proposals.DeleteBefore(th.cdb, lid.Add(1)))
+
+	request := func(ids ...types.ProposalID) ([]byte, error) {
+		batch := RequestBatch{ID: types.RandomHash()}
+		for _, id := range ids {
+			batch.Requests = append(batch.Requests, RequestMessage{Hint: datastore.ProposalDB, Hash: id.AsHash32()})
+		}
+		data, err := codec.Encode(&batch)
+		require.NoError(t, err)
+		return th.handleHashReq(context.Background(), data)
+	}
+
+	out, err := request(kept, types.RandomProposalID())
+	require.NoError(t, err)
+	var got ResponseBatch
+	require.NoError(t, codec.Decode(out, &got))
+	require.Len(t, got.Responses, 1)
+	require.Equal(t, kept.AsHash32(), got.Responses[0].Hash)
+
+	_, err = request(kept, old)
+	require.ErrorIs(t, err, errPruned)
+}
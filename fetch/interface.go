@@ -12,6 +12,7 @@ import (
 
 type requester interface {
 	Request(context.Context, p2p.Peer, []byte, func([]byte), func(error)) error
+	StreamRequest(context.Context, p2p.Peer, []byte, func([]byte) error) error
 }
 
 // The ValidatorFunc type is an adapter to allow the use of functions as
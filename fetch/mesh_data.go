@@ -1,9 +1,11 @@
 package fetch
 
 import (
+	"bytes"
 	"context"
 	"errors"
 	"fmt"
+	"time"
 
 	"github.com/hashicorp/go-multierror"
 
@@ -13,9 +15,13 @@ import (
 	"github.com/spacemeshos/go-spacemesh/datastore"
 	"github.com/spacemeshos/go-spacemesh/log"
 	"github.com/spacemeshos/go-spacemesh/p2p"
+	"github.com/spacemeshos/go-spacemesh/p2p/server"
 )
 
-var errBadRequest = errors.New("invalid request")
+var (
+	errBadRequest  = errors.New("invalid request")
+	errBadResponse = errors.New("invalid response")
+)
 
 // GetAtxs gets the data for given atx IDs and validates them. returns an error if at least one ATX cannot be fetched.
 func (f *Fetch) GetAtxs(ctx context.Context, ids []types.ATXID) error {
@@ -152,17 +158,208 @@ func (f *Fetch) GetPoetProof(ctx context.Context, id types.Hash32) error {
 	}
 }
 
-func (f *Fetch) GetMaliciousIDs(ctx context.Context, peers []p2p.Peer, okCB func([]byte, p2p.Peer), errCB func(error, p2p.Peer)) error {
-	return poll(ctx, f.servers[malProtocol], peers, []byte{}, okCB, errCB)
+// GetMaliciousIDs gets ids of malicious identities from peers.
+func (f *Fetch) GetMaliciousIDs(ctx context.Context, peers []p2p.Peer, okCB func(*MaliciousIDs, p2p.Peer), errCB func(error, p2p.Peer)) error {
+	for _, p := range peers {
+		peer := p
+		go func() {
+			ids, err := requestIDs(ctx, f, peer, &idStream[types.NodeID]{
+				proto:     malStreamProtocol,
+				max:       maxMaliciousIDs,
+				legacy:    malProtocol,
+				legacyReq: []byte{},
+				request: func(after types.NodeID) ([]byte, error) {
+					return codec.Encode(&MaliciousIDsRequest{After: after})
+				},
+				decode: func(data []byte) ([]types.NodeID, error) {
+					var chunk MaliciousIDs
+					if err := codec.Decode(data, &chunk); err != nil {
+						return nil, err
+					}
+					return chunk.NodeIDs, nil
+				},
+			})
+			if err != nil {
+				errCB(err, peer)
+			} else {
+				okCB(&MaliciousIDs{NodeIDs: ids}, peer)
+			}
+		}()
+	}
+	return nil
 }
 
 // GetLayerData get layer data from peers.
-func (f *Fetch) GetLayerData(ctx context.Context, peers []p2p.Peer, lid types.LayerID, okCB func([]byte, p2p.Peer), errCB func(error, p2p.Peer)) error {
+func (f *Fetch) GetLayerData(ctx context.Context, peers []p2p.Peer, lid types.LayerID, okCB func(*LayerData, p2p.Peer), errCB func(error, p2p.Peer)) error {
 	lidBytes, err := codec.Encode(&lid)
 	if err != nil {
 		return err
 	}
-	return poll(ctx, f.servers[lyrDataProtocol], peers, lidBytes, okCB, errCB)
+	for _, p := range peers {
+		peer := p
+		go func() {
+			ids, err := requestIDs(ctx, f, peer, &idStream[types.BallotID]{
+				proto:     lyrDataStreamProtocol,
+				max:       maxLayerBallots,
+				legacy:    lyrDataProtocol,
+				legacyReq: lidBytes,
+				request: func(after types.BallotID) ([]byte, error) {
+					return codec.Encode(&LayerDataRequest{Layer: lid, After: after})
+				},
+				decode: func(data []byte) ([]types.BallotID, error) {
+					var chunk LayerData
+					if err := codec.Decode(data, &chunk); err != nil {
+						return nil, err
+					}
+					return chunk.Ballots, nil
+				},
+			})
+			if err != nil {
+				errCB(err, peer)
+			} else {
+				okCB(&LayerData{Ballots: ids}, peer)
+			}
+		}()
+	}
+	return nil
+}
+
+const (
+	// maxMaliciousIDs, maxEpochATXs and maxLayerBallots limit the total number of ids received
+	// in a streamed response, they match the limits of the legacy single message responses.
+	maxMaliciousIDs = 100_000
+	maxEpochATXs    = 100_000
+	maxLayerBallots = 500
+)
+
+// streamedID is an id that is streamed in ascending order of its bytes.
+type streamedID interface {
+	comparable
+	Bytes() []byte
+}
+
+// idStream describes the request for ids that are streamed in chunks.
+type idStream[T streamedID] struct {
+	proto string
+	// max is the maximal total number of ids in the response.
+	max int
+	// legacy protocol responds with all ids at once, it is used for peers that
+	// don't support proto. Its response is decoded as a single chunk.
+	legacy    string
+	legacyReq []byte
+	// request encodes the request for ids after the specified id.
+	request func(after T) ([]byte, error)
+	decode  func([]byte) ([]T, error)
+}
+
+// requestIDs requests ids from the peer and records the outcome in the peer scores.
+//
+// If the stream is interrupted after some chunks were received, the request is resumed after the last
+// received id, up to MaxRetriesForPeer times. Peers that serve chunks that can't be decoded, ids that are
+// not strictly increasing or more ids than the limit of the stream are reported as invalid.
+func requestIDs[T streamedID](ctx context.Context, f *Fetch, peer p2p.Peer, s *idStream[T]) ([]T, error) {
+	var (
+		ids     []T
+		after   T
+		retries int
+		start   = time.Now()
+		latency time.Duration
+		invalid bool
+	)
+	for {
+		req, err := s.request(after)
+		if err != nil {
+			return nil, err
+		}
+		received := 0
+		err = f.servers[s.proto].StreamRequest(ctx, peer, req, func(data []byte) error {
+			if latency == 0 {
+				latency = time.Since(start)
+			}
+			chunk, err := s.decode(data)
+			if err != nil {
+				invalid = true
+				return fmt.Errorf("decode chunk: %w", err)
+			}
+			if len(ids)+len(chunk) > s.max {
+				invalid = true
+				return fmt.Errorf("%w: more than %d ids", errBadResponse, s.max)
+			}
+			for _, id := range chunk {
+				if bytes.Compare(id.Bytes(), after.Bytes()) <= 0 {
+					invalid = true
+					return fmt.Errorf("%w: ids are not strictly increasing", errBadResponse)
+				}
+				after = id
+			}
+			if len(chunk) > 0 {
+				ids = append(ids, chunk...)
+				received += len(chunk)
+			}
+			return nil
+		})
+		switch {
+		case err == nil:
+			if latency == 0 {
+				latency = time.Since(start)
+			}
+			f.scores.onSuccess(peer, latency)
+			return ids, nil
+		case errors.Is(err, server.ErrNotSupported) && len(ids) == 0:
+			return requestLegacyIDs(ctx, f, peer, s)
+		case invalid:
+			f.ReportInvalid(peer)
+			return nil, err
+		case ctx.Err() != nil:
+			return nil, ctx.Err()
+		}
+		f.scores.onFailure(peer)
+		retries++
+		if received == 0 || retries > f.cfg.MaxRetriesForPeer {
+			return nil, err
+		}
+		f.logger.WithContext(ctx).With().Debug("resuming interrupted stream",
+			log.String("protocol", s.proto),
+			log.Stringer("peer", peer),
+			log.Int("received", len(ids)),
+			log.Err(err),
+		)
+	}
+}
+
+func requestLegacyIDs[T streamedID](ctx context.Context, f *Fetch, peer p2p.Peer, s *idStream[T]) ([]T, error) {
+	var (
+		done  = make(chan error, 1)
+		start = time.Now()
+		data  []byte
+	)
+	okCB := func(resp []byte) {
+		data = resp
+		done <- nil
+	}
+	errCB := func(perr error) {
+		done <- perr
+	}
+	if err := f.servers[s.legacy].Request(ctx, peer, s.legacyReq, okCB, errCB); err != nil {
+		f.scores.onFailure(peer)
+		return nil, err
+	}
+	select {
+	case err := <-done:
+		if err != nil {
+			f.scores.onFailure(peer)
+			return nil, err
+		}
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	ids, err := s.decode(data)
+	if err != nil {
+		f.ReportInvalid(peer)
+		return nil, err
+	}
+	f.scores.onSuccess(peer, time.Since(start))
+	return ids, nil
 }
 
 // GetLayerOpinions get opinions on data in the specified layer from peers.
@@ -171,16 +368,20 @@ func (f *Fetch) GetLayerOpinions(ctx context.Context, peers []p2p.Peer, lid type
 	if err != nil {
 		return err
 	}
-	return poll(ctx, f.servers[lyrOpnsProtocol], peers, lidBytes, okCB, errCB)
+	return f.poll(ctx, f.servers[lyrOpnsProtocol], peers, lidBytes, okCB, errCB)
 }
 
-func poll(ctx context.Context, srv requester, peers []p2p.Peer, req []byte, okCB func([]byte, p2p.Peer), errCB func(error, p2p.Peer)) error {
+// poll sends request to every peer and records the outcome in the peer scores.
+func (f *Fetch) poll(ctx context.Context, srv requester, peers []p2p.Peer, req []byte, okCB func([]byte, p2p.Peer), errCB func(error, p2p.Peer)) error {
 	for _, p := range peers {
 		peer := p
+		start := time.Now()
 		okFunc := func(data []byte) {
+			f.scores.onSuccess(peer, time.Since(start))
 			okCB(data, peer)
 		}
 		errFunc := func(err error) {
+			f.scores.onFailure(peer)
 			errCB(err, peer)
 		}
 		if err := srv.Request(ctx, peer, req, okFunc, errFunc); err != nil {
@@ -196,36 +397,31 @@ func (f *Fetch) PeerEpochInfo(ctx context.Context, peer p2p.Peer, epoch types.Ep
 		log.Stringer("peer", peer),
 		log.Stringer("epoch", epoch))
 
-	var (
-		done = make(chan error, 1)
-		ed   EpochData
-	)
-	okCB := func(data []byte) {
-		defer close(done)
-		done <- codec.Decode(data, &ed)
-	}
-	errCB := func(perr error) {
-		defer close(done)
-		done <- perr
-	}
 	epochBytes, err := codec.Encode(epoch)
 	if err != nil {
 		return nil, err
 	}
-	if err := f.servers[atxProtocol].Request(ctx, peer, epochBytes, okCB, errCB); err != nil {
+	ids, err := requestIDs(ctx, f, peer, &idStream[types.ATXID]{
+		proto:     atxStreamProtocol,
+		max:       maxEpochATXs,
+		legacy:    atxProtocol,
+		legacyReq: epochBytes,
+		request: func(after types.ATXID) ([]byte, error) {
+			return codec.Encode(&EpochInfoRequest{Epoch: epoch, After: after})
+		},
+		decode: func(data []byte) ([]types.ATXID, error) {
+			var chunk EpochData
+			if err := codec.Decode(data, &chunk); err != nil {
+				return nil, err
+			}
+			return chunk.AtxIDs, nil
+		},
+	})
+	if err != nil {
 		return nil, err
 	}
-	select {
-	case err := <-done:
-		if err != nil {
-			return nil, err
-		}
-		f.RegisterPeerHashes(peer, types.ATXIDsToHashes(ed.AtxIDs))
-		return &ed, nil
-	case <-ctx.Done():
-		f.logger.WithContext(ctx).With().Debug("context done")
-		return nil, ctx.Err()
-	}
+	f.RegisterPeerHashes(peer, types.ATXIDsToHashes(ids))
+	return &EpochData{AtxIDs: ids}, nil
 }
 
 func (f *Fetch) PeerMeshHashes(ctx context.Context, peer p2p.Peer, req *MeshHashRequest) (*MeshHashes, error) {
@@ -1,9 +1,12 @@
 package fetch
 
 import (
+	"bytes"
 	"context"
+	"encoding/binary"
 	"errors"
 	"os"
+	"sort"
 	"sync"
 	"testing"
 
@@ -17,6 +20,7 @@ import (
 	"github.com/spacemeshos/go-spacemesh/datastore"
 	"github.com/spacemeshos/go-spacemesh/genvm/sdk/wallet"
 	"github.com/spacemeshos/go-spacemesh/p2p"
+	"github.com/spacemeshos/go-spacemesh/p2p/server"
 	"github.com/spacemeshos/go-spacemesh/signing"
 )
 
@@ -89,6 +93,10 @@ func generateMaliciousIDs(t *testing.T) []byte {
 	for i := 0; i < numMalicious; i++ {
 		malicious.NodeIDs = append(malicious.NodeIDs, types.RandomNodeID())
 	}
+	// ids are streamed in ascending order
+	sort.Slice(malicious.NodeIDs, func(i, j int) bool {
+		return bytes.Compare(malicious.NodeIDs[i].Bytes(), malicious.NodeIDs[j].Bytes()) < 0
+	})
 	data, err := codec.Encode(&malicious)
 	require.NoError(t, err)
 	return data
@@ -100,6 +108,9 @@ func generateLayerContent(t *testing.T) []byte {
 	for i := 0; i < numBallots; i++ {
 		ballotIDs = append(ballotIDs, types.RandomBallotID())
 	}
+	sort.Slice(ballotIDs, func(i, j int) bool {
+		return bytes.Compare(ballotIDs[i].Bytes(), ballotIDs[j].Bytes()) < 0
+	})
 	lb := LayerData{
 		Ballots: ballotIDs,
 	}
@@ -439,7 +450,7 @@ func TestFetch_GetMaliciousIDs(t *testing.T) {
 			errs := make(chan struct{}, len(peers))
 			var wg sync.WaitGroup
 			wg.Add(len(peers))
-			okFunc := func([]byte, p2p.Peer) {
+			okFunc := func(*MaliciousIDs, p2p.Peer) {
 				oks <- struct{}{}
 				wg.Done()
 			}
@@ -455,14 +466,12 @@ func TestFetch_GetMaliciousIDs(t *testing.T) {
 					expErr++
 				}
 				idx := i
-				f.mMalS.EXPECT().Request(gomock.Any(), p, []byte{}, gomock.Any(), gomock.Any()).DoAndReturn(
-					func(_ context.Context, _ p2p.Peer, _ []byte, okCB func([]byte), errCB func(error)) error {
+				f.mMalS.EXPECT().StreamRequest(gomock.Any(), p, gomock.Any(), gomock.Any()).DoAndReturn(
+					func(_ context.Context, _ p2p.Peer, _ []byte, chunk func([]byte) error) error {
 						if tc.errs[idx] == nil {
-							go okCB(generateMaliciousIDs(t))
-						} else {
-							go errCB(tc.errs[idx])
+							return chunk(generateMaliciousIDs(t))
 						}
-						return nil
+						return tc.errs[idx]
 					})
 			}
 			require.NoError(t, f.GetMaliciousIDs(context.Background(), peers, okFunc, errFunc))
@@ -501,7 +510,7 @@ func TestFetch_GetLayerData(t *testing.T) {
 			errs := make(chan struct{}, len(peers))
 			var wg sync.WaitGroup
 			wg.Add(len(peers))
-			okFunc := func(data []byte, peer p2p.Peer) {
+			okFunc := func(data *LayerData, peer p2p.Peer) {
 				oks <- struct{}{}
 				wg.Done()
 			}
@@ -517,14 +526,12 @@ func TestFetch_GetLayerData(t *testing.T) {
 					expErr++
 				}
 				idx := i
-				f.mLyrS.EXPECT().Request(gomock.Any(), p, gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
-					func(_ context.Context, _ p2p.Peer, _ []byte, okCB func([]byte), errCB func(error)) error {
+				f.mLyrS.EXPECT().StreamRequest(gomock.Any(), p, gomock.Any(), gomock.Any()).DoAndReturn(
+					func(_ context.Context, _ p2p.Peer, _ []byte, chunk func([]byte) error) error {
 						if tc.errs[idx] == nil {
-							go okCB(generateLayerContent(t))
-						} else {
-							go errCB(tc.errs[idx])
+							return chunk(generateLayerContent(t))
 						}
-						return nil
+						return tc.errs[idx]
 					})
 			}
 			require.NoError(t, f.GetLayerData(context.Background(), peers, types.LayerID(111), okFunc, errFunc))
@@ -602,6 +609,9 @@ func generateEpochData(t *testing.T) (*EpochData, []byte) {
 	ed := &EpochData{
 		AtxIDs: types.RandomActiveSet(11),
 	}
+	sort.Slice(ed.AtxIDs, func(i, j int) bool {
+		return bytes.Compare(ed.AtxIDs[i].Bytes(), ed.AtxIDs[j].Bytes()) < 0
+	})
 	data, err := codec.Encode(ed)
 	require.NoError(t, err)
 	return ed, data
@@ -631,16 +641,14 @@ func Test_PeerEpochInfo(t *testing.T) {
 			f := createFetch(t)
 			f.mh.EXPECT().ID().Return(p2p.Peer("self")).AnyTimes()
 			var expected *EpochData
-			f.mAtxS.EXPECT().Request(gomock.Any(), peer, gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
-				func(_ context.Context, _ p2p.Peer, req []byte, okCB func([]byte), errCB func(error)) error {
-					if tc.err == nil {
-						var data []byte
-						expected, data = generateEpochData(t)
-						okCB(data)
-					} else {
-						errCB(tc.err)
+			f.mAtxS.EXPECT().StreamRequest(gomock.Any(), peer, gomock.Any(), gomock.Any()).DoAndReturn(
+				func(_ context.Context, _ p2p.Peer, req []byte, chunk func([]byte) error) error {
+					if tc.err != nil {
+						return tc.err
 					}
-					return nil
+					var data []byte
+					expected, data = generateEpochData(t)
+					return chunk(data)
 				})
 			got, err := f.PeerEpochInfo(context.Background(), peer, types.EpochID(111))
 			require.ErrorIs(t, err, tc.err)
@@ -651,6 +659,122 @@ func Test_PeerEpochInfo(t *testing.T) {
 	}
 }
 
+func Test_PeerEpochInfoResume(t *testing.T) {
+	peer := p2p.Peer("p0")
+	f := createFetch(t)
+	f.mh.EXPECT().ID().Return(p2p.Peer("self")).AnyTimes()
+	expected, _ := generateEpochData(t)
+	var afters []types.ATXID
+	f.mAtxS.EXPECT().StreamRequest(gomock.Any(), peer, gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ context.Context, _ p2p.Peer, data []byte, chunk func([]byte) error) error {
+			var req EpochInfoRequest
+			require.NoError(t, codec.Decode(data, &req))
+			require.Equal(t, types.EpochID(111), req.Epoch)
+			afters = append(afters, req.After)
+			ids := expected.AtxIDs
+			if req.After != types.EmptyATXID {
+				ids = ids[5:]
+			} else {
+				ids = ids[:5]
+			}
+			encoded, err := codec.Encode(&EpochData{AtxIDs: ids})
+			require.NoError(t, err)
+			require.NoError(t, chunk(encoded))
+			if req.After == types.EmptyATXID {
+				return errors.New("stream reset")
+			}
+			return nil
+		}).Times(2)
+	got, err := f.PeerEpochInfo(context.Background(), peer, types.EpochID(111))
+	require.NoError(t, err)
+	require.Equal(t, expected, got)
+	require.Equal(t, []types.ATXID{types.EmptyATXID, expected.AtxIDs[4]}, afters)
+}
+
+func Test_PeerEpochInfoLegacy(t *testing.T) {
+	peer := p2p.Peer("p0")
+	f := createFetch(t)
+	f.mh.EXPECT().ID().Return(p2p.Peer("self")).AnyTimes()
+	expected, data := generateEpochData(t)
+	f.mAtxS.EXPECT().StreamRequest(gomock.Any(), peer, gomock.Any(), gomock.Any()).Return(server.ErrNotSupported)
+	f.mAtxS.EXPECT().Request(gomock.Any(), peer, gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ context.Context, _ p2p.Peer, req []byte, okCB func([]byte), errCB func(error)) error {
+			var epoch types.EpochID
+			require.NoError(t, codec.Decode(req, &epoch))
+			require.Equal(t, types.EpochID(111), epoch)
+			okCB(data)
+			return nil
+		})
+	got, err := f.PeerEpochInfo(context.Background(), peer, types.EpochID(111))
+	require.NoError(t, err)
+	require.Equal(t, expected, got)
+}
+
+func Test_PeerEpochInfoInvalid(t *testing.T) {
+	peer := p2p.Peer("p0")
+	f := createFetch(t)
+	f.mAtxS.EXPECT().StreamRequest(gomock.Any(), peer, gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ context.Context, _ p2p.Peer, _ []byte, chunk func([]byte) error) error {
+			return chunk([]byte("malformed"))
+		}).Times(f.cfg.PeerBanThreshold)
+	for i := 0; i < f.cfg.PeerBanThreshold; i++ {
+		_, err := f.PeerEpochInfo(context.Background(), peer, types.EpochID(111))
+		require.Error(t, err)
+	}
+	require.Equal(t, []p2p.Peer{"other"}, f.scores.rank([]p2p.Peer{peer, "other"}))
+}
+
+func Test_PeerEpochInfoLimits(t *testing.T) {
+	expected, _ := generateEpochData(t)
+	for _, tc := range []struct {
+		desc string
+		ids  [][]types.ATXID
+	}{
+		{
+			desc: "not increasing",
+			ids:  [][]types.ATXID{{expected.AtxIDs[1], expected.AtxIDs[0]}},
+		},
+		{
+			desc: "not increasing across chunks",
+			ids:  [][]types.ATXID{expected.AtxIDs[:2], expected.AtxIDs[1:3]},
+		},
+		{
+			desc: "over limit",
+			ids: func() [][]types.ATXID {
+				chunks := make([][]types.ATXID, maxEpochATXs/streamChunkSize+1)
+				var id types.ATXID
+				for i := range chunks {
+					for j := 0; j < streamChunkSize; j++ {
+						binary.BigEndian.PutUint64(id[:], uint64(i*streamChunkSize+j+1))
+						chunks[i] = append(chunks[i], id)
+					}
+				}
+				return chunks
+			}(),
+		},
+	} {
+		tc := tc
+		t.Run(tc.desc, func(t *testing.T) {
+			peer := p2p.Peer("p0")
+			f := createFetch(t)
+			f.mAtxS.EXPECT().StreamRequest(gomock.Any(), peer, gomock.Any(), gomock.Any()).DoAndReturn(
+				func(_ context.Context, _ p2p.Peer, _ []byte, chunk func([]byte) error) error {
+					for _, ids := range tc.ids {
+						data, err := codec.Encode(&EpochData{AtxIDs: ids})
+						require.NoError(t, err)
+						if err := chunk(data); err != nil {
+							return err
+						}
+					}
+					return nil
+				})
+			_, err := f.PeerEpochInfo(context.Background(), peer, types.EpochID(111))
+			require.ErrorIs(t, err, errBadResponse)
+			require.Equal(t, 1, f.scores.get(peer).invalid)
+		})
+	}
+}
+
 func TestFetch_GetMeshHashes(t *testing.T) {
 	peer := p2p.Peer("p0")
 	errUnknown := errors.New("unknown")
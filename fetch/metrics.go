@@ -41,6 +41,12 @@ var (
 		subsystem,
 		"total request that hash has no data",
 		[]string{hint})
+
+	peerBans = metrics.NewCounter(
+		"peer_bans",
+		subsystem,
+		"total number of peers banned for serving invalid data",
+		[]string{}).WithLabelValues()
 )
 
 // logCacheHit logs cache hit.
@@ -50,6 +50,20 @@ func (mr *MockrequesterMockRecorder) Request(arg0, arg1, arg2, arg3, arg4 interf
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Request", reflect.TypeOf((*Mockrequester)(nil).Request), arg0, arg1, arg2, arg3, arg4)
 }
 
+// StreamRequest mocks base method.
+func (m *Mockrequester) StreamRequest(arg0 context.Context, arg1 p2p.Peer, arg2 []byte, arg3 func([]byte) error) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StreamRequest", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// StreamRequest indicates an expected call of StreamRequest.
+func (mr *MockrequesterMockRecorder) StreamRequest(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StreamRequest", reflect.TypeOf((*Mockrequester)(nil).StreamRequest), arg0, arg1, arg2, arg3)
+}
+
 // MockSyncValidator is a mock of SyncValidator interface.
 type MockSyncValidator struct {
 	ctrl     *gomock.Controller
@@ -0,0 +1,180 @@
+package fetch
+
+import (
+	"math/rand"
+	"sort"
+	"sync"
+	"time"
+
+	lru "github.com/hashicorp/golang-lru/v2"
+
+	"github.com/spacemeshos/go-spacemesh/log"
+	"github.com/spacemeshos/go-spacemesh/p2p"
+)
+
+const (
+	// scoresCacheSize is the number of peers with tracked scores.
+	scoresCacheSize = 1000
+	// scoreAlpha is the weight of the last outcome in the moving averages.
+	scoreAlpha = 0.1
+	// minScore is the score of the worst peer, so that it can still be selected and recover.
+	minScore = 0.01
+)
+
+type peerScore struct {
+	// success is a moving average of successful requests, 1 for a new peer.
+	success float64
+	// latency is a moving average of response latency.
+	latency time.Duration
+	// invalid is the number of responses that failed validation since the last ban.
+	invalid     int
+	bannedUntil time.Time
+}
+
+func (s *peerScore) value() float64 {
+	v := s.success / (1 + s.latency.Seconds())
+	if v < minScore {
+		return minScore
+	}
+	return v
+}
+
+// peerScores tracks latency, success rate and validation failures of the responses from peers.
+// Peers that serve invalid data banThreshold times are not selected for banDuration.
+type peerScores struct {
+	logger       log.Log
+	now          func() time.Time
+	banThreshold int
+	banDuration  time.Duration
+
+	mu     sync.Mutex
+	scores *lru.Cache[p2p.Peer, *peerScore]
+}
+
+func newPeerScores(logger log.Log, banThreshold int, banDuration time.Duration) *peerScores {
+	cache, err := lru.New[p2p.Peer, *peerScore](scoresCacheSize)
+	if err != nil {
+		log.Panic("could not initialize cache ", err)
+	}
+	return &peerScores{
+		logger:       logger,
+		now:          time.Now,
+		banThreshold: banThreshold,
+		banDuration:  banDuration,
+		scores:       cache,
+	}
+}
+
+// get returns score for the peer (non-thread-safe).
+func (s *peerScores) get(peer p2p.Peer) *peerScore {
+	score, ok := s.scores.Get(peer)
+	if !ok {
+		score = &peerScore{success: 1}
+		s.scores.Add(peer, score)
+	}
+	return score
+}
+
+func (s *peerScores) onSuccess(peer p2p.Peer, latency time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	score := s.get(peer)
+	score.success += scoreAlpha * (1 - score.success)
+	score.latency += time.Duration(scoreAlpha * float64(latency-score.latency))
+}
+
+func (s *peerScores) onFailure(peer p2p.Peer) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	score := s.get(peer)
+	score.success -= scoreAlpha * score.success
+}
+
+// onInvalid is called when the peer served data that failed validation.
+func (s *peerScores) onInvalid(peer p2p.Peer) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	score := s.get(peer)
+	score.success -= scoreAlpha * score.success
+	score.invalid++
+	if score.invalid < s.banThreshold {
+		return
+	}
+	score.invalid = 0
+	score.bannedUntil = s.now().Add(s.banDuration)
+	peerBans.Inc()
+	s.logger.With().Info("peer is banned for serving invalid data",
+		log.Stringer("peer", peer),
+		log.Duration("duration", s.banDuration),
+	)
+}
+
+// banned returns true if the peer is banned (non-thread-safe).
+func (s *peerScores) banned(peer p2p.Peer, now time.Time) bool {
+	score, ok := s.scores.Peek(peer)
+	return ok && now.Before(score.bannedUntil)
+}
+
+// value returns the score of the peer (non-thread-safe).
+func (s *peerScores) value(peer p2p.Peer) float64 {
+	score, ok := s.scores.Peek(peer)
+	if !ok {
+		return 1
+	}
+	return score.value()
+}
+
+// available returns peers that are not banned.
+// If all peers are banned they are returned as is, to avoid stalling completely.
+func (s *peerScores) available(peers []p2p.Peer) []p2p.Peer {
+	now := s.now()
+	rst := make([]p2p.Peer, 0, len(peers))
+	for _, peer := range peers {
+		if !s.banned(peer, now) {
+			rst = append(rst, peer)
+		}
+	}
+	if len(rst) == 0 {
+		return peers
+	}
+	return rst
+}
+
+// selectPeer selects a peer that is not banned with probability proportional to its score.
+func (s *peerScores) selectPeer(peers []p2p.Peer, rng *rand.Rand) p2p.Peer {
+	if len(peers) == 0 {
+		return p2p.NoPeer
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	peers = s.available(peers)
+	values := make([]float64, len(peers))
+	total := 0.0
+	for i, peer := range peers {
+		values[i] = s.value(peer)
+		total += values[i]
+	}
+	r := rng.Float64() * total
+	for i, value := range values {
+		r -= value
+		if r < 0 {
+			return peers[i]
+		}
+	}
+	return peers[len(peers)-1]
+}
+
+// rank returns peers that are not banned, ordered by score from the best.
+func (s *peerScores) rank(peers []p2p.Peer) []p2p.Peer {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	rst := append([]p2p.Peer{}, s.available(peers)...)
+	values := make(map[p2p.Peer]float64, len(rst))
+	for _, peer := range rst {
+		values[peer] = s.value(peer)
+	}
+	sort.SliceStable(rst, func(i, j int) bool {
+		return values[rst[i]] > values[rst[j]]
+	})
+	return rst
+}
@@ -0,0 +1,69 @@
+package fetch
+
+import (
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/spacemeshos/go-spacemesh/log/logtest"
+	"github.com/spacemeshos/go-spacemesh/p2p"
+)
+
+func TestPeerScoresSelect(t *testing.T) {
+	scores := newPeerScores(logtest.New(t), 3, time.Minute)
+	peers := []p2p.Peer{"good", "slow", "failing"}
+	for i := 0; i < 20; i++ {
+		scores.onSuccess("good", 100*time.Millisecond)
+		scores.onSuccess("slow", 5*time.Second)
+		scores.onFailure("failing")
+	}
+	require.Equal(t, peers, scores.rank([]p2p.Peer{"failing", "slow", "good"}))
+
+	rng := rand.New(rand.NewSource(1))
+	selected := map[p2p.Peer]int{}
+	for i := 0; i < 1000; i++ {
+		selected[scores.selectPeer(peers, rng)]++
+	}
+	require.Greater(t, selected["good"], selected["slow"])
+	require.Greater(t, selected["slow"], selected["failing"])
+	require.Positive(t, selected["failing"])
+
+	require.Equal(t, p2p.NoPeer, scores.selectPeer(nil, rng))
+}
+
+func TestPeerScoresBan(t *testing.T) {
+	scores := newPeerScores(logtest.New(t), 3, time.Minute)
+	now := time.Now()
+	scores.now = func() time.Time { return now }
+	peers := []p2p.Peer{"good", "bad"}
+	for i := 0; i < 2; i++ {
+		scores.onInvalid("bad")
+	}
+	require.Equal(t, peers, scores.rank(peers))
+
+	scores.onInvalid("bad")
+	require.Equal(t, []p2p.Peer{"good"}, scores.rank(peers))
+	rng := rand.New(rand.NewSource(1))
+	for i := 0; i < 100; i++ {
+		require.Equal(t, p2p.Peer("good"), scores.selectPeer(peers, rng))
+	}
+	// banned peers are used if there are no other peers
+	require.Equal(t, p2p.Peer("bad"), scores.selectPeer([]p2p.Peer{"bad"}, rng))
+	require.Equal(t, []p2p.Peer{"bad"}, scores.rank([]p2p.Peer{"bad"}))
+
+	now = now.Add(time.Minute)
+	require.Equal(t, peers, scores.rank(peers))
+}
+
+func TestFetch_RankedPeers(t *testing.T) {
+	f := createFetch(t)
+	peers := []p2p.Peer{"a", "b", "c"}
+	f.mh.EXPECT().GetPeers().Return(peers).AnyTimes()
+	for i := 0; i < f.cfg.PeerBanThreshold; i++ {
+		f.ReportInvalid("a")
+	}
+	f.scores.onFailure("b")
+	require.Equal(t, []p2p.Peer{"c", "b"}, f.RankedPeers())
+}
@@ -110,6 +110,27 @@ type LayerData struct {
 	Ballots []types.BallotID `scale:"max=500"` // expected are 50 proposals per layer + safety margin
 }
 
+// EpochInfoRequest requests ids of the ATXs published in the epoch that are greater than After.
+// The response is streamed in chunks of EpochData with ids in ascending order,
+// so that an interrupted request is resumed after the last received id.
+type EpochInfoRequest struct {
+	Epoch types.EpochID
+	After types.ATXID
+}
+
+// MaliciousIDsRequest requests ids of the malicious identities that are greater than After.
+// The response is streamed in chunks of MaliciousIDs with ids in ascending order.
+type MaliciousIDsRequest struct {
+	After types.NodeID
+}
+
+// LayerDataRequest requests ids of the ballots in the layer that are greater than After.
+// The response is streamed in chunks of LayerData with ids in ascending order.
+type LayerDataRequest struct {
+	Layer types.LayerID
+	After types.BallotID
+}
+
 // LayerOpinion is the response for opinion for a given layer.
 type LayerOpinion struct {
 	PrevAggHash types.Hash32
@@ -3,6 +3,8 @@ package core
 import (
 	"bytes"
 	"fmt"
+	"math"
+	"math/bits"
 
 	"github.com/spacemeshos/go-scale"
 
@@ -41,6 +43,7 @@ type Context struct {
 
 	touched []Address
 	changed map[Address]*Account
+	events  []types.TransactionEvent
 }
 
 // Principal returns address of the account that signed transaction.
@@ -48,6 +51,25 @@ func (c *Context) Principal() Address {
 	return c.PrincipalAccount.Address
 }
 
+// Balance of the principal account.
+func (c *Context) Balance() uint64 {
+	return c.PrincipalAccount.Balance
+}
+
+// Method returns method selector of the transaction.
+func (c *Context) Method() uint8 {
+	return c.Header.Method
+}
+
+// MaxFee returns the maximal fee that the transaction can pay, max gas multiplied by gas price.
+func (c *Context) MaxFee() uint64 {
+	hi, lo := bits.Mul64(c.Header.MaxGas, c.Header.GasPrice)
+	if hi != 0 {
+		return math.MaxUint64
+	}
+	return lo
+}
+
 // Layer returns block layer id.
 func (c *Context) Layer() LayerID {
 	return c.LayerID
@@ -68,6 +90,17 @@ func (c *Context) Handler() Handler {
 	return c.PrincipalHandler
 }
 
+// UpdateTemplate stores the principal template into the state of the principal account.
+// Templates that change their state during execution must call it, otherwise changes are discarded.
+func (c *Context) UpdateTemplate() error {
+	buf := bytes.NewBuffer(nil)
+	if _, err := c.PrincipalTemplate.EncodeScale(scale.NewEncoder(buf)); err != nil {
+		return fmt.Errorf("%w: %s", ErrInternal, err.Error())
+	}
+	c.PrincipalAccount.State = buf.Bytes()
+	return nil
+}
+
 // Spawn account.
 func (c *Context) Spawn(args scale.Encodable) error {
 	account, err := c.load(ComputePrincipal(c.Header.TemplateAddress, args))
@@ -93,15 +126,20 @@ func (c *Context) Spawn(args scale.Encodable) error {
 	account.State = buf.Bytes()
 	account.TemplateAddress = &c.Header.TemplateAddress
 	c.change(account)
+	c.events = append(c.events, types.TransactionEvent{
+		Type: types.EventSpawn,
+		From: c.Principal(),
+		To:   account.Address,
+	})
 	return nil
 }
 
 // Transfer amount to the address after validation passes.
 func (c *Context) Transfer(to Address, amount uint64) error {
-	return c.transfer(&c.PrincipalAccount, to, amount, c.Header.MaxSpend)
+	return c.transfer(&c.PrincipalAccount, to, amount, c.Header.MaxSpend, types.EventTransfer)
 }
 
-func (c *Context) transfer(from *Account, to Address, amount, max uint64) error {
+func (c *Context) transfer(from *Account, to Address, amount, max uint64, typ types.TransactionEventType) error {
 	account, err := c.load(to)
 	if err != nil {
 		return err
@@ -121,6 +159,12 @@ func (c *Context) transfer(from *Account, to Address, amount, max uint64) error
 	from.Balance -= amount
 	account.Balance += amount
 	c.change(account)
+	c.events = append(c.events, types.TransactionEvent{
+		Type:   typ,
+		From:   from.Address,
+		To:     to,
+		Amount: amount,
+	})
 	return nil
 }
 
@@ -217,6 +261,11 @@ func (c *Context) Updated() []types.Address {
 	return rst
 }
 
+// Events emitted during execution.
+func (c *Context) Events() []types.TransactionEvent {
+	return c.events
+}
+
 func (c *Context) load(address types.Address) (*Account, error) {
 	if address == c.Principal() {
 		return &c.PrincipalAccount, nil
@@ -259,14 +308,24 @@ func (r *RemoteContext) Template() Template {
 	return r.template
 }
 
+// Balance of the remote account.
+func (r *RemoteContext) Balance() uint64 {
+	return r.remote.Balance
+}
+
 // Handler ...
 func (r *RemoteContext) Handler() Handler {
 	return r.handler
 }
 
-// Transfer ...
+// UpdateTemplate is noop, remote template is stored after the relayed call.
+func (r *RemoteContext) UpdateTemplate() error {
+	return nil
+}
+
+// Transfer from the remote account, recorded as a drain of the remote account.
 func (r *RemoteContext) Transfer(to Address, amount uint64) error {
-	if err := r.transfer(r.remote, to, amount, amount); err != nil {
+	if err := r.transfer(r.remote, to, amount, amount, types.EventDrain); err != nil {
 		return err
 	}
 	return nil
@@ -22,6 +22,8 @@ var (
 	ErrNotSpawned = errors.New("account is not spawned")
 	// ErrMismatchedTemplate raised if target account doesn't match template account.
 	ErrTemplateMismatch = errors.New("relay template mismatch")
+	// ErrIneffective raised if transaction can't be included into the block.
+	ErrIneffective = errors.New("ineffective tx")
 	// ErrTxLimit overflows max tx size.
 	ErrTxLimit = errors.New("overflows tx limit")
 )
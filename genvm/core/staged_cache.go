@@ -14,6 +14,16 @@ func (db DBLoader) Get(address types.Address) (types.Account, error) {
 	return accounts.Latest(db.Executor, address)
 }
 
+// LayerLoader loads accounts state that was valid at the specified layer.
+type LayerLoader struct {
+	sql.Executor
+	Layer LayerID
+}
+
+func (db LayerLoader) Get(address types.Address) (types.Account, error) {
+	return accounts.Get(db.Executor, address, db.Layer)
+}
+
 // NewStagedCache returns instance of the staged cache.
 func NewStagedCache(loader AccountLoader) *StagedCache {
 	return &StagedCache{loader: loader, cache: map[Address]stagedAccount{}}
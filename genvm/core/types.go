@@ -11,6 +11,8 @@ const (
 	MethodSpawn = 0
 	// MethodSpend ...
 	MethodSpend = 16
+	// MethodSpendMany transfers to several recipients under a single nonce.
+	MethodSpendMany = 18
 )
 
 const TxSizeLimit = 1024
@@ -76,6 +78,12 @@ type Template interface {
 	Verify(Host, []byte, *scale.Decoder) bool
 }
 
+// VariableGasTemplate is implemented by templates with methods that cost depends on arguments.
+type VariableGasTemplate interface {
+	// VariableGas is charged on top of the ExecGas.
+	VariableGas(uint8, scale.Encodable) uint64
+}
+
 // AccountLoader is an interface for loading accounts.
 type AccountLoader interface {
 	Get(Address) (Account, error)
@@ -107,10 +115,14 @@ type Host interface {
 	Relay(expectedTemplate, address Address, call func(Host) error) error
 
 	Principal() Address
+	Balance() uint64
 	Handler() Handler
 	Template() Template
+	Method() uint8
+	MaxFee() uint64
 	Layer() LayerID
 	GetGenesisID() Hash20
+	UpdateTemplate() error
 }
 
 //go:generate scalegen -types Payload
@@ -0,0 +1,80 @@
+package vm
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/spacemeshos/merkle-tree"
+
+	"github.com/spacemeshos/go-spacemesh/codec"
+	"github.com/spacemeshos/go-spacemesh/common/types"
+	"github.com/spacemeshos/go-spacemesh/hash"
+)
+
+var (
+	leafPrefix = []byte{0}
+	nodePrefix = []byte{1}
+)
+
+// ErrInvalidProof is returned if proof doesn't match the state root.
+var ErrInvalidProof = errors.New("invalid account proof")
+
+// AccountProof proves that the account state is the latest state of the account at the layer.
+//
+// Proof is linked to the accounts root of the layer, a merkle root over the latest states
+// of all accounts at that layer, sorted by address. Leaf is a hash of the scale encoded account state,
+// which includes the layer where the account was updated (Account.Layer).
+// Accounts root is computed by every node from its own state, it is not included into
+// proposals or certificates. Therefore proof can be verified only against the root that
+// the client trusts, e.g. the root that matches on several independent nodes.
+type AccountProof struct {
+	// Layer of the accounts root.
+	Layer   types.LayerID
+	Account types.Account
+	// Index of the account in the sorted list of accounts at the layer.
+	Index uint64
+	Nodes []types.Hash32
+}
+
+// Verify that proof links account state to the accounts root of the layer.
+func (p *AccountProof) Verify(root types.Hash32) error {
+	leaf, err := accountLeaf(&p.Account)
+	if err != nil {
+		return err
+	}
+	nodes := make([][]byte, 0, len(p.Nodes))
+	for i := range p.Nodes {
+		nodes = append(nodes, p.Nodes[i][:])
+	}
+	valid, err := merkle.ValidatePartialTree(
+		[]uint64{p.Index},
+		[][]byte{leaf[:]},
+		nodes,
+		root[:],
+		hashNode,
+	)
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrInvalidProof, err.Error())
+	}
+	if !valid {
+		return fmt.Errorf("%w: account %s at layer %s doesn't match root %s",
+			ErrInvalidProof, p.Account.Address, p.Account.Layer, root)
+	}
+	return nil
+}
+
+func hashNode(buf, lchild, rchild []byte) []byte {
+	hasher := hash.New()
+	hasher.Write(nodePrefix)
+	hasher.Write(lchild)
+	hasher.Write(rchild)
+	return hasher.Sum(buf)
+}
+
+func accountLeaf(account *types.Account) (types.Hash32, error) {
+	buf, err := codec.Encode(account)
+	if err != nil {
+		return types.Hash32{}, fmt.Errorf("encode account %s: %w", account.Address, err)
+	}
+	return hash.Sum(leafPrefix, buf), nil
+}
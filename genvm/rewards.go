@@ -11,12 +11,13 @@ import (
 	"github.com/spacemeshos/go-spacemesh/log"
 )
 
-func (v *VM) addRewards(lctx ApplyContext, ss *core.StagedCache, fees uint64, blockRewards []types.CoinbaseReward) ([]types.Reward, error) {
+// CalculateRewards splits the subsidy for the layer and fees between block rewards
+// proportionally to their weight. Rewards are returned in the order of block rewards.
+func CalculateRewards(lid types.LayerID, fees uint64, blockRewards []types.CoinbaseReward) ([]types.Reward, error) {
 	var (
-		layersAfterEffectiveGenesis = lctx.Layer.Difference(types.FirstEffectiveGenesis())
+		layersAfterEffectiveGenesis = lid.Difference(types.FirstEffectiveGenesis())
 		subsidy                     = rewards.TotalSubsidyAtLayer(layersAfterEffectiveGenesis)
 		total                       = subsidy + fees
-		transferred                 uint64
 		totalWeight                 = new(big.Rat)
 	)
 	for _, blockReward := range blockRewards {
@@ -44,22 +45,36 @@ func (v *VM) addRewards(lctx ApplyContext, ss *core.StagedCache, fees uint64, bl
 			return nil, fmt.Errorf("%w: subsidy reward %v for %v overflows uint64",
 				core.ErrInternal, subsidyReward, blockReward.Coinbase)
 		}
+		result = append(result, types.Reward{
+			Layer:       lid,
+			Coinbase:    blockReward.Coinbase,
+			TotalReward: totalReward.Uint64(),
+			LayerReward: subsidyReward.Uint64(),
+		})
+	}
+	return result, nil
+}
 
+func (v *VM) addRewards(lctx ApplyContext, ss *core.StagedCache, fees uint64, blockRewards []types.CoinbaseReward) ([]types.Reward, error) {
+	var (
+		layersAfterEffectiveGenesis = lctx.Layer.Difference(types.FirstEffectiveGenesis())
+		subsidy                     = rewards.TotalSubsidyAtLayer(layersAfterEffectiveGenesis)
+		total                       = subsidy + fees
+		transferred                 uint64
+	)
+	result, err := CalculateRewards(lctx.Layer, fees, blockRewards)
+	if err != nil {
+		return nil, err
+	}
+	for i, blockReward := range blockRewards {
+		reward := result[i]
 		v.logger.With().Debug("rewards for coinbase",
 			lctx.Layer,
 			blockReward.Coinbase,
 			log.Stringer("relative weight", &blockReward.Weight),
-			log.Uint64("subsidy", subsidyReward.Uint64()),
-			log.Uint64("total", totalReward.Uint64()),
+			log.Uint64("subsidy", reward.LayerReward),
+			log.Uint64("total", reward.TotalReward),
 		)
-
-		reward := types.Reward{
-			Layer:       lctx.Layer,
-			Coinbase:    blockReward.Coinbase,
-			TotalReward: totalReward.Uint64(),
-			LayerReward: subsidyReward.Uint64(),
-		}
-		result = append(result, reward)
 		account, err := ss.Get(blockReward.Coinbase)
 		if err != nil {
 			return nil, fmt.Errorf("%w: %s", core.ErrInternal, err.Error())
@@ -68,7 +83,7 @@ func (v *VM) addRewards(lctx ApplyContext, ss *core.StagedCache, fees uint64, bl
 		if err := ss.Update(account); err != nil {
 			return nil, fmt.Errorf("%w: %s", core.ErrInternal, err.Error())
 		}
-		transferred += totalReward.Uint64()
+		transferred += reward.TotalReward
 	}
 	v.logger.With().Debug("rewards for layer",
 		lctx.Layer,
@@ -0,0 +1,87 @@
+package escrow
+
+import (
+	"crypto/sha256"
+
+	"github.com/oasisprotocol/curve25519-voi/primitives/ed25519"
+	"github.com/spacemeshos/go-scale"
+
+	"github.com/spacemeshos/go-spacemesh/common/types"
+	"github.com/spacemeshos/go-spacemesh/genvm/core"
+	"github.com/spacemeshos/go-spacemesh/genvm/sdk"
+	"github.com/spacemeshos/go-spacemesh/genvm/sdk/wallet"
+	"github.com/spacemeshos/go-spacemesh/genvm/templates/escrow"
+	"github.com/spacemeshos/go-spacemesh/signing"
+)
+
+var (
+	methodClaim  = scale.U8(escrow.MethodClaim)
+	methodRefund = scale.U8(escrow.MethodRefund)
+	methodDrain  = scale.U8(escrow.MethodDrain)
+)
+
+// Hashlock computes hashlock for the preimage.
+func Hashlock(preimage types.Hash32) types.Hash32 {
+	return sha256.Sum256(preimage[:])
+}
+
+// Address computes address of the escrow with spawn arguments.
+func Address(args *escrow.SpawnArguments) types.Address {
+	return core.ComputePrincipal(escrow.TemplateAddress, args)
+}
+
+// Spawn creates a transaction that spawns escrow from the single sig wallet.
+func Spawn(pk signing.PrivateKey, args *escrow.SpawnArguments, nonce core.Nonce, opts ...sdk.Opt) []byte {
+	return wallet.Spawn(pk, escrow.TemplateAddress, args, nonce, opts...)
+}
+
+// Claim creates transaction that releases escrow to the recipient, signed with the recipient key.
+func Claim(pk signing.PrivateKey, principal types.Address, preimage types.Hash32, nonce core.Nonce, opts ...sdk.Opt) []byte {
+	options := sdk.Defaults()
+	for _, opt := range opts {
+		opt(options)
+	}
+
+	payload := core.Payload{}
+	payload.Nonce = nonce
+	payload.GasPrice = options.GasPrice
+
+	args := escrow.ClaimArguments{Preimage: preimage}
+	tx := sdk.Encode(&sdk.TxVersion, &principal, &methodClaim, &payload, &args)
+	sig := ed25519.Sign(ed25519.PrivateKey(pk), core.SigningBody(options.GenesisID[:], tx))
+	return append(tx, sig...)
+}
+
+// Refund creates transaction that returns escrow to the refund address, signed with the refund key.
+func Refund(pk signing.PrivateKey, principal types.Address, nonce core.Nonce, opts ...sdk.Opt) []byte {
+	options := sdk.Defaults()
+	for _, opt := range opts {
+		opt(options)
+	}
+
+	payload := core.Payload{}
+	payload.Nonce = nonce
+	payload.GasPrice = options.GasPrice
+
+	tx := sdk.Encode(&sdk.TxVersion, &principal, &methodRefund, &payload, &escrow.RefundArguments{})
+	sig := ed25519.Sign(ed25519.PrivateKey(pk), core.SigningBody(options.GenesisID[:], tx))
+	return append(tx, sig...)
+}
+
+// Drain creates transaction that transfers amount left on the settled escrow to the refund address,
+// signed with the refund key.
+func Drain(pk signing.PrivateKey, principal types.Address, amount uint64, nonce core.Nonce, opts ...sdk.Opt) []byte {
+	options := sdk.Defaults()
+	for _, opt := range opts {
+		opt(options)
+	}
+
+	payload := core.Payload{}
+	payload.Nonce = nonce
+	payload.GasPrice = options.GasPrice
+
+	args := escrow.DrainArguments{Amount: amount}
+	tx := sdk.Encode(&sdk.TxVersion, &principal, &methodDrain, &payload, &args)
+	sig := ed25519.Sign(ed25519.PrivateKey(pk), core.SigningBody(options.GenesisID[:], tx))
+	return append(tx, sig...)
+}
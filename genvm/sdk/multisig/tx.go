@@ -123,3 +123,25 @@ func Spend(ref uint8, pk ed25519.PrivateKey, principal, to types.Address, amount
 	aggregator.Add(part)
 	return aggregator
 }
+
+// SpendMany creates transaction that transfers to all recipients under a single nonce.
+func SpendMany(ref uint8, pk ed25519.PrivateKey, principal types.Address, recipients []multisig.SpendArguments, nonce types.Nonce, opts ...sdk.Opt) *Aggregator {
+	options := sdk.Defaults()
+	for _, opt := range opts {
+		opt(options)
+	}
+
+	payload := core.Payload{}
+	payload.GasPrice = options.GasPrice
+	payload.Nonce = nonce
+
+	args := multisig.SpendManyArguments{Recipients: recipients}
+
+	tx := encode(&sdk.TxVersion, &principal, &sdk.MethodSpendMany, &payload, &args)
+	sig := ed25519.Sign(ed25519.PrivateKey(pk), core.SigningBody(options.GenesisID[:], tx))
+	aggregator := &Aggregator{unsigned: tx, parts: map[uint8]multisig.Part{}}
+	part := multisig.Part{Ref: ref}
+	copy(part.Sig[:], sig)
+	aggregator.Add(part)
+	return aggregator
+}
@@ -43,4 +43,6 @@ var (
 	MethodSpawn = scale.U8(core.MethodSpawn)
 	// MethodSpend ...
 	MethodSpend = scale.U8(core.MethodSpend)
+	// MethodSpendMany ...
+	MethodSpendMany = scale.U8(core.MethodSpendMany)
 )
@@ -76,3 +76,25 @@ func Spend(pk signing.PrivateKey, to types.Address, amount uint64, nonce types.N
 	sig := ed25519.Sign(ed25519.PrivateKey(pk), core.SigningBody(options.GenesisID[:], tx))
 	return append(tx, sig...)
 }
+
+// SpendMany creates transaction that transfers to all recipients under a single nonce.
+func SpendMany(pk signing.PrivateKey, recipients []wallet.SpendArguments, nonce types.Nonce, opts ...sdk.Opt) []byte {
+	options := sdk.Defaults()
+	for _, opt := range opts {
+		opt(options)
+	}
+
+	spawnargs := wallet.SpawnArguments{}
+	copy(spawnargs.PublicKey[:], signing.Public(pk))
+	principal := core.ComputePrincipal(wallet.TemplateAddress, &spawnargs)
+
+	payload := core.Payload{}
+	payload.GasPrice = options.GasPrice
+	payload.Nonce = nonce
+
+	args := wallet.SpendManyArguments{Recipients: recipients}
+
+	tx := encode(&sdk.TxVersion, &principal, &sdk.MethodSpendMany, &payload, &args)
+	sig := ed25519.Sign(ed25519.PrivateKey(pk), core.SigningBody(options.GenesisID[:], tx))
+	return append(tx, sig...)
+}
@@ -0,0 +1,188 @@
+package escrow
+
+import (
+	"crypto/sha256"
+	"errors"
+	"fmt"
+
+	"github.com/oasisprotocol/curve25519-voi/primitives/ed25519"
+	"github.com/spacemeshos/go-scale"
+
+	"github.com/spacemeshos/go-spacemesh/genvm/core"
+)
+
+var (
+	// ErrInvalidPreimage is raised if sha256 of the preimage doesn't match hashlock.
+	ErrInvalidPreimage = errors.New("escrow: invalid preimage")
+	// ErrTimeout is raised if escrow is claimed at or after the timeout layer.
+	ErrTimeout = errors.New("escrow: timeout passed")
+	// ErrNotTimeout is raised if escrow is refunded before the timeout layer.
+	ErrNotTimeout = errors.New("escrow: timeout not passed")
+	// ErrSettled is raised if escrow is claimed or refunded after it was already settled.
+	ErrSettled = errors.New("escrow: already settled")
+	// ErrNotSettled is raised if escrow is drained before it was settled.
+	ErrNotSettled = errors.New("escrow: not settled")
+)
+
+// ESCROW_STATE_SIZE includes account header, two addresses, two public keys, hashlock, timeout,
+// amount, max fee and settled flag.
+const ESCROW_STATE_SIZE = core.ACCOUNT_HEADER_SIZE + 24 + 24 + 2*core.PUBLIC_KEY_SIZE + 32 + 4 + 8 + 8 + 1
+
+//go:generate scalegen
+
+// Escrow is a hash time-locked account.
+//
+// Before Timeout the recipient who knows the preimage can release Amount to the Recipient,
+// starting from Timeout the refunder can return Amount to the RefundAddress. Claim must be signed
+// with RecipientKey and refund with RefundKey. Escrow is settled by the first successful
+// claim or refund. After that the only allowed method is drain, it is signed with RefundKey
+// and transfers the balance left on the account to the RefundAddress.
+//
+// Fees are paid from the escrow balance, so the account needs to be funded with more than Amount.
+// Every transaction can pay at most MaxFee, transactions with larger max gas and gas price are rejected.
+type Escrow struct {
+	Recipient     core.Address
+	RecipientKey  core.PublicKey
+	RefundAddress core.Address
+	RefundKey     core.PublicKey
+	Hashlock      core.Hash32
+	Timeout       core.LayerID
+	Amount        uint64
+	MaxFee        uint64
+	Settled       bool
+}
+
+// MaxSpend returns Amount for claim and refund. Claim with invalid preimage is rejected.
+func (e *Escrow) MaxSpend(method uint8, args any) (uint64, error) {
+	switch method {
+	case core.MethodSpawn:
+		return 0, nil
+	case MethodClaim:
+		if err := e.checkPreimage(args.(*ClaimArguments).Preimage); err != nil {
+			return 0, fmt.Errorf("%w: %s", core.ErrMalformed, err)
+		}
+		return e.Amount, nil
+	case MethodRefund:
+		return e.Amount, nil
+	case MethodDrain:
+		return args.(*DrainArguments).Amount, nil
+	default:
+		return 0, fmt.Errorf("%w: unknown method %d", core.ErrMalformed, method)
+	}
+}
+
+// Verify that the fee is within MaxFee, claim is submitted before the timeout and signed
+// by the recipient, refund is submitted at or after the timeout and signed by the refunder,
+// claim and refund are submitted before the escrow is settled and drain after that.
+// Escrow can't spawn accounts, including itself. It must be spawned by the funder.
+func (e *Escrow) Verify(host core.Host, raw []byte, dec *scale.Decoder) bool {
+	if host.MaxFee() > e.MaxFee {
+		return false
+	}
+	var key core.PublicKey
+	switch host.Method() {
+	case MethodClaim:
+		if e.Settled || e.checkClaim(host.Layer()) != nil {
+			return false
+		}
+		key = e.RecipientKey
+	case MethodRefund:
+		if e.Settled || e.checkRefund(host.Layer()) != nil {
+			return false
+		}
+		key = e.RefundKey
+	case MethodDrain:
+		if !e.Settled {
+			return false
+		}
+		key = e.RefundKey
+	default:
+		return false
+	}
+	sig := core.Signature{}
+	n, err := sig.DecodeScale(dec)
+	if err != nil {
+		return false
+	}
+	return ed25519.Verify(
+		ed25519.PublicKey(key[:]),
+		core.SigningBody(host.GetGenesisID().Bytes(), raw[:len(raw)-n]),
+		sig[:],
+	)
+}
+
+// Claim transfers Amount to the Recipient and settles the escrow.
+func (e *Escrow) Claim(host core.Host, args *ClaimArguments) error {
+	// transaction might be verified before the timeout, but executed after
+	if err := e.checkClaim(host.Layer()); err != nil {
+		return err
+	}
+	if err := e.checkPreimage(args.Preimage); err != nil {
+		return err
+	}
+	return e.settle(host, e.Recipient)
+}
+
+// Refund transfers Amount to the RefundAddress and settles the escrow.
+func (e *Escrow) Refund(host core.Host) error {
+	if err := e.checkRefund(host.Layer()); err != nil {
+		return err
+	}
+	return e.settle(host, e.RefundAddress)
+}
+
+// Drain transfers the balance left after the escrow was settled to the RefundAddress.
+func (e *Escrow) Drain(host core.Host, args *DrainArguments) error {
+	// checked on execution as well, as the drain may be verified against the state
+	// that was reverted before execution
+	if !e.Settled {
+		return ErrNotSettled
+	}
+	return host.Transfer(e.RefundAddress, args.Amount)
+}
+
+// settle is checked on execution as well, as both claim and refund may be verified
+// before the escrow is settled by another transaction.
+func (e *Escrow) settle(host core.Host, to core.Address) error {
+	if e.Settled {
+		return ErrSettled
+	}
+	if err := host.Transfer(to, e.Amount); err != nil {
+		return err
+	}
+	e.Settled = true
+	return host.UpdateTemplate()
+}
+
+func (e *Escrow) checkPreimage(preimage core.Hash32) error {
+	if sha256.Sum256(preimage[:]) != e.Hashlock {
+		return ErrInvalidPreimage
+	}
+	return nil
+}
+
+func (e *Escrow) checkClaim(lid core.LayerID) error {
+	if !lid.Before(e.Timeout) {
+		return ErrTimeout
+	}
+	return nil
+}
+
+func (e *Escrow) checkRefund(lid core.LayerID) error {
+	if lid.Before(e.Timeout) {
+		return ErrNotTimeout
+	}
+	return nil
+}
+
+func (e *Escrow) BaseGas(method uint8) uint64 {
+	return BaseGas(method)
+}
+
+func (e *Escrow) LoadGas() uint64 {
+	return LoadGas()
+}
+
+func (e *Escrow) ExecGas(method uint8) uint64 {
+	return ExecGas(method)
+}
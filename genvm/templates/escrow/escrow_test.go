@@ -0,0 +1,89 @@
+package escrow
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"testing"
+
+	"github.com/oasisprotocol/curve25519-voi/primitives/ed25519"
+	"github.com/spacemeshos/go-scale"
+	"github.com/stretchr/testify/require"
+
+	"github.com/spacemeshos/go-spacemesh/common/types"
+	"github.com/spacemeshos/go-spacemesh/genvm/core"
+)
+
+func TestMaxSpend(t *testing.T) {
+	preimage := types.Hash32{1, 2, 3}
+	escrow := &Escrow{Hashlock: sha256.Sum256(preimage[:]), Timeout: 10, Amount: 100}
+
+	spend, err := escrow.MaxSpend(MethodClaim, &ClaimArguments{Preimage: preimage})
+	require.NoError(t, err)
+	require.EqualValues(t, 100, spend)
+
+	_, err = escrow.MaxSpend(MethodClaim, &ClaimArguments{Preimage: types.Hash32{3, 2, 1}})
+	require.ErrorIs(t, err, core.ErrMalformed)
+
+	spend, err = escrow.MaxSpend(MethodRefund, &RefundArguments{})
+	require.NoError(t, err)
+	require.EqualValues(t, 100, spend)
+
+	spend, err = escrow.MaxSpend(MethodDrain, &DrainArguments{Amount: 10})
+	require.NoError(t, err)
+	require.EqualValues(t, 10, spend)
+
+	_, err = escrow.MaxSpend(core.MethodSpend, nil)
+	require.ErrorIs(t, err, core.ErrMalformed)
+}
+
+func TestVerify(t *testing.T) {
+	recipientPub, recipient, err := ed25519.GenerateKey(nil)
+	require.NoError(t, err)
+	refundPub, refund, err := ed25519.GenerateKey(nil)
+	require.NoError(t, err)
+	escrow := &Escrow{Timeout: 10, MaxFee: 100}
+	copy(escrow.RecipientKey[:], recipientPub)
+	copy(escrow.RefundKey[:], refundPub)
+
+	for _, tc := range []struct {
+		desc    string
+		method  uint8
+		lid     types.LayerID
+		key     ed25519.PrivateKey
+		maxGas  uint64
+		settled bool
+		expect  bool
+	}{
+		{desc: "claim before timeout", method: MethodClaim, lid: 9, key: recipient, expect: true},
+		{desc: "claim signed by refunder", method: MethodClaim, lid: 9, key: refund},
+		{desc: "claim at timeout", method: MethodClaim, lid: 10, key: recipient},
+		{desc: "refund before timeout", method: MethodRefund, lid: 9, key: refund},
+		{desc: "refund at timeout", method: MethodRefund, lid: 10, key: refund, expect: true},
+		{desc: "refund after timeout", method: MethodRefund, lid: 11, key: refund, expect: true},
+		{desc: "refund signed by recipient", method: MethodRefund, lid: 11, key: recipient},
+		{desc: "max fee", method: MethodClaim, lid: 9, key: recipient, maxGas: 100, expect: true},
+		{desc: "fee above max", method: MethodClaim, lid: 9, key: recipient, maxGas: 101},
+		{desc: "settled", method: MethodClaim, lid: 9, key: recipient, settled: true},
+		{desc: "refund settled", method: MethodRefund, lid: 11, key: refund, settled: true},
+		{desc: "drain settled", method: MethodDrain, lid: 9, key: refund, settled: true, expect: true},
+		{desc: "drain not settled", method: MethodDrain, lid: 11, key: refund},
+		{desc: "drain signed by recipient", method: MethodDrain, lid: 9, key: recipient, settled: true},
+		{desc: "spawn", method: core.MethodSpawn, lid: 9, key: recipient},
+		{desc: "spend", method: core.MethodSpend, lid: 9, key: recipient},
+	} {
+		tc := tc
+		t.Run(tc.desc, func(t *testing.T) {
+			escrow := *escrow
+			escrow.Settled = tc.settled
+			ctx := &core.Context{LayerID: tc.lid}
+			ctx.Header.Method = tc.method
+			ctx.Header.MaxGas = tc.maxGas
+			ctx.Header.GasPrice = 1
+			body := []byte("escrow transaction")
+			sig := ed25519.Sign(tc.key, core.SigningBody(ctx.GetGenesisID().Bytes(), body))
+			raw := append(body, sig...)
+			dec := scale.NewDecoder(bytes.NewReader(sig))
+			require.Equal(t, tc.expect, escrow.Verify(ctx, raw, dec))
+		})
+	}
+}
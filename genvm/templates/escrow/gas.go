@@ -0,0 +1,40 @@
+package escrow
+
+import (
+	"math"
+
+	"github.com/spacemeshos/go-spacemesh/genvm/core"
+)
+
+func BaseGas(method uint8) uint64 {
+	switch method {
+	case MethodClaim, MethodRefund, MethodDrain:
+		return core.TX + core.EDVERIFY
+	}
+	return math.MaxUint64
+}
+
+func LoadGas() uint64 {
+	return core.ACCOUNT_ACCESS + core.SizeGas(core.LOAD, ESCROW_STATE_SIZE)
+}
+
+func ExecGas(method uint8) uint64 {
+	switch method {
+	case core.MethodSpawn:
+		return core.SizeGas(core.STORE, ESCROW_STATE_SIZE)
+	case MethodClaim, MethodRefund:
+		gas := core.ACCOUNT_ACCESS
+		gas += core.SizeGas(core.LOAD, core.ACCOUNT_BALANCE_SIZE)
+		gas += core.SizeGas(core.UPDATE, core.ACCOUNT_HEADER_SIZE)
+		gas += core.SizeGas(core.UPDATE, core.ACCOUNT_BALANCE_SIZE)
+		// settled flag
+		gas += core.SizeGas(core.UPDATE, 1)
+		return gas
+	case MethodDrain:
+		gas := core.ACCOUNT_ACCESS
+		gas += core.SizeGas(core.LOAD, core.ACCOUNT_BALANCE_SIZE)
+		gas += core.SizeGas(core.UPDATE, core.ACCOUNT_BALANCE_SIZE)
+		return gas
+	}
+	return math.MaxUint64
+}
@@ -0,0 +1,109 @@
+package escrow
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/spacemeshos/go-scale"
+
+	"github.com/spacemeshos/go-spacemesh/genvm/core"
+	"github.com/spacemeshos/go-spacemesh/genvm/registry"
+)
+
+// TemplateAddress is an address of the escrow template.
+var TemplateAddress core.Address
+
+func init() {
+	TemplateAddress[len(TemplateAddress)-1] = 5
+}
+
+// Escrow selectors don't overlap with selectors of other templates,
+// so that tooling that decodes transactions by the selector can't confuse them.
+const (
+	// MethodClaim releases escrow to the recipient if preimage matches hashlock.
+	MethodClaim = 19
+	// MethodRefund returns escrow to the refund address after the timeout.
+	MethodRefund = 20
+	// MethodDrain transfers balance left after the escrow is settled to the refund address.
+	MethodDrain = 21
+)
+
+// Register escrow template.
+func Register(reg *registry.Registry) {
+	reg.Register(TemplateAddress, &handler{})
+}
+
+var _ (core.Handler) = (*handler)(nil)
+
+type handler struct{}
+
+// Parse header and arguments.
+func (h *handler) Parse(host core.Host, method uint8, decoder *scale.Decoder) (output core.ParseOutput, err error) {
+	var p core.Payload
+	if _, err = p.DecodeScale(decoder); err != nil {
+		err = fmt.Errorf("%w: %s", core.ErrMalformed, err.Error())
+		return
+	}
+	output.GasPrice = p.GasPrice
+	output.Nonce = p.Nonce
+	return output, nil
+}
+
+// New instantiates escrow state.
+func (h *handler) New(args any) (core.Template, error) {
+	spawn := args.(*SpawnArguments)
+	if spawn.Amount == 0 {
+		return nil, fmt.Errorf("amount should be larger than zero")
+	}
+	if spawn.Timeout == 0 {
+		return nil, fmt.Errorf("timeout should be larger than zero")
+	}
+	return &Escrow{
+		Recipient:     spawn.Recipient,
+		RecipientKey:  spawn.RecipientKey,
+		RefundAddress: spawn.RefundAddress,
+		RefundKey:     spawn.RefundKey,
+		Hashlock:      spawn.Hashlock,
+		Timeout:       spawn.Timeout,
+		Amount:        spawn.Amount,
+		MaxFee:        spawn.MaxFee,
+	}, nil
+}
+
+// Load escrow from state.
+func (h *handler) Load(state []byte) (core.Template, error) {
+	dec := scale.NewDecoder(bytes.NewBuffer(state))
+	escrow := &Escrow{}
+	if _, err := escrow.DecodeScale(dec); err != nil {
+		return nil, fmt.Errorf("%w: %s", core.ErrInternal, err)
+	}
+	return escrow, nil
+}
+
+// Exec claim, refund or drain based on the method selector.
+func (h *handler) Exec(host core.Host, method uint8, args scale.Encodable) error {
+	switch method {
+	case MethodClaim:
+		return host.Template().(*Escrow).Claim(host, args.(*ClaimArguments))
+	case MethodRefund:
+		return host.Template().(*Escrow).Refund(host)
+	case MethodDrain:
+		return host.Template().(*Escrow).Drain(host, args.(*DrainArguments))
+	}
+	return fmt.Errorf("%w: unknown method %d", core.ErrMalformed, method)
+}
+
+// Args ...
+func (h *handler) Args(method uint8) scale.Type {
+	switch method {
+	case core.MethodSpawn:
+		return &SpawnArguments{}
+	case MethodClaim:
+		return &ClaimArguments{}
+	case MethodRefund:
+		return &RefundArguments{}
+	case MethodDrain:
+		return &DrainArguments{}
+	}
+	return nil
+}
@@ -0,0 +1,49 @@
+package escrow
+
+import (
+	"fmt"
+
+	"github.com/spacemeshos/go-spacemesh/genvm/core"
+)
+
+//go:generate scalegen
+
+// SpawnArguments for the escrow.
+//
+// All terms of the escrow, including the amount, are committed to the address of the account.
+type SpawnArguments struct {
+	Recipient core.Address
+	// RecipientKey is a public key that signs claim.
+	RecipientKey  core.PublicKey
+	RefundAddress core.Address
+	// RefundKey is a public key that signs refund.
+	RefundKey core.PublicKey
+	// Hashlock is a sha256 of the preimage that is required to claim the escrow.
+	Hashlock core.Hash32
+	// Timeout is the first layer when claim is not allowed and the escrow can be refunded.
+	Timeout core.LayerID
+	Amount  uint64
+	// MaxFee is the maximal fee that claim or refund can pay from the escrow balance.
+	MaxFee uint64
+}
+
+func (args *SpawnArguments) String() string {
+	return fmt.Sprintf("recipient = %s. refund = %s. hashlock = %s. timeout = %d. amount = %d smidge. max fee = %d smidge",
+		args.Recipient.String(), args.RefundAddress.String(), args.Hashlock.String(), args.Timeout, args.Amount, args.MaxFee,
+	)
+}
+
+// ClaimArguments contains preimage of the hashlock.
+type ClaimArguments struct {
+	Preimage core.Hash32
+}
+
+// RefundArguments is empty, refund always returns the amount to the refund address.
+type RefundArguments struct{}
+
+// DrainArguments contains amount that is transferred to the refund address.
+// Amount can't be computed when the transaction is validated, as the balance
+// of the settled escrow depends on the fees and transfers received after settlement.
+type DrainArguments struct {
+	Amount uint64
+}
@@ -4,13 +4,14 @@ import (
 	"math"
 
 	"github.com/spacemeshos/go-spacemesh/genvm/core"
+	"github.com/spacemeshos/go-spacemesh/genvm/templates/wallet"
 )
 
 func BaseGas(method uint8, signatures int) uint64 {
 	switch method {
 	case core.MethodSpawn:
 		return core.TX + core.EDVERIFY*uint64(signatures) + core.SPAWN
-	case core.MethodSpend:
+	case core.MethodSpend, core.MethodSpendMany:
 		return core.TX + core.EDVERIFY*uint64(signatures)
 	}
 	return math.MaxUint64
@@ -30,6 +31,13 @@ func ExecGas(method uint8, keys int) uint64 {
 		gas += core.SizeGas(core.UPDATE, core.ACCOUNT_HEADER_SIZE)
 		gas += core.SizeGas(core.UPDATE, core.ACCOUNT_BALANCE_SIZE)
 		return gas
+	case core.MethodSpendMany:
+		return wallet.ExecGas(method)
 	}
 	return math.MaxUint64
 }
+
+// VariableGas returns gas for SpendMany that scales with the number of recipients.
+func VariableGas(method uint8, args any) uint64 {
+	return wallet.VariableGas(method, args)
+}
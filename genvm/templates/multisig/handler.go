@@ -83,6 +83,10 @@ func (h *handler) Exec(host core.Host, method uint8, args scale.Encodable) error
 		if err := host.Template().(SpendTemplate).Spend(host, args.(*SpendArguments)); err != nil {
 			return err
 		}
+	case core.MethodSpendMany:
+		if err := host.Template().(SpendTemplate).SpendMany(host, args.(*SpendManyArguments)); err != nil {
+			return err
+		}
 	default:
 		return fmt.Errorf("%w: unknown method %d", core.ErrMalformed, method)
 	}
@@ -96,11 +100,14 @@ func (h *handler) Args(method uint8) scale.Type {
 		return &SpawnArguments{}
 	case core.MethodSpend:
 		return &SpendArguments{}
+	case core.MethodSpendMany:
+		return &SpendManyArguments{}
 	}
 	return nil
 }
 
-// SpendTemplate interface for the template that support Spend method.
+// SpendTemplate interface for the template that support Spend and SpendMany methods.
 type SpendTemplate interface {
 	Spend(core.Host, *SpendArguments) error
+	SpendMany(core.Host, *SpendManyArguments) error
 }
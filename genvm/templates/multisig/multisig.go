@@ -7,6 +7,7 @@ import (
 	"github.com/spacemeshos/go-scale"
 
 	"github.com/spacemeshos/go-spacemesh/genvm/core"
+	"github.com/spacemeshos/go-spacemesh/genvm/templates/wallet"
 )
 
 //go:generate scalegen
@@ -29,6 +30,10 @@ func (ms *MultiSig) ExecGas(method uint8) uint64 {
 	return ExecGas(method, len(ms.PublicKeys))
 }
 
+func (ms *MultiSig) VariableGas(method uint8, args scale.Encodable) uint64 {
+	return VariableGas(method, args)
+}
+
 // MaxSpend returns amount specified in the SpendArguments.
 func (ms *MultiSig) MaxSpend(method uint8, args any) (uint64, error) {
 	switch method {
@@ -36,6 +41,8 @@ func (ms *MultiSig) MaxSpend(method uint8, args any) (uint64, error) {
 		return 0, nil
 	case core.MethodSpend:
 		return args.(*SpendArguments).Amount, nil
+	case core.MethodSpendMany:
+		return args.(*SpendManyArguments).Total()
 	default:
 		return 0, fmt.Errorf("%w: unknown method %d", core.ErrMalformed, method)
 	}
@@ -69,3 +76,8 @@ func (ms *MultiSig) Verify(host core.Host, raw []byte, dec *scale.Decoder) bool
 func (ms *MultiSig) Spend(host core.Host, args *SpendArguments) error {
 	return host.Transfer(args.Destination, args.Amount)
 }
+
+// SpendMany transfers to all recipients specified in SpendManyArguments.
+func (ms *MultiSig) SpendMany(host core.Host, args *SpendManyArguments) error {
+	return wallet.TransferMany(host, args)
+}
@@ -37,3 +37,6 @@ type Part struct {
 
 // SpendArguments ...
 type SpendArguments = wallet.SpendArguments
+
+// SpendManyArguments ...
+type SpendManyArguments = wallet.SpendManyArguments
@@ -36,6 +36,7 @@ func (h *handler) Parse(host core.Host, method uint8, decoder *scale.Decoder) (o
 
 // New instatiates vesting state, note that the state is the same as multisig.
 // The difference is that vesting supports one more transaction type.
+// All multisig methods, including SpendMany, are relayed to multisig handler unchanged.
 func (h *handler) New(args any) (core.Template, error) {
 	template, err := h.multisig.New(args)
 	if err != nil {
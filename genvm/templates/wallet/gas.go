@@ -10,7 +10,7 @@ func BaseGas(method uint8) uint64 {
 	switch method {
 	case core.MethodSpawn:
 		return core.TX + core.EDVERIFY + core.SPAWN
-	case core.MethodSpend:
+	case core.MethodSpend, core.MethodSpendMany:
 		return core.TX + core.EDVERIFY
 	}
 	return math.MaxUint64
@@ -30,6 +30,24 @@ func ExecGas(method uint8) uint64 {
 		gas += core.SizeGas(core.UPDATE, core.ACCOUNT_HEADER_SIZE)
 		gas += core.SizeGas(core.UPDATE, core.ACCOUNT_BALANCE_SIZE)
 		return gas
+	case core.MethodSpendMany:
+		return core.SizeGas(core.UPDATE, core.ACCOUNT_HEADER_SIZE)
 	}
 	return math.MaxUint64
 }
+
+// RecipientGas is a cost of a single transfer in SpendMany, charged on top of ExecGas.
+func RecipientGas() uint64 {
+	gas := core.ACCOUNT_ACCESS
+	gas += core.SizeGas(core.LOAD, core.ACCOUNT_BALANCE_SIZE)
+	gas += core.SizeGas(core.UPDATE, core.ACCOUNT_BALANCE_SIZE)
+	return gas
+}
+
+// VariableGas returns gas for SpendMany that scales with the number of recipients.
+func VariableGas(method uint8, args any) uint64 {
+	if method == core.MethodSpendMany {
+		return uint64(len(args.(*SpendManyArguments).Recipients)) * RecipientGas()
+	}
+	return 0
+}
@@ -65,6 +65,10 @@ func (*handler) Exec(host core.Host, method uint8, args scale.Encodable) error {
 		if err := host.Template().(*Wallet).Spend(host, args.(*SpendArguments)); err != nil {
 			return err
 		}
+	case core.MethodSpendMany:
+		if err := host.Template().(*Wallet).SpendMany(host, args.(*SpendManyArguments)); err != nil {
+			return err
+		}
 	default:
 		return fmt.Errorf("%w: unknown method %d", core.ErrMalformed, method)
 	}
@@ -78,6 +82,8 @@ func (h *handler) Args(method uint8) scale.Type {
 		return &SpawnArguments{}
 	case core.MethodSpend:
 		return &SpendArguments{}
+	case core.MethodSpendMany:
+		return &SpendManyArguments{}
 	}
 	return nil
 }
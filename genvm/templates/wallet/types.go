@@ -1,6 +1,9 @@
 package wallet
 
 import (
+	"fmt"
+	"math"
+
 	"github.com/spacemeshos/go-spacemesh/genvm/core"
 )
 
@@ -16,3 +19,26 @@ type SpendArguments struct {
 	Destination core.Address
 	Amount      uint64
 }
+
+// SpendManyArguments contains transfers that are executed atomically.
+//
+// The number of recipients is bounded by core.TxSizeLimit rather than by gas. Every recipient
+// takes up to 33 bytes (address and compact amount), 20 recipients take up to 660 bytes and leave
+// room for the header and a single signature, or up to 4 multisig signatures. Hundreds of recipients
+// would need a larger transaction size limit for all templates. Larger payouts are split into
+// several transactions, every one of them replaces 20 transactions with separate nonces.
+type SpendManyArguments struct {
+	Recipients []SpendArguments `scale:"max=20"` // update TransactionResult.Addresses limit if it changes.
+}
+
+// Total amount transferred to all recipients.
+func (args *SpendManyArguments) Total() (uint64, error) {
+	var total uint64
+	for _, recipient := range args.Recipients {
+		if recipient.Amount > math.MaxUint64-total {
+			return 0, fmt.Errorf("%w: total amount overflows", core.ErrMalformed)
+		}
+		total += recipient.Amount
+	}
+	return total, nil
+}
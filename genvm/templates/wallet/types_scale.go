@@ -65,3 +65,26 @@ func (t *SpendArguments) DecodeScale(dec *scale.Decoder) (total int, err error)
 	}
 	return total, nil
 }
+
+func (t *SpendManyArguments) EncodeScale(enc *scale.Encoder) (total int, err error) {
+	{
+		n, err := scale.EncodeStructSliceWithLimit(enc, t.Recipients, 20)
+		if err != nil {
+			return total, err
+		}
+		total += n
+	}
+	return total, nil
+}
+
+func (t *SpendManyArguments) DecodeScale(dec *scale.Decoder) (total int, err error) {
+	{
+		field, n, err := scale.DecodeStructSliceWithLimit[SpendArguments](dec, 20)
+		if err != nil {
+			return total, err
+		}
+		total += n
+		t.Recipients = field
+	}
+	return total, nil
+}
@@ -28,6 +28,8 @@ func (s *Wallet) MaxSpend(method uint8, args any) (uint64, error) {
 		return 0, nil
 	case core.MethodSpend:
 		return args.(*SpendArguments).Amount, nil
+	case core.MethodSpendMany:
+		return args.(*SpendManyArguments).Total()
 	default:
 		return 0, fmt.Errorf("%w: unknown method %d", core.ErrMalformed, method)
 	}
@@ -52,6 +54,29 @@ func (s *Wallet) Spend(host core.Host, args *SpendArguments) error {
 	return host.Transfer(args.Destination, args.Amount)
 }
 
+// SpendMany transfers to all recipients specified in SpendManyArguments.
+func (s *Wallet) SpendMany(host core.Host, args *SpendManyArguments) error {
+	return TransferMany(host, args)
+}
+
+// TransferMany transfers to all recipients. If balance doesn't cover the total amount
+// nothing is transferred.
+func TransferMany(host core.Host, args *SpendManyArguments) error {
+	total, err := args.Total()
+	if err != nil {
+		return err
+	}
+	if total > host.Balance() {
+		return core.ErrNoBalance
+	}
+	for _, recipient := range args.Recipients {
+		if err := host.Transfer(recipient.Destination, recipient.Amount); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func (s *Wallet) BaseGas(method uint8) uint64 {
 	return BaseGas(method)
 }
@@ -63,3 +88,7 @@ func (s *Wallet) LoadGas() uint64 {
 func (s *Wallet) ExecGas(method uint8) uint64 {
 	return ExecGas(method)
 }
+
+func (s *Wallet) VariableGas(method uint8, args scale.Encodable) uint64 {
+	return VariableGas(method, args)
+}
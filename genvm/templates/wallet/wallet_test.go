@@ -2,6 +2,7 @@ package wallet
 
 import (
 	"bytes"
+	"math"
 	"testing"
 
 	"github.com/oasisprotocol/curve25519-voi/primitives/ed25519"
@@ -33,6 +34,17 @@ func TestMaxSpend(t *testing.T) {
 		require.NoError(t, err)
 		require.EqualValues(t, amount, max)
 	})
+	t.Run("SpendMany", func(t *testing.T) {
+		args := &SpendManyArguments{Recipients: []SpendArguments{{Amount: 100}, {Amount: 200}}}
+		max, err := wallet.MaxSpend(core.MethodSpendMany, args)
+		require.NoError(t, err)
+		require.EqualValues(t, 300, max)
+	})
+	t.Run("SpendMany overflow", func(t *testing.T) {
+		args := &SpendManyArguments{Recipients: []SpendArguments{{Amount: math.MaxUint64}, {Amount: 1}}}
+		_, err := wallet.MaxSpend(core.MethodSpendMany, args)
+		require.ErrorIs(t, err, core.ErrMalformed)
+	})
 }
 
 func TestVerify(t *testing.T) {
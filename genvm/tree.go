@@ -0,0 +1,155 @@
+package vm
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/spacemeshos/merkle-tree"
+	"github.com/spacemeshos/merkle-tree/cache"
+
+	"github.com/spacemeshos/go-spacemesh/common/types"
+	"github.com/spacemeshos/go-spacemesh/hash"
+	"github.com/spacemeshos/go-spacemesh/sql"
+	"github.com/spacemeshos/go-spacemesh/sql/accounts"
+)
+
+// treesCacheSize is the number of accounts trees kept in memory for proofs.
+// Every tree keeps two hashes per account.
+const treesCacheSize = 2
+
+// accountsTree is a merkle tree over the latest states of all accounts at the layer,
+// sorted by address, see AccountProof.
+type accountsTree struct {
+	layer     types.LayerID
+	root      types.Hash32
+	addresses []types.Address
+
+	// mu protects reader, cached layers are read by seeking to the position.
+	mu     sync.Mutex
+	reader merkle.CacheReader
+}
+
+// buildAccountsTree builds the tree over accounts at the layer.
+// If withCache is false only the root is computed.
+func buildAccountsTree(db sql.Executor, lid types.LayerID, withCache bool) (*accountsTree, error) {
+	builder := merkle.NewTreeBuilder().WithHashFunc(hashNode)
+	var writer *cache.Writer
+	if withCache {
+		writer = cache.NewWriter(cache.MinHeightPolicy(0), cache.MakeSliceReadWriterFactory())
+		builder = builder.WithCacheWriter(writer)
+	}
+	tree, err := builder.Build()
+	if err != nil {
+		return nil, fmt.Errorf("build accounts tree: %w", err)
+	}
+	var (
+		rst   = &accountsTree{layer: lid}
+		count int
+		terr  error
+	)
+	if err := accounts.IterateSnapshot(db, lid, types.Address{}, func(account *types.Account) bool {
+		var leaf types.Hash32
+		leaf, terr = accountLeaf(account)
+		if terr != nil {
+			return false
+		}
+		if terr = tree.AddLeaf(leaf[:]); terr != nil {
+			return false
+		}
+		if withCache {
+			rst.addresses = append(rst.addresses, account.Address)
+		}
+		count++
+		return true
+	}); err != nil {
+		return nil, err
+	}
+	if terr != nil {
+		return nil, fmt.Errorf("add leaf: %w", terr)
+	}
+	if count == 0 {
+		rst.root = hash.Sum()
+		return rst, nil
+	}
+	copy(rst.root[:], tree.Root())
+	if withCache {
+		rst.reader, err = writer.GetReader()
+		if err != nil {
+			return nil, fmt.Errorf("accounts tree reader: %w", err)
+		}
+	}
+	return rst, nil
+}
+
+// index returns the position of the account in the tree.
+func (t *accountsTree) index(address types.Address) (uint64, bool) {
+	i := sort.Search(len(t.addresses), func(i int) bool {
+		return bytes.Compare(t.addresses[i][:], address[:]) >= 0
+	})
+	if i == len(t.addresses) || t.addresses[i] != address {
+		return 0, false
+	}
+	return uint64(i), true
+}
+
+// prove returns the leaf and the proof nodes for the account at the index.
+func (t *accountsTree) prove(index uint64) (types.Hash32, []types.Hash32, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	_, leaves, nodes, err := merkle.GenerateProof(map[uint64]bool{index: true}, t.reader)
+	if err != nil {
+		return types.Hash32{}, nil, fmt.Errorf("generate proof for %d: %w", index, err)
+	}
+	var leaf types.Hash32
+	copy(leaf[:], leaves[0])
+	rst := make([]types.Hash32, len(nodes))
+	for i := range nodes {
+		copy(rst[i][:], nodes[i])
+	}
+	return leaf, rst, nil
+}
+
+// accountsTrees keeps the most recently used accounts trees.
+type accountsTrees struct {
+	// mu serializes building trees, so that concurrent requests
+	// don't build trees for the same or different layers at once.
+	mu    sync.Mutex
+	trees []*accountsTree
+}
+
+// get returns the tree for the layer, building it if it is not cached.
+func (c *accountsTrees) get(db sql.Executor, lid types.LayerID) (*accountsTree, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for i, tree := range c.trees {
+		if tree.layer == lid {
+			copy(c.trees[1:i+1], c.trees[:i])
+			c.trees[0] = tree
+			return tree, nil
+		}
+	}
+	tree, err := buildAccountsTree(db, lid, true)
+	if err != nil {
+		return nil, err
+	}
+	c.trees = append([]*accountsTree{tree}, c.trees...)
+	if len(c.trees) > treesCacheSize {
+		c.trees = c.trees[:treesCacheSize]
+	}
+	return tree, nil
+}
+
+// revert drops trees after the layer.
+func (c *accountsTrees) revert(lid types.LayerID) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	trees := c.trees[:0]
+	for _, tree := range c.trees {
+		if !tree.layer.After(lid) {
+			trees = append(trees, tree)
+		}
+	}
+	c.trees = trees
+}
@@ -13,6 +13,7 @@ import (
 	"github.com/spacemeshos/go-spacemesh/events"
 	"github.com/spacemeshos/go-spacemesh/genvm/core"
 	"github.com/spacemeshos/go-spacemesh/genvm/registry"
+	"github.com/spacemeshos/go-spacemesh/genvm/templates/escrow"
 	"github.com/spacemeshos/go-spacemesh/genvm/templates/multisig"
 	"github.com/spacemeshos/go-spacemesh/genvm/templates/vault"
 	"github.com/spacemeshos/go-spacemesh/genvm/templates/vesting"
@@ -41,6 +42,44 @@ func WithLogger(logger log.Log) Opt {
 type Config struct {
 	GasLimit  uint64
 	GenesisID types.Hash20
+	// EscrowLayer is the first layer when escrow template can be spawned and used.
+	// Escrow is disabled if it is zero.
+	EscrowLayer types.LayerID
+	// SpendManyLayer is the first layer when SpendMany method can be used by wallet, multisig
+	// and vesting accounts. SpendMany is disabled if it is zero.
+	SpendManyLayer types.LayerID
+}
+
+func (cfg *Config) escrowEnabled(lid types.LayerID) bool {
+	return cfg.EscrowLayer != 0 && !lid.Before(cfg.EscrowLayer)
+}
+
+func (cfg *Config) spendManyEnabled(lid types.LayerID) bool {
+	return cfg.SpendManyLayer != 0 && !lid.Before(cfg.SpendManyLayer)
+}
+
+// checkForks rejects transactions that use templates or methods that are not enabled in the layer.
+func (cfg *Config) checkForks(header *core.Header, lid types.LayerID) error {
+	if header.TemplateAddress == escrow.TemplateAddress && !cfg.escrowEnabled(lid) {
+		return fmt.Errorf("%w: escrow is not enabled in layer %s", core.ErrMalformed, lid)
+	}
+	if isSpendMany(header) && !cfg.spendManyEnabled(lid) {
+		return fmt.Errorf("%w: spend many is not enabled in layer %s", core.ErrMalformed, lid)
+	}
+	return nil
+}
+
+// isSpendMany is true for SpendMany method of the templates that support it.
+// Vesting relays all multisig methods, therefore it supports SpendMany as well.
+func isSpendMany(header *core.Header) bool {
+	if header.Method != core.MethodSpendMany {
+		return false
+	}
+	switch header.TemplateAddress {
+	case wallet.TemplateAddress, multisig.TemplateAddress, vesting.TemplateAddress:
+		return true
+	}
+	return false
 }
 
 // DefaultConfig returns the default RewardConfig.
@@ -69,6 +108,7 @@ func New(db *sql.Database, opts ...Opt) *VM {
 	multisig.Register(vm.registry)
 	vesting.Register(vm.registry)
 	vault.Register(vm.registry)
+	escrow.Register(vm.registry)
 	for _, opt := range opts {
 		opt(vm)
 	}
@@ -81,6 +121,7 @@ type VM struct {
 	db       *sql.Database
 	cfg      Config
 	registry *registry.Registry
+	trees    accountsTrees
 }
 
 // Validation initializes validation request.
@@ -98,6 +139,41 @@ func (v *VM) GetLayerStateRoot(lid types.LayerID) (types.Hash32, error) {
 	return layers.GetStateHash(v.db, lid)
 }
 
+// GetAccountsRoot returns the root over the complete state at the layer, see AccountProof.
+// If layer is zero the root of the latest applied layer is returned.
+func (v *VM) GetAccountsRoot(lid types.LayerID) (types.LayerID, types.Hash32, error) {
+	tree, err := v.accountsTree(lid)
+	if err != nil {
+		return 0, types.Hash32{}, err
+	}
+	return tree.layer, tree.root, nil
+}
+
+// ComputeAccountsRoot computes the root over the complete state at the layer
+// without caching the tree.
+func (v *VM) ComputeAccountsRoot(lid types.LayerID) (types.Hash32, error) {
+	tree, err := buildAccountsTree(v.db, lid, false)
+	if err != nil {
+		return types.Hash32{}, err
+	}
+	return tree.root, nil
+}
+
+// accountsTree returns the cached tree for the applied layer.
+// If layer is zero the tree for the latest applied layer is returned.
+func (v *VM) accountsTree(lid types.LayerID) (*accountsTree, error) {
+	if lid == 0 {
+		latest, err := layers.GetLatestStateHashLayer(v.db)
+		if err != nil {
+			return nil, err
+		}
+		lid = latest
+	} else if _, err := layers.GetStateHash(v.db, lid); err != nil {
+		return nil, err
+	}
+	return v.trees.get(v.db, lid)
+}
+
 // GetLayerApplied returns layer of the applied transaction.
 func (v *VM) GetLayerApplied(tid types.TransactionID) (types.LayerID, error) {
 	return transactions.GetAppliedLayer(v.db, tid)
@@ -120,33 +196,35 @@ func (v *VM) GetAllAccounts() ([]*types.Account, error) {
 	return accounts.All(v.db)
 }
 
-func (v *VM) revert(lid types.LayerID) error {
-	tx, err := v.db.Tx(context.Background())
-	if err != nil {
+func revert(db sql.Executor, lid types.LayerID) error {
+	if err := accounts.Revert(db, lid); err != nil {
 		return err
 	}
-	defer tx.Release()
-
-	err = accounts.Revert(tx, lid)
-	if err != nil {
-		return err
-	}
-	err = rewards.Revert(tx, lid)
-	if err != nil {
-		return err
-	}
-	return tx.Commit()
+	return rewards.Revert(db, lid)
 }
 
 // Revert all changes that we made after the layer.
 func (v *VM) Revert(lid types.LayerID) error {
-	if err := v.revert(lid); err != nil {
+	if err := v.db.WithTx(context.Background(), func(tx *sql.Tx) error {
+		return revert(tx, lid)
+	}); err != nil {
 		return err
 	}
+	v.trees.revert(lid)
 	v.logger.With().Info("vm reverted to layer", lid)
 	return nil
 }
 
+// RevertTx reverts all changes that we made after the layer within the transaction.
+// Changes are visible to other readers only after the caller commits the transaction.
+func (v *VM) RevertTx(tx *sql.Tx, lid types.LayerID) error {
+	if err := revert(tx, lid); err != nil {
+		return err
+	}
+	v.trees.revert(lid)
+	return nil
+}
+
 // AccountExists returns true if the address exists, spawned or not.
 func (v *VM) AccountExists(address core.Address) (bool, error) {
 	return accounts.Has(v.db, address)
@@ -170,6 +248,51 @@ func (v *VM) GetBalance(address types.Address) (uint64, error) {
 	return account.Balance, nil
 }
 
+// GetAccount returns account state that was valid at the layer.
+// If layer is zero the latest state is returned.
+func (v *VM) GetAccount(address types.Address, lid types.LayerID) (types.Account, error) {
+	if lid == 0 {
+		return accounts.Latest(v.db, address)
+	}
+	return accounts.Get(v.db, address, lid)
+}
+
+// GetAccountHistory returns up to limit account states, updated at the layer or later.
+func (v *VM) GetAccountHistory(address types.Address, from types.LayerID, limit int) ([]*types.Account, error) {
+	return accounts.History(v.db, address, from, limit)
+}
+
+// GetAccountProof returns proof that the account state is the latest state of the account at the layer.
+// Proof is linked to the accounts root of the layer, see AccountProof.
+// If layer is zero the proof for the latest applied layer is returned.
+func (v *VM) GetAccountProof(address types.Address, lid types.LayerID) (*AccountProof, error) {
+	tree, err := v.accountsTree(lid)
+	if err != nil {
+		return nil, err
+	}
+	index, exists := tree.index(address)
+	if !exists {
+		return nil, fmt.Errorf("%w: account %s doesn't exist at layer %s", sql.ErrNotFound, address, tree.layer)
+	}
+	account, err := accounts.Get(v.db, address, tree.layer)
+	if err != nil {
+		return nil, err
+	}
+	leaf, nodes, err := tree.prove(index)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", core.ErrInternal, err.Error())
+	}
+	expected, err := accountLeaf(&account)
+	if err != nil {
+		return nil, err
+	}
+	if expected != leaf {
+		return nil, fmt.Errorf("%w: account %s at layer %s doesn't match the accounts tree",
+			core.ErrInternal, address, tree.layer)
+	}
+	return &AccountProof{Layer: tree.layer, Account: account, Index: index, Nodes: nodes}, nil
+}
+
 // ApplyGenesis saves list of accounts for genesis.
 func (v *VM) ApplyGenesis(genesis []types.Account) error {
 	tx, err := v.db.Tx(context.Background())
@@ -318,31 +441,11 @@ func (v *VM) execute(lctx ApplyContext, ss *core.StagedCache, txs []types.Transa
 		ctx := req.ctx
 		args := req.args
 
-		if header.GasPrice == 0 {
-			logger.With().Warning("ineffective transaction. zero gas price",
-				log.Object("header", header),
-				log.Object("account", &ctx.PrincipalAccount),
-			)
-			ineffective = append(ineffective, types.Transaction{RawTx: tx.GetRaw()})
-			invalidTxCount.Inc()
-			continue
-		}
-		if intrinsic := core.IntrinsicGas(ctx.Gas.BaseGas, tx.GetRaw().Raw); ctx.PrincipalAccount.Balance < intrinsic {
-			logger.With().Warning("ineffective transaction. intrinstic gas not covered",
-				log.Object("header", header),
-				log.Object("account", &ctx.PrincipalAccount),
-				log.Uint64("intrinsic gas", intrinsic),
-			)
-			ineffective = append(ineffective, types.Transaction{RawTx: tx.GetRaw()})
-			invalidTxCount.Inc()
-			continue
-		}
-		if limit < ctx.Header.MaxGas {
-			logger.With().Warning("ineffective transaction. out of block gas",
-				log.Uint64("block gas limit", v.cfg.GasLimit),
-				log.Uint64("current limit", limit),
+		if err := checkEffective(ctx, tx.GetRaw().Raw, limit); err != nil {
+			logger.With().Warning("ineffective transaction",
 				log.Object("header", header),
 				log.Object("account", &ctx.PrincipalAccount),
+				log.Err(err),
 			)
 			ineffective = append(ineffective, types.Transaction{RawTx: tx.GetRaw()})
 			invalidTxCount.Inc()
@@ -377,40 +480,12 @@ func (v *VM) execute(lctx ApplyContext, ss *core.StagedCache, txs []types.Transa
 			log.Object("account", &ctx.PrincipalAccount),
 		)
 
-		rst := types.TransactionWithResult{}
-		rst.Layer = lctx.Layer
-
-		err = ctx.Consume(ctx.Header.MaxGas)
-		if err == nil {
-			err = ctx.PrincipalHandler.Exec(ctx, ctx.Header.Method, args)
-		}
+		rst, err := v.exec(logger, lctx.Layer, ss, tx.GetRaw(), ctx, args)
 		if err != nil {
-			logger.With().Debug("transaction failed",
-				log.Object("header", header),
-				log.Object("account", &ctx.PrincipalAccount),
-				log.Err(err),
-			)
-			if errors.Is(err, core.ErrInternal) {
-				return nil, nil, 0, err
-			}
+			return nil, nil, 0, err
 		}
 		transactionDurationExecute.Observe(float64(time.Since(t2)))
 
-		rst.RawTx = txs[i].GetRaw()
-		rst.TxHeader = &ctx.Header
-		rst.Status = types.TransactionSuccess
-		if err != nil {
-			rst.Status = types.TransactionFailure
-			rst.Message = err.Error()
-		}
-		rst.Gas = ctx.Consumed()
-		rst.Fee = ctx.Fee()
-		rst.Addresses = ctx.Updated()
-
-		err = ctx.Apply(ss)
-		if err != nil {
-			return nil, nil, 0, fmt.Errorf("%w: %s", core.ErrInternal, err.Error())
-		}
 		fees += ctx.Fee()
 		limit -= ctx.Consumed()
 
@@ -420,6 +495,180 @@ func (v *VM) execute(lctx ApplyContext, ss *core.StagedCache, txs []types.Transa
 	return executed, ineffective, fees, nil
 }
 
+// checkEffective returns an error if transaction can't be included into the block
+// with remaining gas limit.
+func checkEffective(ctx *core.Context, raw []byte, limit uint64) error {
+	if ctx.Header.GasPrice == 0 {
+		return fmt.Errorf("%w: zero gas price", core.ErrIneffective)
+	}
+	if intrinsic := core.IntrinsicGas(ctx.Gas.BaseGas, raw); ctx.PrincipalAccount.Balance < intrinsic {
+		return fmt.Errorf("%w: intrinsic gas %d not covered by balance %d",
+			core.ErrIneffective, intrinsic, ctx.PrincipalAccount.Balance)
+	}
+	if limit < ctx.Header.MaxGas {
+		return fmt.Errorf("%w: out of block gas. max gas %d > limit %d",
+			core.ErrIneffective, ctx.Header.MaxGas, limit)
+	}
+	return nil
+}
+
+// exec consumes max gas from the principal, executes the method and applies
+// changes to the staged cache. Only internal errors are returned, execution
+// failures are recorded in the result.
+func (v *VM) exec(
+	logger log.Log,
+	lid types.LayerID,
+	ss *core.StagedCache,
+	raw types.RawTx,
+	ctx *core.Context,
+	args scale.Encodable,
+) (types.TransactionWithResult, error) {
+	rst := types.TransactionWithResult{}
+	rst.Layer = lid
+
+	err := ctx.Consume(ctx.Header.MaxGas)
+	if err == nil {
+		err = ctx.PrincipalHandler.Exec(ctx, ctx.Header.Method, args)
+	}
+	if err != nil {
+		logger.With().Debug("transaction failed",
+			log.Object("header", &ctx.Header),
+			log.Object("account", &ctx.PrincipalAccount),
+			log.Err(err),
+		)
+		if errors.Is(err, core.ErrInternal) {
+			return rst, err
+		}
+	}
+
+	rst.RawTx = raw
+	rst.TxHeader = &ctx.Header
+	rst.Status = types.TransactionSuccess
+	if err != nil {
+		rst.Status = types.TransactionFailure
+		rst.Message = err.Error()
+	}
+	rst.Gas = ctx.Consumed()
+	rst.Fee = ctx.Fee()
+	rst.Addresses = ctx.Updated()
+	rst.Events = ctx.Events()
+
+	if err := ctx.Apply(ss); err != nil {
+		return rst, fmt.Errorf("%w: %s", core.ErrInternal, err.Error())
+	}
+	return rst, nil
+}
+
+// Simulate executes transaction on top of the state that was valid at the layer,
+// without persisting any changes. If layer is zero the latest applied state is used.
+// Transaction is executed as if it was included into the layer after the requested one.
+//
+// If verify is false signature is not verified, so that transaction can be simulated
+// before it is signed. Note that in such case intrinsic gas is computed without signature.
+func (v *VM) Simulate(lid types.LayerID, raw types.RawTx, verify bool) (*types.TransactionWithResult, error) {
+	var loader core.AccountLoader = core.DBLoader{Executor: v.db}
+	if lid == 0 {
+		applied, err := layers.GetLastApplied(v.db)
+		if err != nil {
+			return nil, err
+		}
+		lid = applied
+	} else {
+		loader = core.LayerLoader{Executor: v.db, Layer: lid}
+	}
+	next := lid.Add(1)
+	if next.Before(types.GetEffectiveGenesis()) {
+		next = types.GetEffectiveGenesis()
+	}
+	req := &Request{
+		vm:      v,
+		cache:   core.NewStagedCache(loader),
+		lid:     next,
+		raw:     raw,
+		decoder: scale.NewDecoder(bytes.NewReader(raw.Raw)),
+	}
+	header, err := req.Parse()
+	if err != nil {
+		return nil, err
+	}
+	ctx := req.ctx
+	if err := checkEffective(ctx, raw.Raw, v.cfg.GasLimit); err != nil {
+		return nil, err
+	}
+	if verify && !req.Verify() {
+		return nil, fmt.Errorf("%w: failed verify", core.ErrIneffective)
+	}
+	if ctx.PrincipalAccount.NextNonce > header.Nonce {
+		return nil, fmt.Errorf("%w: nonce too low. expected %d got %d",
+			core.ErrIneffective, ctx.PrincipalAccount.NextNonce, header.Nonce)
+	}
+	rst, err := v.exec(v.logger, next, req.cache, raw, ctx, req.args)
+	if err != nil {
+		return nil, err
+	}
+	return &rst, nil
+}
+
+// GasEstimate is a breakdown of the gas that will be charged for the transaction.
+type GasEstimate struct {
+	// BaseGas is an intrinsic cost of the method, defined by the template.
+	BaseGas uint64
+	// ExecGas is a cost of loading principal account and executing the method.
+	ExecGas uint64
+	// TxDataGas is a cost of storing transaction data.
+	TxDataGas uint64
+	// IntrinsicGas is the sum of BaseGas and TxDataGas. Transaction is ineffective
+	// if principal can't cover it.
+	IntrinsicGas uint64
+	// MaxGas is the total gas consumed by the transaction.
+	MaxGas uint64
+}
+
+// MarshalLogObject implements encoding for the gas estimate.
+func (g *GasEstimate) MarshalLogObject(encoder log.ObjectEncoder) error {
+	encoder.AddUint64("base", g.BaseGas)
+	encoder.AddUint64("exec", g.ExecGas)
+	encoder.AddUint64("txdata", g.TxDataGas)
+	encoder.AddUint64("intrinsic", g.IntrinsicGas)
+	encoder.AddUint64("max", g.MaxGas)
+	return nil
+}
+
+// EstimateGas computes gas that will be charged for the transaction if it is included into
+// the layer after the last applied one, using the latest state of the principal account.
+// Signatures are not verified, but they are charged as part of the transaction data,
+// therefore transaction must be encoded with signatures or placeholders of the same size.
+func (v *VM) EstimateGas(raw []byte) (*GasEstimate, error) {
+	if len(raw) > core.TxSizeLimit {
+		return nil, fmt.Errorf("%w: tx size (%d) > limit (%d)", core.ErrTxLimit, len(raw), core.TxSizeLimit)
+	}
+	applied, err := layers.GetLastApplied(v.db)
+	if err != nil {
+		return nil, err
+	}
+	next := applied.Add(1)
+	if next.Before(types.GetEffectiveGenesis()) {
+		next = types.GetEffectiveGenesis()
+	}
+	header, ctx, _, err := parse(v.logger, next, v.registry,
+		core.NewStagedCache(core.DBLoader{Executor: v.db}), v.cfg,
+		raw, scale.NewDecoder(bytes.NewReader(raw)),
+	)
+	if err != nil {
+		return nil, err
+	}
+	if err := v.cfg.checkForks(header, next); err != nil {
+		return nil, err
+	}
+	return &GasEstimate{
+		BaseGas:      ctx.Gas.BaseGas,
+		ExecGas:      ctx.Gas.FixedGas,
+		TxDataGas:    core.TxDataGas(len(raw)),
+		IntrinsicGas: core.IntrinsicGas(ctx.Gas.BaseGas, raw),
+		MaxGas:       ctx.Header.MaxGas,
+	}, nil
+}
+
 // Request used to implement 2-step validation flow.
 // After Parse is executed - conservative cache may do validation and skip Verify
 // if transaction can't be executed.
@@ -446,6 +695,19 @@ func (r *Request) Parse() (*core.Header, error) {
 	if err != nil {
 		return nil, err
 	}
+	escrowTx, spendMany := header.TemplateAddress == escrow.TemplateAddress, isSpendMany(header)
+	if (escrowTx || spendMany) && ctx.LayerID == 0 {
+		// transaction is validated for the mempool. escrow and spend many depend on the layer,
+		// so they are validated as if the transaction is included into the next layer
+		applied, err := layers.GetLastApplied(r.vm.db)
+		if err != nil {
+			return nil, err
+		}
+		ctx.LayerID = applied.Add(1)
+	}
+	if err := r.vm.cfg.checkForks(header, ctx.LayerID); err != nil {
+		return nil, err
+	}
 	r.ctx = ctx
 	r.args = args
 	transactionDurationParse.Observe(float64(time.Since(start)))
@@ -564,6 +826,9 @@ func parse(logger log.Log, lid types.LayerID, reg *registry.Registry, loader cor
 	} else {
 		ctx.Gas.FixedGas += ctx.PrincipalTemplate.LoadGas()
 		ctx.Gas.FixedGas += ctx.PrincipalTemplate.ExecGas(method)
+		if template, ok := ctx.PrincipalTemplate.(core.VariableGasTemplate); ok {
+			ctx.Gas.FixedGas += template.VariableGas(method, args)
+		}
 	}
 	ctx.Gas.BaseGas = ctx.PrincipalTemplate.BaseGas(method)
 
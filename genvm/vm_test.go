@@ -24,9 +24,11 @@ import (
 	"github.com/spacemeshos/go-spacemesh/common/types"
 	"github.com/spacemeshos/go-spacemesh/genvm/core"
 	"github.com/spacemeshos/go-spacemesh/genvm/sdk"
+	sdkescrow "github.com/spacemeshos/go-spacemesh/genvm/sdk/escrow"
 	sdkmultisig "github.com/spacemeshos/go-spacemesh/genvm/sdk/multisig"
 	sdkvesting "github.com/spacemeshos/go-spacemesh/genvm/sdk/vesting"
 	sdkwallet "github.com/spacemeshos/go-spacemesh/genvm/sdk/wallet"
+	"github.com/spacemeshos/go-spacemesh/genvm/templates/escrow"
 	"github.com/spacemeshos/go-spacemesh/genvm/templates/multisig"
 	"github.com/spacemeshos/go-spacemesh/genvm/templates/vault"
 	"github.com/spacemeshos/go-spacemesh/genvm/templates/vesting"
@@ -281,6 +283,16 @@ func (t *tester) withGasLimit(limit uint64) *tester {
 	return t
 }
 
+func (t *tester) withEscrowLayer(lid types.LayerID) *tester {
+	t.VM.cfg.EscrowLayer = lid
+	return t
+}
+
+func (t *tester) withSpendManyLayer(lid types.LayerID) *tester {
+	t.VM.cfg.SpendManyLayer = lid
+	return t
+}
+
 func (t *tester) addAccount(account testAccount) {
 	t.accounts = append(t.accounts, account)
 	t.nonces = append(t.nonces, 0)
@@ -2046,6 +2058,371 @@ func TestVaultValidation(t *testing.T) {
 	})
 }
 
+func TestTransactionEvents(t *testing.T) {
+	genesis := types.GetEffectiveGenesis()
+	tt := newTester(t).
+		addVesting(1, 1, 1).
+		addVault(1, 1000, 100, genesis, genesis.Add(10)).
+		addSingleSig(1).
+		applyGenesis()
+	vestingAddr, vaultAddr, walletAddr := tt.accounts[0].getAddress(), tt.accounts[1].getAddress(), tt.accounts[2].getAddress()
+
+	_, rst, err := tt.Apply(testContext(genesis), notVerified(
+		tt.selfSpawn(0),
+		tt.spawn(0, 1),
+		tt.spend(0, 2, 100),
+	), nil)
+	require.NoError(t, err)
+	require.Len(t, rst, 3)
+	require.Equal(t, []types.TransactionEvent{{Type: types.EventSpawn, From: vestingAddr, To: vestingAddr}}, rst[0].Events)
+	require.Equal(t, []types.TransactionEvent{{Type: types.EventSpawn, From: vestingAddr, To: vaultAddr}}, rst[1].Events)
+	require.Equal(t, []types.TransactionEvent{{Type: types.EventTransfer, From: vestingAddr, To: walletAddr, Amount: 100}}, rst[2].Events)
+
+	drainer := tt.accounts[0].(*vestingAccount)
+	_, rst, err = tt.Apply(testContext(genesis.Add(1)), notVerified(
+		types.NewRawTx(drainer.drainVault(vaultAddr, walletAddr, 50, tt.nextNonce(0))),
+		types.NewRawTx(drainer.drainVault(vaultAddr, walletAddr, 10_000, tt.nextNonce(0))),
+	), nil)
+	require.NoError(t, err)
+	require.Len(t, rst, 2)
+	require.Equal(t, types.TransactionSuccess, rst[0].Status)
+	require.Equal(t, []types.TransactionEvent{
+		{Type: types.EventDrain, From: vaultAddr, To: walletAddr, Amount: 50},
+	}, rst[0].Events)
+	require.Equal(t, types.TransactionFailure, rst[1].Status)
+	require.Empty(t, rst[1].Events)
+}
+
+func TestSpendMany(t *testing.T) {
+	var spendMany func(account testAccount, recipients []wallet.SpendArguments, nonce core.Nonce) []byte
+	spendMany = func(account testAccount, recipients []wallet.SpendArguments, nonce core.Nonce) []byte {
+		switch acc := account.(type) {
+		case *singlesigAccount:
+			return sdkwallet.SpendMany(signing.PrivateKey(acc.pk), recipients, nonce)
+		case *vestingAccount:
+			return spendMany(&acc.multisigAccount, recipients, nonce)
+		case *multisigAccount:
+			agg := sdkmultisig.SpendMany(0, acc.pks[0], acc.address, recipients, nonce)
+			for i := 1; i < acc.k; i++ {
+				part := sdkmultisig.SpendMany(uint8(i), acc.pks[i], acc.address, recipients, nonce)
+				agg.Add(*part.Part(uint8(i)))
+			}
+			return agg.Raw()
+		}
+		panic("unexpected account type")
+	}
+	for _, tc := range []struct {
+		desc string
+		tt   *tester
+	}{
+		{desc: "wallet", tt: newTester(t).addSingleSig(1).addSingleSig(5)},
+		{desc: "multisig", tt: newTester(t).addMultisig(1, 2, 3).addSingleSig(5)},
+		{desc: "vesting", tt: newTester(t).addVesting(1, 2, 3).addSingleSig(5)},
+	} {
+		tc := tc
+		t.Run(tc.desc, func(t *testing.T) {
+			const genesisBalance = 1_000_000_000
+			lid := types.GetEffectiveGenesis().Add(1)
+			tt := tc.tt.withSpendManyLayer(lid.Add(2)).applyGenesisWithBalance(genesisBalance)
+			_, _, err := tt.Apply(testContext(lid), notVerified(tt.selfSpawn(0)), nil)
+			require.NoError(t, err)
+			require.NoError(t, layers.SetApplied(tt.db, lid, types.RandomBlockID()))
+
+			var recipients []wallet.SpendArguments
+			for i := 1; i < len(tt.accounts); i++ {
+				recipients = append(recipients, wallet.SpendArguments{
+					Destination: tt.accounts[i].getAddress(),
+					Amount:      uint64(i * 100),
+				})
+			}
+			raw := spendMany(tt.accounts[0], recipients, tt.nextNonce(0))
+			_, err = tt.Validation(types.NewRawTx(raw)).Parse()
+			require.ErrorIs(t, err, core.ErrMalformed, "spend many is disabled before fork layer")
+			tt.withSpendManyLayer(lid.Add(1))
+
+			req := tt.Validation(types.NewRawTx(raw))
+			header, err := req.Parse()
+			require.NoError(t, err)
+			require.True(t, req.Verify())
+			require.EqualValues(t, 100+200+300+400+500, header.MaxSpend)
+
+			ineffective, rst, err := tt.Apply(testContext(lid.Add(1)), notVerified(types.NewRawTx(raw)), nil)
+			require.NoError(t, err)
+			require.Empty(t, ineffective)
+			require.Len(t, rst, 1)
+			require.Equal(t, types.TransactionSuccess, rst[0].Status)
+			require.Len(t, rst[0].Addresses, len(tt.accounts))
+			expected := tt.accounts[0].baseGas(core.MethodSpendMany) +
+				tt.accounts[0].loadGas() +
+				tt.accounts[0].execGas(core.MethodSpendMany) +
+				len(recipients)*int(wallet.RecipientGas()) +
+				int(core.TxDataGas(len(raw)))
+			require.Equal(t, expected, int(rst[0].Gas))
+			for _, recipient := range recipients {
+				balance, err := tt.GetBalance(recipient.Destination)
+				require.NoError(t, err)
+				require.Equal(t, genesisBalance+recipient.Amount, balance)
+			}
+
+			// nothing is transferred if balance doesn't cover all recipients
+			balance, err := tt.GetBalance(tt.accounts[0].getAddress())
+			require.NoError(t, err)
+			recipients[len(recipients)-1].Amount = balance
+			raw = spendMany(tt.accounts[0], recipients, tt.nextNonce(0))
+			_, rst, err = tt.Apply(testContext(lid.Add(2)), notVerified(types.NewRawTx(raw)), nil)
+			require.NoError(t, err)
+			require.Len(t, rst, 1)
+			require.Equal(t, types.TransactionFailure, rst[0].Status)
+			require.Equal(t, core.ErrNoBalance.Error(), rst[0].Message)
+			require.Len(t, rst[0].Addresses, 1)
+			for i, recipient := range recipients {
+				balance, err := tt.GetBalance(recipient.Destination)
+				require.NoError(t, err)
+				require.Equal(t, genesisBalance+uint64((i+1)*100), balance)
+			}
+		})
+	}
+}
+
+func TestEscrow(t *testing.T) {
+	const (
+		amount = 1000
+		maxFee = 1_000_000
+		funds  = amount + maxFee
+	)
+	const seed = 101
+	lid := types.GetEffectiveGenesis().Add(1)
+	newEscrowTester := func(t *testing.T) *tester {
+		return newTester(t).withSeed(seed).addSingleSig(3).withEscrowLayer(lid).applyGenesis()
+	}
+	tt := newEscrowTester(t)
+	funder, recipient := tt.accounts[0].(*singlesigAccount), tt.accounts[1].(*singlesigAccount)
+	funderKey, recipientKey := signing.PrivateKey(funder.pk), signing.PrivateKey(recipient.pk)
+	timeout := lid.Add(10)
+
+	preimage := types.RandomHash()
+	claimable := escrow.SpawnArguments{
+		Recipient:     recipient.getAddress(),
+		RefundAddress: funder.getAddress(),
+		Hashlock:      sdkescrow.Hashlock(preimage),
+		Timeout:       timeout,
+		Amount:        amount,
+		MaxFee:        maxFee,
+	}
+	copy(claimable.RecipientKey[:], signing.Public(recipientKey))
+	copy(claimable.RefundKey[:], signing.Public(funderKey))
+	refundable := claimable
+	refundable.Hashlock = sdkescrow.Hashlock(types.RandomHash())
+
+	var txs []types.RawTx
+	txs = append(txs, tt.selfSpawn(0))
+	for _, args := range []*escrow.SpawnArguments{&claimable, &refundable} {
+		args := args
+		txs = append(txs,
+			types.NewRawTx(funder.spawn(escrow.TemplateAddress, args, tt.nextNonce(0))),
+			types.NewRawTx(funder.spend(sdkescrow.Address(args), funds, tt.nextNonce(0))),
+		)
+	}
+	ineffective, rst, err := tt.Apply(testContext(lid), notVerified(txs...), nil)
+	require.NoError(t, err)
+	require.Empty(t, ineffective)
+	for _, tx := range rst {
+		require.Equal(t, types.TransactionSuccess, tx.Status)
+	}
+
+	claimAddress, refundAddress := sdkescrow.Address(&claimable), sdkescrow.Address(&refundable)
+
+	t.Run("validation", func(t *testing.T) {
+		for _, tc := range []struct {
+			desc    string
+			applied types.LayerID
+			raw     []byte
+			err     error
+			verify  bool
+		}{
+			{
+				desc:   "claim",
+				raw:    sdkescrow.Claim(recipientKey, claimAddress, preimage, 0),
+				verify: true,
+			},
+			{
+				desc: "claim signed by funder",
+				raw:  sdkescrow.Claim(funderKey, claimAddress, preimage, 0),
+			},
+			{
+				desc: "claim invalid preimage",
+				raw:  sdkescrow.Claim(recipientKey, refundAddress, preimage, 0),
+				err:  core.ErrMalformed,
+			},
+			{
+				desc:    "claim after timeout",
+				applied: timeout.Sub(1),
+				raw:     sdkescrow.Claim(recipientKey, claimAddress, preimage, 0),
+			},
+			{
+				desc: "claim fee above max fee",
+				raw:  sdkescrow.Claim(recipientKey, claimAddress, preimage, 0, sdk.WithGasPrice(maxFee)),
+			},
+			{
+				desc: "refund before timeout",
+				raw:  sdkescrow.Refund(funderKey, refundAddress, 0),
+			},
+			{
+				desc:    "refund after timeout",
+				applied: timeout.Sub(1),
+				raw:     sdkescrow.Refund(funderKey, refundAddress, 0),
+				verify:  true,
+			},
+			{
+				desc:    "refund signed by recipient",
+				applied: timeout.Sub(1),
+				raw:     sdkescrow.Refund(recipientKey, refundAddress, 0),
+			},
+			{
+				desc: "self spawn",
+				raw: sdk.Encode(&sdk.TxVersion, &claimAddress, &sdk.MethodSpawn,
+					&escrow.TemplateAddress, &core.Payload{}, &claimable),
+			},
+		} {
+			tc := tc
+			t.Run(tc.desc, func(t *testing.T) {
+				// same seed generates same accounts
+				tt := newEscrowTester(t)
+				_, _, err := tt.Apply(testContext(lid), notVerified(txs...), nil)
+				require.NoError(t, err)
+				applied := lid
+				if tc.applied != 0 {
+					applied = tc.applied
+				}
+				require.NoError(t, layers.SetApplied(tt.db, applied, types.RandomBlockID()))
+
+				req := tt.Validation(types.NewRawTx(tc.raw))
+				_, err = req.Parse()
+				require.ErrorIs(t, err, tc.err)
+				if err == nil {
+					require.Equal(t, tc.verify, req.Verify())
+				}
+			})
+		}
+	})
+	t.Run("disabled before fork layer", func(t *testing.T) {
+		tt := newEscrowTester(t)
+		_, _, err := tt.Apply(testContext(lid), notVerified(txs...), nil)
+		require.NoError(t, err)
+		require.NoError(t, layers.SetApplied(tt.db, lid, types.RandomBlockID()))
+		tt.withEscrowLayer(timeout)
+
+		req := tt.Validation(types.NewRawTx(sdkescrow.Claim(recipientKey, claimAddress, preimage, 0)))
+		_, err = req.Parse()
+		require.ErrorIs(t, err, core.ErrMalformed)
+	})
+	t.Run("settled once", func(t *testing.T) {
+		tt := newEscrowTester(t)
+		_, _, err := tt.Apply(testContext(lid), notVerified(txs...), nil)
+		require.NoError(t, err)
+		require.NoError(t, layers.SetApplied(tt.db, lid, types.RandomBlockID()))
+
+		var verified []types.Transaction
+		for nonce := core.Nonce(0); nonce < 2; nonce++ {
+			raw := types.NewRawTx(sdkescrow.Claim(recipientKey, claimAddress, preimage, nonce))
+			req := tt.Validation(raw)
+			header, err := req.Parse()
+			require.NoError(t, err)
+			require.True(t, req.Verify())
+			verified = append(verified, types.Transaction{RawTx: raw, TxHeader: header})
+		}
+		_, rst, err := tt.Apply(testContext(lid.Add(1)), verified, nil)
+		require.NoError(t, err)
+		require.Len(t, rst, 2)
+		require.Equal(t, types.TransactionSuccess, rst[0].Status)
+		require.Equal(t, types.TransactionFailure, rst[1].Status)
+		require.Equal(t, escrow.ErrSettled.Error(), rst[1].Message)
+
+		balance, err := tt.GetBalance(recipient.getAddress())
+		require.NoError(t, err)
+		require.Equal(t, uint64(1_000_000_000_000+amount), balance)
+
+		req := tt.Validation(types.NewRawTx(sdkescrow.Claim(recipientKey, claimAddress, preimage, 2)))
+		_, err = req.Parse()
+		require.NoError(t, err)
+		require.False(t, req.Verify(), "settled escrow doesn't verify")
+	})
+	t.Run("claim", func(t *testing.T) {
+		_, rst, err := tt.Apply(testContext(lid.Add(1)), notVerified(
+			types.NewRawTx(sdkescrow.Claim(recipientKey, claimAddress, preimage, 0)),
+		), nil)
+		require.NoError(t, err)
+		require.Len(t, rst, 1)
+		require.Equal(t, types.TransactionSuccess, rst[0].Status)
+
+		balance, err := tt.GetBalance(recipient.getAddress())
+		require.NoError(t, err)
+		require.Equal(t, uint64(1_000_000_000_000+amount), balance)
+		balance, err = tt.GetBalance(claimAddress)
+		require.NoError(t, err)
+		require.Equal(t, funds-amount-rst[0].Fee, balance)
+	})
+	t.Run("refund after claim", func(t *testing.T) {
+		ineffective, rst, err := tt.Apply(testContext(timeout.Add(1)), notVerified(
+			types.NewRawTx(sdkescrow.Refund(funderKey, claimAddress, 1)),
+		), nil)
+		require.NoError(t, err)
+		require.Empty(t, rst)
+		require.Len(t, ineffective, 1)
+	})
+	t.Run("refund", func(t *testing.T) {
+		before, err := tt.GetBalance(funder.getAddress())
+		require.NoError(t, err)
+		_, rst, err := tt.Apply(testContext(timeout.Add(2)), notVerified(
+			types.NewRawTx(sdkescrow.Refund(funderKey, refundAddress, 0)),
+		), nil)
+		require.NoError(t, err)
+		require.Len(t, rst, 1)
+		require.Equal(t, types.TransactionSuccess, rst[0].Status)
+		require.Equal(t, escrow.ExecGas(escrow.MethodRefund)+escrow.LoadGas()+
+			core.IntrinsicGas(escrow.BaseGas(escrow.MethodRefund), rst[0].Raw), rst[0].Gas)
+
+		after, err := tt.GetBalance(funder.getAddress())
+		require.NoError(t, err)
+		require.Equal(t, before+amount, after)
+	})
+	t.Run("drain after settlement", func(t *testing.T) {
+		// transfers after settlement are not locked on the escrow
+		_, rst, err := tt.Apply(testContext(timeout.Add(3)), notVerified(
+			types.NewRawTx(funder.spend(refundAddress, 100, tt.nextNonce(0))),
+		), nil)
+		require.NoError(t, err)
+		require.Len(t, rst, 1)
+		require.Equal(t, types.TransactionSuccess, rst[0].Status)
+
+		left, err := tt.GetBalance(refundAddress)
+		require.NoError(t, err)
+		gas := func(raw []byte) uint64 {
+			return escrow.ExecGas(escrow.MethodDrain) + escrow.LoadGas() +
+				core.IntrinsicGas(escrow.BaseGas(escrow.MethodDrain), raw)
+		}
+		// amount is compact encoded, fee doesn't change if it has the same encoded size
+		amount := left - gas(sdkescrow.Drain(funderKey, refundAddress, left, 1))
+		drain := types.NewRawTx(sdkescrow.Drain(funderKey, refundAddress, amount, 1))
+		require.Equal(t, left, amount+gas(drain.Raw))
+
+		before, err := tt.GetBalance(funder.getAddress())
+		require.NoError(t, err)
+		ineffective, rst, err := tt.Apply(testContext(timeout.Add(4)), notVerified(drain), nil)
+		require.NoError(t, err)
+		require.Empty(t, ineffective)
+		require.Len(t, rst, 1)
+		require.Equal(t, types.TransactionSuccess, rst[0].Status, rst[0].Message)
+
+		after, err := tt.GetBalance(funder.getAddress())
+		require.NoError(t, err)
+		require.Equal(t, before+amount, after)
+		left, err = tt.GetBalance(refundAddress)
+		require.NoError(t, err)
+		require.Zero(t, left)
+	})
+}
+
 func BenchmarkTransactions(b *testing.B) {
 	bench := func(b *testing.B, tt *tester, txs []types.Transaction) {
 		lid := types.GetEffectiveGenesis().Add(2)
@@ -2290,6 +2667,285 @@ func TestStateHashFromUpdatedAccounts(t *testing.T) {
 	require.Equal(t, expected, root)
 }
 
+func TestAccountsRoot(t *testing.T) {
+	tt := newTester(t).addSingleSig(2).applyGenesis()
+	lid := types.GetEffectiveGenesis()
+	_, _, err := tt.Apply(testContext(lid), notVerified(tt.selfSpawn(0)), nil)
+	require.NoError(t, err)
+
+	// root commits to the accounts that weren't updated in the layer
+	var leaves [][]byte
+	for _, account := range []int{0, 1} {
+		state, err := accounts.Get(tt.db, tt.accounts[account].getAddress(), lid)
+		require.NoError(t, err)
+		buf, err := codec.Encode(&state)
+		require.NoError(t, err)
+		leaf := hash.Sum([]byte{0}, buf)
+		leaves = append(leaves, leaf[:])
+	}
+	if bytes.Compare(tt.accounts[0].getAddress().Bytes(), tt.accounts[1].getAddress().Bytes()) > 0 {
+		leaves[0], leaves[1] = leaves[1], leaves[0]
+	}
+	expected := types.Hash32(hash.Sum([]byte{1}, leaves[0], leaves[1]))
+
+	layer, root, err := tt.GetAccountsRoot(lid)
+	require.NoError(t, err)
+	require.Equal(t, lid, layer)
+	require.Equal(t, expected, root)
+	computed, err := tt.ComputeAccountsRoot(lid)
+	require.NoError(t, err)
+	require.Equal(t, expected, computed)
+
+	_, _, err = tt.Apply(testContext(lid.Add(1)), notVerified(tt.spend(0, 1, 100)), nil)
+	require.NoError(t, err)
+	layer, root, err = tt.GetAccountsRoot(0)
+	require.NoError(t, err)
+	require.Equal(t, lid.Add(1), layer)
+	require.NotEqual(t, expected, root)
+
+	// cached tree is dropped when the layer is reverted
+	require.NoError(t, tt.Revert(lid))
+	_, _, err = tt.Apply(testContext(lid.Add(1)), notVerified(tt.spend(0, 1, 200)), nil)
+	require.NoError(t, err)
+	computed, err = tt.ComputeAccountsRoot(lid.Add(1))
+	require.NoError(t, err)
+	require.NotEqual(t, root, computed)
+	_, root, err = tt.GetAccountsRoot(lid.Add(1))
+	require.NoError(t, err)
+	require.Equal(t, computed, root)
+
+	_, _, err = tt.GetAccountsRoot(lid.Add(2))
+	require.ErrorIs(t, err, sql.ErrNotFound)
+}
+
+func TestAccountProof(t *testing.T) {
+	tt := newTester(t).addSingleSig(6).applyGenesis()
+	lid := types.GetEffectiveGenesis()
+	_, _, err := tt.Apply(testContext(lid), notVerified(
+		tt.selfSpawn(0),
+		tt.selfSpawn(1),
+		tt.spend(0, 2, 100),
+		tt.spend(1, 3, 100),
+		tt.spend(1, 4, 100),
+	), nil)
+	require.NoError(t, err)
+	_, _, err = tt.Apply(testContext(lid.Add(1)), notVerified(tt.spend(0, 2, 100)), nil)
+	require.NoError(t, err)
+
+	for _, tc := range []struct {
+		desc    string
+		account int
+		lid     types.LayerID
+		updated types.LayerID
+		root    types.LayerID
+	}{
+		{desc: "latest", account: 2, updated: lid.Add(1), root: lid.Add(1)},
+		{desc: "historical", account: 2, lid: lid, updated: lid, root: lid},
+		{desc: "not updated recently", account: 4, lid: lid.Add(1), updated: lid, root: lid.Add(1)},
+		{desc: "principal", account: 1, updated: lid, root: lid.Add(1)},
+		{desc: "genesis", account: 5, updated: 0, root: lid.Add(1)},
+	} {
+		tc := tc
+		t.Run(tc.desc, func(t *testing.T) {
+			proof, err := tt.GetAccountProof(tt.accounts[tc.account].getAddress(), tc.lid)
+			require.NoError(t, err)
+			require.Equal(t, tc.updated, proof.Account.Layer)
+			require.Equal(t, tc.root, proof.Layer)
+
+			_, root, err := tt.GetAccountsRoot(tc.root)
+			require.NoError(t, err)
+			require.NoError(t, proof.Verify(root))
+
+			proof.Account.Balance++
+			require.ErrorIs(t, proof.Verify(root), ErrInvalidProof)
+		})
+	}
+	t.Run("not found", func(t *testing.T) {
+		_, err := tt.GetAccountProof(types.Address{1, 1, 1}, 0)
+		require.ErrorIs(t, err, sql.ErrNotFound)
+	})
+}
+
+func TestAccountsTree(t *testing.T) {
+	db := sql.InMemory()
+	lid := types.LayerID(10)
+	var addresses []types.Address
+	for i := 0; i < 9; i++ {
+		tree, err := buildAccountsTree(db, lid, true)
+		require.NoError(t, err)
+		computed, err := buildAccountsTree(db, lid, false)
+		require.NoError(t, err)
+		require.Equal(t, tree.root, computed.root)
+		if i == 0 {
+			require.Equal(t, types.Hash32(hash.Sum()), tree.root)
+		}
+		for _, address := range addresses {
+			index, exists := tree.index(address)
+			require.True(t, exists)
+			_, nodes, err := tree.prove(index)
+			require.NoError(t, err)
+			account, err := accounts.Get(db, address, lid)
+			require.NoError(t, err)
+			proof := AccountProof{Layer: lid, Account: account, Index: index, Nodes: nodes}
+			require.NoError(t, proof.Verify(tree.root), "accounts %d index %d", i, index)
+		}
+		address := types.GenerateAddress([]byte{byte(i)})
+		require.NoError(t, accounts.Update(db, &types.Account{Layer: lid, Address: address, Balance: uint64(i)}))
+		addresses = append(addresses, address)
+	}
+}
+
+func TestAccountHistory(t *testing.T) {
+	tt := newTester(t).addSingleSig(2).applyGenesis()
+	_, _, err := tt.Apply(testContext(types.GetEffectiveGenesis()), notVerified(tt.selfSpawn(0)), nil)
+	require.NoError(t, err)
+	lid := types.GetEffectiveGenesis().Add(1)
+	for i := 0; i < 4; i++ {
+		_, _, err := tt.Apply(testContext(lid.Add(uint32(i))), notVerified(tt.spend(0, 1, 100)), nil)
+		require.NoError(t, err)
+	}
+	address := tt.accounts[1].getAddress()
+
+	history, err := tt.GetAccountHistory(address, 0, 10)
+	require.NoError(t, err)
+	require.Len(t, history, 5) // genesis and 4 spends
+	for i, account := range history[1:] {
+		require.Equal(t, lid.Add(uint32(i)), account.Layer)
+		require.Equal(t, history[i].Balance+100, account.Balance)
+
+		at, err := tt.GetAccount(address, account.Layer)
+		require.NoError(t, err)
+		require.Equal(t, *account, at)
+	}
+	latest, err := tt.GetAccount(address, 0)
+	require.NoError(t, err)
+	require.Equal(t, *history[len(history)-1], latest)
+
+	page, err := tt.GetAccountHistory(address, lid.Add(2), 1)
+	require.NoError(t, err)
+	require.Equal(t, history[3:4], page)
+}
+
+func TestSimulate(t *testing.T) {
+	const genesisBalance = 1_000_000_000_000
+	tt := newTester(t).addSingleSig(3).applyGenesisWithBalance(genesisBalance)
+	lid := types.GetEffectiveGenesis()
+	skipped, _, err := tt.Apply(testContext(lid), notVerified(tt.selfSpawn(0)), nil)
+	require.NoError(t, err)
+	require.Empty(t, skipped)
+	balance, err := tt.GetBalance(tt.accounts[0].getAddress())
+	require.NoError(t, err)
+
+	t.Run("spend", func(t *testing.T) {
+		nonce := tt.nonces[0]
+		rst, err := tt.Simulate(0, tt.spendWithNonce(0, 1, 100, nonce), true)
+		require.NoError(t, err)
+		require.Equal(t, types.TransactionSuccess, rst.Status)
+		gas := uint64(tt.estimateSpendGas(0, 1, 100, nonce))
+		require.Equal(t, gas, rst.Gas)
+		require.Equal(t, gas, rst.Fee)
+		require.Equal(t, []types.Address{tt.accounts[0].getAddress(), tt.accounts[1].getAddress()}, rst.Addresses)
+
+		// state is not modified by simulation
+		current, err := tt.GetBalance(tt.accounts[0].getAddress())
+		require.NoError(t, err)
+		require.Equal(t, balance, current)
+		current, err = tt.GetBalance(tt.accounts[1].getAddress())
+		require.NoError(t, err)
+		require.Equal(t, uint64(genesisBalance), current)
+	})
+	t.Run("failure", func(t *testing.T) {
+		rst, err := tt.Simulate(0, tt.spendWithNonce(0, 1, genesisBalance, tt.nonces[0]), true)
+		require.NoError(t, err)
+		require.Equal(t, types.TransactionFailure, rst.Status)
+		require.Contains(t, rst.Message, core.ErrNoBalance.Error())
+	})
+	t.Run("nonce too low", func(t *testing.T) {
+		_, err := tt.Simulate(0, tt.spendWithNonce(0, 1, 100, 0), true)
+		require.ErrorIs(t, err, core.ErrIneffective)
+	})
+	t.Run("invalid signature", func(t *testing.T) {
+		raw := tt.spendWithNonce(0, 1, 100, tt.nonces[0])
+		raw.Raw[len(raw.Raw)-1]++
+		_, err := tt.Simulate(0, raw, true)
+		require.ErrorIs(t, err, core.ErrIneffective)
+		rst, err := tt.Simulate(0, raw, false)
+		require.NoError(t, err)
+		require.Equal(t, types.TransactionSuccess, rst.Status)
+	})
+	t.Run("historical state", func(t *testing.T) {
+		// principal wasn't spawned before effective genesis
+		_, err := tt.Simulate(lid.Sub(1), tt.spendWithNonce(0, 1, 100, tt.nonces[0]), true)
+		require.ErrorIs(t, err, core.ErrNotSpawned)
+	})
+}
+
+func TestEstimateGas(t *testing.T) {
+	for _, tc := range []struct {
+		desc string
+		tt   *tester
+	}{
+		{"SingleSig", newTester(t).addSingleSig(2).applyGenesis()},
+		{"MultiSig25", newTester(t).addMultisig(2, 2, 5).applyGenesis()},
+		{"Vesting13", newTester(t).addVesting(2, 1, 3).applyGenesis()},
+	} {
+		tc := tc
+		t.Run(tc.desc, func(t *testing.T) {
+			tt := tc.tt
+			lid := types.GetEffectiveGenesis()
+
+			spawn := tt.selfSpawn(0)
+			estimate, err := tt.EstimateGas(spawn.Raw)
+			require.NoError(t, err)
+			require.Equal(t, uint64(tt.estimateSpawnGas(0, 0)), estimate.MaxGas)
+			require.Equal(t, uint64(tt.accounts[0].baseGas(core.MethodSpawn)), estimate.BaseGas)
+			require.Equal(t, core.TxDataGas(len(spawn.Raw)), estimate.TxDataGas)
+			require.Equal(t, estimate.BaseGas+estimate.TxDataGas, estimate.IntrinsicGas)
+			require.Equal(t, estimate.IntrinsicGas+estimate.ExecGas, estimate.MaxGas)
+
+			_, rst, err := tt.Apply(testContext(lid), notVerified(spawn), nil)
+			require.NoError(t, err)
+			require.Len(t, rst, 1)
+			require.Equal(t, estimate.MaxGas, rst[0].Gas)
+
+			nonce := tt.nonces[0]
+			spend := tt.spend(0, 1, 100)
+			estimate, err = tt.EstimateGas(spend.Raw)
+			require.NoError(t, err)
+			require.Equal(t, uint64(tt.estimateSpendGas(0, 1, 100, nonce)), estimate.MaxGas)
+
+			_, rst, err = tt.Apply(testContext(lid.Add(1)), notVerified(spend), nil)
+			require.NoError(t, err)
+			require.Len(t, rst, 1)
+			require.Equal(t, estimate.MaxGas, rst[0].Gas)
+		})
+	}
+	t.Run("not spawned", func(t *testing.T) {
+		tt := newTester(t).addSingleSig(2).applyGenesis()
+		_, err := tt.EstimateGas(tt.spend(0, 1, 100).Raw)
+		require.ErrorIs(t, err, core.ErrNotSpawned)
+	})
+	t.Run("before fork layer", func(t *testing.T) {
+		lid := types.GetEffectiveGenesis().Add(1)
+		tt := newTester(t).addSingleSig(2).withSpendManyLayer(lid.Add(2)).applyGenesis()
+		_, _, err := tt.Apply(testContext(lid), notVerified(tt.selfSpawn(0)), nil)
+		require.NoError(t, err)
+		require.NoError(t, layers.SetApplied(tt.db, lid, types.RandomBlockID()))
+
+		acc := tt.accounts[0].(*singlesigAccount)
+		raw := sdkwallet.SpendMany(signing.PrivateKey(acc.pk), []wallet.SpendArguments{
+			{Destination: tt.accounts[1].getAddress(), Amount: 100},
+		}, tt.nextNonce(0))
+		_, err = tt.EstimateGas(raw)
+		require.ErrorIs(t, err, core.ErrMalformed, "spend many is disabled in the next layer")
+
+		tt.withSpendManyLayer(lid.Add(1))
+		estimate, err := tt.EstimateGas(raw)
+		require.NoError(t, err)
+		require.NotZero(t, estimate.MaxGas)
+	})
+}
+
 func BenchmarkWallet(b *testing.B) {
 	b.Run("Accounts100k/Txs100k", func(b *testing.B) {
 		benchmarkWallet(b, 100_000, 100_000)
@@ -0,0 +1,235 @@
+// Package indexer maintains denormalized tables for explorer-style queries:
+// activity of every address, rewards aggregated per epoch for coinbases and smeshers,
+// and lineage of spawned accounts.
+package indexer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/spacemeshos/go-spacemesh/common/types"
+	"github.com/spacemeshos/go-spacemesh/events"
+	vm "github.com/spacemeshos/go-spacemesh/genvm"
+	"github.com/spacemeshos/go-spacemesh/log"
+	"github.com/spacemeshos/go-spacemesh/sql"
+	"github.com/spacemeshos/go-spacemesh/sql/atxs"
+	"github.com/spacemeshos/go-spacemesh/sql/blocks"
+	"github.com/spacemeshos/go-spacemesh/sql/layers"
+	"github.com/spacemeshos/go-spacemesh/sql/transactions"
+)
+
+// Config for the indexer.
+type Config struct {
+	// Enabled indexer. It is not needed for consensus and disabled by default.
+	Enabled bool `mapstructure:"enabled"`
+	// Interval between updates if applied layers are not reported by events.
+	Interval time.Duration `mapstructure:"interval"`
+}
+
+// DefaultConfig for the indexer.
+func DefaultConfig() Config {
+	return Config{
+		Interval: time.Minute,
+	}
+}
+
+// Opt for configuring Indexer.
+type Opt func(*Indexer)
+
+// WithLogger changes logger.
+func WithLogger(logger log.Log) Opt {
+	return func(i *Indexer) {
+		i.logger = logger
+	}
+}
+
+// WithConfig changes config.
+func WithConfig(cfg Config) Opt {
+	return func(i *Indexer) {
+		i.cfg = cfg
+	}
+}
+
+// New creates Indexer.
+func New(db *sql.Database, opts ...Opt) *Indexer {
+	i := &Indexer{
+		logger: log.NewNop(),
+		cfg:    DefaultConfig(),
+		db:     db,
+	}
+	for _, opt := range opts {
+		opt(i)
+	}
+	return i
+}
+
+// Indexer follows applied layers and updates indexed tables.
+//
+// Applied block is recorded for every indexed layer. If mesh reverts the state and applies
+// a different block, indexed data is reverted to the layer before the first changed layer.
+type Indexer struct {
+	logger log.Log
+	cfg    Config
+	db     *sql.Database
+}
+
+// Run updates the index when layer is applied, or periodically, until context is canceled.
+func (i *Indexer) Run(ctx context.Context) error {
+	if i.cfg.Interval <= 0 {
+		return fmt.Errorf("indexer interval must be positive: %v", i.cfg.Interval)
+	}
+	// events are consumed in a separate goroutine so that a long update
+	// doesn't block the publisher
+	applied := make(chan struct{}, 1)
+	if sub := events.SubscribeLayers(); sub != nil {
+		defer sub.Close()
+		go func() {
+			for ev := range sub.Out() {
+				if update, ok := ev.(events.LayerUpdate); ok && update.Status == events.LayerStatusTypeApplied {
+					select {
+					case applied <- struct{}{}:
+					default:
+					}
+				}
+			}
+		}()
+	}
+	ticker := time.NewTicker(i.cfg.Interval)
+	defer ticker.Stop()
+	for {
+		if err := i.Update(ctx); err != nil && !errors.Is(err, context.Canceled) {
+			i.logger.With().Error("failed to update index", log.Err(err))
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-applied:
+		case <-ticker.C:
+		}
+	}
+}
+
+// Update reverts indexed layers that are no longer applied and indexes layers
+// up to the last applied layer.
+func (i *Indexer) Update(ctx context.Context) error {
+	diverged, found, err := FirstDiverged(i.db)
+	if err != nil {
+		return err
+	}
+	if found {
+		if err := i.Revert(ctx, diverged.Sub(1)); err != nil {
+			return err
+		}
+	}
+	last, err := LastIndexed(i.db)
+	if err != nil {
+		return err
+	}
+	applied, err := layers.GetLastApplied(i.db)
+	if err != nil {
+		return err
+	}
+	start := types.MaxLayer(last.Add(1), types.GetEffectiveGenesis().Add(1))
+	for lid := start; !lid.After(applied); lid = lid.Add(1) {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		var reverted bool
+		if err := i.db.WithTx(ctx, func(tx *sql.Tx) error {
+			bid, err := layers.GetApplied(tx, lid)
+			if errors.Is(err, sql.ErrNotFound) {
+				// state was reverted concurrently
+				reverted = true
+				return nil
+			} else if err != nil {
+				return err
+			}
+			return indexLayer(tx, lid, bid)
+		}); err != nil {
+			return fmt.Errorf("index layer %s: %w", lid, err)
+		}
+		if reverted {
+			return nil
+		}
+		indexedLayer.Set(float64(lid))
+	}
+	return nil
+}
+
+// Revert indexed data after the layer.
+func (i *Indexer) Revert(ctx context.Context, revertTo types.LayerID) error {
+	if err := i.db.WithTx(ctx, func(tx *sql.Tx) error {
+		return Revert(tx, revertTo)
+	}); err != nil {
+		return err
+	}
+	indexedLayer.Set(float64(revertTo))
+	i.logger.With().Info("reverted index", log.Stringer("revert_to", revertTo))
+	return nil
+}
+
+func indexLayer(tx *sql.Tx, lid types.LayerID, bid types.BlockID) error {
+	var (
+		fees uint64
+		ierr error
+	)
+	if err := transactions.IterateResults(tx, transactions.ResultsFilter{Start: &lid, End: &lid},
+		func(rst *types.TransactionWithResult) bool {
+			fees += rst.Fee
+			for _, address := range rst.Addresses {
+				if ierr = addActivity(tx, address, lid, rst.ID); ierr != nil {
+					return false
+				}
+			}
+			for _, ev := range rst.Events {
+				if ev.Type != types.EventSpawn {
+					continue
+				}
+				if ierr = addSpawn(tx, &Spawn{Account: ev.To, Spawner: ev.From, TID: rst.ID, Layer: lid}); ierr != nil {
+					return false
+				}
+			}
+			return true
+		}); err != nil {
+		return err
+	}
+	if ierr != nil {
+		return ierr
+	}
+	if bid != types.EmptyBlockID {
+		if err := indexRewards(tx, lid, bid, fees); err != nil {
+			return err
+		}
+	}
+	return addLayer(tx, lid, bid)
+}
+
+// indexRewards splits rewards of the block between smeshers the same way vm splits them between coinbases.
+func indexRewards(tx *sql.Tx, lid types.LayerID, bid types.BlockID, fees uint64) error {
+	block, err := blocks.Get(tx, bid)
+	if err != nil {
+		return fmt.Errorf("get block %s: %w", bid, err)
+	}
+	smeshers := make([]types.NodeID, 0, len(block.Rewards))
+	weights := make([]types.CoinbaseReward, 0, len(block.Rewards))
+	for _, reward := range block.Rewards {
+		atx, err := atxs.Get(tx, reward.AtxID)
+		if err != nil {
+			return fmt.Errorf("get atx %s: %w", reward.AtxID, err)
+		}
+		smeshers = append(smeshers, atx.SmesherID)
+		weights = append(weights, types.CoinbaseReward{Coinbase: atx.Coinbase, Weight: reward.Weight})
+	}
+	rewards, err := vm.CalculateRewards(lid, fees, weights)
+	if err != nil {
+		return err
+	}
+	for i := range rewards {
+		if err := addReward(tx, smeshers[i], &rewards[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,342 @@
+package indexer
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/spacemeshos/go-spacemesh/common/types"
+	vm "github.com/spacemeshos/go-spacemesh/genvm"
+	"github.com/spacemeshos/go-spacemesh/log/logtest"
+	"github.com/spacemeshos/go-spacemesh/sql"
+	"github.com/spacemeshos/go-spacemesh/sql/atxs"
+	"github.com/spacemeshos/go-spacemesh/sql/blocks"
+	"github.com/spacemeshos/go-spacemesh/sql/layers"
+	"github.com/spacemeshos/go-spacemesh/sql/transactions"
+)
+
+func TestMain(m *testing.M) {
+	types.SetLayersPerEpoch(4)
+	res := m.Run()
+	os.Exit(res)
+}
+
+type tester struct {
+	tb testing.TB
+	db *sql.Database
+	// rewards computed independently from the indexer
+	rewards map[types.LayerID][]types.Reward
+}
+
+func newTester(tb testing.TB) *tester {
+	db := sql.InMemory()
+	require.NoError(tb, layers.SetApplied(db, types.GetEffectiveGenesis(), types.EmptyBlockID))
+	return &tester{tb: tb, db: db, rewards: map[types.LayerID][]types.Reward{}}
+}
+
+func (t *tester) addAtx(smesher types.NodeID, coinbase types.Address) types.ATXID {
+	atx := &types.ActivationTx{
+		InnerActivationTx: types.InnerActivationTx{
+			NIPostChallenge: types.NIPostChallenge{PublishEpoch: 1, PrevATXID: types.RandomATXID()},
+			Coinbase:        coinbase,
+			NumUnits:        1,
+		},
+		SmesherID: smesher,
+	}
+	atx.SetEffectiveNumUnits(atx.NumUnits)
+	atx.SetReceived(time.Now())
+	vatx, err := atx.Verify(0, 1)
+	require.NoError(t.tb, err)
+	require.NoError(t.tb, atxs.Add(t.db, vatx))
+	return vatx.ID()
+}
+
+// apply stores the block with rewards and results of transactions, and marks the block as applied.
+func (t *tester) apply(lid types.LayerID, id byte, rewards []types.AnyReward, txs ...types.TransactionWithResult) {
+	bid := types.EmptyBlockID
+	if id != 0 {
+		block := types.NewExistingBlock(types.BlockID{id}, types.InnerBlock{LayerIndex: lid, Rewards: rewards})
+		require.NoError(t.tb, blocks.Add(t.db, block))
+		bid = block.ID()
+	}
+	var fees uint64
+	require.NoError(t.tb, t.db.WithTx(context.Background(), func(tx *sql.Tx) error {
+		for i := range txs {
+			txs[i].Layer = lid
+			txs[i].Block = bid
+			fees += txs[i].Fee
+			require.NoError(t.tb, transactions.Add(tx, &txs[i].Transaction, time.Time{}))
+			require.NoError(t.tb, transactions.AddResult(tx, txs[i].ID, &txs[i].TransactionResult))
+			if len(txs[i].Events) > 0 {
+				require.NoError(t.tb, transactions.AddEvents(tx, txs[i].ID, txs[i].Events))
+			}
+		}
+		return layers.SetApplied(tx, lid, bid)
+	}))
+	if id == 0 {
+		return
+	}
+	var weights []types.CoinbaseReward
+	for _, reward := range rewards {
+		atx, err := atxs.Get(t.db, reward.AtxID)
+		require.NoError(t.tb, err)
+		weights = append(weights, types.CoinbaseReward{Coinbase: atx.Coinbase, Weight: reward.Weight})
+	}
+	computed, err := vm.CalculateRewards(lid, fees, weights)
+	require.NoError(t.tb, err)
+	t.rewards[lid] = computed
+}
+
+func (t *tester) revert(from types.LayerID) {
+	require.NoError(t.tb, t.db.WithTx(context.Background(), func(tx *sql.Tx) error {
+		if err := transactions.UndoLayers(tx, from); err != nil {
+			return err
+		}
+		return layers.UnsetAppliedFrom(tx, from)
+	}))
+	for lid := range t.rewards {
+		if !lid.Before(from) {
+			delete(t.rewards, lid)
+		}
+	}
+}
+
+// coinbaseRewards aggregates rewards computed by vm for the coinbase.
+func (t *tester) coinbaseRewards(coinbase types.Address) map[types.EpochID]EpochRewards {
+	rst := map[types.EpochID]EpochRewards{}
+	for _, rewards := range t.rewards {
+		for _, reward := range rewards {
+			if reward.Coinbase != coinbase {
+				continue
+			}
+			epoch := reward.Layer.GetEpoch()
+			aggregated := rst[epoch]
+			aggregated.Epoch = epoch
+			aggregated.TotalReward += reward.TotalReward
+			aggregated.LayerReward += reward.LayerReward
+			aggregated.Count++
+			rst[epoch] = aggregated
+		}
+	}
+	return rst
+}
+
+func toMap(rewards []EpochRewards) map[types.EpochID]EpochRewards {
+	rst := map[types.EpochID]EpochRewards{}
+	for _, reward := range rewards {
+		rst[reward.Epoch] = reward
+	}
+	return rst
+}
+
+func tx(seed byte, fee uint64, addresses ...types.Address) types.TransactionWithResult {
+	var rst types.TransactionWithResult
+	rst.RawTx = types.NewRawTx([]byte{seed})
+	rst.Fee = fee
+	rst.Addresses = addresses
+	return rst
+}
+
+func spawn(seed byte, principal, account types.Address) types.TransactionWithResult {
+	rst := tx(seed, 1, principal)
+	if account != principal {
+		rst.Addresses = append(rst.Addresses, account)
+	}
+	rst.Events = []types.TransactionEvent{{Type: types.EventSpawn, From: principal, To: account}}
+	return rst
+}
+
+func weight(num uint64) types.RatNum {
+	return types.RatNum{Num: num, Denom: 1}
+}
+
+func TestIndexActivity(t *testing.T) {
+	tt := newTester(t)
+	genesis := types.GetEffectiveGenesis()
+	principal := types.Address{1}
+	other := types.Address{2}
+	tt.apply(genesis.Add(1), 0, nil, tx(1, 1, principal))
+	tt.apply(genesis.Add(2), 0, nil, tx(2, 1, principal, other), tx(3, 1, other))
+	tt.apply(genesis.Add(3), 0, nil, tx(4, 1, principal))
+
+	indexer := New(tt.db, WithLogger(logtest.New(t)))
+	require.NoError(t, indexer.Update(context.Background()))
+	last, err := LastIndexed(tt.db)
+	require.NoError(t, err)
+	require.Equal(t, genesis.Add(3), last)
+
+	activity, err := GetActivity(tt.db, ActivityFilter{Address: principal, Limit: 10})
+	require.NoError(t, err)
+	require.Equal(t, []Activity{
+		{Layer: genesis.Add(3), TID: types.NewRawTx([]byte{4}).ID},
+		{Layer: genesis.Add(2), TID: types.NewRawTx([]byte{2}).ID},
+		{Layer: genesis.Add(1), TID: types.NewRawTx([]byte{1}).ID},
+	}, activity)
+
+	activity, err = GetActivity(tt.db, ActivityFilter{Address: principal, Offset: 1, Limit: 1})
+	require.NoError(t, err)
+	require.Equal(t, []Activity{{Layer: genesis.Add(2), TID: types.NewRawTx([]byte{2}).ID}}, activity)
+
+	activity, err = GetActivity(tt.db, ActivityFilter{Address: other, Start: genesis.Add(2), End: genesis.Add(2), Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, activity, 2)
+
+	activity, err = GetActivity(tt.db, ActivityFilter{Address: types.Address{3}, Limit: 10})
+	require.NoError(t, err)
+	require.Empty(t, activity)
+}
+
+func TestIndexRewards(t *testing.T) {
+	tt := newTester(t)
+	genesis := types.GetEffectiveGenesis()
+	smeshers := []types.NodeID{{1}, {2}, {3}}
+	coinbase := types.Address{1}
+	// two smeshers share the coinbase
+	atx1 := tt.addAtx(smeshers[0], coinbase)
+	atx2 := tt.addAtx(smeshers[1], coinbase)
+	atx3 := tt.addAtx(smeshers[2], types.Address{2})
+	for i := 1; i <= 9; i++ {
+		tt.apply(genesis.Add(uint32(i)), byte(i), []types.AnyReward{
+			{AtxID: atx1, Weight: weight(1)},
+			{AtxID: atx2, Weight: weight(2)},
+			{AtxID: atx3, Weight: weight(uint64(i))},
+		}, tx(byte(i), uint64(i*100), types.Address{3}))
+	}
+	tt.apply(genesis.Add(10), 0, nil)
+
+	require.NoError(t, New(tt.db).Update(context.Background()))
+	last, err := LastIndexed(tt.db)
+	require.NoError(t, err)
+	require.Equal(t, genesis.Add(10), last)
+
+	from, to := genesis.GetEpoch(), genesis.Add(10).GetEpoch()
+	rewards, err := GetCoinbaseRewards(tt.db, coinbase, from, to)
+	require.NoError(t, err)
+	require.Equal(t, tt.coinbaseRewards(coinbase), toMap(rewards))
+	rewards, err = GetCoinbaseRewards(tt.db, types.Address{2}, from, to)
+	require.NoError(t, err)
+	require.Equal(t, tt.coinbaseRewards(types.Address{2}), toMap(rewards))
+
+	first, err := GetSmesherRewards(tt.db, smeshers[0], from, to)
+	require.NoError(t, err)
+	second, err := GetSmesherRewards(tt.db, smeshers[1], from, to)
+	require.NoError(t, err)
+	require.Len(t, second, len(first))
+	for i := range first {
+		require.Equal(t, first[i].Epoch, second[i].Epoch)
+		require.Equal(t, tt.coinbaseRewards(coinbase)[first[i].Epoch].TotalReward,
+			first[i].TotalReward+second[i].TotalReward)
+		require.Less(t, first[i].TotalReward, second[i].TotalReward)
+	}
+
+	rewards, err = GetSmesherRewards(tt.db, smeshers[2], to, to)
+	require.NoError(t, err)
+	require.Len(t, rewards, 1)
+	require.Equal(t, to, rewards[0].Epoch)
+}
+
+func TestIndexSpawns(t *testing.T) {
+	tt := newTester(t)
+	genesis := types.GetEffectiveGenesis()
+	root := types.Address{1}
+	child := types.Address{2}
+	grandchild := types.Address{3}
+	tt.apply(genesis.Add(1), 0, nil, spawn(1, root, root))
+	tt.apply(genesis.Add(2), 0, nil, spawn(2, root, child), spawn(3, root, types.Address{4}))
+	tt.apply(genesis.Add(3), 0, nil, spawn(4, child, grandchild))
+	require.NoError(t, New(tt.db).Update(context.Background()))
+
+	lineage, err := GetLineage(tt.db, grandchild)
+	require.NoError(t, err)
+	require.Len(t, lineage, 3)
+	require.Equal(t, &Spawn{
+		Account: grandchild, Spawner: child, TID: types.NewRawTx([]byte{4}).ID, Layer: genesis.Add(3),
+	}, lineage[0])
+	require.Equal(t, child, lineage[1].Account)
+	require.Equal(t, root, lineage[2].Account)
+	require.Equal(t, root, lineage[2].Spawner)
+
+	spawned, err := GetSpawned(tt.db, root, 0, 10)
+	require.NoError(t, err)
+	require.Len(t, spawned, 2)
+	require.Equal(t, child, spawned[0].Account)
+	require.Equal(t, types.Address{4}, spawned[1].Account)
+
+	spawned, err = GetSpawned(tt.db, root, 1, 10)
+	require.NoError(t, err)
+	require.Len(t, spawned, 1)
+
+	_, err = GetSpawn(tt.db, types.Address{5})
+	require.ErrorIs(t, err, sql.ErrNotFound)
+	_, err = GetLineage(tt.db, types.Address{5})
+	require.ErrorIs(t, err, sql.ErrNotFound)
+}
+
+func TestIndexReorg(t *testing.T) {
+	tt := newTester(t)
+	genesis := types.GetEffectiveGenesis()
+	coinbase := types.Address{1}
+	principal := types.Address{2}
+	atx := tt.addAtx(types.NodeID{1}, coinbase)
+	other := tt.addAtx(types.NodeID{2}, types.Address{3})
+	for i := 1; i <= 8; i++ {
+		tt.apply(genesis.Add(uint32(i)), byte(i), []types.AnyReward{{AtxID: atx, Weight: weight(1)}},
+			tx(byte(i), 10, principal))
+	}
+	indexer := New(tt.db, WithLogger(logtest.New(t)))
+	require.NoError(t, indexer.Update(context.Background()))
+
+	// layer in the middle of the epoch is applied with a different block
+	reverted := genesis.Add(6)
+	tt.revert(reverted)
+	tt.apply(reverted, 100, []types.AnyReward{{AtxID: other, Weight: weight(1)}}, tx(100, 1))
+	tt.apply(reverted.Add(1), 0, nil)
+
+	diverged, found, err := FirstDiverged(tt.db)
+	require.NoError(t, err)
+	require.True(t, found)
+	require.Equal(t, reverted, diverged)
+
+	require.NoError(t, indexer.Update(context.Background()))
+	_, found, err = FirstDiverged(tt.db)
+	require.NoError(t, err)
+	require.False(t, found)
+	last, err := LastIndexed(tt.db)
+	require.NoError(t, err)
+	require.Equal(t, reverted.Add(1), last)
+
+	from, to := genesis.GetEpoch(), reverted.Add(1).GetEpoch()
+	for _, address := range []types.Address{coinbase, {3}} {
+		rewards, err := GetCoinbaseRewards(tt.db, address, from, to)
+		require.NoError(t, err)
+		require.Equal(t, tt.coinbaseRewards(address), toMap(rewards))
+	}
+	activity, err := GetActivity(tt.db, ActivityFilter{Address: principal, Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, activity, 5)
+	require.Equal(t, genesis.Add(5), activity[0].Layer)
+}
+
+func TestRunUpdates(t *testing.T) {
+	tt := newTester(t)
+	genesis := types.GetEffectiveGenesis()
+	indexer := New(tt.db, WithConfig(Config{Enabled: true, Interval: 10 * time.Millisecond}))
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	errc := make(chan error, 1)
+	go func() {
+		errc <- indexer.Run(ctx)
+	}()
+
+	tt.apply(genesis.Add(1), 0, nil, tx(1, 1, types.Address{1}))
+	require.Eventually(t, func() bool {
+		last, err := LastIndexed(tt.db)
+		require.NoError(t, err)
+		return last == genesis.Add(1)
+	}, time.Second, 10*time.Millisecond)
+	cancel()
+	require.NoError(t, <-errc)
+}
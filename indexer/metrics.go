@@ -0,0 +1,14 @@
+package indexer
+
+import (
+	"github.com/spacemeshos/go-spacemesh/metrics"
+)
+
+const namespace = "indexer"
+
+var indexedLayer = metrics.NewGauge(
+	"layer",
+	namespace,
+	"last indexed layer",
+	[]string{},
+).WithLabelValues()
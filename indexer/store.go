@@ -0,0 +1,316 @@
+package indexer
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/spacemeshos/go-spacemesh/common/types"
+	"github.com/spacemeshos/go-spacemesh/sql"
+)
+
+// Activity is a transaction that updated the address.
+type Activity struct {
+	Layer types.LayerID
+	TID   types.TransactionID
+}
+
+// EpochRewards aggregates rewards received within the epoch.
+type EpochRewards struct {
+	Epoch       types.EpochID
+	TotalReward uint64
+	LayerReward uint64
+	// Count is a number of rewards.
+	Count uint64
+}
+
+// Spawn links the spawned account with the principal that spawned it.
+// Self-spawned accounts are their own spawners.
+type Spawn struct {
+	Account types.Address
+	Spawner types.Address
+	TID     types.TransactionID
+	Layer   types.LayerID
+}
+
+// LastIndexed returns the last indexed layer, or 0 if nothing was indexed.
+func LastIndexed(db sql.Executor) (types.LayerID, error) {
+	var lid types.LayerID
+	if _, err := db.Exec("select max(layer) from indexer_layers;", nil,
+		func(stmt *sql.Statement) bool {
+			lid = types.LayerID(stmt.ColumnInt64(0))
+			return true
+		}); err != nil {
+		return 0, fmt.Errorf("last indexed: %w", err)
+	}
+	return lid, nil
+}
+
+// FirstDiverged returns the first indexed layer with a block that is no longer applied.
+func FirstDiverged(db sql.Executor) (types.LayerID, bool, error) {
+	var (
+		lid   types.LayerID
+		found bool
+	)
+	if _, err := db.Exec(`select il.layer from indexer_layers il
+		left join layers l on l.id = il.layer
+		where l.applied_block is null or l.applied_block != il.block
+		order by il.layer asc limit 1;`, nil,
+		func(stmt *sql.Statement) bool {
+			lid = types.LayerID(stmt.ColumnInt64(0))
+			found = true
+			return false
+		}); err != nil {
+		return 0, false, fmt.Errorf("first diverged: %w", err)
+	}
+	return lid, found, nil
+}
+
+func addLayer(db sql.Executor, lid types.LayerID, bid types.BlockID) error {
+	if _, err := db.Exec("insert into indexer_layers (layer, block) values (?1, ?2);",
+		func(stmt *sql.Statement) {
+			stmt.BindInt64(1, int64(lid))
+			stmt.BindBytes(2, bid[:])
+		}, nil); err != nil {
+		return fmt.Errorf("add indexed layer %s: %w", lid, err)
+	}
+	return nil
+}
+
+func addActivity(db sql.Executor, address types.Address, lid types.LayerID, tid types.TransactionID) error {
+	if _, err := db.Exec(`insert into indexer_activity (address, layer, tid) values (?1, ?2, ?3)
+		on conflict do nothing;`,
+		func(stmt *sql.Statement) {
+			stmt.BindBytes(1, address[:])
+			stmt.BindInt64(2, int64(lid))
+			stmt.BindBytes(3, tid[:])
+		}, nil); err != nil {
+		return fmt.Errorf("add activity %s for %s: %w", tid, address.String(), err)
+	}
+	return nil
+}
+
+func addSpawn(db sql.Executor, spawn *Spawn) error {
+	if _, err := db.Exec(`insert into indexer_spawns (account, spawner, tid, layer) values (?1, ?2, ?3, ?4)
+		on conflict do nothing;`,
+		func(stmt *sql.Statement) {
+			stmt.BindBytes(1, spawn.Account[:])
+			stmt.BindBytes(2, spawn.Spawner[:])
+			stmt.BindBytes(3, spawn.TID[:])
+			stmt.BindInt64(4, int64(spawn.Layer))
+		}, nil); err != nil {
+		return fmt.Errorf("add spawn of %s: %w", spawn.Account.String(), err)
+	}
+	return nil
+}
+
+func addReward(db sql.Executor, smesher types.NodeID, reward *types.Reward) error {
+	if _, err := db.Exec(`insert into indexer_rewards (smesher, layer, coinbase, total_reward, layer_reward)
+		values (?1, ?2, ?3, ?4, ?5)
+		on conflict (smesher, layer) do update set
+			total_reward = add_uint64(total_reward, ?4),
+			layer_reward = add_uint64(layer_reward, ?5);`,
+		func(stmt *sql.Statement) {
+			stmt.BindBytes(1, smesher[:])
+			stmt.BindInt64(2, int64(reward.Layer))
+			stmt.BindBytes(3, reward.Coinbase[:])
+			stmt.BindInt64(4, int64(reward.TotalReward))
+			stmt.BindInt64(5, int64(reward.LayerReward))
+		}, nil); err != nil {
+		return fmt.Errorf("add reward for %s: %w", smesher, err)
+	}
+	epoch := reward.Layer.GetEpoch()
+	for _, q := range []struct {
+		table, key string
+		id         []byte
+	}{
+		{table: "indexer_coinbase_epoch_rewards", key: "coinbase", id: reward.Coinbase[:]},
+		{table: "indexer_smesher_epoch_rewards", key: "smesher", id: smesher[:]},
+	} {
+		if _, err := db.Exec(`insert into `+q.table+` (`+q.key+`, epoch, total_reward, layer_reward, count)
+			values (?1, ?2, ?3, ?4, 1)
+			on conflict (`+q.key+`, epoch) do update set
+				total_reward = add_uint64(total_reward, ?3),
+				layer_reward = add_uint64(layer_reward, ?4),
+				count = count + 1;`,
+			func(stmt *sql.Statement) {
+				stmt.BindBytes(1, q.id)
+				stmt.BindInt64(2, int64(epoch))
+				stmt.BindInt64(3, int64(reward.TotalReward))
+				stmt.BindInt64(4, int64(reward.LayerReward))
+			}, nil); err != nil {
+			return fmt.Errorf("add %s for %s: %w", q.table, smesher, err)
+		}
+	}
+	return nil
+}
+
+// Revert deletes indexed data after the layer.
+// Epoch aggregates of the partially reverted epoch are recomputed from rewards that are kept.
+func Revert(db sql.Executor, revertTo types.LayerID) error {
+	for _, table := range []string{"indexer_layers", "indexer_activity", "indexer_spawns", "indexer_rewards"} {
+		if _, err := db.Exec("delete from "+table+" where layer > ?1;",
+			func(stmt *sql.Statement) {
+				stmt.BindInt64(1, int64(revertTo))
+			}, nil); err != nil {
+			return fmt.Errorf("revert %s to %s: %w", table, revertTo, err)
+		}
+	}
+	epoch := revertTo.Add(1).GetEpoch()
+	for _, q := range []struct{ table, key string }{
+		{table: "indexer_coinbase_epoch_rewards", key: "coinbase"},
+		{table: "indexer_smesher_epoch_rewards", key: "smesher"},
+	} {
+		if _, err := db.Exec("delete from "+q.table+" where epoch >= ?1;",
+			func(stmt *sql.Statement) {
+				stmt.BindInt64(1, int64(epoch))
+			}, nil); err != nil {
+			return fmt.Errorf("revert %s to %s: %w", q.table, revertTo, err)
+		}
+		if _, err := db.Exec(`insert into `+q.table+` (`+q.key+`, epoch, total_reward, layer_reward, count)
+			select `+q.key+`, ?1, sum(total_reward), sum(layer_reward), count(*) from indexer_rewards
+			where layer >= ?2
+			group by `+q.key+`;`,
+			func(stmt *sql.Statement) {
+				stmt.BindInt64(1, int64(epoch))
+				stmt.BindInt64(2, int64(epoch.FirstLayer()))
+			}, nil); err != nil {
+			return fmt.Errorf("recompute %s for epoch %s: %w", q.table, epoch, err)
+		}
+	}
+	return nil
+}
+
+// ActivityFilter selects a page of the address activity.
+type ActivityFilter struct {
+	Address types.Address
+	// Start and End are inclusive bounds of layers. Zero End is unbounded.
+	Start, End types.LayerID
+	Offset     uint64
+	Limit      uint64
+}
+
+// GetActivity returns a page of transactions that updated the address, newest first.
+func GetActivity(db sql.Executor, filter ActivityFilter) ([]Activity, error) {
+	end := filter.End
+	if end == 0 {
+		end = types.LayerID(1<<32 - 1)
+	}
+	var rst []Activity
+	if _, err := db.Exec(`select layer, tid from indexer_activity
+		where address = ?1 and layer between ?2 and ?3
+		order by layer desc, tid asc
+		limit ?4 offset ?5;`,
+		func(stmt *sql.Statement) {
+			stmt.BindBytes(1, filter.Address[:])
+			stmt.BindInt64(2, int64(filter.Start))
+			stmt.BindInt64(3, int64(end))
+			stmt.BindInt64(4, int64(filter.Limit))
+			stmt.BindInt64(5, int64(filter.Offset))
+		}, func(stmt *sql.Statement) bool {
+			activity := Activity{Layer: types.LayerID(stmt.ColumnInt64(0))}
+			stmt.ColumnBytes(1, activity.TID[:])
+			rst = append(rst, activity)
+			return true
+		}); err != nil {
+		return nil, fmt.Errorf("activity of %s: %w", filter.Address.String(), err)
+	}
+	return rst, nil
+}
+
+func epochRewards(db sql.Executor, table, key string, id []byte, from, to types.EpochID) ([]EpochRewards, error) {
+	var rst []EpochRewards
+	if _, err := db.Exec(`select epoch, total_reward, layer_reward, count from `+table+`
+		where `+key+` = ?1 and epoch between ?2 and ?3
+		order by epoch asc;`,
+		func(stmt *sql.Statement) {
+			stmt.BindBytes(1, id)
+			stmt.BindInt64(2, int64(from))
+			stmt.BindInt64(3, int64(to))
+		}, func(stmt *sql.Statement) bool {
+			rst = append(rst, EpochRewards{
+				Epoch:       types.EpochID(stmt.ColumnInt64(0)),
+				TotalReward: uint64(stmt.ColumnInt64(1)),
+				LayerReward: uint64(stmt.ColumnInt64(2)),
+				Count:       uint64(stmt.ColumnInt64(3)),
+			})
+			return true
+		}); err != nil {
+		return nil, fmt.Errorf("epoch rewards from %s: %w", table, err)
+	}
+	return rst, nil
+}
+
+// GetCoinbaseRewards returns rewards for the coinbase aggregated per epoch, for epochs in [from, to].
+func GetCoinbaseRewards(db sql.Executor, coinbase types.Address, from, to types.EpochID) ([]EpochRewards, error) {
+	return epochRewards(db, "indexer_coinbase_epoch_rewards", "coinbase", coinbase[:], from, to)
+}
+
+// GetSmesherRewards returns rewards for the smesher aggregated per epoch, for epochs in [from, to].
+func GetSmesherRewards(db sql.Executor, smesher types.NodeID, from, to types.EpochID) ([]EpochRewards, error) {
+	return epochRewards(db, "indexer_smesher_epoch_rewards", "smesher", smesher[:], from, to)
+}
+
+func decodeSpawn(stmt *sql.Statement) *Spawn {
+	var spawn Spawn
+	stmt.ColumnBytes(0, spawn.Account[:])
+	stmt.ColumnBytes(1, spawn.Spawner[:])
+	stmt.ColumnBytes(2, spawn.TID[:])
+	spawn.Layer = types.LayerID(stmt.ColumnInt64(3))
+	return &spawn
+}
+
+// GetSpawn returns how the account was spawned.
+func GetSpawn(db sql.Executor, account types.Address) (*Spawn, error) {
+	var spawn *Spawn
+	if _, err := db.Exec("select account, spawner, tid, layer from indexer_spawns where account = ?1;",
+		func(stmt *sql.Statement) {
+			stmt.BindBytes(1, account[:])
+		}, func(stmt *sql.Statement) bool {
+			spawn = decodeSpawn(stmt)
+			return false
+		}); err != nil {
+		return nil, fmt.Errorf("spawn of %s: %w", account.String(), err)
+	}
+	if spawn == nil {
+		return nil, fmt.Errorf("%w: spawn of %s", sql.ErrNotFound, account.String())
+	}
+	return spawn, nil
+}
+
+// GetLineage returns spawns from the account up to the first self-spawned account.
+func GetLineage(db sql.Executor, account types.Address) ([]*Spawn, error) {
+	var rst []*Spawn
+	for {
+		spawn, err := GetSpawn(db, account)
+		if errors.Is(err, sql.ErrNotFound) && len(rst) > 0 {
+			return rst, nil
+		} else if err != nil {
+			return nil, err
+		}
+		rst = append(rst, spawn)
+		if spawn.Spawner == spawn.Account {
+			return rst, nil
+		}
+		account = spawn.Spawner
+	}
+}
+
+// GetSpawned returns a page of accounts spawned by the principal, excluding the principal itself.
+func GetSpawned(db sql.Executor, spawner types.Address, offset, limit uint64) ([]*Spawn, error) {
+	var rst []*Spawn
+	if _, err := db.Exec(`select account, spawner, tid, layer from indexer_spawns
+		where spawner = ?1 and account != ?1
+		order by layer asc, account asc
+		limit ?2 offset ?3;`,
+		func(stmt *sql.Statement) {
+			stmt.BindBytes(1, spawner[:])
+			stmt.BindInt64(2, int64(limit))
+			stmt.BindInt64(3, int64(offset))
+		}, func(stmt *sql.Statement) bool {
+			rst = append(rst, decodeSpawn(stmt))
+			return true
+		}); err != nil {
+		return nil, fmt.Errorf("spawned by %s: %w", spawner.String(), err)
+	}
+	return rst, nil
+}
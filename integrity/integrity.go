@@ -0,0 +1,491 @@
+// Package integrity cross-validates mesh and state data stored in the database.
+package integrity
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/spacemeshos/go-spacemesh/common/types"
+	vm "github.com/spacemeshos/go-spacemesh/genvm"
+	"github.com/spacemeshos/go-spacemesh/indexer"
+	"github.com/spacemeshos/go-spacemesh/log"
+	"github.com/spacemeshos/go-spacemesh/sql"
+	"github.com/spacemeshos/go-spacemesh/sql/accounts"
+	"github.com/spacemeshos/go-spacemesh/sql/atxs"
+	"github.com/spacemeshos/go-spacemesh/sql/blocks"
+	"github.com/spacemeshos/go-spacemesh/sql/layers"
+	"github.com/spacemeshos/go-spacemesh/sql/transactions"
+	"github.com/spacemeshos/go-spacemesh/tortoise/opinionhash"
+)
+
+// Kind of the inconsistency.
+type Kind string
+
+const (
+	// MissingApplied is reported if the layer before the last applied layer has no applied block.
+	MissingApplied Kind = "missing-applied"
+	// MissingBlock is reported if the applied block is not in the blocks table.
+	MissingBlock Kind = "missing-block"
+	// MissingTransaction is reported if the transaction referenced by the applied block is not stored.
+	MissingTransaction Kind = "missing-transaction"
+	// MissingActivation is reported if the activation referenced by the applied block rewards is not stored.
+	MissingActivation Kind = "missing-activation"
+	// AccountsMismatch is reported if the stored accounts don't match the accounts
+	// after replaying the applied block of the layer.
+	AccountsMismatch Kind = "accounts-mismatch"
+	// MissingStateHash is reported if the applied layer has no state hash.
+	MissingStateHash Kind = "missing-state-hash"
+	// StateHashMismatch is reported if the stored state hash doesn't match the replayed one,
+	// while the stored accounts match the replayed accounts. It is expected for layers applied
+	// by a version that computed state hash in a different format.
+	StateHashMismatch Kind = "state-hash-mismatch"
+	// MissingAggregatedHash is reported if the aggregated hash chain has a gap.
+	MissingAggregatedHash Kind = "missing-aggregated-hash"
+	// AggregatedHashMismatch is reported if the aggregated hash doesn't match the hash
+	// recomputed from the previous aggregated hash and the valid blocks of the layer.
+	AggregatedHashMismatch Kind = "aggregated-hash-mismatch"
+)
+
+// Revertable is false if the state doesn't depend on the inconsistency, and reverting
+// the state doesn't fix it. Such issues are reported, but ignored by Repair.
+func (k Kind) Revertable() bool {
+	switch k {
+	case StateHashMismatch:
+		return false
+	}
+	return true
+}
+
+// Issue is an inconsistency found in the layer.
+type Issue struct {
+	Kind    Kind
+	Layer   types.LayerID
+	Details string
+}
+
+func (i Issue) String() string {
+	return fmt.Sprintf("%s: layer %s: %s", i.Kind, i.Layer, i.Details)
+}
+
+// Report of the check.
+type Report struct {
+	// Applied is the last applied layer when the check started.
+	Applied types.LayerID
+	Issues  []Issue
+}
+
+// Valid is true if no issues were found.
+func (r *Report) Valid() bool {
+	return len(r.Issues) == 0
+}
+
+// FirstInvalid returns the earliest applied layer with revertable issues.
+func (r *Report) FirstInvalid() (types.LayerID, bool) {
+	var (
+		first types.LayerID
+		found bool
+	)
+	for _, issue := range r.Issues {
+		if issue.Layer.After(r.Applied) || !issue.Kind.Revertable() {
+			continue
+		}
+		if !found || issue.Layer.Before(first) {
+			first = issue.Layer
+			found = true
+		}
+	}
+	return first, found
+}
+
+func (r *Report) add(kind Kind, lid types.LayerID, format string, args ...any) {
+	r.Issues = append(r.Issues, Issue{Kind: kind, Layer: lid, Details: fmt.Sprintf(format, args...)})
+}
+
+// Opt for configuring Checker.
+type Opt func(*Checker)
+
+// WithLogger changes logger.
+func WithLogger(logger log.Log) Opt {
+	return func(c *Checker) {
+		c.logger = logger
+	}
+}
+
+// WithVMConfig sets config of the vm that replays layers. It must match the config of the node.
+func WithVMConfig(cfg vm.Config) Opt {
+	return func(c *Checker) {
+		c.cfg = cfg
+	}
+}
+
+// New creates Checker.
+func New(db *sql.Database, opts ...Opt) *Checker {
+	c := &Checker{
+		logger: log.NewNop(),
+		cfg:    vm.DefaultConfig(),
+		db:     db,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	c.vm = vm.New(db, vm.WithConfig(c.cfg), vm.WithLogger(c.logger))
+	return c
+}
+
+// Checker verifies that the database is consistent, and can revert the state
+// to the last consistent layer.
+//
+// Checker must not be used while the node is running.
+type Checker struct {
+	logger log.Log
+	cfg    vm.Config
+	db     *sql.Database
+	vm     *vm.VM
+
+	// scratch is the state where layers are replayed during the check.
+	scratch *sql.Database
+	replay  *vm.VM
+	// stale is true if the scratch state must be copied from the stored state
+	// before replaying the next layer.
+	stale bool
+}
+
+// Check every layer from effective genesis up to the last applied layer:
+//   - applied block is stored;
+//   - transactions and activations referenced by applied blocks are stored;
+//   - replaying the applied block on top of the state of the previous layer results
+//     in the stored accounts, state hash and accounts root;
+//   - aggregated hash matches the hash of the previous aggregated hash and the valid blocks.
+//
+// Layers are replayed in the scratch state, initialized with the state at effective genesis.
+// Scratch state is copied from the stored state again after a layer that can't be replayed
+// or doesn't match, so that every issue points to the layer where the state diverged.
+func (c *Checker) Check(ctx context.Context) (*Report, error) {
+	applied, err := layers.GetLastApplied(c.db)
+	if err != nil {
+		return nil, err
+	}
+	report := &Report{Applied: applied}
+	genesis := types.GetEffectiveGenesis()
+	if applied.Before(genesis) {
+		// mesh is not initialized
+		return report, nil
+	}
+	hash, err := layers.GetAggregatedHash(c.db, genesis)
+	if err != nil && !errors.Is(err, sql.ErrNotFound) {
+		return nil, err
+	}
+	if err != nil || hash == (types.Hash32{}) {
+		report.add(MissingAggregatedHash, genesis, "aggregated hash is not set")
+	}
+	c.stale = true
+	defer c.closeScratch()
+	for lid := genesis.Add(1); !lid.After(applied); lid = lid.Add(1) {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		if err := c.checkLayer(report, lid); err != nil {
+			return nil, fmt.Errorf("check layer %s: %w", lid, err)
+		}
+	}
+	if err := c.checkTransactions(report); err != nil {
+		return nil, err
+	}
+	c.logger.With().Info("checked database",
+		log.Stringer("applied", applied),
+		log.Int("issues", len(report.Issues)),
+	)
+	return report, nil
+}
+
+func (c *Checker) checkLayer(report *Report, lid types.LayerID) error {
+	var (
+		block      *types.Block
+		replayable = true
+	)
+	bid, err := layers.GetApplied(c.db, lid)
+	switch {
+	case errors.Is(err, sql.ErrNotFound):
+		report.add(MissingApplied, lid, "applied block is not set")
+		replayable = false
+	case err != nil:
+		return err
+	case bid != types.EmptyBlockID:
+		block, err = blocks.Get(c.db, bid)
+		if errors.Is(err, sql.ErrNotFound) {
+			report.add(MissingBlock, lid, "applied block %s", bid.String())
+			replayable = false
+		} else if err != nil {
+			return err
+		}
+	}
+
+	if replayable {
+		if err := c.replayLayer(report, lid, block); err != nil {
+			return err
+		}
+	} else {
+		c.stale = true
+	}
+	return c.checkAggregatedHash(report, lid, bid)
+}
+
+// replayLayer applies the block to the scratch state and compares the result with the stored state.
+// Block is nil for the empty layer.
+func (c *Checker) replayLayer(report *Report, lid types.LayerID, block *types.Block) error {
+	if c.stale {
+		if err := c.resetScratch(lid.Sub(1)); err != nil {
+			return fmt.Errorf("copy state at %s: %w", lid.Sub(1), err)
+		}
+	}
+	txs, rewards, ok, err := c.blockInputs(report, lid, block)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		c.stale = true
+		return nil
+	}
+	if _, _, err := c.replay.Apply(vm.ApplyContext{Layer: lid}, txs, rewards); err != nil {
+		return fmt.Errorf("replay: %w", err)
+	}
+	replayedRoot, err := c.replay.ComputeAccountsRoot(lid)
+	if err != nil {
+		return err
+	}
+	computed, err := c.vm.ComputeAccountsRoot(lid)
+	if err != nil {
+		return err
+	}
+	if computed != replayedRoot {
+		report.add(AccountsMismatch, lid, "stored accounts %s, replayed %s", computed.String(), replayedRoot.String())
+		c.stale = true
+		// hashes can't be compared if the state doesn't match
+		return nil
+	}
+
+	replayed, err := layers.GetStateHash(c.scratch, lid)
+	if err != nil {
+		return err
+	}
+	stored, err := layers.GetStateHash(c.db, lid)
+	switch {
+	case errors.Is(err, sql.ErrNotFound):
+		report.add(MissingStateHash, lid, "state hash is not set")
+	case err != nil:
+		return err
+	case stored != replayed:
+		report.add(StateHashMismatch, lid, "stored %s, replayed %s", stored.String(), replayed.String())
+	}
+	return nil
+}
+
+// blockInputs loads transactions and rewards of the block in the same way as mesh executor.
+// Returns false if the block can't be replayed as some of the referenced data is not stored.
+func (c *Checker) blockInputs(report *Report, lid types.LayerID, block *types.Block) (
+	[]types.Transaction, []types.CoinbaseReward, bool, error,
+) {
+	if block == nil {
+		return nil, nil, true, nil
+	}
+	ok := true
+	txs := make([]types.Transaction, 0, len(block.TxIDs))
+	for _, tid := range block.TxIDs {
+		mtx, err := transactions.Get(c.db, tid)
+		if errors.Is(err, sql.ErrNotFound) {
+			// reported by checkTransactions
+			ok = false
+			continue
+		} else if err != nil {
+			return nil, nil, false, err
+		}
+		// executor skips transactions applied in the previous layers
+		if mtx.State == types.APPLIED && mtx.LayerID.Before(lid) {
+			continue
+		}
+		txs = append(txs, mtx.Transaction)
+	}
+	rewards := make([]types.CoinbaseReward, 0, len(block.Rewards))
+	for _, reward := range block.Rewards {
+		atx, err := atxs.Get(c.db, reward.AtxID)
+		if errors.Is(err, sql.ErrNotFound) {
+			report.add(MissingActivation, lid, "activation %s in block %s", reward.AtxID.String(), block.ID().String())
+			ok = false
+			continue
+		} else if err != nil {
+			return nil, nil, false, err
+		}
+		rewards = append(rewards, types.CoinbaseReward{Coinbase: atx.Coinbase, Weight: reward.Weight})
+	}
+	sort.Slice(rewards, func(i, j int) bool {
+		return bytes.Compare(rewards[i].Coinbase.Bytes(), rewards[j].Coinbase.Bytes()) < 0
+	})
+	return txs, rewards, ok, nil
+}
+
+// resetScratch copies the stored state at the layer into the new scratch state.
+func (c *Checker) resetScratch(lid types.LayerID) error {
+	c.closeScratch()
+	scratch := sql.InMemory()
+	if err := scratch.WithTx(context.Background(), func(tx *sql.Tx) error {
+		var ierr error
+		if err := accounts.IterateSnapshot(c.db, lid, types.Address{}, func(account *types.Account) bool {
+			ierr = accounts.Update(tx, account)
+			return ierr == nil
+		}); err != nil {
+			return err
+		}
+		return ierr
+	}); err != nil {
+		scratch.Close()
+		return err
+	}
+	c.scratch = scratch
+	c.replay = vm.New(scratch, vm.WithConfig(c.cfg), vm.WithLogger(c.logger))
+	c.stale = false
+	return nil
+}
+
+func (c *Checker) closeScratch() {
+	if c.scratch != nil {
+		c.scratch.Close()
+		c.scratch = nil
+		c.replay = nil
+	}
+}
+
+// checkAggregatedHash recomputes the aggregated hash in the same way as tortoise:
+// the previous aggregated hash followed by the valid blocks of the layer, or by the abstain sentinel
+// if hare didn't terminate. Valid blocks are not marked if the layer was applied within hare distance,
+// in such case the applied block is the one supported by hare.
+func (c *Checker) checkAggregatedHash(report *Report, lid types.LayerID, applied types.BlockID) error {
+	hash, err := layers.GetAggregatedHash(c.db, lid)
+	if err != nil && !errors.Is(err, sql.ErrNotFound) {
+		return err
+	}
+	// null is read as an empty hash
+	if err != nil || hash == (types.Hash32{}) {
+		report.add(MissingAggregatedHash, lid, "aggregated hash is not set")
+		return nil
+	}
+	prev, err := layers.GetAggregatedHash(c.db, lid.Sub(1))
+	if err != nil && !errors.Is(err, sql.ErrNotFound) {
+		return err
+	}
+	if err != nil || prev == (types.Hash32{}) {
+		// missing hash is reported for the previous layer
+		return nil
+	}
+	layer, err := blocks.Layer(c.db, lid)
+	if err != nil {
+		return err
+	}
+	var (
+		valid   []*types.Block
+		current *types.Block
+	)
+	for _, block := range layer {
+		ok, err := blocks.IsValid(c.db, block.ID())
+		if err != nil && !errors.Is(err, blocks.ErrValidityNotDecided) {
+			return err
+		}
+		if ok {
+			valid = append(valid, block)
+		}
+		if block.ID() == applied {
+			current = block
+		}
+	}
+	if applied != types.EmptyBlockID && current == nil {
+		// hash can't be recomputed without the applied block, missing block is already reported
+		return nil
+	}
+	if len(valid) == 0 && current != nil {
+		valid = append(valid, current)
+	}
+	sort.Slice(valid, func(i, j int) bool {
+		if valid[i].TickHeight != valid[j].TickHeight {
+			return valid[i].TickHeight < valid[j].TickHeight
+		}
+		return valid[i].ID().Compare(valid[j].ID())
+	})
+	hasher := opinionhash.New()
+	hasher.WritePrevious(prev)
+	for _, block := range valid {
+		hasher.WriteSupport(block.ID(), block.TickHeight)
+	}
+	supported := hasher.Hash()
+	hasher.Reset()
+	hasher.WritePrevious(prev)
+	hasher.WriteAbstain()
+	if hash != supported && hash != hasher.Hash() {
+		report.add(AggregatedHashMismatch, lid, "stored %s, computed %s", hash.String(), supported.String())
+	}
+	return nil
+}
+
+func (c *Checker) checkTransactions(report *Report) error {
+	if _, err := c.db.Exec(`select bt.tid, bt.bid, bt.layer, l.applied_block from block_transactions bt
+		inner join layers l on l.id = bt.layer
+		left join transactions t on t.id = bt.tid
+		where t.id is null and l.applied_block is not null
+		order by bt.layer asc;`, nil, func(stmt *sql.Statement) bool {
+		var (
+			tid     types.TransactionID
+			bid     types.BlockID
+			applied types.BlockID
+		)
+		stmt.ColumnBytes(0, tid[:])
+		// block id is stored in block_transactions padded to 32 bytes
+		stmt.ColumnBytes(1, bid[:])
+		stmt.ColumnBytes(3, applied[:])
+		if bid == applied {
+			report.add(MissingTransaction, types.LayerID(stmt.ColumnInt64(2)),
+				"transaction %s in block %s", tid.String(), bid.String())
+		}
+		return true
+	}); err != nil {
+		return fmt.Errorf("check block transactions: %w", err)
+	}
+	return nil
+}
+
+// Repair reverts the state to the layer preceding the first applied layer with revertable issues
+// in the report. The node re-applies reverted layers after restart, fetching missing data from peers.
+//
+// Returns the layer the state was reverted to, and false if there was nothing to revert.
+func (c *Checker) Repair(ctx context.Context, report *Report) (types.LayerID, bool, error) {
+	first, found := report.FirstInvalid()
+	if !found {
+		return 0, false, nil
+	}
+	genesis := types.GetEffectiveGenesis()
+	if !first.After(genesis) {
+		return 0, false, fmt.Errorf("genesis layer %s is invalid, database must be resynced", first)
+	}
+	revert := first.Sub(1)
+	// state, applied layers and index are reverted together, otherwise a crash in between
+	// leaves the state reverted while layers are still marked as applied
+	if err := c.db.WithTx(ctx, func(tx *sql.Tx) error {
+		if err := c.vm.RevertTx(tx, revert); err != nil {
+			return fmt.Errorf("revert state: %w", err)
+		}
+		if err := transactions.UndoLayers(tx, first); err != nil {
+			return fmt.Errorf("undo transactions: %w", err)
+		}
+		if err := layers.UnsetAppliedFrom(tx, first); err != nil {
+			return fmt.Errorf("unset applied: %w", err)
+		}
+		if err := indexer.Revert(tx, revert); err != nil {
+			return fmt.Errorf("revert index: %w", err)
+		}
+		return nil
+	}); err != nil {
+		return 0, false, fmt.Errorf("revert to %s: %w", revert, err)
+	}
+	c.logger.With().Info("reverted state", log.Stringer("revert_to", revert))
+	return revert, true, nil
+}
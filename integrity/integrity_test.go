@@ -0,0 +1,239 @@
+package integrity
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/spacemeshos/go-spacemesh/common/types"
+	vm "github.com/spacemeshos/go-spacemesh/genvm"
+	sdkwallet "github.com/spacemeshos/go-spacemesh/genvm/sdk/wallet"
+	"github.com/spacemeshos/go-spacemesh/hash"
+	"github.com/spacemeshos/go-spacemesh/indexer"
+	"github.com/spacemeshos/go-spacemesh/log/logtest"
+	"github.com/spacemeshos/go-spacemesh/signing"
+	"github.com/spacemeshos/go-spacemesh/sql"
+	"github.com/spacemeshos/go-spacemesh/sql/atxs"
+	"github.com/spacemeshos/go-spacemesh/sql/blocks"
+	"github.com/spacemeshos/go-spacemesh/sql/layers"
+	"github.com/spacemeshos/go-spacemesh/sql/transactions"
+	"github.com/spacemeshos/go-spacemesh/tortoise/opinionhash"
+)
+
+func TestMain(m *testing.M) {
+	types.SetLayersPerEpoch(4)
+	res := m.Run()
+	os.Exit(res)
+}
+
+// applyLayers applies n layers after genesis in the same way as mesh executor.
+// Every layer rewards the principal, the principal spawns wallet in the second layer
+// and spends from it in the following layers.
+func applyLayers(tb testing.TB, db *sql.Database, n int) []*types.Block {
+	tb.Helper()
+	genesis := types.GetEffectiveGenesis()
+	require.NoError(tb, layers.SetApplied(db, genesis, types.EmptyBlockID))
+	require.NoError(tb, layers.SetMeshHash(db, genesis, hash.Sum(nil)))
+
+	signer, err := signing.NewEdSigner()
+	require.NoError(tb, err)
+	pk := signer.PrivateKey()
+	principal := sdkwallet.Address(signer.PublicKey().Bytes())
+
+	state := vm.New(db, vm.WithLogger(logtest.New(tb)))
+	prev := hash.Sum(nil)
+	var created []*types.Block
+	for i := 1; i <= n; i++ {
+		lid := genesis.Add(uint32(i))
+		atx := types.NewActivationTx(types.NIPostChallenge{}, principal, nil, 1, nil)
+		atx.SetID(types.ATXID{byte(i)})
+		atx.SmesherID = types.RandomNodeID()
+		atx.SetEffectiveNumUnits(atx.NumUnits)
+		atx.SetReceived(time.Now())
+		vatx, err := atx.Verify(0, 1)
+		require.NoError(tb, err)
+		require.NoError(tb, atxs.Add(db, vatx))
+
+		var txs []types.Transaction
+		switch {
+		case i == 2:
+			txs = append(txs, types.Transaction{RawTx: types.NewRawTx(sdkwallet.SelfSpawn(pk, 0))})
+		case i > 2:
+			txs = append(txs, types.Transaction{RawTx: types.NewRawTx(
+				sdkwallet.Spend(pk, types.Address{1, 2, 3}, 100, types.Nonce(i-2)))})
+		}
+		block := types.NewExistingBlock(types.BlockID{byte(i)}, types.InnerBlock{
+			LayerIndex: lid,
+			TickHeight: uint64(i),
+			Rewards:    []types.AnyReward{{AtxID: atx.ID(), Weight: types.RatNum{Num: 1, Denom: 1}}},
+		})
+		for _, tx := range txs {
+			require.NoError(tb, transactions.Add(db, &tx, time.Now()))
+			block.TxIDs = append(block.TxIDs, tx.ID)
+		}
+		require.NoError(tb, blocks.Add(db, block))
+
+		_, executed, err := state.Apply(vm.ApplyContext{Layer: lid}, txs,
+			[]types.CoinbaseReward{{Coinbase: principal, Weight: types.RatNum{Num: 1, Denom: 1}}})
+		require.NoError(tb, err)
+		require.Len(tb, executed, len(txs))
+		require.NoError(tb, db.WithTx(context.Background(), func(dtx *sql.Tx) error {
+			for _, tx := range executed {
+				tx.Block = block.ID()
+				if err := transactions.AddResult(dtx, tx.ID, &tx.TransactionResult); err != nil {
+					return err
+				}
+			}
+			return nil
+		}))
+		require.NoError(tb, blocks.SetValid(db, block.ID()))
+		require.NoError(tb, layers.SetApplied(db, lid, block.ID()))
+
+		hasher := opinionhash.New()
+		hasher.WritePrevious(prev)
+		hasher.WriteSupport(block.ID(), block.TickHeight)
+		prev = hasher.Hash()
+		require.NoError(tb, layers.SetMeshHash(db, lid, prev))
+		created = append(created, block)
+	}
+	return created
+}
+
+func setLayerHash(tb testing.TB, db *sql.Database, column string, lid types.LayerID, value []byte) {
+	tb.Helper()
+	_, err := db.Exec("update layers set "+column+" = ?2 where id = ?1;", func(stmt *sql.Statement) {
+		stmt.BindInt64(1, int64(lid))
+		if value == nil {
+			stmt.BindNull(2)
+		} else {
+			stmt.BindBytes(2, value)
+		}
+	}, nil)
+	require.NoError(tb, err)
+}
+
+func issueKinds(report *Report) map[Kind]types.LayerID {
+	kinds := map[Kind]types.LayerID{}
+	for _, issue := range report.Issues {
+		if _, exists := kinds[issue.Kind]; !exists {
+			kinds[issue.Kind] = issue.Layer
+		}
+	}
+	return kinds
+}
+
+func TestCheckValid(t *testing.T) {
+	db := sql.InMemory()
+	applyLayers(t, db, 5)
+
+	report, err := New(db, WithLogger(logtest.New(t))).Check(context.Background())
+	require.NoError(t, err)
+	require.True(t, report.Valid(), "%v", report.Issues)
+	require.Equal(t, types.GetEffectiveGenesis().Add(5), report.Applied)
+}
+
+func TestCheckAndRepair(t *testing.T) {
+	db := sql.InMemory()
+	created := applyLayers(t, db, 5)
+	genesis := types.GetEffectiveGenesis()
+	require.NoError(t, indexer.New(db).Update(context.Background()))
+
+	_, err := db.Exec("update accounts set balance = balance + 1 where layer_updated = ?1;",
+		func(stmt *sql.Statement) {
+			stmt.BindInt64(1, int64(genesis.Add(4)))
+		}, nil)
+	require.NoError(t, err)
+	_, err = db.Exec("delete from blocks where id = ?1;", func(stmt *sql.Statement) {
+		stmt.BindBytes(1, created[2].ID().Bytes())
+	}, nil)
+	require.NoError(t, err)
+	tid := types.TransactionID{1}
+	require.NoError(t, transactions.AddToBlock(db, tid, genesis.Add(5), created[4].ID()))
+	setLayerHash(t, db, "aggregated_hash", genesis.Add(5), nil)
+	setLayerHash(t, db, "aggregated_hash", genesis.Add(4), types.Hash32{1}.Bytes())
+
+	checker := New(db, WithLogger(logtest.New(t)))
+	report, err := checker.Check(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, map[Kind]types.LayerID{
+		MissingBlock:           genesis.Add(3),
+		AccountsMismatch:       genesis.Add(4),
+		MissingTransaction:     genesis.Add(5),
+		MissingAggregatedHash:  genesis.Add(5),
+		AggregatedHashMismatch: genesis.Add(4),
+	}, issueKinds(report))
+	first, found := report.FirstInvalid()
+	require.True(t, found)
+	require.Equal(t, genesis.Add(3), first)
+
+	revert, repaired, err := checker.Repair(context.Background(), report)
+	require.NoError(t, err)
+	require.True(t, repaired)
+	require.Equal(t, genesis.Add(2), revert)
+
+	applied, err := layers.GetLastApplied(db)
+	require.NoError(t, err)
+	require.Equal(t, revert, applied)
+	indexed, err := indexer.LastIndexed(db)
+	require.NoError(t, err)
+	require.Equal(t, revert, indexed)
+
+	report, err = checker.Check(context.Background())
+	require.NoError(t, err)
+	require.True(t, report.Valid(), "%v", report.Issues)
+}
+
+func TestCheckHashFormat(t *testing.T) {
+	db := sql.InMemory()
+	applyLayers(t, db, 4)
+	genesis := types.GetEffectiveGenesis()
+	// layers applied by versions with different hash formats
+	setLayerHash(t, db, "state_hash", genesis.Add(2), types.Hash32{1}.Bytes())
+
+	checker := New(db, WithLogger(logtest.New(t)))
+	report, err := checker.Check(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, map[Kind]types.LayerID{
+		StateHashMismatch: genesis.Add(2),
+	}, issueKinds(report))
+	_, found := report.FirstInvalid()
+	require.False(t, found)
+	_, repaired, err := checker.Repair(context.Background(), report)
+	require.NoError(t, err)
+	require.False(t, repaired)
+}
+
+func TestCheckReplayConfig(t *testing.T) {
+	db := sql.InMemory()
+	applyLayers(t, db, 3)
+
+	// transactions are verified with the genesis id during replay,
+	// and become ineffective if it doesn't match the genesis id they were signed for
+	cfg := vm.DefaultConfig()
+	cfg.GenesisID = types.Hash20{1}
+	report, err := New(db, WithVMConfig(cfg)).Check(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, map[Kind]types.LayerID{
+		AccountsMismatch: types.GetEffectiveGenesis().Add(2),
+	}, issueKinds(report))
+}
+
+func TestRepairValid(t *testing.T) {
+	db := sql.InMemory()
+	applyLayers(t, db, 2)
+	checker := New(db)
+	report, err := checker.Check(context.Background())
+	require.NoError(t, err)
+	_, repaired, err := checker.Repair(context.Background(), report)
+	require.NoError(t, err)
+	require.False(t, repaired)
+}
+
+func TestCheckEmpty(t *testing.T) {
+	report, err := New(sql.InMemory()).Check(context.Background())
+	require.NoError(t, err)
+	require.True(t, report.Valid())
+}
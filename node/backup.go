@@ -0,0 +1,47 @@
+package node
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/spacemeshos/go-spacemesh/sql/backup"
+)
+
+// backupCmd creates a consistent copy of the node database.
+// It is safe to run while the node is running, as the database is opened in WAL mode.
+func backupCmd() *cobra.Command {
+	var out string
+	c := &cobra.Command{
+		Use:   "backup",
+		Short: "Write a consistent snapshot of the node database and its manifest",
+		Args:  cobra.NoArgs,
+		// errors are not caused by the usage, don't print it
+		SilenceUsage: true,
+		RunE: func(c *cobra.Command, args []string) error {
+			conf, err := loadConfig(c.Root())
+			if err != nil {
+				return fmt.Errorf("failed to initialize config: %w", err)
+			}
+			db, err := openExisting(conf)
+			if err != nil {
+				return err
+			}
+			defer db.Close()
+			manifest, err := backup.Backup(c.Context(), db, out)
+			if err != nil {
+				return err
+			}
+			data, err := json.MarshalIndent(manifest, "", "  ")
+			if err != nil {
+				return err
+			}
+			fmt.Fprintln(c.OutOrStdout(), string(data))
+			return nil
+		},
+	}
+	c.Flags().StringVarP(&out, "out", "o", "", "directory for the backup, must not contain a previous backup")
+	c.MarkFlagRequired("out")
+	return c
+}
@@ -0,0 +1,87 @@
+package node
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/spacemeshos/go-spacemesh/common/types"
+	"github.com/spacemeshos/go-spacemesh/integrity"
+	"github.com/spacemeshos/go-spacemesh/log"
+	"github.com/spacemeshos/go-spacemesh/sql/recovery"
+)
+
+// checkCmd verifies consistency of the node database and optionally reverts the state
+// to the last consistent layer.
+func checkCmd() *cobra.Command {
+	var repair bool
+	c := &cobra.Command{
+		Use:   "check",
+		Short: "Verify consistency of the node database",
+		Long: `Verify consistency of the node database.
+Applied layers are replayed and compared with the stored state.
+With --repair the state is reverted to the layer before the first inconsistent layer,
+and the node re-applies the following layers after restart.
+Hashes that differ only in format from the replayed ones are reported, but not repaired.
+The node must be stopped.`,
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		RunE: func(c *cobra.Command, args []string) error {
+			conf, err := loadConfig(c.Root())
+			if err != nil {
+				return fmt.Errorf("failed to initialize config: %w", err)
+			}
+			app := &App{Config: conf}
+			if err := app.Lock(); err != nil {
+				return fmt.Errorf("node must be stopped: %w", err)
+			}
+			defer app.Unlock()
+			db, err := openExisting(conf)
+			if err != nil {
+				return err
+			}
+			defer db.Close()
+
+			types.SetLayersPerEpoch(conf.LayersPerEpoch)
+			restore, err := recovery.CheckpointInfo(db)
+			if err != nil {
+				return err
+			}
+			if restore != 0 {
+				types.SetEffectiveGenesis(restore.Uint32() - 1)
+			}
+
+			checker := integrity.New(db,
+				integrity.WithLogger(log.NewNop()),
+				integrity.WithVMConfig(app.vmConfig()),
+			)
+			report, err := checker.Check(c.Context())
+			if err != nil {
+				return err
+			}
+			out := c.OutOrStdout()
+			for _, issue := range report.Issues {
+				fmt.Fprintln(out, issue.String())
+			}
+			fmt.Fprintf(out, "checked layers up to %s: %d issues\n", report.Applied, len(report.Issues))
+			if report.Valid() {
+				return nil
+			}
+			if !repair {
+				return fmt.Errorf("database is inconsistent")
+			}
+			revert, reverted, err := checker.Repair(c.Context(), report)
+			if err != nil {
+				return err
+			}
+			if reverted {
+				fmt.Fprintf(out, "state reverted to layer %s\n", revert)
+			} else {
+				fmt.Fprintln(out, "no applied layers to revert")
+			}
+			return nil
+		},
+	}
+	c.Flags().BoolVar(&repair, "repair", false, "revert the state to the last consistent layer")
+	return c
+}
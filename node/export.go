@@ -0,0 +1,58 @@
+package node
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/spacemeshos/go-spacemesh/common/types"
+	"github.com/spacemeshos/go-spacemesh/sql/export"
+)
+
+// exportCmd writes chain data for a range of layers into newline-delimited JSON files.
+// It is safe to run while the node is running, data is read in a single transaction.
+func exportCmd() *cobra.Command {
+	var (
+		out      string
+		from, to uint32
+	)
+	c := &cobra.Command{
+		Use:   "export",
+		Short: "Export layers, blocks, transactions, rewards, atxs and malfeasance proofs as JSON lines",
+		Long: `Export layers, blocks, transactions, rewards, atxs and malfeasance proofs as JSON lines.
+Every kind of records is written into a separate file in the output directory,
+manifest.json describes the exported range and the number of records in every file.
+The range is capped by the last applied layer.`,
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		RunE: func(c *cobra.Command, args []string) error {
+			conf, err := loadConfig(c.Root())
+			if err != nil {
+				return fmt.Errorf("failed to initialize config: %w", err)
+			}
+			types.SetLayersPerEpoch(conf.LayersPerEpoch)
+			db, err := openExisting(conf)
+			if err != nil {
+				return err
+			}
+			defer db.Close()
+			manifest, err := export.Export(c.Context(), db, out, types.LayerID(from), types.LayerID(to))
+			if err != nil {
+				return err
+			}
+			data, err := json.MarshalIndent(manifest, "", "  ")
+			if err != nil {
+				return err
+			}
+			fmt.Fprintln(c.OutOrStdout(), string(data))
+			return nil
+		},
+	}
+	c.Flags().StringVarP(&out, "out", "o", "", "directory for the export, must not contain a previous export")
+	c.Flags().Uint32Var(&from, "from", 0, "first exported layer")
+	c.Flags().Uint32Var(&to, "to", 0, "last exported layer")
+	c.MarkFlagRequired("out")
+	c.MarkFlagRequired("to")
+	return c
+}
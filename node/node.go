@@ -50,6 +50,7 @@ import (
 	"github.com/spacemeshos/go-spacemesh/hare"
 	"github.com/spacemeshos/go-spacemesh/hare/eligibility"
 	"github.com/spacemeshos/go-spacemesh/hash"
+	"github.com/spacemeshos/go-spacemesh/indexer"
 	"github.com/spacemeshos/go-spacemesh/layerpatrol"
 	"github.com/spacemeshos/go-spacemesh/log"
 	"github.com/spacemeshos/go-spacemesh/malfeasance"
@@ -61,6 +62,8 @@ import (
 	"github.com/spacemeshos/go-spacemesh/p2p"
 	"github.com/spacemeshos/go-spacemesh/p2p/pubsub"
 	"github.com/spacemeshos/go-spacemesh/proposals"
+	"github.com/spacemeshos/go-spacemesh/prune"
+	"github.com/spacemeshos/go-spacemesh/replica"
 	"github.com/spacemeshos/go-spacemesh/signing"
 	"github.com/spacemeshos/go-spacemesh/sql"
 	"github.com/spacemeshos/go-spacemesh/sql/layers"
@@ -111,6 +114,10 @@ const (
 	ExecutorLogger         = "executor"
 	MalfeasanceLogger      = "malfeasance"
 	BootstrapLogger        = "bootstrap"
+	PruneLogger            = "prune"
+	IndexerLogger          = "indexer"
+	DatabaseLogger         = "database"
+	ReplicaLogger          = "replica"
 )
 
 func GetCommand() *cobra.Command {
@@ -205,6 +212,11 @@ func GetCommand() *cobra.Command {
 		},
 	}
 	c.AddCommand(&versionCmd)
+	c.AddCommand(backupCmd())
+	c.AddCommand(schemaCmd())
+	c.AddCommand(checkCmd())
+	c.AddCommand(replicaCmd())
+	c.AddCommand(exportCmd())
 
 	return c
 }
@@ -316,10 +328,12 @@ type App struct {
 	grpcPublicService  *grpcserver.Server
 	grpcPrivateService *grpcserver.Server
 	jsonAPIService     *grpcserver.JSONHTTPServer
+	jsonPrivateService *grpcserver.JSONHTTPServer
 	syncer             *syncer.Syncer
 	proposalListener   *proposals.Handler
 	proposalBuilder    *miner.ProposalBuilder
 	mesh               *mesh.Mesh
+	replica            *replica.Replica
 	cachedDB           *datastore.CachedDB
 	clock              *timesync.NodeClock
 	hare               *hare.Hare
@@ -557,16 +571,14 @@ func (app *App) initServices(ctx context.Context) error {
 	validator := activation.NewValidator(poetDb, app.Config.POST, nipostValidatorLogger, app.postVerifier)
 	app.validator = validator
 
-	cfg := vm.DefaultConfig()
-	cfg.GasLimit = app.Config.BlockGasLimit
-	cfg.GenesisID = app.Config.Genesis.GenesisID()
 	state := vm.New(app.db,
-		vm.WithConfig(cfg),
+		vm.WithConfig(app.vmConfig()),
 		vm.WithLogger(app.addLogger(VMLogger, lg)))
 	app.conState = txs.NewConservativeState(state, app.db,
 		txs.WithCSConfig(txs.CSConfig{
 			BlockGasLimit:     app.Config.BlockGasLimit,
 			NumTXsPerProposal: app.Config.TxsPerProposal,
+			Mempool:           app.Config.Mempool,
 		}),
 		txs.WithLogger(app.addLogger(ConStateLogger, lg)))
 
@@ -990,6 +1002,30 @@ func (app *App) listenToUpdates(ctx context.Context) {
 	})
 }
 
+// explainSlowQueries periodically logs query plans of the queries with the largest total
+// duration of slow executions.
+func explainSlowQueries(ctx context.Context, logger log.Log, db *sql.Database, interval time.Duration) {
+	const top = 10
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		for _, stats := range db.ExplainSlowQueries(top) {
+			logger.With().Info("slow query",
+				log.String("query", stats.Query),
+				log.Int("count", stats.Count),
+				log.Duration("total", stats.Total),
+				log.Duration("max", stats.Max),
+				log.String("plan", stats.Plan),
+			)
+		}
+	}
+}
+
 func (app *App) startServices(ctx context.Context) error {
 	if err := app.fetcher.Start(); err != nil {
 		return fmt.Errorf("failed to start fetcher: %w", err)
@@ -1000,6 +1036,31 @@ func (app *App) startServices(ctx context.Context) error {
 	})
 	app.syncer.Start()
 	app.beaconProtocol.Start(ctx)
+	if app.Config.Prune.Enabled {
+		pruner := prune.New(app.db, app.tortoise, app.Config.Tortoise.Hdist, app.Config.Tortoise.WindowSize,
+			prune.WithLogger(app.addLogger(PruneLogger, app.log)),
+			prune.WithConfig(app.Config.Prune),
+		)
+		app.eg.Go(func() error {
+			return pruner.Run(ctx)
+		})
+	}
+	if app.Config.DatabaseSlowQueryThreshold > 0 && app.Config.DatabaseSlowQueryExplainInterval > 0 {
+		logger := app.addLogger(DatabaseLogger, app.log)
+		app.eg.Go(func() error {
+			explainSlowQueries(ctx, logger, app.db, app.Config.DatabaseSlowQueryExplainInterval)
+			return nil
+		})
+	}
+	if app.Config.Indexer.Enabled {
+		idx := indexer.New(app.db,
+			indexer.WithLogger(app.addLogger(IndexerLogger, app.log)),
+			indexer.WithConfig(app.Config.Indexer),
+		)
+		app.eg.Go(func() error {
+			return idx.Run(ctx)
+		})
+	}
 
 	app.blockGen.Start()
 	app.certifier.Start()
@@ -1032,19 +1093,44 @@ func (app *App) startServices(ctx context.Context) error {
 	return nil
 }
 
+// vmConfig returns config of the vm that applies layers.
+func (app *App) vmConfig() vm.Config {
+	cfg := vm.DefaultConfig()
+	cfg.GasLimit = app.Config.BlockGasLimit
+	cfg.GenesisID = app.Config.Genesis.GenesisID()
+	cfg.EscrowLayer = types.LayerID(app.Config.EscrowLayer)
+	cfg.SpendManyLayer = types.LayerID(app.Config.SpendManyLayer)
+	return cfg
+}
+
 func (app *App) initService(ctx context.Context, svc grpcserver.Service) (grpcserver.ServiceAPI, error) {
+	if app.replica != nil {
+		return app.initReplicaService(svc)
+	}
 	// TODO(mafa): add app.log.WithName("service") to all services
 	switch svc {
 	case grpcserver.Debug:
-		return grpcserver.NewDebugService(app.db, app.conState, app.host, app.hOracle, app.log.WithName("grpc.Debug")), nil
+		var tracer *pubsub.Tracer
+		if app.host != nil {
+			tracer = app.host.GossipTracer()
+		}
+		return grpcserver.NewDebugService(app.db, app.conState, app.host, app.hOracle, tracer, app.log.WithName("grpc.Debug")), nil
 	case grpcserver.GlobalState:
 		return grpcserver.NewGlobalStateService(app.mesh, app.conState, app.log.WithName("grpc.GlobalState")), nil
 	case grpcserver.Mesh:
 		return grpcserver.NewMeshService(app.cachedDB, app.mesh, app.conState, app.clock, app.Config.LayersPerEpoch, app.Config.Genesis.GenesisID(), app.Config.LayerDuration, app.Config.LayerAvgSize, uint32(app.Config.TxsPerProposal), app.log.WithName("grpc.Mesh")), nil
 	case grpcserver.Node:
-		return grpcserver.NewNodeService(app.host, app.mesh, app.clock, app.syncer, cmd.Version, cmd.Commit, app.log.WithName("grpc.Node")), nil
+		// database is opened by setupDBs before api services are started, it is nil only
+		// when api services are started without the rest of the node, as in node tests
+		dbVersion := func() (int, error) {
+			if app.db == nil {
+				return 0, errors.New("database is not open")
+			}
+			return sql.Version(app.db)
+		}
+		return grpcserver.NewNodeService(app.host, app.mesh, app.clock, app.syncer, cmd.Version, cmd.Commit, dbVersion, app.log.WithName("grpc.Node")), nil
 	case grpcserver.Admin:
-		return grpcserver.NewAdminService(app.db, app.Config.DataDir(), app.log.WithName("grpc.Admin")), nil
+		return grpcserver.NewAdminService(app.db, app.Config.DataDir(), app.host, app.conState, app.log.WithName("grpc.Admin")), nil
 	case grpcserver.Smesher:
 		return grpcserver.NewSmesherService(app.postSetupMgr, app.atxBuilder, app.Config.API.SmesherStreamInterval, app.Config.SMESHING.Opts, app.log.WithName("grpc.Smesher")), nil
 	case grpcserver.Transaction:
@@ -1068,8 +1154,9 @@ func (app *App) startAPIServices(ctx context.Context) error {
 	logger := app.addLogger(GRPCLogger, app.log).Zap()
 	grpczap.SetGrpcLoggerV2(grpclog, logger)
 	var (
-		unique = map[grpcserver.Service]struct{}{}
-		public []grpcserver.ServiceAPI
+		unique  = map[grpcserver.Service]struct{}{}
+		public  []grpcserver.ServiceAPI
+		private []grpcserver.ServiceAPI
 	)
 	if len(app.Config.API.PublicServices) > 0 {
 		app.grpcPublicService = app.newGrpc(logger, app.Config.API.PublicListener)
@@ -1098,6 +1185,7 @@ func (app *App) startAPIServices(ctx context.Context) error {
 			return err
 		}
 		gsvc.RegisterService(app.grpcPrivateService)
+		private = append(private, gsvc)
 		unique[svc] = struct{}{}
 	}
 	if len(app.Config.API.JSONListener) > 0 {
@@ -1107,6 +1195,13 @@ func (app *App) startAPIServices(ctx context.Context) error {
 		app.jsonAPIService = grpcserver.NewJSONHTTPServer(app.Config.API.JSONListener, app.log.WithName("grpc.JSON"))
 		app.jsonAPIService.StartService(ctx, public...)
 	}
+	if len(app.Config.API.PrivateJSONListener) > 0 {
+		if len(private) == 0 {
+			return fmt.Errorf("can't start private json server without private services")
+		}
+		app.jsonPrivateService = grpcserver.NewJSONHTTPServer(app.Config.API.PrivateJSONListener, app.log.WithName("grpc.PrivateJSON"))
+		app.jsonPrivateService.StartService(ctx, private...)
+	}
 	if app.grpcPublicService != nil {
 		app.grpcPublicService.Start()
 	}
@@ -1122,6 +1217,11 @@ func (app *App) stopServices(ctx context.Context) {
 			app.log.With().Error("error stopping json gateway server", log.Err(err))
 		}
 	}
+	if app.jsonPrivateService != nil {
+		if err := app.jsonPrivateService.Shutdown(ctx); err != nil {
+			app.log.With().Error("error stopping private json gateway server", log.Err(err))
+		}
+	}
 
 	if app.grpcPublicService != nil {
 		app.log.Info("stopping public grpc service")
@@ -1270,11 +1370,17 @@ func (app *App) setupDBs(ctx context.Context, lg log.Log, dbPath string) error {
 	sqlDB, err := sql.Open("file:"+filepath.Join(dbPath, dbFile),
 		sql.WithConnections(app.Config.DatabaseConnections),
 		sql.WithLatencyMetering(app.Config.DatabaseLatencyMetering),
+		sql.WithSlowQueryLog(app.Config.DatabaseSlowQueryThreshold),
 	)
 	if err != nil {
 		return fmt.Errorf("open sqlite db %w", err)
 	}
 	app.db = sqlDB
+	version, err := sql.Version(sqlDB)
+	if err != nil {
+		return err
+	}
+	lg.With().Info("database schema", log.Int("version", version))
 	if app.Config.CollectMetrics {
 		app.dbMetrics = dbmetrics.NewDBMetricsCollector(ctx, sqlDB, app.addLogger(StateDbLogger, lg), 5*time.Minute)
 	}
@@ -0,0 +1,182 @@
+package node
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+
+	"github.com/spacemeshos/go-spacemesh/api/grpcserver"
+	"github.com/spacemeshos/go-spacemesh/common/types"
+	"github.com/spacemeshos/go-spacemesh/config"
+	"github.com/spacemeshos/go-spacemesh/datastore"
+	"github.com/spacemeshos/go-spacemesh/events"
+	vm "github.com/spacemeshos/go-spacemesh/genvm"
+	"github.com/spacemeshos/go-spacemesh/log"
+	"github.com/spacemeshos/go-spacemesh/metrics"
+	"github.com/spacemeshos/go-spacemesh/replica"
+	"github.com/spacemeshos/go-spacemesh/sql"
+	"github.com/spacemeshos/go-spacemesh/sql/recovery"
+	"github.com/spacemeshos/go-spacemesh/timesync"
+	"github.com/spacemeshos/go-spacemesh/txs"
+)
+
+// replicaCmd serves read-only api from the database of another node.
+func replicaCmd() *cobra.Command {
+	var (
+		path string
+		cfg  = replica.DefaultConfig()
+	)
+	c := &cobra.Command{
+		Use:   "replica",
+		Short: "Serve read-only api from the database written by another node",
+		Long: `Serve read-only api from the database written by another node.
+Database is opened read-only and the node doesn't start consensus, sync or p2p.
+Only MeshService, GlobalStateService and TransactionService can be enabled, transactions
+can't be submitted. The primary node keeps database in WAL mode, so that its changes are visible
+to the replica. The replica doesn't take the lock on the data directory.
+
+Only layer events are followed by polling the database: layer and node status streams are updated,
+while transaction, account and reward streams stay silent. Clients of the replica should poll
+the corresponding queries instead.`,
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		RunE: func(c *cobra.Command, args []string) error {
+			conf, err := loadConfig(c.Root())
+			if err != nil {
+				return fmt.Errorf("failed to initialize config: %w", err)
+			}
+			if conf.LOGGING.Encoder == config.JSONLogEncoder {
+				log.JSONLog(true)
+			}
+			if path == "" {
+				path = filepath.Join(conf.DataDir(), dbFile)
+			}
+			app := New(
+				WithConfig(conf),
+				WithLog(log.RegisterHooks(
+					log.NewWithLevel("replica", zap.NewAtomicLevelAt(zap.DebugLevel)),
+					events.EventHook()),
+				),
+			)
+			types.SetLayersPerEpoch(app.Config.LayersPerEpoch)
+			ctx, cancel := signal.NotifyContext(c.Context(), os.Interrupt, syscall.SIGTERM)
+			defer cancel()
+			err = app.StartReplica(ctx, path, cfg)
+
+			cleanupCtx, cleanupCancel := context.WithTimeout(context.Background(), 30*time.Second)
+			defer cleanupCancel()
+			app.Cleanup(cleanupCtx)
+			_ = app.eg.Wait()
+			return err
+		},
+	}
+	c.Flags().StringVar(&path, "db", "", "path to the database, defaults to the database in the data directory")
+	c.Flags().DurationVar(&cfg.PollInterval, "poll-interval", cfg.PollInterval,
+		"interval between checks for the layers applied by the primary node")
+	return c
+}
+
+// StartReplica serves read-only api from the database at the path until context is canceled.
+func (app *App) StartReplica(ctx context.Context, path string, cfg replica.Config) error {
+	app.setupLogging()
+	app.errCh = make(chan error, 1)
+	if _, err := os.Stat(path); err != nil {
+		return fmt.Errorf("database %s: %w", path, err)
+	}
+	db, err := sql.Open("file:"+path,
+		sql.WithReadOnly(),
+		sql.WithConnections(app.Config.DatabaseConnections),
+		sql.WithLatencyMetering(app.Config.DatabaseLatencyMetering),
+		sql.WithSlowQueryLog(app.Config.DatabaseSlowQueryThreshold),
+	)
+	if err != nil {
+		return fmt.Errorf("open sqlite db %w", err)
+	}
+	app.db = db
+	version, err := sql.Version(db)
+	if err != nil {
+		return err
+	}
+	app.log.With().Info("replica database",
+		log.String("path", path),
+		log.Int("version", version),
+	)
+	restore, err := recovery.CheckpointInfo(db)
+	if err != nil {
+		return err
+	}
+	if restore != 0 {
+		types.SetEffectiveGenesis(restore.Uint32() - 1)
+	}
+	// identities are marked malicious by the primary, negative results can't be cached
+	app.cachedDB = datastore.NewCachedDB(db, app.addLogger(CachedDBLogger, app.log),
+		datastore.WithoutMalfeasanceCache())
+
+	gTime, err := time.Parse(time.RFC3339, app.Config.Genesis.GenesisTime)
+	if err != nil {
+		return fmt.Errorf("cannot parse genesis time %s: %w", app.Config.Genesis.GenesisTime, err)
+	}
+	app.clock, err = timesync.NewClock(
+		timesync.WithLayerDuration(app.Config.LayerDuration),
+		timesync.WithTickInterval(1*time.Second),
+		timesync.WithGenesisTime(gTime),
+		timesync.WithLogger(app.addLogger(ClockLogger, app.log)),
+	)
+	if err != nil {
+		return fmt.Errorf("cannot create clock: %w", err)
+	}
+
+	state := vm.New(db,
+		vm.WithConfig(app.vmConfig()),
+		vm.WithLogger(app.addLogger(VMLogger, app.log)))
+	// transactions are never added to the cache, projections are read from the state
+	app.conState = txs.NewConservativeState(state, db,
+		txs.WithLogger(app.addLogger(ConStateLogger, app.log)))
+
+	app.replica = replica.New(app.cachedDB,
+		replica.WithConfig(cfg),
+		replica.WithLogger(app.addLogger(ReplicaLogger, app.log)),
+	)
+	if err := app.replica.Update(); err != nil {
+		return err
+	}
+	app.eg.Go(func() error {
+		if err := app.replica.Run(ctx); err != nil {
+			app.errCh <- err
+		}
+		return nil
+	})
+	if app.Config.CollectMetrics {
+		metrics.StartMetricsServer(app.Config.MetricsPort)
+	}
+	if err := app.startAPIServices(ctx); err != nil {
+		return err
+	}
+	events.SubscribeToLayers(app.clock)
+	app.log.Info("replica started")
+	select {
+	case <-ctx.Done():
+		return nil
+	case err = <-app.errCh:
+		return err
+	}
+}
+
+func (app *App) initReplicaService(svc grpcserver.Service) (grpcserver.ServiceAPI, error) {
+	switch svc {
+	case grpcserver.GlobalState:
+		return grpcserver.NewGlobalStateService(app.replica, app.conState, app.log.WithName("grpc.GlobalState")), nil
+	case grpcserver.Mesh:
+		return grpcserver.NewMeshService(app.cachedDB, app.replica, app.conState, app.clock, app.Config.LayersPerEpoch, app.Config.Genesis.GenesisID(), app.Config.LayerDuration, app.Config.LayerAvgSize, uint32(app.Config.TxsPerProposal), app.log.WithName("grpc.Mesh")), nil
+	case grpcserver.Transaction:
+		return grpcserver.NewTransactionService(app.db, app.replica, app.replica, app.conState, app.replica, app.replica, app.log.WithName("grpc.Transaction")), nil
+	}
+	return nil, fmt.Errorf("service %s is not available in replica mode", svc)
+}
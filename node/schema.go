@@ -0,0 +1,102 @@
+package node
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+
+	"github.com/spacemeshos/go-spacemesh/config"
+	"github.com/spacemeshos/go-spacemesh/sql"
+)
+
+// schemaCmd reports and rolls back the version of the database schema.
+func schemaCmd() *cobra.Command {
+	c := &cobra.Command{
+		Use:   "schema",
+		Short: "Manage the version of the node database schema",
+	}
+	c.AddCommand(&cobra.Command{
+		Use:          "version",
+		Short:        "Show the version of the database schema and the version supported by this release",
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		RunE: func(c *cobra.Command, args []string) error {
+			conf, err := loadConfig(c.Root())
+			if err != nil {
+				return fmt.Errorf("failed to initialize config: %w", err)
+			}
+			db, err := openExisting(conf)
+			if err != nil {
+				return err
+			}
+			defer db.Close()
+			version, err := sql.Version(db)
+			if err != nil {
+				return err
+			}
+			supported, err := sql.SupportedVersion()
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(c.OutOrStdout(), "database: %d\nsupported: %d\n", version, supported)
+			return nil
+		},
+	})
+
+	var target int
+	rollbackCmd := &cobra.Command{
+		Use:   "rollback",
+		Short: "Revert database migrations applied after the target version",
+		Long: `Revert database migrations applied after the target version.
+Run it with the release that applied the migrations before downgrading the node.
+The node must be stopped.`,
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		RunE: func(c *cobra.Command, args []string) error {
+			conf, err := loadConfig(c.Root())
+			if err != nil {
+				return fmt.Errorf("failed to initialize config: %w", err)
+			}
+			app := &App{Config: conf}
+			if err := app.Lock(); err != nil {
+				return fmt.Errorf("node must be stopped: %w", err)
+			}
+			defer app.Unlock()
+			db, err := openExisting(conf)
+			if err != nil {
+				return err
+			}
+			defer db.Close()
+			if err := db.WithTx(context.Background(), func(tx *sql.Tx) error {
+				return sql.Rollback(tx, target)
+			}); err != nil {
+				return err
+			}
+			version, err := sql.Version(db)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(c.OutOrStdout(), "database: %d\n", version)
+			return nil
+		},
+	}
+	rollbackCmd.Flags().IntVar(&target, "to", 0, "version of the schema after rollback")
+	rollbackCmd.MarkFlagRequired("to")
+	c.AddCommand(rollbackCmd)
+	return c
+}
+
+// openExisting opens the node database without applying migrations.
+func openExisting(conf *config.Config) (*sql.Database, error) {
+	path := filepath.Join(conf.DataDir(), dbFile)
+	if _, err := os.Stat(path); err != nil {
+		return nil, fmt.Errorf("database %s: %w", path, err)
+	}
+	return sql.Open("file:"+path,
+		sql.WithMigrations(nil),
+		sql.WithConnections(1),
+	)
+}
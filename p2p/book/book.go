@@ -4,6 +4,7 @@ import (
 	"bufio"
 	"container/list"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"hash/crc64"
 	"io"
@@ -19,6 +20,9 @@ import (
 
 const SELF ID = "SELF"
 
+// ErrNotFound is returned if the peer is not in the book.
+var ErrNotFound = errors.New("peer not found")
+
 type ID = string
 
 type Address = ma.Multiaddr
@@ -86,6 +90,17 @@ type addressInfo struct {
 	Raw       jsonAddress `json:"raw"`
 	Class     class       `json:"class"`
 	Connected bool        `json:"connected"`
+	// connection history and operator decisions, see Peer.
+	// times are in unix seconds.
+	Protocols   []string `json:"protocols,omitempty"`
+	FirstSeen   int64    `json:"first_seen,omitempty"`
+	LastSeen    int64    `json:"last_seen,omitempty"`
+	Inbound     int      `json:"inbound,omitempty"`
+	Dials       int      `json:"dials,omitempty"`
+	Outbound    int      `json:"outbound,omitempty"`
+	Pinned      bool     `json:"pinned,omitempty"`
+	Banned      bool     `json:"banned,omitempty"`
+	BannedUntil int64    `json:"banned_until,omitempty"`
 
 	shareable bool // true if item is in shareable array
 	bucket    bucket
@@ -94,10 +109,87 @@ type addressInfo struct {
 	success   int
 }
 
+func (a *addressInfo) banned(now time.Time) bool {
+	return a.Banned && (a.BannedUntil == 0 || now.Unix() < a.BannedUntil)
+}
+
+// dialable is false for peers that are recorded without known address,
+// such as peers that connected to us or that were banned by id.
+func (a *addressInfo) dialable() bool {
+	return a.Raw.Address != nil
+}
+
+func (a *addressInfo) peer() Peer {
+	p := Peer{
+		ID:        a.ID,
+		Protocols: a.Protocols,
+		FirstSeen: unixTime(a.FirstSeen),
+		LastSeen:  unixTime(a.LastSeen),
+		Connected: a.Connected,
+		Inbound:   a.Inbound,
+		Dials:     a.Dials,
+		Outbound:  a.Outbound,
+		Pinned:    a.Pinned,
+		Banned:    a.Banned,
+	}
+	if a.dialable() {
+		p.Address = a.Raw.String()
+	}
+	if a.Banned {
+		p.BannedUntil = unixTime(a.BannedUntil)
+	}
+	return p
+}
+
+func unixTime(sec int64) time.Time {
+	if sec == 0 {
+		return time.Time{}
+	}
+	return time.Unix(sec, 0)
+}
+
+// Peer is the record about a peer in the book.
+type Peer struct {
+	ID ID `json:"id"`
+	// Address is the address the peer is dialed on, empty if it is not known.
+	Address string `json:"address,omitempty"`
+	// Protocols supported by the peer, as reported when it was connected last time.
+	Protocols []string  `json:"protocols,omitempty"`
+	FirstSeen time.Time `json:"first_seen"`
+	LastSeen  time.Time `json:"last_seen"`
+	// Connected is true if the peer is connected now.
+	Connected bool `json:"connected"`
+	// Inbound is the number of connections accepted from the peer.
+	Inbound int `json:"inbound"`
+	// Dials is the number of dials to the peer, Outbound is the number of successful ones.
+	Dials    int `json:"dials"`
+	Outbound int `json:"outbound"`
+	// Pinned peers are protected from connection pruning and reconnected if disconnected.
+	Pinned bool `json:"pinned"`
+	// Banned peers are neither dialed nor accepted. Ban is permanent if BannedUntil is zero.
+	Banned      bool      `json:"banned"`
+	BannedUntil time.Time `json:"banned_until,omitempty"`
+}
+
+// Reachability is the share of successful dials to the peer, 0 if the peer was never dialed.
+func (p *Peer) Reachability() float64 {
+	if p.Dials == 0 {
+		return 0
+	}
+	return float64(p.Outbound) / float64(p.Dials)
+}
+
 type jsonAddress struct {
 	Address
 }
 
+func (u jsonAddress) MarshalJSON() ([]byte, error) {
+	if u.Address == nil {
+		return json.Marshal("")
+	}
+	return json.Marshal(u.Address.String())
+}
+
 func (u *jsonAddress) UnmarshalJSON(data []byte) error {
 	// i didn't manage to find a way to use UnmarshalJSON method on the
 	// private multiaddr type
@@ -105,6 +197,10 @@ func (u *jsonAddress) UnmarshalJSON(data []byte) error {
 	if err := json.Unmarshal(data, &s); err != nil {
 		return err
 	}
+	if len(s) == 0 {
+		u.Address = nil
+		return nil
+	}
 	addr, err := ma.NewMultiaddr(s)
 	if err != nil {
 		return err
@@ -129,12 +225,20 @@ func WithRand(seed int64) Opt {
 	}
 }
 
+// WithTime overwrites the source of current time.
+func WithTime(now func() time.Time) Opt {
+	return func(b *Book) {
+		b.now = now
+	}
+}
+
 func New(opts ...Opt) *Book {
 	b := &Book{
 		limit:     50000,
 		known:     map[ID]*addressInfo{},
 		queue:     list.New(),
 		rng:       rand.New(rand.NewSource(time.Now().Unix())),
+		now:       time.Now,
 		shareable: []*addressInfo{},
 	}
 	for _, opt := range opts {
@@ -143,13 +247,18 @@ func New(opts ...Opt) *Book {
 	return b
 }
 
+// Book keeps addresses of peers for the peer exchange, and records their connection history,
+// protocols they support and whether the operator pinned or banned them.
 type Book struct {
 	mu        sync.Mutex
 	limit     int
 	known     map[ID]*addressInfo
 	queue     *list.List
 	rng       *rand.Rand
+	now       func() time.Time
 	shareable []*addressInfo
+	// connected are peers that were connected when the book was persisted.
+	connected []Address
 }
 
 func (b *Book) Add(src, id ID, raw Address) {
@@ -176,12 +285,35 @@ func (b *Book) Add(src, id ID, raw Address) {
 		b.shareable = append(b.shareable, addr)
 		b.queue.PushBack(addr)
 		b.known[id] = addr
+	} else if !addr.dialable() {
+		addr.Raw.Address = raw
+		addr.bucket = bucket
+		if !addr.shareable && addr.Class >= learned {
+			addr.shareable = true
+			b.shareable = append(b.shareable, addr)
+		}
+		b.queue.PushBack(addr)
 	} else if addr.Raw.Address != raw && !addr.protected {
 		addr.Raw.Address = raw
 		addr.bucket = bucket
 	}
 }
 
+// record returns the peer, and adds it without address if it is not known (non-thread-safe).
+// Returns nil if the book is full, unless force is true.
+func (b *Book) record(id ID, force bool) *addressInfo {
+	addr := b.known[id]
+	if addr != nil {
+		return addr
+	}
+	if len(b.known) >= b.limit && !force {
+		return nil
+	}
+	addr = &addressInfo{ID: id, Class: learned}
+	b.known[id] = addr
+	return addr
+}
+
 type Event int
 
 const (
@@ -214,7 +346,7 @@ func (b *Book) Update(id ID, events ...Event) {
 			addr.Connected = false
 		case Success, Fail:
 			b.queue.PushBack(addr)
-			if addr.protected {
+			if addr.protected || addr.Pinned || addr.Banned {
 				continue
 			}
 			if event == Success {
@@ -272,6 +404,9 @@ func (b *Book) Recover(r io.Reader) error {
 	b.known = known
 	queue := []*addressInfo{}
 	for _, addr := range b.known {
+		if !addr.dialable() {
+			continue
+		}
 		addr.bucket = bucketize(addr.Raw.Address)
 		queue = append(queue, addr)
 		if addr.Class >= learned {
@@ -289,12 +424,203 @@ func (b *Book) Recover(r io.Reader) error {
 			return queue[i].ID < queue[j].ID
 		}
 	})
+	b.connected = nil
 	for _, addr := range queue {
 		b.queue.PushBack(addr)
+		if addr.Connected {
+			b.connected = append(b.connected, addr.Raw.Address)
+		}
+	}
+	for _, addr := range b.known {
+		addr.Connected = false
+	}
+	return nil
+}
+
+// Recovered returns addresses of the peers that were connected when the book was persisted.
+func (b *Book) Recovered() []Address {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.connected
+}
+
+// Get returns the record about the peer.
+func (b *Book) Get(id ID) (Peer, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	addr := b.known[id]
+	if addr == nil {
+		return Peer{}, false
+	}
+	return addr.peer(), true
+}
+
+// Peers returns records about all peers in the book, the most recently seen first.
+func (b *Book) Peers() []Peer {
+	b.mu.Lock()
+	rst := make([]Peer, 0, len(b.known))
+	for _, addr := range b.known {
+		rst = append(rst, addr.peer())
+	}
+	b.mu.Unlock()
+	sort.Slice(rst, func(i, j int) bool {
+		if rst[i].LastSeen.Equal(rst[j].LastSeen) {
+			return rst[i].ID < rst[j].ID
+		}
+		return rst[i].LastSeen.After(rst[j].LastSeen)
+	})
+	return rst
+}
+
+// Remove deletes the peer from the book, including pin and ban.
+func (b *Book) Remove(id ID) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	addr := b.known[id]
+	if addr == nil {
+		return fmt.Errorf("%w: %s", ErrNotFound, id)
+	}
+	delete(b.known, id)
+	// removed from the queue and shareable lazily
+	addr.Class = deleted
+	return nil
+}
+
+// Pin pins or unpins the peer. Pinned peer is never deleted from the book.
+func (b *Book) Pin(id ID, pin bool) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	addr := b.known[id]
+	if addr == nil {
+		return fmt.Errorf("%w: %s", ErrNotFound, id)
 	}
+	addr.Pinned = pin
 	return nil
 }
 
+// Pinned returns addresses of the pinned peers.
+func (b *Book) Pinned() []Address {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	var rst []Address
+	for _, addr := range b.known {
+		if addr.Pinned && addr.dialable() {
+			rst = append(rst, addr.Raw.Address)
+		}
+	}
+	return rst
+}
+
+// Ban bans the peer for the duration, or permanently if the duration is zero.
+// Banned peer is unpinned. Peer doesn't have to be in the book to be banned.
+func (b *Book) Ban(id ID, duration time.Duration) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	addr := b.record(id, true)
+	addr.Pinned = false
+	addr.Banned = true
+	addr.BannedUntil = 0
+	if duration > 0 {
+		addr.BannedUntil = b.now().Add(duration).Unix()
+	}
+}
+
+// Unban lifts the ban from the peer.
+func (b *Book) Unban(id ID) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	addr := b.known[id]
+	if addr == nil {
+		return fmt.Errorf("%w: %s", ErrNotFound, id)
+	}
+	addr.Banned = false
+	addr.BannedUntil = 0
+	return nil
+}
+
+// Banned returns true if the peer is banned.
+func (b *Book) Banned(id ID) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	addr := b.known[id]
+	return addr != nil && addr.banned(b.now())
+}
+
+// Dial records a dial to the peer. Returns false if the peer is banned and must not be dialed.
+func (b *Book) Dial(id ID) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	addr := b.record(id, false)
+	if addr == nil {
+		return true
+	}
+	if addr.banned(b.now()) {
+		return false
+	}
+	addr.Dials++
+	return true
+}
+
+// Connect records a new connection with the peer. Address of the outbound connection
+// is the one the peer listens on, and it is added to the book if the peer has no address.
+func (b *Book) Connect(id ID, raw Address, outbound bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	addr := b.record(id, false)
+	if addr == nil {
+		return
+	}
+	now := b.now().Unix()
+	if addr.FirstSeen == 0 {
+		addr.FirstSeen = now
+	}
+	addr.LastSeen = now
+	addr.Connected = true
+	if !outbound {
+		addr.Inbound++
+		return
+	}
+	addr.Outbound++
+	if raw != nil && !addr.dialable() {
+		addr.Raw.Address = raw
+		addr.bucket = bucketize(raw)
+		b.queue.PushBack(addr)
+	}
+}
+
+// Disconnect records that all connections with the peer are closed, and the protocols it supports.
+func (b *Book) Disconnect(id ID, protocols []string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	addr := b.known[id]
+	if addr == nil {
+		return
+	}
+	addr.Connected = false
+	addr.LastSeen = b.now().Unix()
+	setProtocols(addr, protocols)
+}
+
+// Seen updates last seen time and protocols of the connected peer.
+func (b *Book) Seen(id ID, protocols []string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	addr := b.known[id]
+	if addr == nil {
+		return
+	}
+	addr.LastSeen = b.now().Unix()
+	setProtocols(addr, protocols)
+}
+
+func setProtocols(addr *addressInfo, protocols []string) {
+	if len(protocols) == 0 {
+		return
+	}
+	addr.Protocols = append([]string{}, protocols...)
+	sort.Strings(addr.Protocols)
+}
+
 type Stats struct {
 	Total     int
 	Connected int
@@ -315,10 +641,12 @@ func (b *Book) Stats() Stats {
 		if addr.Connected {
 			stats.Connected++
 		}
-		if addr.bucket == public {
-			stats.Public++
-		} else {
-			stats.Private++
+		if addr.dialable() {
+			if addr.bucket == public {
+				stats.Public++
+			} else {
+				stats.Private++
+			}
 		}
 		switch addr.Class {
 		case stale:
@@ -350,7 +678,7 @@ func (b *Book) iterShareable(src ID, bucket bucket) iterator {
 				rst.shareable = false
 			} else {
 				i++
-				if rst.bucket == bucket && rst.ID != src {
+				if rst.bucket == bucket && rst.ID != src && !rst.banned(b.now()) {
 					return rst.Raw.Address
 				}
 			}
@@ -364,11 +692,11 @@ func (b *Book) drainQueue() iterator {
 			if b.queue.Len() == 0 {
 				return nil
 			}
-			rst := b.queue.Remove(b.queue.Front())
-			if rst.(*addressInfo).Class == deleted {
+			rst := b.queue.Remove(b.queue.Front()).(*addressInfo)
+			if rst.Class == deleted || !rst.dialable() || rst.banned(b.now()) {
 				continue
 			}
-			return rst.(*addressInfo).Raw.Address
+			return rst.Raw.Address
 		}
 	}
 }
@@ -5,6 +5,7 @@ import (
 	"io"
 	"strings"
 	"testing"
+	"time"
 
 	ma "github.com/multiformats/go-multiaddr"
 	"github.com/stretchr/testify/require"
@@ -448,3 +449,104 @@ func TestBook(t *testing.T) {
 		})
 	}
 }
+
+func TestPeerRecords(t *testing.T) {
+	now := time.Now()
+	b := book.New(book.WithTime(func() time.Time { return now }))
+	addr := ma.StringCast("/ip4/1.1.1.1/tcp/7513/p2p/12D3KooWJuQRTVZvaqvWh5ZWvtX8hsjYZXhFJQfNW9uKLVvBZxNo")
+
+	require.True(t, b.Dial("a"))
+	require.True(t, b.Dial("a"))
+	b.Connect("a", addr, true)
+	now = now.Add(time.Second)
+	b.Connect("b", nil, false)
+	now = now.Add(time.Second)
+	b.Disconnect("a", []string{"b/1", "a/1"})
+
+	a, ok := b.Get("a")
+	require.True(t, ok)
+	require.False(t, a.Connected)
+	require.Equal(t, addr.String(), a.Address)
+	require.Equal(t, []string{"a/1", "b/1"}, a.Protocols)
+	require.Equal(t, 2, a.Dials)
+	require.Equal(t, 1, a.Outbound)
+	require.Equal(t, 0.5, a.Reachability())
+	require.Equal(t, []book.Address{addr}, b.DrainQueue(2), "outbound address is dialable")
+
+	inbound, ok := b.Get("b")
+	require.True(t, ok)
+	require.True(t, inbound.Connected)
+	require.Empty(t, inbound.Address, "inbound address is not the listen address")
+	require.Equal(t, 1, inbound.Inbound)
+	require.Zero(t, inbound.Reachability())
+
+	peers := b.Peers()
+	require.Len(t, peers, 2)
+	require.Equal(t, "a", peers[0].ID, "the most recently seen first")
+
+	require.NoError(t, b.Remove("a"))
+	_, ok = b.Get("a")
+	require.False(t, ok)
+	require.ErrorIs(t, b.Remove("a"), book.ErrNotFound)
+	require.ErrorIs(t, b.Pin("a", true), book.ErrNotFound)
+}
+
+func TestPeerBan(t *testing.T) {
+	now := time.Now()
+	b := book.New(book.WithLimit(1), book.WithTime(func() time.Time { return now }))
+	addr := ma.StringCast("/ip4/1.1.1.1/tcp/7513")
+
+	b.Add(book.SELF, "a", addr)
+	require.NoError(t, b.Pin("a", true))
+	require.Equal(t, []book.Address{addr}, b.Pinned())
+	b.Ban("a", time.Minute)
+	b.Ban("b", 0)
+	for _, id := range []book.ID{"a", "b"} {
+		require.True(t, b.Banned(id))
+		require.False(t, b.Dial(id))
+	}
+	require.Empty(t, b.Pinned(), "banned peer is unpinned")
+	require.Empty(t, b.DrainQueue(1), "banned peer is not dialed")
+	require.True(t, b.Dial("c"), "not recorded if the book is full")
+
+	now = now.Add(time.Minute)
+	require.False(t, b.Banned("a"))
+	require.True(t, b.Banned("b"))
+	require.NoError(t, b.Unban("b"))
+	require.False(t, b.Banned("b"))
+	require.ErrorIs(t, b.Unban("d"), book.ErrNotFound)
+}
+
+func TestPeerPinnedNotDeleted(t *testing.T) {
+	b := book.New()
+	b.Add(book.SELF, "a", ma.StringCast("/ip4/1.1.1.1/tcp/7513"))
+	require.NoError(t, b.Pin("a", true))
+	for i := 0; i < 4; i++ {
+		b.Update("a", book.Fail)
+	}
+	_, ok := b.Get("a")
+	require.True(t, ok)
+}
+
+func TestPeerRecordsPersist(t *testing.T) {
+	b := book.New()
+	connected := ma.StringCast("/ip4/1.1.1.1/tcp/7513")
+	b.Add(book.SELF, "a", connected)
+	b.Add(book.SELF, "c", ma.StringCast("/ip4/2.2.2.2/tcp/7513"))
+	require.NoError(t, b.Pin("a", true))
+	b.Connect("a", nil, false)
+	b.Ban("b", 0)
+	buf := bytes.NewBuffer(nil)
+	require.NoError(t, b.Persist(buf))
+
+	recovered := book.New()
+	require.NoError(t, recovered.Recover(buf))
+	require.Equal(t, []book.Address{connected}, recovered.Recovered())
+	require.Equal(t, []book.Address{connected}, recovered.Pinned())
+	require.True(t, recovered.Banned("b"))
+	a, ok := recovered.Get("a")
+	require.True(t, ok)
+	require.False(t, a.Connected, "connections are not restored")
+	require.Equal(t, 1, a.Inbound)
+	require.Len(t, recovered.DrainQueue(3), 2, "peer without address is not dialed")
+}
@@ -26,7 +26,9 @@ import (
 	"go.uber.org/zap"
 
 	"github.com/spacemeshos/go-spacemesh/log"
+	"github.com/spacemeshos/go-spacemesh/p2p/book"
 	p2pmetrics "github.com/spacemeshos/go-spacemesh/p2p/metrics"
+	"github.com/spacemeshos/go-spacemesh/p2p/pubsub"
 )
 
 // DefaultConfig config.
@@ -42,6 +44,8 @@ func DefaultConfig() Config {
 		AcceptQueue:        tptu.AcceptQueueLength,
 		EnableHolepunching: true,
 		RelayServer:        RelayServer{TTL: 20 * time.Minute, Reservations: 512},
+		GossipTrace:        pubsub.DefaultTraceConfig(),
+		PeerBookLimit:      50000,
 	}
 }
 
@@ -58,26 +62,28 @@ type Config struct {
 	MaxMessageSize     int
 
 	// see https://lwn.net/Articles/542629/ for reuseport explanation
-	DisableReusePort       bool        `mapstructure:"disable-reuseport"`
-	DisableNatPort         bool        `mapstructure:"disable-natport"`
-	DisableDHT             bool        `mapstructure:"disable-dht"`
-	Flood                  bool        `mapstructure:"flood"`
-	Listen                 string      `mapstructure:"listen"`
-	Bootnodes              []string    `mapstructure:"bootnodes"`
-	Direct                 []string    `mapstructure:"direct"`
-	MinPeers               int         `mapstructure:"min-peers"`
-	LowPeers               int         `mapstructure:"low-peers"`
-	HighPeers              int         `mapstructure:"high-peers"`
-	AutoscalePeers         bool        `mapstructure:"autoscale-peers"`
-	AdvertiseAddress       string      `mapstructure:"advertise-address"`
-	AcceptQueue            int         `mapstructure:"p2p-accept-queue"`
-	Metrics                bool        `mapstructure:"p2p-metrics"`
-	Bootnode               bool        `mapstructure:"p2p-bootnode"`
-	ForceReachability      string      `mapstructure:"p2p-reachability"`
-	EnableHolepunching     bool        `mapstructure:"p2p-holepunching"`
-	DisableLegacyDiscovery bool        `mapstructure:"p2p-disable-legacy-discovery"`
-	PrivateNetwork         bool        `mapstructure:"p2p-private-network"`
-	RelayServer            RelayServer `mapstructure:"relay-server"`
+	DisableReusePort       bool               `mapstructure:"disable-reuseport"`
+	DisableNatPort         bool               `mapstructure:"disable-natport"`
+	DisableDHT             bool               `mapstructure:"disable-dht"`
+	Flood                  bool               `mapstructure:"flood"`
+	Listen                 string             `mapstructure:"listen"`
+	Bootnodes              []string           `mapstructure:"bootnodes"`
+	Direct                 []string           `mapstructure:"direct"`
+	MinPeers               int                `mapstructure:"min-peers"`
+	LowPeers               int                `mapstructure:"low-peers"`
+	HighPeers              int                `mapstructure:"high-peers"`
+	AutoscalePeers         bool               `mapstructure:"autoscale-peers"`
+	AdvertiseAddress       string             `mapstructure:"advertise-address"`
+	AcceptQueue            int                `mapstructure:"p2p-accept-queue"`
+	Metrics                bool               `mapstructure:"p2p-metrics"`
+	Bootnode               bool               `mapstructure:"p2p-bootnode"`
+	ForceReachability      string             `mapstructure:"p2p-reachability"`
+	EnableHolepunching     bool               `mapstructure:"p2p-holepunching"`
+	DisableLegacyDiscovery bool               `mapstructure:"p2p-disable-legacy-discovery"`
+	PrivateNetwork         bool               `mapstructure:"p2p-private-network"`
+	RelayServer            RelayServer        `mapstructure:"relay-server"`
+	GossipTrace            pubsub.TraceConfig `mapstructure:"gossip-trace"`
+	PeerBookLimit          int                `mapstructure:"p2p-peer-book-limit"`
 }
 
 type RelayServer struct {
@@ -125,6 +131,12 @@ func New(_ context.Context, logger log.Log, cfg Config, prologue []byte, opts ..
 	if err != nil {
 		return nil, fmt.Errorf("can't create peer store: %w", err)
 	}
+	peers := book.New(book.WithLimit(cfg.PeerBookLimit))
+	if len(cfg.DataDir) != 0 {
+		if err := loadBook(peers, cfg.DataDir); err != nil {
+			return nil, fmt.Errorf("can't load peer book: %w", err)
+		}
+	}
 	lopts := []libp2p.Option{
 		libp2p.Identity(key),
 		libp2p.ListenAddrStrings(cfg.Listen),
@@ -149,6 +161,7 @@ func New(_ context.Context, logger log.Log, cfg Config, prologue []byte, opts ..
 		libp2p.Muxer("/yamux/1.0.0", &streamer),
 		libp2p.ConnectionManager(cm),
 		libp2p.Peerstore(ps),
+		libp2p.ConnectionGater(&gater{book: peers, logger: logger.WithName("gater")}),
 		libp2p.BandwidthReporter(p2pmetrics.NewBandwidthCollector()),
 		libp2p.EnableNATService(),
 	}
@@ -199,7 +212,7 @@ func New(_ context.Context, logger log.Log, cfg Config, prologue []byte, opts ..
 	logger.Zap().Info("local node identity", zap.Stringer("identity", h.ID()))
 	// TODO(dshulyak) this is small mess. refactor to avoid this patching
 	// both New and Upgrade should use options.
-	opts = append(opts, WithConfig(cfg), WithLog(logger))
+	opts = append(opts, WithConfig(cfg), WithLog(logger), WithPeerBook(peers))
 	return Upgrade(h, opts...)
 }
 
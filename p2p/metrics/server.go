@@ -0,0 +1,42 @@
+package metrics
+
+import (
+	"github.com/spacemeshos/go-spacemesh/metrics"
+)
+
+// Results of the requests handled by the server.
+const (
+	ResultOK = "ok"
+	// ResultError is a request that failed in the handler.
+	ResultError = "error"
+	// ResultRateLimited is a request rejected by the limit on requests from all peers.
+	ResultRateLimited = "rate_limited"
+	// ResultPeerRateLimited is a request rejected by the limit on requests from the peer.
+	ResultPeerRateLimited = "peer_rate_limited"
+	// ResultConcurrencyLimited is a request rejected because too many requests are handled concurrently.
+	ResultConcurrencyLimited = "concurrency_limited"
+)
+
+var (
+	// ServerRequests counts requests handled by the server per protocol and result.
+	ServerRequests = metrics.NewCounter(
+		"server_requests",
+		subsystem,
+		"Number of requests handled by the server per protocol and result",
+		[]string{"protocol", "result"},
+	)
+	// ServerInflightRequests is the number of requests that are currently handled by the server.
+	ServerInflightRequests = metrics.NewGauge(
+		"server_inflight_requests",
+		subsystem,
+		"Number of requests handled concurrently by the server per protocol",
+		[]string{"protocol"},
+	)
+	// ServerResponseChunks counts chunks of the streamed responses written by the server.
+	ServerResponseChunks = metrics.NewCounter(
+		"server_response_chunks",
+		subsystem,
+		"Number of chunks of the streamed responses written by the server per protocol",
+		[]string{"protocol"},
+	)
+)
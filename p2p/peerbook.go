@@ -0,0 +1,286 @@
+package p2p
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/control"
+	"github.com/libp2p/go-libp2p/core/network"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/libp2p/go-libp2p/core/peerstore"
+	ma "github.com/multiformats/go-multiaddr"
+
+	"github.com/spacemeshos/go-spacemesh/log"
+	"github.com/spacemeshos/go-spacemesh/p2p/book"
+)
+
+const (
+	// pinnedTag protects pinned peers from connection pruning.
+	pinnedTag = "pinned"
+	// reconnectInterval is the interval between attempts to reconnect pinned peers.
+	reconnectInterval = time.Minute
+	// peerBookSaveInterval is the interval between writes of the peer book to disk.
+	peerBookSaveInterval = 10 * time.Minute
+)
+
+var (
+	// ErrNoPeerBook is returned by the peer management methods if the host was created without peer book.
+	ErrNoPeerBook = errors.New("p2p: peer book is disabled")
+	// ErrPeerBanned is returned if banned peer is added or pinned.
+	ErrPeerBanned = errors.New("p2p: peer is banned")
+)
+
+// WithPeerBook sets book that records connections and is used to manage peers.
+// The book is also used by the peer exchange, and it is saved in the data directory.
+// Book should be used by the connection gater of the host to enforce bans.
+func WithPeerBook(b *book.Book) Opt {
+	return func(fh *Host) {
+		fh.book = b
+	}
+}
+
+// gater rejects connections with peers that are banned in the book,
+// and records dials to other peers.
+type gater struct {
+	book   *book.Book
+	logger log.Log
+}
+
+// InterceptPeerDial rejects dials to banned peers, and counts dials to others.
+func (g *gater) InterceptPeerDial(id peer.ID) bool {
+	return g.book.Dial(id.String())
+}
+
+// InterceptAddrDial rejects dials to banned peers.
+func (g *gater) InterceptAddrDial(id peer.ID, _ ma.Multiaddr) bool {
+	return !g.book.Banned(id.String())
+}
+
+// InterceptAccept accepts all connections, peer is not known at this stage.
+func (g *gater) InterceptAccept(network.ConnMultiaddrs) bool {
+	return true
+}
+
+// InterceptSecured rejects connections with banned peers.
+func (g *gater) InterceptSecured(_ network.Direction, id peer.ID, _ network.ConnMultiaddrs) bool {
+	if g.book.Banned(id.String()) {
+		g.logger.With().Debug("rejected connection with banned peer", log.Stringer("peer", id))
+		return false
+	}
+	return true
+}
+
+// InterceptUpgraded accepts all upgraded connections.
+func (g *gater) InterceptUpgraded(network.Conn) (bool, control.DisconnectReason) {
+	return true, 0
+}
+
+func (fh *Host) protocols(pid peer.ID) []string {
+	protocols, err := fh.Peerstore().GetProtocols(pid)
+	if err != nil {
+		return nil
+	}
+	rst := make([]string, 0, len(protocols))
+	for _, proto := range protocols {
+		rst = append(rst, string(proto))
+	}
+	return rst
+}
+
+func (fh *Host) notifyPeerBook() {
+	fh.Network().Notify(&network.NotifyBundle{
+		ConnectedF: func(_ network.Network, c network.Conn) {
+			// only the address of outbound connection is the one the peer listens on
+			outbound := c.Stat().Direction == network.DirOutbound
+			var addr ma.Multiaddr
+			if outbound {
+				addr = c.RemoteMultiaddr().Encapsulate(ma.StringCast("/p2p/" + c.RemotePeer().String()))
+			}
+			fh.book.Connect(c.RemotePeer().String(), addr, outbound)
+		},
+		DisconnectedF: func(n network.Network, c network.Conn) {
+			if n.Connectedness(c.RemotePeer()) != network.Connected {
+				fh.book.Disconnect(c.RemotePeer().String(), fh.protocols(c.RemotePeer()))
+			}
+		},
+	})
+}
+
+// maintainPeerBook periodically reconnects pinned peers and saves the book.
+func (fh *Host) maintainPeerBook(ctx context.Context) {
+	for _, info := range fh.pinned() {
+		fh.Peerstore().AddAddrs(info.ID, info.Addrs, peerstore.PermanentAddrTTL)
+		fh.ConnManager().Protect(info.ID, pinnedTag)
+	}
+	reconnect := time.NewTicker(reconnectInterval)
+	defer reconnect.Stop()
+	save := time.NewTicker(peerBookSaveInterval)
+	defer save.Stop()
+	for {
+		fh.reconnectPinned(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-reconnect.C:
+		case <-save.C:
+			for _, pid := range fh.Network().Peers() {
+				fh.book.Seen(pid.String(), fh.protocols(pid))
+			}
+			fh.savePeerBook()
+		}
+	}
+}
+
+func (fh *Host) pinned() []peer.AddrInfo {
+	var rst []peer.AddrInfo
+	for _, addr := range fh.book.Pinned() {
+		info, err := peer.AddrInfoFromP2pAddr(addr)
+		if err != nil {
+			fh.logger.With().Debug("pinned address without peer id",
+				log.Stringer("address", addr),
+				log.Err(err),
+			)
+			continue
+		}
+		rst = append(rst, *info)
+	}
+	return rst
+}
+
+func (fh *Host) reconnectPinned(ctx context.Context) {
+	for _, info := range fh.pinned() {
+		if fh.Network().Connectedness(info.ID) == network.Connected {
+			continue
+		}
+		if err := fh.Connect(ctx, info); err != nil {
+			fh.logger.With().Debug("failed to connect pinned peer",
+				log.Stringer("peer", info.ID),
+				log.Err(err),
+			)
+		}
+	}
+}
+
+func (fh *Host) savePeerBook() {
+	if len(fh.cfg.DataDir) == 0 {
+		return
+	}
+	if err := saveBook(fh.book, fh.cfg.DataDir); err != nil {
+		fh.logger.With().Warning("failed to save peer book", log.Err(err))
+	}
+}
+
+// Peers returns records of the peers in the peer book.
+func (fh *Host) Peers() ([]book.Peer, error) {
+	if fh.book == nil {
+		return nil, ErrNoPeerBook
+	}
+	return fh.book.Peers(), nil
+}
+
+// AddPeer adds peer to the peer book and connects to it. Pinned peer is protected from pruning,
+// and is reconnected if the connection is lost. Peer stays in the book if connection fails.
+func (fh *Host) AddPeer(ctx context.Context, info peer.AddrInfo, pin bool) error {
+	if fh.book == nil {
+		return ErrNoPeerBook
+	}
+	if fh.book.Banned(info.ID.String()) {
+		return fmt.Errorf("%w: %s", ErrPeerBanned, info.ID)
+	}
+	if len(info.Addrs) == 0 {
+		return fmt.Errorf("peer %s without address", info.ID)
+	}
+	addrs, err := peer.AddrInfoToP2pAddrs(&info)
+	if err != nil {
+		return err
+	}
+	fh.book.Add(book.SELF, info.ID.String(), addrs[0])
+	if _, exists := fh.book.Get(info.ID.String()); !exists {
+		return fmt.Errorf("peer book is full, can't add %s", info.ID)
+	}
+	if pin {
+		if err := fh.pin(info.ID, true); err != nil {
+			return err
+		}
+	}
+	defer fh.savePeerBook()
+	fh.Peerstore().AddAddrs(info.ID, info.Addrs, peerstore.PermanentAddrTTL)
+	if err := fh.Connect(ctx, info); err != nil {
+		return fmt.Errorf("connect %s: %w", info.ID, err)
+	}
+	return nil
+}
+
+// RemovePeer removes the peer from the peer book with its pin and ban, and closes connections with it.
+func (fh *Host) RemovePeer(pid peer.ID) error {
+	if fh.book == nil {
+		return ErrNoPeerBook
+	}
+	if err := fh.book.Remove(pid.String()); err != nil {
+		return err
+	}
+	defer fh.savePeerBook()
+	fh.ConnManager().Unprotect(pid, pinnedTag)
+	fh.Peerstore().ClearAddrs(pid)
+	return fh.Network().ClosePeer(pid)
+}
+
+// PinPeer pins or unpins the peer that is in the peer book.
+func (fh *Host) PinPeer(pid peer.ID, pin bool) error {
+	if fh.book == nil {
+		return ErrNoPeerBook
+	}
+	if err := fh.pin(pid, pin); err != nil {
+		return err
+	}
+	fh.savePeerBook()
+	return nil
+}
+
+func (fh *Host) pin(pid peer.ID, pin bool) error {
+	if pin && fh.book.Banned(pid.String()) {
+		return fmt.Errorf("%w: %s", ErrPeerBanned, pid)
+	}
+	if err := fh.book.Pin(pid.String(), pin); err != nil {
+		return err
+	}
+	if pin {
+		fh.ConnManager().Protect(pid, pinnedTag)
+	} else {
+		fh.ConnManager().Unprotect(pid, pinnedTag)
+	}
+	return nil
+}
+
+// BanPeer bans the peer for the duration, or permanently if the duration is zero,
+// and closes connections with it.
+func (fh *Host) BanPeer(pid peer.ID, duration time.Duration) error {
+	if fh.book == nil {
+		return ErrNoPeerBook
+	}
+	if pid == fh.ID() {
+		return errors.New("can't ban self")
+	}
+	fh.book.Ban(pid.String(), duration)
+	defer fh.savePeerBook()
+	fh.ConnManager().Unprotect(pid, pinnedTag)
+	fh.logger.With().Info("peer is banned",
+		log.Stringer("peer", pid),
+		log.Duration("duration", duration),
+	)
+	return fh.Network().ClosePeer(pid)
+}
+
+// UnbanPeer lifts the ban from the peer.
+func (fh *Host) UnbanPeer(pid peer.ID) error {
+	if fh.book == nil {
+		return ErrNoPeerBook
+	}
+	if err := fh.book.Unban(pid.String()); err != nil {
+		return err
+	}
+	fh.savePeerBook()
+	return nil
+}
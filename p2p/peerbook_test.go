@@ -0,0 +1,72 @@
+package p2p
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/network"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/stretchr/testify/require"
+
+	"github.com/spacemeshos/go-spacemesh/log/logtest"
+	"github.com/spacemeshos/go-spacemesh/p2p/book"
+)
+
+func newTestHost(tb testing.TB, dir string) *Host {
+	tb.Helper()
+	cfg := DefaultConfig()
+	cfg.DataDir = dir
+	cfg.Listen = "/ip4/127.0.0.1/tcp/0"
+	cfg.DisableLegacyDiscovery = true
+	h, err := New(context.Background(), logtest.New(tb), cfg, []byte("test"))
+	require.NoError(tb, err)
+	tb.Cleanup(func() { h.Host.Close() })
+	return h
+}
+
+func TestPeerManagement(t *testing.T) {
+	dir := t.TempDir()
+	h1 := newTestHost(t, dir)
+	h2 := newTestHost(t, t.TempDir())
+	ctx := context.Background()
+	info := peer.AddrInfo{ID: h2.ID(), Addrs: h2.Addrs()}
+
+	require.NoError(t, h1.AddPeer(ctx, info, true))
+	require.True(t, h1.ConnManager().IsProtected(h2.ID(), pinnedTag))
+	peers, err := h1.Peers()
+	require.NoError(t, err)
+	require.Len(t, peers, 1)
+	require.Equal(t, h2.ID().String(), peers[0].ID)
+	require.True(t, peers[0].Pinned)
+	require.True(t, peers[0].Connected)
+	require.Equal(t, 1, peers[0].Outbound)
+	require.Eventually(t, func() bool {
+		got, _ := h2.Peers()
+		return len(got) == 1 && got[0].Inbound == 1
+	}, time.Second, 10*time.Millisecond)
+
+	require.NoError(t, h1.BanPeer(h2.ID(), 0))
+	require.False(t, h1.ConnManager().IsProtected(h2.ID(), pinnedTag))
+	require.Eventually(t, func() bool {
+		return h2.Network().Connectedness(h1.ID()) != network.Connected
+	}, time.Second, 10*time.Millisecond)
+	require.Error(t, h1.Connect(ctx, info))
+	require.Error(t, h2.Connect(ctx, peer.AddrInfo{ID: h1.ID(), Addrs: h1.Addrs()}))
+	require.ErrorIs(t, h1.AddPeer(ctx, info, false), ErrPeerBanned)
+	require.ErrorContains(t, h1.BanPeer(h1.ID(), 0), "self")
+
+	// bans are persisted immediately
+	saved := book.New()
+	require.NoError(t, loadBook(saved, dir))
+	require.True(t, saved.Banned(h2.ID().String()))
+
+	require.NoError(t, h1.UnbanPeer(h2.ID()))
+	require.NoError(t, h1.Connect(ctx, info))
+	require.NoError(t, h1.RemovePeer(h2.ID()))
+	require.Eventually(t, func() bool {
+		return h1.Network().Connectedness(h2.ID()) != network.Connected
+	}, time.Second, 10*time.Millisecond)
+	require.ErrorIs(t, h1.PinPeer(h2.ID(), true), book.ErrNotFound)
+	require.ErrorIs(t, h1.UnbanPeer(h2.ID()), book.ErrNotFound)
+}
@@ -1,14 +1,11 @@
 package peerexchange
 
 import (
-	"bytes"
 	"context"
 	"errors"
 	"fmt"
 	"math"
 	"net"
-	"os"
-	"path/filepath"
 	"strconv"
 	"time"
 
@@ -18,21 +15,17 @@ import (
 	"github.com/libp2p/go-libp2p/p2p/host/eventbus"
 	ma "github.com/multiformats/go-multiaddr"
 	manet "github.com/multiformats/go-multiaddr/net"
-	"github.com/natefinch/atomic"
 	"golang.org/x/sync/errgroup"
 
 	"github.com/spacemeshos/go-spacemesh/log"
 	"github.com/spacemeshos/go-spacemesh/p2p/book"
 )
 
-const peersFile = "peers.txt"
-
 const peerPollPeriod = 500 * time.Millisecond
 
 // Config for Discovery.
 type Config struct {
 	Bootnodes            []string
-	DataDir              string
 	AdvertiseAddress     string // Address to advertise to a peers.
 	MinPeers             int
 	FastCrawl, SlowCrawl time.Duration
@@ -54,8 +47,9 @@ type Discovery struct {
 	collector *collector
 }
 
-// New creates a Discovery instance.
-func New(logger log.Log, h host.Host, config Config) (*Discovery, error) {
+// New creates a Discovery instance. Addresses are learned into the book,
+// and the book is persisted by the caller.
+func New(logger log.Log, h host.Host, b *book.Book, config Config) (*Discovery, error) {
 	ctx, cancel := context.WithCancel(context.Background())
 	d := &Discovery{
 		cfg:    config,
@@ -63,7 +57,7 @@ func New(logger log.Log, h host.Host, config Config) (*Discovery, error) {
 		host:   h,
 		ctx:    ctx,
 		cancel: cancel,
-		book:   book.New(),
+		book:   b,
 	}
 	d.collector = newCollector(d.book)
 	var advertise ma.Multiaddr
@@ -95,11 +89,6 @@ func New(logger log.Log, h host.Host, config Config) (*Discovery, error) {
 	}
 	protocol := newPeerExchange(h, d.book, advertise, logger)
 	d.crawl = newCrawler(logger, h, d.book, protocol)
-	if len(config.DataDir) != 0 {
-		if err := d.recovery(ctx); err != nil {
-			return nil, err
-		}
-	}
 	if len(config.AdvertiseAddress) == 0 {
 		if err := d.watchPortChanges(ctx, protocol); err != nil {
 			return nil, err
@@ -114,39 +103,6 @@ func (d *Discovery) StartScan() {
 	d.scanPeers(d.ctx)
 }
 
-func (d *Discovery) recovery(ctx context.Context) error {
-	fpath := filepath.Join(d.cfg.DataDir, peersFile)
-	f, err := os.Open(fpath)
-	if err != nil && !errors.Is(err, os.ErrNotExist) {
-		return fmt.Errorf("can't recover from file %v: %w", fpath, err)
-	}
-	defer f.Close()
-	if err == nil {
-		if err := d.book.Recover(f); err != nil {
-			return err
-		}
-	}
-	d.eg.Go(func() error {
-		ticker := time.NewTicker(30 * time.Minute)
-		defer ticker.Stop()
-		for {
-			select {
-			case <-ctx.Done():
-				return nil
-			case <-ticker.C:
-				buf := bytes.NewBuffer(nil)
-				if err := d.book.Persist(buf); err != nil {
-					return err
-				}
-				if err := atomic.WriteFile(fpath, buf); err != nil {
-					return err
-				}
-			}
-		}
-	})
-	return nil
-}
-
 func (d *Discovery) watchPortChanges(ctx context.Context, protocol *peerExchange) error {
 	sub, err := d.host.EventBus().Subscribe(new(event.EvtLocalAddressesUpdated), eventbus.BufSize(4))
 	if err != nil {
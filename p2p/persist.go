@@ -1,106 +1,55 @@
 package p2p
 
 import (
-	"context"
-	"encoding/binary"
-	"encoding/json"
+	"bytes"
 	"errors"
 	"fmt"
-	"hash/crc64"
-	"io"
 	"os"
 	"path/filepath"
-	"time"
 
-	"github.com/libp2p/go-libp2p/core/host"
 	"github.com/libp2p/go-libp2p/core/peer"
 	"github.com/natefinch/atomic"
 
-	"github.com/spacemeshos/go-spacemesh/log"
+	"github.com/spacemeshos/go-spacemesh/p2p/book"
 )
 
-const connectedFile = "connected.txt"
+// peersFile is the file with the peer book, it is also used by the peer exchange
+// to store learned addresses.
+const peersFile = "peers.txt"
 
-func persist(ctx context.Context, logger log.Log, h host.Host, dir string, period time.Duration) {
-	ticker := time.NewTicker(period)
-	defer ticker.Stop()
-	for {
-		select {
-		case <-ctx.Done():
-			return
-		case <-ticker.C:
-			if err := writePeers(h, dir); err != nil {
-				logger.With().Warning("failed to write connected peers to file",
-					log.String("directory", dir),
-					log.Err(err),
-				)
-			}
-		}
-	}
-}
-
-func writePeers(h host.Host, dir string) error {
-	peers := h.Network().Peers()
-	if len(peers) == 0 {
-		return nil
-	}
-
-	checksum := crc64.New(crc64.MakeTable(crc64.ISO))
-	tmp, err := os.CreateTemp(dir, "connected-***")
-	if err != nil {
+func saveBook(b *book.Book, dir string) error {
+	buf := bytes.NewBuffer(nil)
+	if err := b.Persist(buf); err != nil {
 		return err
 	}
-	crc := make([]byte, crc64.Size)
-	if _, err := tmp.Write(crc); err != nil {
-		tmp.Close()
-		return err
+	fpath := filepath.Join(dir, peersFile)
+	if err := atomic.WriteFile(fpath, buf); err != nil {
+		return fmt.Errorf("write %s: %w", fpath, err)
 	}
-	codec := json.NewEncoder(io.MultiWriter(tmp, checksum))
-	for _, pid := range peers {
-		info := h.Peerstore().PeerInfo(pid)
-		if err := codec.Encode(info); err != nil {
-			tmp.Close()
-			return err
-		}
-	}
-	binary.BigEndian.PutUint64(crc, checksum.Sum64())
-	if _, err = tmp.WriteAt(crc, 0); err != nil {
-		tmp.Close()
-		return err
-	}
-	tmp.Close()
-	return atomic.ReplaceFile(tmp.Name(), filepath.Join(dir, connectedFile))
+	return nil
 }
 
-func loadPeers(dir string) ([]peer.AddrInfo, error) {
-	f, err := os.Open(filepath.Join(dir, connectedFile))
+func loadBook(b *book.Book, dir string) error {
+	fpath := filepath.Join(dir, peersFile)
+	f, err := os.Open(fpath)
 	if err != nil {
 		if errors.Is(err, os.ErrNotExist) {
-			return nil, nil
+			return nil
 		}
-		return nil, err
+		return fmt.Errorf("can't recover from file %v: %w", fpath, err)
 	}
 	defer f.Close()
-	crc := make([]byte, crc64.Size)
-	if _, err := f.Read(crc); err != nil {
-		return nil, err
-	}
-	checksum := crc64.New(crc64.MakeTable(crc64.ISO))
-	codec := json.NewDecoder(io.TeeReader(f, checksum))
-	rst := []peer.AddrInfo{}
-	for {
-		var info peer.AddrInfo
-		if err := codec.Decode(&info); err != nil {
-			if errors.Is(err, io.EOF) {
-				if saved := binary.BigEndian.Uint64(crc); saved != checksum.Sum64() {
-					return nil, fmt.Errorf(
-						"invalid checksum %d != %d", saved, checksum.Sum64())
-				}
-				return rst, nil
-			} else {
-				return nil, err
-			}
+	return b.Recover(f)
+}
+
+// backupPeers returns peers that were connected when the book was saved.
+func backupPeers(b *book.Book) []peer.AddrInfo {
+	var rst []peer.AddrInfo
+	for _, addr := range b.Recovered() {
+		info, err := peer.AddrInfoFromP2pAddr(addr)
+		if err == nil {
+			rst = append(rst, *info)
 		}
-		rst = append(rst, info)
 	}
+	return rst
 }
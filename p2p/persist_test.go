@@ -1,70 +1,57 @@
 package p2p
 
 import (
-	"context"
+	"bytes"
 	"os"
 	"path/filepath"
 	"testing"
-	"time"
 
-	mocknet "github.com/libp2p/go-libp2p/p2p/net/mock"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/libp2p/go-libp2p/core/test"
+	ma "github.com/multiformats/go-multiaddr"
 	"github.com/stretchr/testify/require"
-	"golang.org/x/sync/errgroup"
 
-	"github.com/spacemeshos/go-spacemesh/log/logtest"
+	"github.com/spacemeshos/go-spacemesh/p2p/book"
 )
 
-func TestConnectedPersist(t *testing.T) {
+func TestBookPersist(t *testing.T) {
 	dir := t.TempDir()
-	ctx, cancel := context.WithCancel(context.Background())
+	b := book.New()
 	const n = 3
-	mock, err := mocknet.FullMeshConnected(n)
-	require.NoError(t, err)
-	var eg errgroup.Group
-	eg.Go(func() error {
-		persist(ctx, logtest.New(t), mock.Hosts()[0], dir, 100*time.Microsecond)
-		return nil
-	})
-	require.Eventually(t, func() bool {
-		_, err := os.Stat(filepath.Join(dir, connectedFile))
-		return err == nil
-	}, 3*time.Second, 50*time.Microsecond)
-	cancel()
-	eg.Wait()
-	peers, err := loadPeers(dir)
-	require.NoError(t, err)
-	require.Len(t, peers, n-1)
+	var expected []peer.AddrInfo
+	for i := 0; i < n; i++ {
+		id := test.RandPeerIDFatal(t)
+		addr := ma.StringCast("/ip4/1.1.1.1/tcp/7513/p2p/" + id.String())
+		b.Add(book.SELF, id.String(), addr)
+		if i < n-1 {
+			b.Connect(id.String(), addr, true)
+			expected = append(expected, peer.AddrInfo{ID: id, Addrs: []ma.Multiaddr{ma.StringCast("/ip4/1.1.1.1/tcp/7513")}})
+		}
+	}
+	require.NoError(t, saveBook(b, dir))
+
+	loaded := book.New()
+	require.NoError(t, loadBook(loaded, dir))
+	require.Len(t, loaded.Peers(), n)
+	require.ElementsMatch(t, expected, backupPeers(loaded))
 }
 
-func TestConnectedLoadEmpty(t *testing.T) {
-	dir := t.TempDir()
-	peers, err := loadPeers(dir)
-	require.NoError(t, err)
-	require.Empty(t, peers)
+func TestBookLoadEmpty(t *testing.T) {
+	b := book.New()
+	require.NoError(t, loadBook(b, t.TempDir()))
+	require.Empty(t, b.Peers())
+	require.Empty(t, backupPeers(b))
 }
 
-func TestConnectedBrokenCRC(t *testing.T) {
+func TestBookBrokenChecksum(t *testing.T) {
 	dir := t.TempDir()
-	ctx, cancel := context.WithCancel(context.Background())
-	const n = 3
-	mock, err := mocknet.FullMeshConnected(n)
-	require.NoError(t, err)
-	var eg errgroup.Group
-	eg.Go(func() error {
-		persist(ctx, logtest.New(t), mock.Hosts()[0], dir, 100*time.Microsecond)
-		return nil
-	})
-	require.Eventually(t, func() bool {
-		_, err := os.Stat(filepath.Join(dir, connectedFile))
-		return err == nil
-	}, 3*time.Second, 50*time.Microsecond)
-	cancel()
-	eg.Wait()
-	f, err := os.OpenFile(filepath.Join(dir, connectedFile), os.O_RDWR, 0o600)
-	require.NoError(t, err)
-	_, err = f.WriteAt([]byte{0, 1, 1, 1}, 0)
+	b := book.New()
+	b.Add(book.SELF, "a", ma.StringCast("/ip4/1.1.1.1/tcp/7513"))
+	require.NoError(t, saveBook(b, dir))
+	fpath := filepath.Join(dir, peersFile)
+	data, err := os.ReadFile(fpath)
 	require.NoError(t, err)
-	peers, err := loadPeers(dir)
-	require.ErrorContains(t, err, "invalid checksum")
-	require.Empty(t, peers)
+	data = bytes.Replace(data, []byte("1.1.1.1"), []byte("2.2.2.2"), 1)
+	require.NoError(t, os.WriteFile(fpath, data, 0o600))
+	require.ErrorContains(t, loadBook(book.New(), dir), "checksum")
 }
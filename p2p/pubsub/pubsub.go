@@ -91,6 +91,8 @@ type Config struct {
 	// Direct peers should be configured on both ends.
 	Direct         []peer.AddrInfo
 	MaxMessageSize int
+	// Tracer records propagation of the messages, if not nil.
+	Tracer *Tracer
 }
 
 // New creates PubSub instance.
@@ -106,6 +108,7 @@ func New(ctx context.Context, logger log.Log, h host.Host, cfg Config) (*PubSub,
 		pubsub: ps,
 		topics: map[string]*pubsub.Topic{},
 		host:   h,
+		tracer: cfg.Tracer,
 	}, nil
 }
 
@@ -235,6 +238,9 @@ func getOptions(cfg Config) []pubsub.Option {
 	if cfg.MaxMessageSize != 0 {
 		options = append(options, pubsub.WithMaxMessageSize(cfg.MaxMessageSize))
 	}
+	if cfg.Tracer != nil {
+		options = append(options, pubsub.WithRawTracer(cfg.Tracer))
+	}
 
 	// enable Peer eXchange on bootstrappers
 	if cfg.IsBootnode {
@@ -0,0 +1,299 @@
+package pubsub
+
+import (
+	"bufio"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sync"
+	"time"
+
+	pubsub "github.com/libp2p/go-libp2p-pubsub"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/libp2p/go-libp2p/core/protocol"
+
+	"github.com/spacemeshos/go-spacemesh/log"
+)
+
+// traceFlushInterval is the interval between checks for traces with expired window.
+const traceFlushInterval = time.Second
+
+// TraceConfig configures tracing of gossip messages.
+type TraceConfig struct {
+	Enable bool `mapstructure:"enable"`
+	// Topics are patterns of the traced topics, as understood by path.Match.
+	Topics []string `mapstructure:"topics"`
+	// Window is the time after the message was first seen, during which duplicates are counted.
+	// Trace is published when the window is over.
+	Window time.Duration `mapstructure:"window"`
+	// File is the path of the JSON-lines file that traces are appended to.
+	// Relative path is relative to the data directory. Traces are not written to a file if empty.
+	File string `mapstructure:"file"`
+}
+
+// DefaultTraceConfig returns disabled tracing of atxs, proposals, hare and beacon messages.
+func DefaultTraceConfig() TraceConfig {
+	return TraceConfig{
+		Topics: []string{AtxProtocol, ProposalProtocol, HareProtocol, "b*1"},
+		Window: 10 * time.Second,
+	}
+}
+
+// MessageTrace is the record of the propagation of a gossip message.
+type MessageTrace struct {
+	// ID is the hex encoded message id.
+	ID    string `json:"id"`
+	Topic string `json:"topic"`
+	// FirstSeen is the time when the message was received for the first time.
+	FirstSeen time.Time `json:"first_seen"`
+	// Peer that delivered the message first.
+	Peer string `json:"peer"`
+	// Duplicates is the number of copies received within the trace window.
+	Duplicates int `json:"duplicates"`
+	// Result is accept, ignore or reject if the message was validated by the handlers,
+	// otherwise the reason why gossipsub dropped the message.
+	Result string `json:"result"`
+	// Error returned by the handlers.
+	Error string `json:"error,omitempty"`
+	// Validation is the time spent in the handlers.
+	Validation time.Duration `json:"validation"`
+}
+
+// Tracer records first seen time, delivering peer, number of duplicates and validation result
+// of the gossip messages in the traced topics. Traces are published after the trace window
+// to the subscribers and appended to the file.
+type Tracer struct {
+	logger log.Log
+	cfg    TraceConfig
+	now    func() time.Time
+
+	mu          sync.Mutex
+	traces      map[string]*MessageTrace
+	pending     []*MessageTrace // ordered by first seen time
+	subscribers map[chan MessageTrace]struct{}
+	file        *os.File
+	out         *bufio.Writer
+}
+
+// NewTracer creates Tracer, and opens the file for traces if it is configured.
+func NewTracer(logger log.Log, dataDir string, cfg TraceConfig) (*Tracer, error) {
+	t := &Tracer{
+		logger:      logger,
+		cfg:         cfg,
+		now:         time.Now,
+		traces:      map[string]*MessageTrace{},
+		subscribers: map[chan MessageTrace]struct{}{},
+	}
+	for _, pattern := range cfg.Topics {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid topic pattern %q: %w", pattern, err)
+		}
+	}
+	if len(cfg.File) > 0 {
+		name := cfg.File
+		if !filepath.IsAbs(name) {
+			name = filepath.Join(dataDir, name)
+		}
+		f, err := os.OpenFile(name, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+		if err != nil {
+			return nil, fmt.Errorf("open gossip traces file: %w", err)
+		}
+		t.file = f
+		t.out = bufio.NewWriter(f)
+	}
+	return t, nil
+}
+
+// Run publishes traces when their window is over, until the context is canceled.
+func (t *Tracer) Run(ctx context.Context) {
+	ticker := time.NewTicker(traceFlushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			t.flush(t.now())
+		}
+	}
+}
+
+// Close writes remaining traces and closes the file.
+func (t *Tracer) Close() error {
+	t.flush(time.Time{})
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for ch := range t.subscribers {
+		close(ch)
+		delete(t.subscribers, ch)
+	}
+	if t.file == nil {
+		return nil
+	}
+	return t.file.Close()
+}
+
+// Subscribe returns channel with published traces. Traces are dropped if the subscriber
+// doesn't keep up and the channel is full. Returned function must be called to unsubscribe.
+func (t *Tracer) Subscribe(size int) (<-chan MessageTrace, func()) {
+	ch := make(chan MessageTrace, size)
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.subscribers[ch] = struct{}{}
+	return ch, func() {
+		t.mu.Lock()
+		defer t.mu.Unlock()
+		if _, ok := t.subscribers[ch]; ok {
+			delete(t.subscribers, ch)
+			close(ch)
+		}
+	}
+}
+
+// flush publishes traces first seen before now minus window. Zero now publishes all traces.
+func (t *Tracer) flush(now time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	n := 0
+	for _, trace := range t.pending {
+		if !now.IsZero() && now.Sub(trace.FirstSeen) < t.cfg.Window {
+			break
+		}
+		n++
+		delete(t.traces, trace.ID)
+		for ch := range t.subscribers {
+			select {
+			case ch <- *trace:
+			default:
+			}
+		}
+		if t.out == nil {
+			continue
+		}
+		data, err := json.Marshal(trace)
+		if err != nil {
+			t.logger.With().Fatal("failed to encode gossip trace", log.Err(err))
+		}
+		data = append(data, '\n')
+		if _, err := t.out.Write(data); err != nil {
+			t.logger.With().Warning("failed to write gossip trace", log.Err(err))
+		}
+	}
+	t.pending = t.pending[n:]
+	if n == 0 || t.out == nil {
+		return
+	}
+	if err := t.out.Flush(); err != nil {
+		t.logger.With().Warning("failed to flush gossip traces", log.Err(err))
+	}
+}
+
+func (t *Tracer) traced(topic string) bool {
+	for _, pattern := range t.cfg.Topics {
+		if ok, _ := path.Match(pattern, topic); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// get returns the trace of the message if it is traced (non-thread-safe).
+func (t *Tracer) get(msg *pubsub.Message) *MessageTrace {
+	return t.traces[hex.EncodeToString([]byte(msg.ID))]
+}
+
+// validated is called with the result of the handlers for the message.
+func (t *Tracer) validated(msg *pubsub.Message, err error, duration time.Duration) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	trace := t.get(msg)
+	if trace == nil {
+		return
+	}
+	trace.Result = castResult(err)
+	if err != nil {
+		trace.Error = err.Error()
+	}
+	trace.Validation = duration
+}
+
+// ValidateMessage is invoked when a message first enters the validation pipeline.
+func (t *Tracer) ValidateMessage(msg *pubsub.Message) {
+	if !t.traced(msg.GetTopic()) {
+		return
+	}
+	id := hex.EncodeToString([]byte(msg.ID))
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if _, ok := t.traces[id]; ok {
+		return
+	}
+	trace := &MessageTrace{
+		ID:        id,
+		Topic:     msg.GetTopic(),
+		FirstSeen: t.now(),
+		Peer:      msg.ReceivedFrom.String(),
+	}
+	t.traces[id] = trace
+	t.pending = append(t.pending, trace)
+}
+
+// DuplicateMessage is invoked when a duplicate message is dropped.
+func (t *Tracer) DuplicateMessage(msg *pubsub.Message) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if trace := t.get(msg); trace != nil {
+		trace.Duplicates++
+	}
+}
+
+// RejectMessage is invoked when a message is Rejected or Ignored.
+func (t *Tracer) RejectMessage(msg *pubsub.Message, reason string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	// result of the handlers is recorded by validated
+	if trace := t.get(msg); trace != nil && trace.Result == "" {
+		trace.Result = reason
+	}
+}
+
+// AddPeer is invoked when a new peer is added.
+func (t *Tracer) AddPeer(peer.ID, protocol.ID) {}
+
+// RemovePeer is invoked when a peer is removed.
+func (t *Tracer) RemovePeer(peer.ID) {}
+
+// Join is invoked when a new topic is joined.
+func (t *Tracer) Join(string) {}
+
+// Leave is invoked when a topic is abandoned.
+func (t *Tracer) Leave(string) {}
+
+// Graft is invoked when a new peer is grafted on the mesh (gossipsub).
+func (t *Tracer) Graft(peer.ID, string) {}
+
+// Prune is invoked when a peer is pruned from the message (gossipsub).
+func (t *Tracer) Prune(peer.ID, string) {}
+
+// DeliverMessage is invoked when a message is delivered.
+func (t *Tracer) DeliverMessage(*pubsub.Message) {}
+
+// ThrottlePeer is invoked when a peer is throttled by the peer gater.
+func (t *Tracer) ThrottlePeer(peer.ID) {}
+
+// RecvRPC is invoked when an incoming RPC is received.
+func (t *Tracer) RecvRPC(*pubsub.RPC) {}
+
+// SendRPC is invoked when a RPC is sent.
+func (t *Tracer) SendRPC(*pubsub.RPC, peer.ID) {}
+
+// DropRPC is invoked when an outbound RPC is dropped, typically because of a queue full.
+func (t *Tracer) DropRPC(*pubsub.RPC, peer.ID) {}
+
+// UndeliverableMessage is invoked when the consumer of Subscribe is not reading messages fast enough and
+// the pressure release mechanism trigger, dropping messages.
+func (t *Tracer) UndeliverableMessage(*pubsub.Message) {}
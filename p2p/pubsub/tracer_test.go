@@ -0,0 +1,160 @@
+package pubsub
+
+import (
+	"bufio"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	pubsub "github.com/libp2p/go-libp2p-pubsub"
+	pb "github.com/libp2p/go-libp2p-pubsub/pb"
+	"github.com/libp2p/go-libp2p/core/peer"
+	mocknet "github.com/libp2p/go-libp2p/p2p/net/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/spacemeshos/go-spacemesh/log/logtest"
+)
+
+func testMessage(topic, id string, from peer.ID) *pubsub.Message {
+	return &pubsub.Message{
+		Message:      &pb.Message{Topic: &topic, Data: []byte(id)},
+		ID:           id,
+		ReceivedFrom: from,
+	}
+}
+
+func readTraces(tb testing.TB, name string) []MessageTrace {
+	tb.Helper()
+	f, err := os.Open(name)
+	require.NoError(tb, err)
+	defer f.Close()
+	var traces []MessageTrace
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var trace MessageTrace
+		require.NoError(tb, json.Unmarshal(scanner.Bytes(), &trace))
+		traces = append(traces, trace)
+	}
+	require.NoError(tb, scanner.Err())
+	return traces
+}
+
+func TestTracer(t *testing.T) {
+	dir := t.TempDir()
+	cfg := DefaultTraceConfig()
+	cfg.File = "traces.jsonl"
+	tracer, err := NewTracer(logtest.New(t), dir, cfg)
+	require.NoError(t, err)
+	now := time.Now()
+	tracer.now = func() time.Time { return now }
+	traces, unsubscribe := tracer.Subscribe(10)
+	defer unsubscribe()
+
+	accepted := testMessage(HareProtocol, "accepted", "first")
+	tracer.ValidateMessage(accepted)
+	tracer.validated(accepted, nil, time.Millisecond)
+	tracer.DeliverMessage(accepted)
+	tracer.DuplicateMessage(accepted)
+	tracer.DuplicateMessage(accepted)
+
+	now = now.Add(time.Second)
+	rejected := testMessage(AtxProtocol, "rejected", "second")
+	tracer.ValidateMessage(rejected)
+	tracer.validated(rejected, fmt.Errorf("%w: invalid atx", ErrValidationReject), time.Millisecond)
+	tracer.RejectMessage(rejected, pubsub.RejectValidationFailed)
+
+	throttled := testMessage("b11", "throttled", "third")
+	tracer.ValidateMessage(throttled)
+	tracer.RejectMessage(throttled, pubsub.RejectValidationThrottled)
+
+	// not traced
+	tracer.ValidateMessage(testMessage(TxProtocol, "tx", "first"))
+
+	tracer.flush(now.Add(cfg.Window - time.Second))
+	require.Equal(t, MessageTrace{
+		ID:         hex.EncodeToString([]byte("accepted")),
+		Topic:      HareProtocol,
+		FirstSeen:  now.Add(-time.Second),
+		Peer:       peer.ID("first").String(),
+		Duplicates: 2,
+		Result:     "accept",
+		Validation: time.Millisecond,
+	}, <-traces)
+	require.Empty(t, traces)
+
+	// duplicates after the window are not counted
+	tracer.flush(now.Add(cfg.Window))
+	tracer.DuplicateMessage(rejected)
+	require.Equal(t, MessageTrace{
+		ID:         hex.EncodeToString([]byte("rejected")),
+		Topic:      AtxProtocol,
+		FirstSeen:  now,
+		Peer:       peer.ID("second").String(),
+		Result:     "reject",
+		Error:      ErrValidationReject.Error() + ": invalid atx",
+		Validation: time.Millisecond,
+	}, <-traces)
+	require.Equal(t, pubsub.RejectValidationThrottled, (<-traces).Result)
+
+	require.NoError(t, tracer.Close())
+	_, ok := <-traces
+	require.False(t, ok)
+	written := readTraces(t, filepath.Join(dir, cfg.File))
+	require.Len(t, written, 3)
+	require.Equal(t, 2, written[0].Duplicates)
+	require.Equal(t, "reject", written[1].Result)
+}
+
+func TestTracerInvalidTopic(t *testing.T) {
+	_, err := NewTracer(logtest.New(t), t.TempDir(), TraceConfig{Topics: []string{"["}})
+	require.ErrorContains(t, err, "invalid topic pattern")
+}
+
+func TestTracerGossip(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	mesh, err := mocknet.FullMeshLinked(3)
+	require.NoError(t, err)
+	tracer, err := NewTracer(logtest.New(t), t.TempDir(), DefaultTraceConfig())
+	require.NoError(t, err)
+	var pubsubs []*PubSub
+	for i, h := range mesh.Hosts() {
+		cfg := Config{Flood: true, IsBootnode: true}
+		if i == 0 {
+			cfg.Tracer = tracer
+		}
+		ps, err := New(ctx, logtest.New(t), h, cfg)
+		require.NoError(t, err)
+		ps.Register(HareProtocol, func(context.Context, peer.ID, []byte) error {
+			return nil
+		})
+		pubsubs = append(pubsubs, ps)
+	}
+	require.NoError(t, mesh.ConnectAllButSelf())
+	require.Eventually(t, func() bool {
+		return len(pubsubs[0].ProtocolPeers(HareProtocol)) == 2
+	}, 5*time.Second, 10*time.Millisecond)
+
+	traces, unsubscribe := tracer.Subscribe(1)
+	defer unsubscribe()
+	require.NoError(t, pubsubs[1].Publish(ctx, HareProtocol, []byte("hare")))
+	require.Eventually(t, func() bool {
+		tracer.mu.Lock()
+		defer tracer.mu.Unlock()
+		return len(tracer.pending) == 1 && tracer.pending[0].Result != ""
+	}, 5*time.Second, 10*time.Millisecond)
+	require.NoError(t, tracer.Close())
+
+	trace := <-traces
+	require.Equal(t, HareProtocol, trace.Topic)
+	require.Equal(t, "accept", trace.Result)
+	require.Contains(t, []string{
+		mesh.Hosts()[1].ID().String(),
+		mesh.Hosts()[2].ID().String(),
+	}, trace.Peer)
+}
@@ -20,6 +20,7 @@ type PubSub struct {
 	logger log.Log
 	pubsub *pubsub.PubSub
 	host   host.Host
+	tracer *Tracer
 
 	mu     sync.RWMutex
 	topics map[string]*pubsub.Topic
@@ -39,6 +40,9 @@ func (ps *PubSub) Register(topic string, handler GossipHandler) {
 		err := handler(log.WithNewRequestID(ctx), pid, msg.Data)
 		metrics.ProcessedMessagesDuration.WithLabelValues(topic, castResult(err)).
 			Observe(float64(time.Since(start)))
+		if ps.tracer != nil {
+			ps.tracer.validated(msg, err, time.Since(start))
+		}
 		switch {
 		case errors.Is(err, ErrValidationReject):
 			return pubsub.ValidationReject
@@ -9,17 +9,51 @@ import (
 	"io"
 	"time"
 
+	lru "github.com/hashicorp/golang-lru/v2"
 	"github.com/libp2p/go-libp2p/core/network"
 	"github.com/libp2p/go-libp2p/core/peer"
 	"github.com/libp2p/go-libp2p/core/protocol"
+	"github.com/multiformats/go-multistream"
 	"github.com/multiformats/go-varint"
+	"golang.org/x/time/rate"
 
 	"github.com/spacemeshos/go-spacemesh/codec"
 	"github.com/spacemeshos/go-spacemesh/log"
+	"github.com/spacemeshos/go-spacemesh/p2p/metrics"
 )
 
-// ErrNotConnected is returned when peer is not connected.
-var ErrNotConnected = errors.New("peer is not connected")
+var (
+	// ErrNotConnected is returned when peer is not connected.
+	ErrNotConnected = errors.New("peer is not connected")
+	// ErrRateLimited is returned to the requester if the request was rejected by one of the Limits.
+	ErrRateLimited = errors.New("rate limited")
+	// ErrNotSupported is returned when peer doesn't serve the protocol.
+	ErrNotSupported = errors.New("protocol not supported")
+)
+
+// peersCacheSize is the number of peers with tracked rate limits.
+const peersCacheSize = 1000
+
+// Limits configures limits on the requests served by the server.
+// Zero value disables the corresponding limit. Requests that exceed any limit
+// are rejected with ErrRateLimited.
+type Limits struct {
+	// Rate of requests per second from all peers and Burst of the token bucket.
+	Rate  float64 `mapstructure:"rate"`
+	Burst int     `mapstructure:"burst"`
+	// PeerRate and PeerBurst limit requests from a single peer.
+	PeerRate  float64 `mapstructure:"peer-rate"`
+	PeerBurst int     `mapstructure:"peer-burst"`
+	// Concurrency is the maximal number of requests that are handled concurrently.
+	Concurrency int `mapstructure:"concurrency"`
+}
+
+func newLimiter(r float64, burst int) *rate.Limiter {
+	if burst < 1 {
+		burst = 1
+	}
+	return rate.NewLimiter(rate.Limit(r), burst)
+}
 
 // Opt is a type to configure a server.
 type Opt func(s *Server)
@@ -31,6 +65,14 @@ func WithTimeout(timeout time.Duration) Opt {
 	}
 }
 
+// WithStreamTimeout configures the time limit for the whole streamed response.
+// The limit releases the concurrency slot held by a peer that reads the response too slowly.
+func WithStreamTimeout(timeout time.Duration) Opt {
+	return func(s *Server) {
+		s.streamTimeout = timeout
+	}
+}
+
 // WithLog configures logger for the server.
 func WithLog(log log.Log) Opt {
 	return func(s *Server) {
@@ -51,12 +93,27 @@ func WithRequestSizeLimit(limit int) Opt {
 	}
 }
 
+// WithLimits configures rate and concurrency limits for the served requests.
+func WithLimits(limits Limits) Opt {
+	return func(s *Server) {
+		s.limits = limits
+	}
+}
+
 // Handler is the handler to be defined by the application.
 type Handler func(context.Context, []byte) ([]byte, error)
 
+// StreamHandler is the handler for the responses that are streamed in chunks.
+// Every call to send writes a chunk to the stream and blocks until it is accepted by the peer,
+// so that the handler doesn't get ahead of a slow peer.
+type StreamHandler func(ctx context.Context, req []byte, send func([]byte) error) error
+
 //go:generate scalegen -types Response
 
 // Response is a server response.
+//
+// Streamed response is a sequence of responses with chunks in Data, terminated by a response
+// with empty Data. Error in any of them terminates the response.
 type Response struct {
 	Data  []byte `scale:"max=10485760"` // 10 MiB
 	Error string `scale:"max=1024"`     // TODO(mafa): make error code instead of string
@@ -73,11 +130,18 @@ type Host interface {
 
 // Server for the Handler.
 type Server struct {
-	logger       log.Log
-	protocol     string
-	handler      Handler
-	timeout      time.Duration
-	requestLimit int
+	logger        log.Log
+	protocol      string
+	handler       Handler
+	stream        StreamHandler
+	timeout       time.Duration
+	streamTimeout time.Duration
+	requestLimit  int
+
+	limits   Limits
+	limiter  *rate.Limiter
+	peers    *lru.Cache[peer.ID, *rate.Limiter]
+	inflight chan struct{}
 
 	h Host
 
@@ -86,19 +150,48 @@ type Server struct {
 
 // New server for the handler.
 func New(h Host, proto string, handler Handler, opts ...Opt) *Server {
+	srv := newServer(h, proto, opts...)
+	srv.handler = handler
+	h.SetStreamHandler(protocol.ID(proto), srv.streamHandler)
+	return srv
+}
+
+// NewStreaming creates server for the handler that streams responses in chunks.
+// Such server is requested with StreamRequest. Timeout is applied to every chunk,
+// and stream timeout to the whole response.
+func NewStreaming(h Host, proto string, handler StreamHandler, opts ...Opt) *Server {
+	srv := newServer(h, proto, opts...)
+	srv.stream = handler
+	h.SetStreamHandler(protocol.ID(proto), srv.streamHandler)
+	return srv
+}
+
+func newServer(h Host, proto string, opts ...Opt) *Server {
 	srv := &Server{
-		ctx:          context.Background(),
-		logger:       log.NewNop(),
-		protocol:     proto,
-		handler:      handler,
-		h:            h,
-		timeout:      10 * time.Second,
-		requestLimit: 10240,
+		ctx:           context.Background(),
+		logger:        log.NewNop(),
+		protocol:      proto,
+		h:             h,
+		timeout:       10 * time.Second,
+		streamTimeout: 2 * time.Minute,
+		requestLimit:  10240,
 	}
 	for _, opt := range opts {
 		opt(srv)
 	}
-	h.SetStreamHandler(protocol.ID(proto), srv.streamHandler)
+	if srv.limits.Rate > 0 {
+		srv.limiter = newLimiter(srv.limits.Rate, srv.limits.Burst)
+	}
+	if srv.limits.PeerRate > 0 {
+		peers, err := lru.New[peer.ID, *rate.Limiter](peersCacheSize)
+		if err != nil {
+			log.Panic("could not initialize cache ", err)
+		}
+		srv.peers = peers
+	}
+	if srv.limits.Concurrency > 0 {
+		srv.inflight = make(chan struct{}, srv.limits.Concurrency)
+	}
 	return srv
 }
 
@@ -124,20 +217,45 @@ func (s *Server) streamHandler(stream network.Stream) {
 	if err != nil {
 		return
 	}
-	start := time.Now()
-	buf, err = s.handler(log.WithNewRequestID(s.ctx), buf)
-	s.logger.With().Debug("protocol handler execution time",
-		log.String("protocol", s.protocol),
-		log.Duration("duration", time.Since(start)),
-	)
+	wr := bufio.NewWriter(stream)
 	var resp Response
-	if err != nil {
-		resp.Error = err.Error()
+	if result := s.allow(stream.Conn().RemotePeer()); result != metrics.ResultOK {
+		metrics.ServerRequests.WithLabelValues(s.protocol, result).Inc()
+		resp.Error = ErrRateLimited.Error()
 	} else {
-		resp.Data = buf
+		metrics.ServerInflightRequests.WithLabelValues(s.protocol).Inc()
+		start := time.Now()
+		if s.stream != nil {
+			ctx, cancel := context.WithTimeout(log.WithNewRequestID(s.ctx), s.streamTimeout)
+			deadline, _ := ctx.Deadline()
+			err = s.stream(ctx, buf, func(chunk []byte) error {
+				return s.writeChunk(stream, wr, chunk, deadline)
+			})
+			cancel()
+		} else {
+			buf, err = s.handler(log.WithNewRequestID(s.ctx), buf)
+		}
+		if s.inflight != nil {
+			// slot is released before the response is written, so that the response
+			// is not racing with the next request from the same peer
+			<-s.inflight
+		}
+		metrics.ServerInflightRequests.WithLabelValues(s.protocol).Dec()
+		s.logger.With().Debug("protocol handler execution time",
+			log.String("protocol", s.protocol),
+			log.Duration("duration", time.Since(start)),
+		)
+		if err != nil {
+			metrics.ServerRequests.WithLabelValues(s.protocol, metrics.ResultError).Inc()
+			resp.Error = err.Error()
+		} else {
+			metrics.ServerRequests.WithLabelValues(s.protocol, metrics.ResultOK).Inc()
+			if s.stream == nil {
+				resp.Data = buf
+			}
+		}
 	}
 
-	wr := bufio.NewWriter(stream)
 	if _, err := codec.EncodeTo(wr, &resp); err != nil {
 		s.logger.With().Warning("failed to write response", log.Err(err))
 		return
@@ -147,14 +265,96 @@ func (s *Server) streamHandler(stream network.Stream) {
 	}
 }
 
+// writeChunk writes the chunk of the streamed response and extends the deadline of the stream
+// up to the deadline of the whole response.
+func (s *Server) writeChunk(stream network.Stream, wr *bufio.Writer, chunk []byte, deadline time.Time) error {
+	if len(chunk) == 0 {
+		// empty chunk terminates the response
+		return nil
+	}
+	now := time.Now()
+	if !now.Before(deadline) {
+		return context.DeadlineExceeded
+	}
+	next := now.Add(s.timeout)
+	if next.After(deadline) {
+		next = deadline
+	}
+	_ = stream.SetDeadline(next)
+	if _, err := codec.EncodeTo(wr, &Response{Data: chunk}); err != nil {
+		return err
+	}
+	if err := wr.Flush(); err != nil {
+		return err
+	}
+	metrics.ServerResponseChunks.WithLabelValues(s.protocol).Inc()
+	return nil
+}
+
+// allow checks the limits for the request from the peer.
+// If the request is allowed, a slot for concurrent requests is taken and must be released
+// after the request is handled. Tokens are taken only if the request passes all limits,
+// so that requests rejected by the server limits don't count against the peer.
+func (s *Server) allow(pid peer.ID) string {
+	var (
+		now              = time.Now()
+		peerToken, token *rate.Reservation
+	)
+	if s.peers != nil {
+		limiter, ok := s.peers.Get(pid)
+		if !ok {
+			limiter = newLimiter(s.limits.PeerRate, s.limits.PeerBurst)
+			s.peers.Add(pid, limiter)
+		}
+		if peerToken = reserveAt(limiter, now); peerToken == nil {
+			return metrics.ResultPeerRateLimited
+		}
+	}
+	if s.limiter != nil {
+		if token = reserveAt(s.limiter, now); token == nil {
+			cancelAt(peerToken, now)
+			return metrics.ResultRateLimited
+		}
+	}
+	if s.inflight != nil {
+		select {
+		case s.inflight <- struct{}{}:
+		default:
+			cancelAt(peerToken, now)
+			cancelAt(token, now)
+			return metrics.ResultConcurrencyLimited
+		}
+	}
+	return metrics.ResultOK
+}
+
+// reserveAt takes a token if it is available at now without waiting, otherwise returns nil.
+func reserveAt(limiter *rate.Limiter, now time.Time) *rate.Reservation {
+	r := limiter.ReserveN(now, 1)
+	if !r.OK() {
+		return nil
+	}
+	if r.DelayFrom(now) > 0 {
+		r.CancelAt(now)
+		return nil
+	}
+	return r
+}
+
+// cancelAt returns the token to the limiter. It must be called with the same time
+// that was used for the reservation, reservations that already took effect
+// are not restored by the limiter.
+func cancelAt(r *rate.Reservation, now time.Time) {
+	if r != nil {
+		r.CancelAt(now)
+	}
+}
+
 // Request sends a binary request to the peer. Request is executed in the background, one of the callbacks
 // is guaranteed to be called on success/error.
 func (s *Server) Request(ctx context.Context, pid peer.ID, req []byte, resp func([]byte), failure func(error)) error {
-	if len(req) > s.requestLimit {
-		return fmt.Errorf("request length (%d) is longer than limit %d", len(req), s.requestLimit)
-	}
-	if s.h.Network().Connectedness(pid) != network.Connected {
-		return fmt.Errorf("%w: %s", ErrNotConnected, pid)
+	if err := s.checkRequest(pid, req); err != nil {
+		return err
 	}
 	go func() {
 		start := time.Now()
@@ -166,7 +366,7 @@ func (s *Server) Request(ctx context.Context, pid peer.ID, req []byte, resp func
 		}()
 		ctx, cancel := context.WithTimeout(ctx, s.timeout)
 		defer cancel()
-		stream, err := s.h.NewStream(network.WithNoDial(ctx, "existing connection"), pid, protocol.ID(s.protocol))
+		stream, err := s.newStream(ctx, pid)
 		if err != nil {
 			failure(err)
 			return
@@ -175,35 +375,125 @@ func (s *Server) Request(ctx context.Context, pid peer.ID, req []byte, resp func
 		defer stream.SetDeadline(time.Time{})
 		_ = stream.SetDeadline(time.Now().Add(s.timeout))
 
-		wr := bufio.NewWriter(stream)
-		sz := make([]byte, binary.MaxVarintLen64)
-		n := binary.PutUvarint(sz, uint64(len(req)))
-		_, err = wr.Write(sz[:n])
-		if err != nil {
+		if err := writeRequest(stream, req); err != nil {
 			failure(err)
 			return
 		}
-		_, err = wr.Write(req)
-		if err != nil {
-			failure(err)
-			return
-		}
-		if err := wr.Flush(); err != nil {
-			failure(err)
-			return
-		}
-
 		rd := bufio.NewReader(stream)
 		var r Response
 		if _, err := codec.DecodeFrom(rd, &r); err != nil {
 			failure(err)
 			return
 		}
-		if len(r.Error) > 0 {
-			failure(errors.New(r.Error))
+		if err := s.responseError(&r); err != nil {
+			failure(err)
 		} else {
 			resp(r.Data)
 		}
 	}()
 	return nil
 }
+
+// StreamRequest sends a binary request to the peer that is served by the StreamHandler,
+// and calls chunk for every chunk of the response. It blocks until the response is complete,
+// the stream fails or chunk returns an error.
+// The next chunk is not read before chunk returns, so that the slow reader throttles the server.
+func (s *Server) StreamRequest(ctx context.Context, pid peer.ID, req []byte, chunk func([]byte) error) error {
+	if err := s.checkRequest(pid, req); err != nil {
+		return err
+	}
+	start := time.Now()
+	defer func() {
+		s.logger.WithContext(ctx).With().Debug("stream request execution time",
+			log.String("protocol", s.protocol),
+			log.Duration("duration", time.Since(start)),
+		)
+	}()
+	sctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+	stream, err := s.newStream(sctx, pid)
+	if err != nil {
+		return err
+	}
+	defer stream.Close()
+	defer stream.SetDeadline(time.Time{})
+	_ = stream.SetDeadline(time.Now().Add(s.timeout))
+
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			stream.Reset()
+		case <-done:
+		}
+	}()
+
+	if err := writeRequest(stream, req); err != nil {
+		return err
+	}
+	rd := bufio.NewReader(stream)
+	for {
+		var r Response
+		if _, err := codec.DecodeFrom(rd, &r); err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := s.responseError(&r); err != nil {
+			return err
+		}
+		if len(r.Data) == 0 {
+			return nil
+		}
+		if err := chunk(r.Data); err != nil {
+			return err
+		}
+		_ = stream.SetDeadline(time.Now().Add(s.timeout))
+	}
+}
+
+func (s *Server) checkRequest(pid peer.ID, req []byte) error {
+	if len(req) > s.requestLimit {
+		return fmt.Errorf("request length (%d) is longer than limit %d", len(req), s.requestLimit)
+	}
+	if s.h.Network().Connectedness(pid) != network.Connected {
+		return fmt.Errorf("%w: %s", ErrNotConnected, pid)
+	}
+	return nil
+}
+
+func (s *Server) newStream(ctx context.Context, pid peer.ID) (network.Stream, error) {
+	stream, err := s.h.NewStream(network.WithNoDial(ctx, "existing connection"), pid, protocol.ID(s.protocol))
+	if errors.Is(err, multistream.ErrNotSupported[protocol.ID]{}) {
+		return nil, fmt.Errorf("%w: %s", ErrNotSupported, s.protocol)
+	}
+	return stream, err
+}
+
+func (s *Server) responseError(r *Response) error {
+	switch {
+	case r.Error == ErrRateLimited.Error():
+		return fmt.Errorf("%w: %s", ErrRateLimited, s.protocol)
+	case len(r.Error) > 0:
+		return errors.New(r.Error)
+	}
+	return nil
+}
+
+func writeRequest(stream io.Writer, req []byte) error {
+	wr := bufio.NewWriter(stream)
+	sz := make([]byte, binary.MaxVarintLen64)
+	n := binary.PutUvarint(sz, uint64(len(req)))
+	if _, err := wr.Write(sz[:n]); err != nil {
+		return err
+	}
+	if _, err := wr.Write(req); err != nil {
+		return err
+	}
+	return wr.Flush()
+}
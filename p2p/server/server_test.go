@@ -1,11 +1,13 @@
 package server
 
 import (
+	"bytes"
 	"context"
 	"errors"
 	"testing"
 	"time"
 
+	"github.com/libp2p/go-libp2p/core/protocol"
 	mocknet "github.com/libp2p/go-libp2p/p2p/net/mock"
 	"github.com/spacemeshos/go-scale/tester"
 	"github.com/stretchr/testify/require"
@@ -92,6 +94,211 @@ func TestServer(t *testing.T) {
 	})
 }
 
+func TestServerLimits(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	mesh, err := mocknet.FullMeshConnected(3)
+	require.NoError(t, err)
+	proto := "test"
+	request := []byte("test request")
+
+	var (
+		handler = func(_ context.Context, msg []byte) ([]byte, error) {
+			return msg, nil
+		}
+		block    = make(chan struct{})
+		blocked  = make(chan struct{}, 1)
+		blocking = func(_ context.Context, msg []byte) ([]byte, error) {
+			blocked <- struct{}{}
+			<-block
+			return msg, nil
+		}
+		opts = []Opt{WithTimeout(time.Second), WithContext(ctx)}
+	)
+	clients := []*Server{
+		New(mesh.Hosts()[0], proto, handler, opts...),
+		New(mesh.Hosts()[1], proto, handler, opts...),
+	}
+	send := func(tb testing.TB, client *Server) error {
+		tb.Helper()
+		errch := make(chan error, 1)
+		require.NoError(tb, client.Request(ctx, mesh.Hosts()[2].ID(), request,
+			func([]byte) { errch <- nil },
+			func(err error) { errch <- err },
+		))
+		select {
+		case <-time.After(time.Second):
+			require.FailNow(tb, "timed out while waiting for response")
+		case err := <-errch:
+			return err
+		}
+		return nil
+	}
+	serve := func(h Handler, limits Limits) {
+		mesh.Hosts()[2].RemoveStreamHandler(protocol.ID(proto))
+		_ = New(mesh.Hosts()[2], proto, h, append(opts, WithLimits(limits))...)
+	}
+
+	t.Run("peer rate", func(t *testing.T) {
+		serve(handler, Limits{PeerRate: 0.001, PeerBurst: 2})
+		require.NoError(t, send(t, clients[0]))
+		require.NoError(t, send(t, clients[0]))
+		err := send(t, clients[0])
+		require.ErrorIs(t, err, ErrRateLimited)
+		require.ErrorContains(t, err, proto)
+		require.NoError(t, send(t, clients[1]))
+	})
+	t.Run("protocol rate", func(t *testing.T) {
+		serve(handler, Limits{Rate: 0.001, Burst: 1})
+		require.NoError(t, send(t, clients[0]))
+		require.ErrorIs(t, send(t, clients[1]), ErrRateLimited)
+	})
+	t.Run("concurrency", func(t *testing.T) {
+		serve(blocking, Limits{Concurrency: 1})
+		errch := make(chan error, 1)
+		go func() {
+			errch <- send(t, clients[0])
+		}()
+		<-blocked
+		require.ErrorIs(t, send(t, clients[1]), ErrRateLimited)
+		close(block)
+		require.NoError(t, <-errch)
+		require.NoError(t, send(t, clients[1]))
+	})
+	t.Run("rejected requests don't take peer tokens", func(t *testing.T) {
+		var (
+			release = make(chan struct{})
+			entered = make(chan struct{}, 2)
+		)
+		serve(func(_ context.Context, msg []byte) ([]byte, error) {
+			entered <- struct{}{}
+			<-release
+			return msg, nil
+		}, Limits{PeerRate: 0.001, PeerBurst: 1, Concurrency: 1})
+		errch := make(chan error, 1)
+		go func() {
+			errch <- send(t, clients[0])
+		}()
+		<-entered
+		require.ErrorIs(t, send(t, clients[1]), ErrRateLimited)
+		close(release)
+		require.NoError(t, <-errch)
+		require.NoError(t, send(t, clients[1]))
+	})
+}
+
+func TestServerStream(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	mesh, err := mocknet.FullMeshConnected(3)
+	require.NoError(t, err)
+	proto := "test"
+	testErr := errors.New("test error")
+
+	var chunks [][]byte
+	for i := 0; i < 10; i++ {
+		chunks = append(chunks, bytes.Repeat([]byte{byte(i)}, 1000))
+	}
+	handler := func(_ context.Context, req []byte, send func([]byte) error) error {
+		for _, chunk := range chunks {
+			if err := send(chunk); err != nil {
+				return err
+			}
+		}
+		if string(req) == "fail" {
+			return testErr
+		}
+		return nil
+	}
+	opts := []Opt{WithTimeout(time.Second), WithContext(ctx)}
+	client := NewStreaming(mesh.Hosts()[0], proto, handler, opts...)
+	_ = NewStreaming(mesh.Hosts()[1], proto, handler, opts...)
+
+	t.Run("chunks", func(t *testing.T) {
+		var received [][]byte
+		require.NoError(t, client.StreamRequest(ctx, mesh.Hosts()[1].ID(), []byte("test"), func(chunk []byte) error {
+			received = append(received, chunk)
+			return nil
+		}))
+		require.Equal(t, chunks, received)
+	})
+	t.Run("error after chunks", func(t *testing.T) {
+		var received [][]byte
+		err := client.StreamRequest(ctx, mesh.Hosts()[1].ID(), []byte("fail"), func(chunk []byte) error {
+			received = append(received, chunk)
+			return nil
+		})
+		require.Equal(t, testErr, err)
+		require.Equal(t, chunks, received)
+	})
+	t.Run("stopped by reader", func(t *testing.T) {
+		received := 0
+		err := client.StreamRequest(ctx, mesh.Hosts()[1].ID(), []byte("test"), func(chunk []byte) error {
+			received++
+			if received == 2 {
+				return testErr
+			}
+			return nil
+		})
+		require.ErrorIs(t, err, testErr)
+		require.Equal(t, 2, received)
+	})
+	t.Run("canceled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(ctx)
+		err := client.StreamRequest(ctx, mesh.Hosts()[1].ID(), []byte("test"), func(chunk []byte) error {
+			cancel()
+			return nil
+		})
+		require.ErrorIs(t, err, context.Canceled)
+	})
+	t.Run("not supported", func(t *testing.T) {
+		err := client.StreamRequest(ctx, mesh.Hosts()[2].ID(), []byte("test"), func([]byte) error {
+			return nil
+		})
+		require.ErrorIs(t, err, ErrNotSupported)
+	})
+}
+
+func TestServerStreamTimeout(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	mesh, err := mocknet.FullMeshConnected(2)
+	require.NoError(t, err)
+	proto := "test"
+	handler := func(ctx context.Context, _ []byte, send func([]byte) error) error {
+		for i := 0; i < 10; i++ {
+			if err := send([]byte{byte(i)}); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	opts := []Opt{WithTimeout(time.Second), WithContext(ctx)}
+	client := NewStreaming(mesh.Hosts()[0], proto, handler, opts...)
+	_ = NewStreaming(mesh.Hosts()[1], proto, handler, append(opts,
+		WithStreamTimeout(100*time.Millisecond),
+		WithLimits(Limits{Concurrency: 1}),
+	)...)
+
+	// slow reader is disconnected once the whole response timeout elapses
+	err = client.StreamRequest(ctx, mesh.Hosts()[1].ID(), nil, func([]byte) error {
+		time.Sleep(50 * time.Millisecond)
+		return nil
+	})
+	require.Error(t, err)
+
+	// and the slot for the concurrent requests is released
+	received := 0
+	require.Eventually(t, func() bool {
+		received = 0
+		return client.StreamRequest(ctx, mesh.Hosts()[1].ID(), nil, func([]byte) error {
+			received++
+			return nil
+		}) == nil
+	}, time.Second, 10*time.Millisecond)
+	require.Equal(t, 10, received)
+}
+
 func FuzzResponseConsistency(f *testing.F) {
 	tester.FuzzConsistency[Response](f)
 }
@@ -14,6 +14,7 @@ import (
 	"golang.org/x/sync/errgroup"
 
 	"github.com/spacemeshos/go-spacemesh/log"
+	"github.com/spacemeshos/go-spacemesh/p2p/book"
 	discovery "github.com/spacemeshos/go-spacemesh/p2p/dhtdiscovery"
 	"github.com/spacemeshos/go-spacemesh/p2p/peerexchange"
 	"github.com/spacemeshos/go-spacemesh/p2p/pubsub"
@@ -68,6 +69,8 @@ type Host struct {
 
 	host.Host
 	*pubsub.PubSub
+	tracer *pubsub.Tracer
+	book   *book.Book
 
 	nodeReporter func()
 
@@ -100,18 +103,27 @@ func Upgrade(h host.Host, opts ...Opt) (*Host, error) {
 	for _, peer := range direct {
 		h.ConnManager().Protect(peer.ID, "direct")
 	}
+	if cfg.GossipTrace.Enable {
+		if fh.tracer, err = pubsub.NewTracer(fh.logger.WithName("gossip-trace"), cfg.DataDir, cfg.GossipTrace); err != nil {
+			return nil, fmt.Errorf("failed to initialize gossip tracer: %w", err)
+		}
+	}
 	if fh.PubSub, err = pubsub.New(fh.ctx, fh.logger, h, pubsub.Config{
 		Flood:          cfg.Flood,
 		IsBootnode:     cfg.Bootnode,
 		Direct:         direct,
 		Bootnodes:      bootnodes,
 		MaxMessageSize: cfg.MaxMessageSize,
+		Tracer:         fh.tracer,
 	}); err != nil {
 		return nil, fmt.Errorf("failed to initialize pubsub: %w", err)
 	}
 	if !cfg.DisableLegacyDiscovery {
-		if fh.legacy, err = peerexchange.New(fh.logger, h, peerexchange.Config{
-			DataDir:          cfg.DataDir,
+		addrs := fh.book
+		if addrs == nil {
+			addrs = book.New()
+		}
+		if fh.legacy, err = peerexchange.New(fh.logger, h, addrs, peerexchange.Config{
 			Bootnodes:        cfg.Bootnodes,
 			AdvertiseAddress: cfg.AdvertiseAddress,
 			MinPeers:         cfg.MinPeers,
@@ -138,11 +150,8 @@ func Upgrade(h host.Host, opts ...Opt) (*Host, error) {
 	}
 	if cfg.Bootnode {
 		dopts = append(dopts, discovery.Server())
-	} else {
-		backup, err := loadPeers(cfg.DataDir)
-		if err != nil {
-			fh.logger.With().Warning("failed to to load backup peers", log.Err(err))
-		} else if len(backup) > 0 {
+	} else if fh.book != nil {
+		if backup := backupPeers(fh.book); len(backup) > 0 {
 			dopts = append(dopts, discovery.WithBackup(backup))
 		}
 	}
@@ -151,6 +160,9 @@ func Upgrade(h host.Host, opts ...Opt) (*Host, error) {
 		return nil, err
 	}
 	fh.discovery = dhtdisc
+	if fh.book != nil {
+		fh.notifyPeerBook()
+	}
 	if fh.nodeReporter != nil {
 		fh.Network().Notify(&network.NotifyBundle{
 			ConnectedF: func(network.Network, network.Conn) {
@@ -169,6 +181,11 @@ func (fh *Host) GetPeers() []Peer {
 	return fh.Host.Network().Peers()
 }
 
+// GossipTracer returns tracer of gossip messages, nil if tracing is disabled.
+func (fh *Host) GossipTracer() *pubsub.Tracer {
+	return fh.tracer
+}
+
 // PeerCount returns number of connected peers.
 func (fh *Host) PeerCount() uint64 {
 	return uint64(len(fh.Host.Network().Peers()))
@@ -184,9 +201,15 @@ func (fh *Host) Start() error {
 		fh.legacy.StartScan()
 	}
 	fh.discovery.Start()
-	if !fh.cfg.Bootnode {
+	if fh.tracer != nil {
 		fh.eg.Go(func() error {
-			persist(fh.ctx, fh.logger, fh.Host, fh.cfg.DataDir, 30*time.Minute)
+			fh.tracer.Run(fh.ctx)
+			return nil
+		})
+	}
+	if fh.book != nil {
+		fh.eg.Go(func() error {
+			fh.maintainPeerBook(fh.ctx)
 			return nil
 		})
 	}
@@ -207,9 +230,17 @@ func (fh *Host) Stop() error {
 	}
 	fh.discovery.Stop()
 	fh.eg.Wait()
+	if fh.book != nil {
+		fh.savePeerBook()
+	}
 	if err := fh.Host.Close(); err != nil {
 		return fmt.Errorf("failed to close libp2p host: %w", err)
 	}
+	if fh.tracer != nil {
+		if err := fh.tracer.Close(); err != nil {
+			return fmt.Errorf("failed to close gossip tracer: %w", err)
+		}
+	}
 	lp2plog.SetPrimaryCore(zapcore.NewNopCore())
 	return nil
 }
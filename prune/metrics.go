@@ -0,0 +1,25 @@
+package prune
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/spacemeshos/go-spacemesh/metrics"
+)
+
+const namespace = "prune"
+
+var (
+	pruneLatency = metrics.NewHistogramWithBuckets(
+		"latency",
+		namespace,
+		"latency of a single pruning run in seconds",
+		[]string{},
+		prometheus.ExponentialBuckets(0.01, 2, 12),
+	).WithLabelValues()
+	prunedLayer = metrics.NewGauge(
+		"layer",
+		namespace,
+		"mesh data before this layer is pruned",
+		[]string{},
+	).WithLabelValues()
+)
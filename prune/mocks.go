@@ -0,0 +1,49 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: ./interface.go
+
+// Package prune is a generated GoMock package.
+package prune
+
+import (
+	reflect "reflect"
+
+	gomock "github.com/golang/mock/gomock"
+	types "github.com/spacemeshos/go-spacemesh/common/types"
+)
+
+// Mocktortoise is a mock of tortoise interface.
+type Mocktortoise struct {
+	ctrl     *gomock.Controller
+	recorder *MocktortoiseMockRecorder
+}
+
+// MocktortoiseMockRecorder is the mock recorder for Mocktortoise.
+type MocktortoiseMockRecorder struct {
+	mock *Mocktortoise
+}
+
+// NewMocktortoise creates a new mock instance.
+func NewMocktortoise(ctrl *gomock.Controller) *Mocktortoise {
+	mock := &Mocktortoise{ctrl: ctrl}
+	mock.recorder = &MocktortoiseMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *Mocktortoise) EXPECT() *MocktortoiseMockRecorder {
+	return m.recorder
+}
+
+// LatestComplete mocks base method.
+func (m *Mocktortoise) LatestComplete() types.LayerID {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LatestComplete")
+	ret0, _ := ret[0].(types.LayerID)
+	return ret0
+}
+
+// LatestComplete indicates an expected call of LatestComplete.
+func (mr *MocktortoiseMockRecorder) LatestComplete() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LatestComplete", reflect.TypeOf((*Mocktortoise)(nil).LatestComplete))
+}
@@ -0,0 +1,194 @@
+// Package prune removes mesh data that is not needed after the layer is outside
+// of the tortoise window.
+package prune
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/spacemeshos/go-spacemesh/common/types"
+	"github.com/spacemeshos/go-spacemesh/log"
+	"github.com/spacemeshos/go-spacemesh/sql"
+	"github.com/spacemeshos/go-spacemesh/sql/certificates"
+	"github.com/spacemeshos/go-spacemesh/sql/layers"
+	"github.com/spacemeshos/go-spacemesh/sql/proposals"
+	"github.com/spacemeshos/go-spacemesh/sql/transactions"
+)
+
+// Config for pruning.
+type Config struct {
+	// Enabled pruning. Archive nodes keep all data and should not enable it.
+	Enabled bool `mapstructure:"enabled"`
+	// SafetyMargin is a number of layers that are kept in addition to the tortoise window and hdist.
+	SafetyMargin uint32 `mapstructure:"safety-margin"`
+	// PrunedIDsRetention is a number of layers after the cutoff for which ids of pruned
+	// proposals are kept, so that fetch can tell peers that the proposals were pruned.
+	PrunedIDsRetention uint32 `mapstructure:"pruned-ids-retention"`
+	// Interval between pruning runs.
+	Interval time.Duration `mapstructure:"interval"`
+	// BatchSize is a number of layers that are pruned in a single transaction.
+	BatchSize uint32 `mapstructure:"batch-size"`
+}
+
+// DefaultConfig for pruning.
+func DefaultConfig() Config {
+	return Config{
+		SafetyMargin:       1000,
+		PrunedIDsRetention: 10000,
+		Interval:           10 * time.Minute,
+		BatchSize:          100,
+	}
+}
+
+// Opt for configuring Pruner.
+type Opt func(*Pruner)
+
+// WithLogger changes logger.
+func WithLogger(logger log.Log) Opt {
+	return func(p *Pruner) {
+		p.logger = logger
+	}
+}
+
+// WithConfig changes config.
+func WithConfig(cfg Config) Opt {
+	return func(p *Pruner) {
+		p.cfg = cfg
+	}
+}
+
+// New creates Pruner that keeps data within the tortoise window, hdist and safety margin
+// from the last verified and applied layer.
+func New(db *sql.Database, trtl tortoise, hdist, window uint32, opts ...Opt) *Pruner {
+	p := &Pruner{
+		logger: log.NewNop(),
+		cfg:    DefaultConfig(),
+		db:     db,
+		trtl:   trtl,
+		hdist:  hdist,
+		window: window,
+	}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p
+}
+
+// Pruner deletes proposals, their transaction references and certificates.
+//
+// Data is deleted up to the first layer of the epoch. Ballots are not pruned, as tortoise
+// decodes votes of every ballot relative to its base ballot when it recovers from the database.
+// Blocks, hare outputs, aggregated hashes, atxs, accounts and malfeasance proofs are not pruned,
+// as they are needed for state, checkpoints and malfeasance proofs.
+type Pruner struct {
+	logger log.Log
+	cfg    Config
+	db     *sql.Database
+	trtl   tortoise
+	hdist  uint32
+	window uint32
+}
+
+// Run pruning periodically until context is canceled.
+func (p *Pruner) Run(ctx context.Context) error {
+	if p.cfg.Interval <= 0 {
+		return fmt.Errorf("prune interval must be positive: %v", p.cfg.Interval)
+	}
+	ticker := time.NewTicker(p.cfg.Interval)
+	defer ticker.Stop()
+	for {
+		if err := p.Prune(ctx); err != nil && ctx.Err() == nil {
+			p.logger.With().Error("failed to prune mesh data", log.Err(err))
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// Prune data before the cutoff computed from the last verified and applied layer.
+// Data is deleted in batches of layers, each batch in its own transaction.
+func (p *Pruner) Prune(ctx context.Context) error {
+	if p.cfg.BatchSize == 0 {
+		return errors.New("prune batch size must be positive")
+	}
+	applied, err := layers.GetLastApplied(p.db)
+	if err != nil {
+		return err
+	}
+	verified := p.trtl.LatestComplete()
+	keep := p.window
+	if p.hdist > keep {
+		keep = p.hdist
+	}
+	keep += p.cfg.SafetyMargin
+	cutoff := Cutoff(types.MinLayer(verified, applied), keep)
+	pruned, err := layers.GetPruned(p.db)
+	if err != nil {
+		return err
+	}
+	if !cutoff.After(pruned) {
+		return nil
+	}
+	start := time.Now()
+	for pruned.Before(cutoff) {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		next := types.MinLayer(types.MaxLayer(pruned, types.GetEffectiveGenesis()).Add(p.cfg.BatchSize), cutoff)
+		if err := p.pruneBefore(ctx, next); err != nil {
+			return err
+		}
+		pruned = next
+		prunedLayer.Set(float64(pruned))
+	}
+	pruneLatency.Observe(time.Since(start).Seconds())
+	p.logger.With().Info("pruned mesh data",
+		log.Stringer("before", cutoff),
+		log.Stringer("verified", verified),
+		log.Stringer("applied", applied),
+		log.Duration("duration", time.Since(start)),
+	)
+	return nil
+}
+
+func (p *Pruner) pruneBefore(ctx context.Context, lid types.LayerID) error {
+	if err := p.db.WithTx(ctx, func(tx *sql.Tx) error {
+		if err := proposals.DeleteBefore(tx, lid); err != nil {
+			return err
+		}
+		if lid > types.LayerID(p.cfg.PrunedIDsRetention) {
+			if err := proposals.DeletePrunedBefore(tx, lid.Sub(p.cfg.PrunedIDsRetention)); err != nil {
+				return err
+			}
+		}
+		if err := transactions.DeleteProposalTxsBefore(tx, lid); err != nil {
+			return err
+		}
+		if err := certificates.DeleteCertBefore(tx, lid); err != nil {
+			return err
+		}
+		return layers.SetPruned(tx, lid)
+	}); err != nil {
+		return fmt.Errorf("prune before %s: %w", lid, err)
+	}
+	return nil
+}
+
+// Cutoff returns the first layer that is kept if data is kept for at least keep layers before the last layer.
+// Cutoff is rounded down to the first layer in the epoch, and never precedes effective genesis.
+func Cutoff(last types.LayerID, keep uint32) types.LayerID {
+	genesis := types.GetEffectiveGenesis()
+	if !last.After(genesis.Add(keep)) {
+		return genesis
+	}
+	cutoff := last.Sub(keep).GetEpoch().FirstLayer()
+	if cutoff.Before(genesis) {
+		return genesis
+	}
+	return cutoff
+}
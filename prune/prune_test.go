@@ -0,0 +1,128 @@
+package prune
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/spacemeshos/go-spacemesh/common/types"
+	"github.com/spacemeshos/go-spacemesh/log/logtest"
+	"github.com/spacemeshos/go-spacemesh/sql"
+	"github.com/spacemeshos/go-spacemesh/sql/ballots"
+	"github.com/spacemeshos/go-spacemesh/sql/certificates"
+	"github.com/spacemeshos/go-spacemesh/sql/layers"
+	"github.com/spacemeshos/go-spacemesh/sql/proposals"
+	"github.com/spacemeshos/go-spacemesh/sql/transactions"
+)
+
+const layersPerEpoch = 4
+
+func TestMain(m *testing.M) {
+	types.SetLayersPerEpoch(layersPerEpoch)
+	res := m.Run()
+	os.Exit(res)
+}
+
+func TestCutoff(t *testing.T) {
+	genesis := types.GetEffectiveGenesis()
+	for _, tc := range []struct {
+		desc    string
+		applied types.LayerID
+		keep    uint32
+		expect  types.LayerID
+	}{
+		{desc: "before genesis", applied: 0, keep: 10, expect: genesis},
+		{desc: "within keep", applied: genesis.Add(10), keep: 10, expect: genesis},
+		{desc: "first epoch after genesis", applied: genesis.Add(11), keep: 10, expect: genesis.Add(1)},
+		{desc: "rounded to epoch", applied: 30, keep: 10, expect: 20},
+		{desc: "epoch start", applied: 34, keep: 10, expect: 24},
+	} {
+		tc := tc
+		t.Run(tc.desc, func(t *testing.T) {
+			require.Equal(t, tc.expect, Cutoff(tc.applied, tc.keep))
+		})
+	}
+}
+
+func createProposal(tb testing.TB, db sql.Executor, lid types.LayerID) *types.Proposal {
+	tb.Helper()
+	ballot := types.NewExistingBallot(types.RandomBallotID(), types.RandomEdSignature(), types.RandomNodeID(), lid)
+	require.NoError(tb, ballots.Add(db, &ballot))
+	proposal := &types.Proposal{
+		InnerProposal: types.InnerProposal{
+			Ballot: ballot,
+			TxIDs:  []types.TransactionID{types.RandomTransactionID()},
+		},
+		Signature: types.RandomEdSignature(),
+	}
+	proposal.SetID(types.RandomProposalID())
+	require.NoError(tb, proposals.Add(db, proposal))
+	require.NoError(tb, transactions.AddToProposal(db, proposal.TxIDs[0], lid, proposal.ID()))
+	require.NoError(tb, certificates.Add(db, lid, &types.Certificate{BlockID: types.BlockID{byte(lid)}}))
+	return proposal
+}
+
+func TestPrune(t *testing.T) {
+	db := sql.InMemory()
+	const (
+		hdist    = 2
+		window   = 4
+		margin   = 4
+		verified = 30
+		last     = 34
+	)
+	var created []*types.Proposal
+	for lid := types.LayerID(1); lid <= last; lid++ {
+		created = append(created, createProposal(t, db, lid))
+		require.NoError(t, layers.SetApplied(db, lid, types.BlockID{byte(lid)}))
+	}
+	trtl := NewMocktortoise(gomock.NewController(t))
+	trtl.EXPECT().LatestComplete().Return(types.LayerID(verified)).AnyTimes()
+	pruner := New(db, trtl, hdist, window,
+		WithLogger(logtest.New(t)),
+		WithConfig(Config{Enabled: true, SafetyMargin: margin, PrunedIDsRetention: 8, BatchSize: 3}),
+	)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	require.ErrorIs(t, pruner.Prune(ctx), context.Canceled)
+	pruned, err := layers.GetPruned(db)
+	require.NoError(t, err)
+	require.Zero(t, pruned)
+
+	require.NoError(t, pruner.Prune(context.Background()))
+
+	cutoff := Cutoff(verified, window+margin)
+	require.Equal(t, types.LayerID(20), cutoff)
+	pruned, err = layers.GetPruned(db)
+	require.NoError(t, err)
+	require.Equal(t, cutoff, pruned)
+
+	for _, proposal := range created {
+		expect := !proposal.Layer.Before(cutoff)
+
+		has, err := proposals.Has(db, proposal.ID())
+		require.NoError(t, err)
+		require.Equal(t, expect, has, "proposal in layer %s", proposal.Layer)
+
+		has, err = proposals.IsPruned(db, proposal.ID())
+		require.NoError(t, err)
+		require.Equal(t, !expect && !proposal.Layer.Before(cutoff.Sub(8)), has,
+			"pruned proposal in layer %s", proposal.Layer)
+
+		has, err = transactions.HasProposalTX(db, proposal.ID(), proposal.TxIDs[0])
+		require.NoError(t, err)
+		require.Equal(t, expect, has, "proposal txs in layer %s", proposal.Layer)
+
+		certs, err := certificates.Get(db, proposal.Layer)
+		require.NoError(t, err)
+		require.Len(t, certs, 1)
+		require.Equal(t, expect, certs[0].Cert != nil, "certificate in layer %s", proposal.Layer)
+
+		has, err = ballots.Has(db, proposal.Ballot.ID())
+		require.NoError(t, err)
+		require.True(t, has, "ballot in layer %s", proposal.Layer)
+	}
+}
@@ -0,0 +1,225 @@
+// Package replica serves read-only API from a database that is written by another node.
+//
+// Replica doesn't run consensus, doesn't connect to the p2p network and never writes
+// to the database. The primary node must keep the database in WAL mode, committed changes
+// are visible to the replica immediately. Replica polls the database to notice newly
+// applied layers and reports them to the subscribers of the layer events.
+//
+// Only layer and node status events are reported. Transaction, account and reward events
+// are emitted by the primary while it applies layers, so the corresponding streams stay
+// silent on the replica.
+package replica
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/spacemeshos/go-spacemesh/common/types"
+	"github.com/spacemeshos/go-spacemesh/datastore"
+	"github.com/spacemeshos/go-spacemesh/events"
+	"github.com/spacemeshos/go-spacemesh/log"
+	"github.com/spacemeshos/go-spacemesh/sql/ballots"
+	"github.com/spacemeshos/go-spacemesh/sql/blocks"
+	"github.com/spacemeshos/go-spacemesh/sql/layers"
+	"github.com/spacemeshos/go-spacemesh/sql/rewards"
+)
+
+// ErrReadOnly is returned for requests that need to modify the state or publish to the network.
+var ErrReadOnly = errors.New("replica: read-only")
+
+// Config for the replica.
+type Config struct {
+	// PollInterval between checks for the layers updated by the primary node.
+	PollInterval time.Duration `mapstructure:"poll-interval"`
+}
+
+// DefaultConfig for the replica.
+func DefaultConfig() Config {
+	return Config{
+		PollInterval: 5 * time.Second,
+	}
+}
+
+// Opt for configuring Replica.
+type Opt func(*Replica)
+
+// WithLogger changes logger.
+func WithLogger(logger log.Log) Opt {
+	return func(r *Replica) {
+		r.logger = logger
+	}
+}
+
+// WithConfig changes config.
+func WithConfig(cfg Config) Opt {
+	return func(r *Replica) {
+		r.cfg = cfg
+	}
+}
+
+// New creates Replica.
+func New(cdb *datastore.CachedDB, opts ...Opt) *Replica {
+	r := &Replica{
+		logger: log.NewNop(),
+		cfg:    DefaultConfig(),
+		cdb:    cdb,
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+// Replica implements the mesh api on top of the database written by the primary node.
+type Replica struct {
+	logger log.Log
+	cfg    Config
+	cdb    *datastore.CachedDB
+
+	mu        sync.Mutex
+	latest    types.LayerID
+	applied   types.LayerID
+	processed types.LayerID
+}
+
+// Run polls the database for updates until context is canceled.
+func (r *Replica) Run(ctx context.Context) error {
+	if r.cfg.PollInterval <= 0 {
+		return fmt.Errorf("replica poll interval must be positive: %v", r.cfg.PollInterval)
+	}
+	ticker := time.NewTicker(r.cfg.PollInterval)
+	defer ticker.Stop()
+	for {
+		if err := r.Update(); err != nil {
+			r.logger.With().Error("failed to follow primary", log.Err(err))
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// Update reads the latest, applied and processed layers from the database.
+// Layers that were applied since the previous update are reported to the layer events.
+func (r *Replica) Update() error {
+	latest, err := ballots.LatestLayer(r.cdb)
+	if err != nil {
+		return err
+	}
+	applied, err := layers.GetLastApplied(r.cdb)
+	if err != nil {
+		return err
+	}
+	processed, err := layers.GetProcessed(r.cdb)
+	if err != nil {
+		return err
+	}
+	if processed.After(latest) {
+		latest = processed
+	}
+
+	r.mu.Lock()
+	prevLatest, prevApplied := r.latest, r.applied
+	r.latest, r.applied, r.processed = latest, applied, processed
+	r.mu.Unlock()
+
+	if latest != prevLatest {
+		events.ReportNodeStatusUpdate()
+	}
+	// nothing is reported on the first update, there are no subscribers
+	// interested in the history before the replica started
+	if prevApplied == 0 || !applied.After(prevApplied) {
+		return nil
+	}
+	r.logger.With().Debug("primary applied layers",
+		log.Stringer("from", prevApplied.Add(1)),
+		log.Stringer("to", applied),
+	)
+	for lid := prevApplied.Add(1); !lid.After(applied); lid = lid.Add(1) {
+		events.ReportLayerUpdate(events.LayerUpdate{
+			LayerID: lid,
+			Status:  events.LayerStatusTypeApplied,
+		})
+	}
+	return nil
+}
+
+// LatestLayer returns the latest layer known to the primary node.
+func (r *Replica) LatestLayer() types.LayerID {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.latest
+}
+
+// LatestLayerInState returns the latest layer applied by the primary node.
+func (r *Replica) LatestLayerInState() types.LayerID {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.applied
+}
+
+// ProcessedLayer returns the latest layer processed by the primary node.
+func (r *Replica) ProcessedLayer() types.LayerID {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.processed
+}
+
+// GetLayer returns ballots and blocks in the layer.
+func (r *Replica) GetLayer(lid types.LayerID) (*types.Layer, error) {
+	blts, err := ballots.Layer(r.cdb, lid)
+	if err != nil {
+		return nil, fmt.Errorf("layer ballots: %w", err)
+	}
+	blks, err := blocks.Layer(r.cdb, lid)
+	if err != nil {
+		return nil, fmt.Errorf("layer blks: %w", err)
+	}
+	return types.NewExistingLayer(lid, blts, blks), nil
+}
+
+// GetATXs returns atxs found in the database and ids of missing atxs.
+func (r *Replica) GetATXs(ctx context.Context, ids []types.ATXID) (map[types.ATXID]*types.VerifiedActivationTx, []types.ATXID) {
+	var missing []types.ATXID
+	atxs := make(map[types.ATXID]*types.VerifiedActivationTx, len(ids))
+	for _, id := range ids {
+		atx, err := r.cdb.GetFullAtx(id)
+		if err != nil {
+			r.logger.WithContext(ctx).With().Warning("could not get atx from database", id, log.Err(err))
+			missing = append(missing, id)
+		} else {
+			atxs[atx.ID()] = atx
+		}
+	}
+	return atxs, missing
+}
+
+// GetRewards returns rewards received by the coinbase.
+func (r *Replica) GetRewards(coinbase types.Address) ([]*types.Reward, error) {
+	return rewards.List(r.cdb, coinbase)
+}
+
+// MeshHash returns the aggregated mesh hash at the layer.
+func (r *Replica) MeshHash(lid types.LayerID) (types.Hash32, error) {
+	return layers.GetAggregatedHash(r.cdb, lid)
+}
+
+// IsSynced returns true, replica serves whatever the primary node has.
+func (r *Replica) IsSynced(context.Context) bool {
+	return true
+}
+
+// VerifyAndCacheTx rejects transactions, replica can't add them to the mempool.
+func (r *Replica) VerifyAndCacheTx(context.Context, []byte) error {
+	return ErrReadOnly
+}
+
+// Publish rejects messages, replica is not connected to the network.
+func (r *Replica) Publish(context.Context, string, []byte) error {
+	return ErrReadOnly
+}
@@ -0,0 +1,92 @@
+package replica
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/spacemeshos/go-spacemesh/common/types"
+	"github.com/spacemeshos/go-spacemesh/datastore"
+	"github.com/spacemeshos/go-spacemesh/events"
+	"github.com/spacemeshos/go-spacemesh/log/logtest"
+	"github.com/spacemeshos/go-spacemesh/sql"
+	"github.com/spacemeshos/go-spacemesh/sql/ballots"
+	"github.com/spacemeshos/go-spacemesh/sql/layers"
+)
+
+func open(tb testing.TB) (*sql.Database, *Replica) {
+	tb.Helper()
+	uri := "file:" + filepath.Join(tb.TempDir(), "state.sql")
+	primary, err := sql.Open(uri)
+	require.NoError(tb, err)
+	tb.Cleanup(func() { require.NoError(tb, primary.Close()) })
+	db, err := sql.Open(uri, sql.WithReadOnly())
+	require.NoError(tb, err)
+	tb.Cleanup(func() { require.NoError(tb, db.Close()) })
+	return primary, New(datastore.NewCachedDB(db, logtest.New(tb)), WithLogger(logtest.New(tb)))
+}
+
+func addBallot(tb testing.TB, db *sql.Database, id byte, lid types.LayerID) *types.Ballot {
+	tb.Helper()
+	ballot := types.NewExistingBallot(types.BallotID{id}, types.EmptyEdSignature, types.NodeID{id}, lid)
+	require.NoError(tb, ballots.Add(db, &ballot))
+	return &ballot
+}
+
+func apply(tb testing.TB, db *sql.Database, lid types.LayerID) {
+	tb.Helper()
+	require.NoError(tb, layers.SetProcessed(db, lid))
+	require.NoError(tb, layers.SetApplied(db, lid, types.EmptyBlockID))
+}
+
+func TestFollowPrimary(t *testing.T) {
+	events.InitializeReporter()
+	t.Cleanup(events.CloseEventReporter)
+	sub := events.SubscribeLayers()
+	require.NotNil(t, sub)
+
+	primary, r := open(t)
+	apply(t, primary, 2)
+	require.NoError(t, r.Update())
+	require.Equal(t, types.LayerID(2), r.LatestLayer())
+	require.Equal(t, types.LayerID(2), r.LatestLayerInState())
+	require.Equal(t, types.LayerID(2), r.ProcessedLayer())
+
+	ballot := addBallot(t, primary, 1, 5)
+	apply(t, primary, 3)
+	apply(t, primary, 4)
+	require.NoError(t, r.Update())
+	require.Equal(t, types.LayerID(5), r.LatestLayer())
+	require.Equal(t, types.LayerID(4), r.LatestLayerInState())
+	require.Equal(t, types.LayerID(4), r.ProcessedLayer())
+
+	for _, expect := range []types.LayerID{3, 4} {
+		select {
+		case ev := <-sub.Out():
+			require.Equal(t, events.LayerUpdate{LayerID: expect, Status: events.LayerStatusTypeApplied}, ev)
+		case <-time.After(time.Second):
+			require.FailNow(t, "timed out waiting for layer update", "layer %s", expect)
+		}
+	}
+
+	layer, err := r.GetLayer(5)
+	require.NoError(t, err)
+	require.Len(t, layer.Ballots(), 1)
+	require.Equal(t, ballot.ID(), layer.Ballots()[0].ID())
+}
+
+func TestReadOnly(t *testing.T) {
+	_, r := open(t)
+	require.ErrorIs(t, r.Publish(context.Background(), "tx", []byte{1}), ErrReadOnly)
+	require.ErrorIs(t, r.VerifyAndCacheTx(context.Background(), []byte{1}), ErrReadOnly)
+	require.True(t, r.IsSynced(context.Background()))
+}
+
+func TestRunInvalidInterval(t *testing.T) {
+	_, r := open(t)
+	r.cfg.PollInterval = 0
+	require.Error(t, r.Run(context.Background()))
+}
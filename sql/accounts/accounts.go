@@ -121,6 +121,71 @@ func Snapshot(db sql.Executor, layer types.LayerID) ([]*types.Account, error) {
 	return rst, nil
 }
 
+// IterateSnapshot iterates over the accounts states that were valid at the specified layer,
+// starting from the address in the order of addresses. Iteration stops if fn returns false.
+func IterateSnapshot(db sql.Executor, layer types.LayerID, from types.Address, fn func(*types.Account) bool) error {
+	if _, err := db.Exec(`
+			select address, balance, next_nonce, max(layer_updated), template, state from accounts
+			where layer_updated <= ?1 and address >= ?2
+			group by address order by address asc;`,
+		func(stmt *sql.Statement) {
+			stmt.BindInt64(1, int64(layer))
+			stmt.BindBytes(2, from.Bytes())
+		},
+		func(stmt *sql.Statement) bool {
+			var account types.Account
+			stmt.ColumnBytes(0, account.Address[:])
+			account.Balance = uint64(stmt.ColumnInt64(1))
+			account.NextNonce = uint64(stmt.ColumnInt64(2))
+			account.Layer = types.LayerID(uint32(stmt.ColumnInt64(3)))
+			if stmt.ColumnLen(4) > 0 {
+				var template types.Address
+				stmt.ColumnBytes(4, template[:])
+				account.TemplateAddress = &template
+				account.State = make([]byte, stmt.ColumnLen(5))
+				stmt.ColumnBytes(5, account.State)
+			}
+			return fn(&account)
+		}); err != nil {
+		return fmt.Errorf("iterate snapshot at %v from %v: %w", layer, from, err)
+	}
+	return nil
+}
+
+// History returns up to limit account states for the address, updated at the
+// specified layer or later, in the order of layers.
+// Next page can be requested starting from the layer after the last returned state.
+func History(db sql.Executor, address types.Address, from types.LayerID, limit int) ([]*types.Account, error) {
+	var rst []*types.Account
+	if _, err := db.Exec(`
+			select balance, next_nonce, layer_updated, template, state from accounts
+			where address = ?1 and layer_updated >= ?2
+			order by layer_updated asc limit ?3;`,
+		func(stmt *sql.Statement) {
+			stmt.BindBytes(1, address.Bytes())
+			stmt.BindInt64(2, int64(from))
+			stmt.BindInt64(3, int64(limit))
+		},
+		func(stmt *sql.Statement) bool {
+			account := types.Account{Address: address}
+			account.Balance = uint64(stmt.ColumnInt64(0))
+			account.NextNonce = uint64(stmt.ColumnInt64(1))
+			account.Layer = types.LayerID(uint32(stmt.ColumnInt64(2)))
+			if stmt.ColumnLen(3) > 0 {
+				var template types.Address
+				stmt.ColumnBytes(3, template[:])
+				account.TemplateAddress = &template
+				account.State = make([]byte, stmt.ColumnLen(4))
+				stmt.ColumnBytes(4, account.State)
+			}
+			rst = append(rst, &account)
+			return true
+		}); err != nil {
+		return nil, fmt.Errorf("failed to load history for %v from %v: %w", address, from, err)
+	}
+	return rst, nil
+}
+
 // Update account state at a certain layer.
 func Update(db sql.Executor, to *types.Account) error {
 	_, err := db.Exec(`insert into 
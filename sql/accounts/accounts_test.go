@@ -45,6 +45,57 @@ func TestHas(t *testing.T) {
 	require.True(t, has)
 }
 
+func TestGet(t *testing.T) {
+	address := types.Address{1, 2, 3}
+	db := sql.InMemory()
+	seq := genSeq(address, 10)
+	for _, update := range seq[1:] {
+		require.NoError(t, Update(db, update))
+	}
+
+	before, err := Get(db, address, seq[0].Layer)
+	require.NoError(t, err)
+	require.Equal(t, types.Account{Address: address}, before)
+	for _, expected := range seq[1:] {
+		account, err := Get(db, address, expected.Layer)
+		require.NoError(t, err)
+		require.Equal(t, expected, &account)
+	}
+	after, err := Get(db, address, seq[len(seq)-1].Layer.Add(10))
+	require.NoError(t, err)
+	require.Equal(t, seq[len(seq)-1], &after)
+}
+
+func TestHistory(t *testing.T) {
+	address := types.Address{1, 2, 3}
+	db := sql.InMemory()
+	seq := genSeq(address, 10)
+	for _, update := range seq {
+		require.NoError(t, Update(db, update))
+	}
+	require.NoError(t, Update(db, &types.Account{Address: types.Address{3, 2, 1}, Layer: 5}))
+
+	var (
+		history []*types.Account
+		from    types.LayerID
+	)
+	for {
+		page, err := History(db, address, from, 3)
+		require.NoError(t, err)
+		if len(page) == 0 {
+			break
+		}
+		require.LessOrEqual(t, len(page), 3)
+		history = append(history, page...)
+		from = page[len(page)-1].Layer.Add(1)
+	}
+	require.Equal(t, seq, history)
+
+	history, err := History(db, address, seq[7].Layer, 10)
+	require.NoError(t, err)
+	require.Equal(t, seq[7:], history)
+}
+
 func TestRevert(t *testing.T) {
 	address := types.Address{1, 1}
 	seq := genSeq(address, 10)
@@ -104,3 +155,32 @@ func TestSnapshot(t *testing.T) {
 		}
 	}
 }
+
+func TestIterateSnapshot(t *testing.T) {
+	db := sql.InMemory()
+	addresses := []types.Address{{1, 1}, {2, 2}, {3, 3}}
+	n := []int{10, 7, 20}
+	for i, address := range addresses {
+		for _, update := range genSeq(address, n[i]) {
+			require.NoError(t, Update(db, update))
+		}
+	}
+	var got []*types.Account
+	require.NoError(t, IterateSnapshot(db, 8, addresses[1], func(account *types.Account) bool {
+		got = append(got, account)
+		return true
+	}))
+	require.Len(t, got, 2)
+	require.Equal(t, addresses[1], got[0].Address)
+	require.EqualValues(t, 7, got[0].Layer)
+	require.Equal(t, addresses[2], got[1].Address)
+	require.EqualValues(t, 8, got[1].Layer)
+
+	got = nil
+	require.NoError(t, IterateSnapshot(db, 8, types.Address{}, func(account *types.Account) bool {
+		got = append(got, account)
+		return false
+	}))
+	require.Len(t, got, 1)
+	require.Equal(t, addresses[0], got[0].Address)
+}
@@ -212,6 +212,26 @@ func GetIDsByEpoch(db sql.Executor, epoch types.EpochID) (ids []types.ATXID, err
 	return ids, nil
 }
 
+// GetIDsByEpochAfter gets up to limit ATX IDs for a given epoch that are greater than after,
+// in ascending order.
+func GetIDsByEpochAfter(db sql.Executor, epoch types.EpochID, after types.ATXID, limit int) (ids []types.ATXID, err error) {
+	enc := func(stmt *sql.Statement) {
+		stmt.BindInt64(1, int64(epoch))
+		stmt.BindBytes(2, after.Bytes())
+		stmt.BindInt64(3, int64(limit))
+	}
+	dec := func(stmt *sql.Statement) bool {
+		var id types.ATXID
+		stmt.ColumnBytes(0, id[:])
+		ids = append(ids, id)
+		return true
+	}
+	if _, err := db.Exec("select id from atxs where epoch = ?1 and id > ?2 order by id limit ?3;", enc, dec); err != nil {
+		return nil, fmt.Errorf("exec epoch %v after %v: %w", epoch, after, err)
+	}
+	return ids, nil
+}
+
 // VRFNonce gets the VRF nonce of a smesher for a given epoch.
 func VRFNonce(db sql.Executor, id types.NodeID, epoch types.EpochID) (nonce types.VRFPostIndex, err error) {
 	enc := func(stmt *sql.Statement) {
@@ -1,7 +1,9 @@
 package atxs_test
 
 import (
+	"bytes"
 	"os"
+	"sort"
 	"testing"
 	"time"
 
@@ -414,6 +416,43 @@ func TestGetIDsByEpoch(t *testing.T) {
 	require.EqualValues(t, []types.ATXID{atx4.ID()}, ids3)
 }
 
+func TestGetIDsByEpochAfter(t *testing.T) {
+	db := sql.InMemory()
+
+	epoch := types.EpochID(1)
+	var ids []types.ATXID
+	for i := 0; i < 5; i++ {
+		sig, err := signing.NewEdSigner()
+		require.NoError(t, err)
+		atx, err := newAtx(sig, withPublishEpoch(epoch))
+		require.NoError(t, err)
+		require.NoError(t, atxs.Add(db, atx))
+		ids = append(ids, atx.ID())
+	}
+	sig, err := signing.NewEdSigner()
+	require.NoError(t, err)
+	other, err := newAtx(sig, withPublishEpoch(epoch+1))
+	require.NoError(t, err)
+	require.NoError(t, atxs.Add(db, other))
+	sort.Slice(ids, func(i, j int) bool {
+		return bytes.Compare(ids[i].Bytes(), ids[j].Bytes()) < 0
+	})
+
+	var got []types.ATXID
+	after := types.EmptyATXID
+	for {
+		page, err := atxs.GetIDsByEpochAfter(db, epoch, after, 2)
+		require.NoError(t, err)
+		require.LessOrEqual(t, len(page), 2)
+		if len(page) == 0 {
+			break
+		}
+		got = append(got, page...)
+		after = page[len(page)-1]
+	}
+	require.Equal(t, ids, got)
+}
+
 func TestVRFNonce(t *testing.T) {
 	// Arrange
 	db := sql.InMemory()
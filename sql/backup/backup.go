@@ -0,0 +1,115 @@
+// Package backup creates consistent snapshots of the node database while it is in use.
+package backup
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/spacemeshos/go-spacemesh/common/types"
+	"github.com/spacemeshos/go-spacemesh/sql"
+	"github.com/spacemeshos/go-spacemesh/sql/layers"
+)
+
+const (
+	// DatabaseFile is a name of the database copy in the backup directory.
+	DatabaseFile = "state.sql"
+	// ManifestFile is a name of the manifest in the backup directory.
+	ManifestFile = "manifest.json"
+)
+
+// Manifest describes the state of the database in the backup.
+type Manifest struct {
+	// Layer is the latest applied layer.
+	Layer types.LayerID `json:"layer"`
+	// StateHash after applying Layer.
+	StateHash types.Hash32 `json:"state_hash"`
+	// Version is the last migration applied to the database.
+	Version int       `json:"version"`
+	Created time.Time `json:"created"`
+}
+
+// Backup writes a copy of the database and the manifest into dir.
+//
+// The directory is created if it doesn't exist, but it must not contain a previous backup.
+// Manifest is read from the copy, therefore it matches the copied data even
+// if the database is updated concurrently.
+func Backup(ctx context.Context, db *sql.Database, dir string) (manifest *Manifest, err error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("create backup dir %s: %w", dir, err)
+	}
+	path := filepath.Join(dir, DatabaseFile)
+	if _, err := os.Stat(path); err == nil {
+		return nil, fmt.Errorf("backup %s already exists", path)
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("stat %s: %w", path, err)
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if err := db.VacuumInto(path); err != nil {
+		return nil, err
+	}
+	// incomplete backup must not be mistaken for a valid one
+	defer func() {
+		if err != nil {
+			os.Remove(path)
+		}
+	}()
+	copied, err := sql.Open("file:"+path, sql.WithMigrations(nil), sql.WithConnections(1))
+	if err != nil {
+		return nil, err
+	}
+	manifest, err = readManifest(copied)
+	if cerr := copied.Close(); err == nil && cerr != nil {
+		err = fmt.Errorf("close backup: %w", cerr)
+	}
+	if err != nil {
+		return nil, err
+	}
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("encode manifest: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, ManifestFile), data, 0o600); err != nil {
+		return nil, fmt.Errorf("write manifest: %w", err)
+	}
+	return manifest, nil
+}
+
+func readManifest(db sql.Executor) (*Manifest, error) {
+	manifest := &Manifest{Created: time.Now().UTC()}
+	var err error
+	manifest.Version, err = sql.Version(db)
+	if err != nil {
+		return nil, err
+	}
+	manifest.Layer, err = layers.GetLastApplied(db)
+	if err != nil {
+		return nil, err
+	}
+	if manifest.Layer != 0 {
+		manifest.StateHash, err = layers.GetStateHash(db, manifest.Layer)
+		if err != nil && !errors.Is(err, sql.ErrNotFound) {
+			return nil, err
+		}
+	}
+	return manifest, nil
+}
+
+// ReadManifest from the backup directory.
+func ReadManifest(dir string) (*Manifest, error) {
+	data, err := os.ReadFile(filepath.Join(dir, ManifestFile))
+	if err != nil {
+		return nil, fmt.Errorf("read manifest: %w", err)
+	}
+	var manifest Manifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, fmt.Errorf("decode manifest: %w", err)
+	}
+	return &manifest, nil
+}
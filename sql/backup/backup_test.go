@@ -0,0 +1,63 @@
+package backup
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/spacemeshos/go-spacemesh/common/types"
+	"github.com/spacemeshos/go-spacemesh/sql"
+	"github.com/spacemeshos/go-spacemesh/sql/layers"
+)
+
+func TestBackup(t *testing.T) {
+	db, err := sql.Open("file:" + filepath.Join(t.TempDir(), "state.sql"))
+	require.NoError(t, err)
+	t.Cleanup(func() { require.NoError(t, db.Close()) })
+
+	lid := types.LayerID(10)
+	hash := types.Hash32{1, 2, 3}
+	require.NoError(t, layers.SetApplied(db, lid, types.BlockID{1}))
+	require.NoError(t, layers.UpdateStateHash(db, lid, hash))
+	version, err := sql.Version(db)
+	require.NoError(t, err)
+
+	dir := filepath.Join(t.TempDir(), "backup")
+	manifest, err := Backup(context.Background(), db, dir)
+	require.NoError(t, err)
+	require.Equal(t, lid, manifest.Layer)
+	require.Equal(t, hash, manifest.StateHash)
+	require.Equal(t, version, manifest.Version)
+
+	// database is usable after backup and changes don't affect the copy
+	require.NoError(t, layers.SetApplied(db, lid.Add(1), types.BlockID{2}))
+
+	stored, err := ReadManifest(dir)
+	require.NoError(t, err)
+	require.Equal(t, manifest.Layer, stored.Layer)
+	require.Equal(t, manifest.StateHash, stored.StateHash)
+	require.Equal(t, manifest.Version, stored.Version)
+
+	copied, err := sql.Open("file:" + filepath.Join(dir, DatabaseFile))
+	require.NoError(t, err)
+	defer copied.Close()
+	applied, err := layers.GetLastApplied(copied)
+	require.NoError(t, err)
+	require.Equal(t, lid, applied)
+
+	_, err = Backup(context.Background(), db, dir)
+	require.ErrorContains(t, err, "already exists")
+}
+
+func TestBackupEmpty(t *testing.T) {
+	db, err := sql.Open("file:" + filepath.Join(t.TempDir(), "state.sql"))
+	require.NoError(t, err)
+	t.Cleanup(func() { require.NoError(t, db.Close()) })
+
+	manifest, err := Backup(context.Background(), db, t.TempDir())
+	require.NoError(t, err)
+	require.Equal(t, types.LayerID(0), manifest.Layer)
+	require.Equal(t, types.Hash32{}, manifest.StateHash)
+}
@@ -133,6 +133,24 @@ func IDsInLayer(db sql.Executor, lid types.LayerID) (rst []types.BallotID, err e
 	return rst, err
 }
 
+// IDsInLayerAfter returns up to limit ballots ids in the layer that are greater than after,
+// in ascending order.
+func IDsInLayerAfter(db sql.Executor, lid types.LayerID, after types.BallotID, limit int) (rst []types.BallotID, err error) {
+	if _, err := db.Exec("select id from ballots where layer = ?1 and id > ?2 order by id limit ?3;", func(stmt *sql.Statement) {
+		stmt.BindInt64(1, int64(lid.Uint32()))
+		stmt.BindBytes(2, after.Bytes())
+		stmt.BindInt64(3, int64(limit))
+	}, func(stmt *sql.Statement) bool {
+		id := types.BallotID{}
+		stmt.ColumnBytes(0, id[:])
+		rst = append(rst, id)
+		return true
+	}); err != nil {
+		return nil, fmt.Errorf("ballots for layer %s after %s: %w", lid, after, err)
+	}
+	return rst, nil
+}
+
 // CountByPubkeyLayer counts number of ballots in the layer for the nodeID.
 func CountByPubkeyLayer(db sql.Executor, lid types.LayerID, nodeID types.NodeID) (int, error) {
 	rows, err := db.Exec("select 1 from ballots where layer = ?1 and pubkey = ?2;", func(stmt *sql.Statement) {
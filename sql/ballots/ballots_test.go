@@ -39,6 +39,13 @@ func TestLayer(t *testing.T) {
 	require.NoError(t, err)
 	require.Len(t, ids, len(ballots))
 
+	ids, err = IDsInLayerAfter(db, start, types.BallotID{}, 1)
+	require.NoError(t, err)
+	require.Equal(t, []types.BallotID{ballots[0].ID()}, ids)
+	ids, err = IDsInLayerAfter(db, start, ids[0], 10)
+	require.NoError(t, err)
+	require.Equal(t, []types.BallotID{ballots[1].ID()}, ids)
+
 	rst, err := Layer(db, start)
 	require.NoError(t, err)
 	require.Len(t, rst, len(ballots))
@@ -147,3 +147,15 @@ func SetInvalid(db sql.Executor, lid types.LayerID, bid types.BlockID) error {
 	}
 	return nil
 }
+
+// DeleteCertBefore deletes certificates before the layer.
+// Hare output is preserved, as it is used by the tortoise.
+func DeleteCertBefore(db sql.Executor, lid types.LayerID) error {
+	if _, err := db.Exec("update certificates set cert = null where layer < ?1;",
+		func(stmt *sql.Statement) {
+			stmt.BindInt64(1, int64(lid))
+		}, nil); err != nil {
+		return fmt.Errorf("delete certs before %s: %w", lid, err)
+	}
+	return nil
+}
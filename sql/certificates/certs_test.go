@@ -93,6 +93,27 @@ func TestCertificates(t *testing.T) {
 	require.False(t, got[1].Valid)
 }
 
+func TestDeleteCertBefore(t *testing.T) {
+	db := sql.InMemory()
+	for _, lid := range []types.LayerID{9, 10} {
+		require.NoError(t, Add(db, lid, makeCert(lid, types.BlockID{byte(lid)})))
+	}
+	require.NoError(t, DeleteCertBefore(db, 10))
+
+	got, err := Get(db, 9)
+	require.NoError(t, err)
+	require.Len(t, got, 1)
+	require.Nil(t, got[0].Cert)
+	ho, err := GetHareOutput(db, 9)
+	require.NoError(t, err)
+	require.Equal(t, types.BlockID{9}, ho)
+
+	got, err = Get(db, 10)
+	require.NoError(t, err)
+	require.Len(t, got, 1)
+	require.NotNil(t, got[0].Cert)
+}
+
 func TestHareOutput(t *testing.T) {
 	db := sql.InMemory()
 	lid := types.LayerID(10)
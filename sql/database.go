@@ -56,9 +56,11 @@ func defaultConf() *conf {
 
 type conf struct {
 	flags         sqlite.OpenFlags
+	readOnly      bool
 	connections   int
 	migrations    Migrations
 	enableLatency bool
+	slowThreshold time.Duration
 }
 
 // WithConnections overwrites number of pooled connections.
@@ -84,6 +86,23 @@ func WithLatencyMetering(enable bool) Opt {
 	}
 }
 
+// WithSlowQueryLog enables log of queries that took longer than the threshold.
+// Zero threshold disables the log.
+func WithSlowQueryLog(threshold time.Duration) Opt {
+	return func(c *conf) {
+		c.slowThreshold = threshold
+	}
+}
+
+// WithReadOnly opens database in read-only mode, migrations are not applied.
+// Changes committed by the writer are visible to the following reads,
+// as long as the writer keeps database in WAL mode.
+func WithReadOnly() Opt {
+	return func(c *conf) {
+		c.readOnly = true
+	}
+}
+
 // Opt for configuring database.
 type Opt func(c *conf)
 
@@ -107,6 +126,12 @@ func Open(uri string, opts ...Opt) (*Database, error) {
 	for _, opt := range opts {
 		opt(config)
 	}
+	if config.readOnly {
+		config.flags = sqlite.SQLITE_OPEN_READONLY |
+			sqlite.SQLITE_OPEN_URI |
+			sqlite.SQLITE_OPEN_NOMUTEX
+		config.migrations = nil
+	}
 	pool, err := sqlitex.Open(uri, config.flags, config.connections)
 	if err != nil {
 		return nil, fmt.Errorf("open db %s: %w", uri, err)
@@ -115,6 +140,9 @@ func Open(uri string, opts ...Opt) (*Database, error) {
 	if config.enableLatency {
 		db.latency = newQueryLatency()
 	}
+	if config.slowThreshold > 0 {
+		db.slow = newSlowLog(config.slowThreshold)
+	}
 	if config.migrations != nil {
 		tx, err := db.Tx(context.Background())
 		if err != nil {
@@ -147,6 +175,7 @@ type Database struct {
 	closeMux sync.Mutex
 
 	latency *prometheus.HistogramVec
+	slow    *slowLog
 }
 
 func (db *Database) getTx(ctx context.Context, initstmt string) (*Tx, error) {
@@ -219,15 +248,29 @@ func (db *Database) Exec(query string, encoder Encoder, decoder Decoder) (int, e
 		return 0, ErrNoConnection
 	}
 	defer db.pool.Put(conn)
-	if db.latency != nil {
+	if db.latency != nil || db.slow != nil {
 		start := time.Now()
 		defer func() {
-			db.latency.WithLabelValues(query).Observe(float64(time.Since(start)))
+			db.observe(conn, query, time.Since(start))
 		}()
 	}
 	return exec(conn, query, encoder, decoder)
 }
 
+// VacuumInto writes a consistent copy of the database into the file at path.
+//
+// Copy is created within a read transaction, so it is safe to call while database is
+// used by other connections or processes. The file at path must not exist.
+// https://www.sqlite.org/lang_vacuum.html#vacuuminto
+func (db *Database) VacuumInto(path string) error {
+	if _, err := db.Exec("VACUUM INTO ?1;", func(stmt *Statement) {
+		stmt.BindText(1, path)
+	}, nil); err != nil {
+		return fmt.Errorf("vacuum into %s: %w", path, err)
+	}
+	return nil
+}
+
 // Close closes all pooled connections.
 func (db *Database) Close() error {
 	db.closeMux.Lock()
@@ -320,10 +363,10 @@ func (tx *Tx) Release() error {
 
 // Exec query.
 func (tx *Tx) Exec(query string, encoder Encoder, decoder Decoder) (int, error) {
-	if tx.db.latency != nil {
+	if tx.db.latency != nil || tx.db.slow != nil {
 		start := time.Now()
 		defer func() {
-			tx.db.latency.WithLabelValues(query).Observe(float64(time.Since(start)))
+			tx.db.observe(tx.conn, query, time.Since(start))
 		}()
 	}
 	return exec(tx.conn, query, encoder, decoder)
@@ -2,6 +2,7 @@ package sql
 
 import (
 	"context"
+	"path/filepath"
 	"testing"
 
 	"github.com/stretchr/testify/require"
@@ -48,3 +49,35 @@ func TestTransactionIsolation(t *testing.T) {
 	require.NoError(t, err)
 	require.Equal(t, rows, 0)
 }
+
+func TestReadOnly(t *testing.T) {
+	uri := "file:" + filepath.Join(t.TempDir(), "state.sql")
+	db, err := Open(uri, WithMigrations(testTables))
+	require.NoError(t, err)
+	t.Cleanup(func() { require.NoError(t, db.Close()) })
+	insert := func(key string) {
+		_, err := db.Exec("insert into testing1(id, field) values (?1, ?2)", func(stmt *Statement) {
+			stmt.BindText(1, key)
+			stmt.BindInt64(2, 1)
+		}, nil)
+		require.NoError(t, err)
+	}
+	insert("a")
+
+	replica, err := Open(uri, WithMigrations(testTables), WithReadOnly(), WithConnections(2))
+	require.NoError(t, err)
+	t.Cleanup(func() { require.NoError(t, replica.Close()) })
+	count := func() int {
+		rows, err := replica.Exec("select 1 from testing1", nil, nil)
+		require.NoError(t, err)
+		return rows
+	}
+	require.Equal(t, 1, count())
+
+	insert("b")
+	require.Equal(t, 2, count())
+
+	_, err = replica.Exec("insert into testing1(id, field) values ('c', 1)", nil, nil)
+	require.Error(t, err)
+	require.Equal(t, 2, count())
+}
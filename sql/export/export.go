@@ -0,0 +1,446 @@
+// Package export writes chain data for a range of layers into newline-delimited JSON files.
+//
+// Every file contains records of one kind, one JSON object per line. Records use
+// explicit schemas defined in this package, they don't change when internal types change.
+// Identifiers and hashes are encoded as full lowercase hex, addresses use bech32 encoding.
+package export
+
+import (
+	"bufio"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/spacemeshos/go-spacemesh/common/types"
+	"github.com/spacemeshos/go-spacemesh/sql"
+	"github.com/spacemeshos/go-spacemesh/sql/atxs"
+	"github.com/spacemeshos/go-spacemesh/sql/blocks"
+	"github.com/spacemeshos/go-spacemesh/sql/identities"
+	"github.com/spacemeshos/go-spacemesh/sql/layers"
+	"github.com/spacemeshos/go-spacemesh/sql/rewards"
+	"github.com/spacemeshos/go-spacemesh/sql/transactions"
+)
+
+// Version of the schema of exported records.
+// It must be incremented when existing fields are changed or removed.
+const Version = 1
+
+// Names of the files in the export directory.
+const (
+	ManifestFile     = "manifest.json"
+	LayersFile       = "layers.jsonl"
+	BlocksFile       = "blocks.jsonl"
+	TransactionsFile = "transactions.jsonl"
+	RewardsFile      = "rewards.jsonl"
+	AtxsFile         = "atxs.jsonl"
+	MalfeasanceFile  = "malfeasance.jsonl"
+)
+
+// Manifest describes the export.
+type Manifest struct {
+	Version int `json:"version"`
+	// From and To are the first and the last exported layers.
+	// To is capped by the last applied layer.
+	From    types.LayerID `json:"from"`
+	To      types.LayerID `json:"to"`
+	Created time.Time     `json:"created"`
+	// Records is the number of records in every file.
+	Records map[string]int `json:"records"`
+}
+
+// Layer record.
+type Layer struct {
+	Layer uint32 `json:"layer"`
+	// AppliedBlock is empty if the layer was applied without a block.
+	AppliedBlock string `json:"applied_block"`
+	StateHash    string `json:"state_hash"`
+	MeshHash     string `json:"mesh_hash"`
+}
+
+// BlockReward is a reward for the atx in the block.
+type BlockReward struct {
+	Atx         string `json:"atx"`
+	WeightNum   uint64 `json:"weight_num"`
+	WeightDenom uint64 `json:"weight_denom"`
+}
+
+// Block record.
+type Block struct {
+	ID         string `json:"id"`
+	Layer      uint32 `json:"layer"`
+	TickHeight uint64 `json:"tick_height"`
+	// Valid is null if tortoise didn't decide on the block yet.
+	Valid        *bool         `json:"valid"`
+	Rewards      []BlockReward `json:"rewards"`
+	Transactions []string      `json:"transactions"`
+}
+
+// Transaction record, only transactions with results are exported.
+type Transaction struct {
+	ID        string   `json:"id"`
+	Layer     uint32   `json:"layer"`
+	Block     string   `json:"block"`
+	Principal string   `json:"principal"`
+	Template  string   `json:"template"`
+	Method    uint8    `json:"method"`
+	Nonce     uint64   `json:"nonce"`
+	MaxGas    uint64   `json:"max_gas"`
+	GasPrice  uint64   `json:"gas_price"`
+	MaxSpend  uint64   `json:"max_spend"`
+	Status    string   `json:"status"`
+	Message   string   `json:"message"`
+	Gas       uint64   `json:"gas"`
+	Fee       uint64   `json:"fee"`
+	Addresses []string `json:"addresses"`
+	Raw       string   `json:"raw"`
+}
+
+// Reward record.
+type Reward struct {
+	Layer       uint32 `json:"layer"`
+	Coinbase    string `json:"coinbase"`
+	TotalReward uint64 `json:"total_reward"`
+	LayerReward uint64 `json:"layer_reward"`
+}
+
+// Atx record, atxs published in epochs of the exported layers are exported.
+type Atx struct {
+	ID                string `json:"id"`
+	NodeID            string `json:"node_id"`
+	PublishEpoch      uint32 `json:"publish_epoch"`
+	Sequence          uint64 `json:"sequence"`
+	PrevAtx           string `json:"prev_atx"`
+	PositioningAtx    string `json:"positioning_atx"`
+	Coinbase          string `json:"coinbase"`
+	NumUnits          uint32 `json:"num_units"`
+	EffectiveNumUnits uint32 `json:"effective_num_units"`
+	BaseTickHeight    uint64 `json:"base_tick_height"`
+	TickCount         uint64 `json:"tick_count"`
+	Weight            uint64 `json:"weight"`
+}
+
+// Malfeasance record, proofs for the exported layers are exported.
+type Malfeasance struct {
+	NodeID   string    `json:"node_id"`
+	Layer    uint32    `json:"layer"`
+	Type     uint8     `json:"type"`
+	Received time.Time `json:"received"`
+	// Proof is the scale encoded proof.
+	Proof string `json:"proof"`
+}
+
+// Export writes records for layers [from, to] into dir.
+//
+// The directory is created if it doesn't exist, but it must not contain a previous export.
+// Data is read in a single transaction, so the files are consistent with each other
+// even if the database is updated concurrently.
+func Export(ctx context.Context, db *sql.Database, dir string, from, to types.LayerID) (_ *Manifest, err error) {
+	if to.Before(from) {
+		return nil, fmt.Errorf("invalid range [%v, %v]", from, to)
+	}
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("create export dir %s: %w", dir, err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, ManifestFile)); err == nil {
+		return nil, fmt.Errorf("export in %s already exists", dir)
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("stat %s: %w", dir, err)
+	}
+	tx, err := db.Tx(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Release()
+
+	applied, err := layers.GetLastApplied(tx)
+	if err != nil {
+		return nil, err
+	}
+	if applied.Before(to) {
+		to = applied
+	}
+	if to.Before(from) {
+		return nil, fmt.Errorf("layer %v is not applied, last applied %v", from, applied)
+	}
+	rst := &Manifest{
+		Version: Version,
+		From:    from,
+		To:      to,
+		Created: time.Now().UTC(),
+		Records: map[string]int{},
+	}
+	// incomplete export must not be mistaken for a valid one
+	var created []string
+	defer func() {
+		if err != nil {
+			for _, path := range created {
+				os.Remove(path)
+			}
+		}
+	}()
+	for _, file := range []struct {
+		name  string
+		write func(context.Context, sql.Executor, types.LayerID, types.LayerID, func(any) error) error
+	}{
+		{LayersFile, writeLayers},
+		{BlocksFile, writeBlocks},
+		{TransactionsFile, writeTransactions},
+		{RewardsFile, writeRewards},
+		{AtxsFile, writeAtxs},
+		{MalfeasanceFile, writeMalfeasance},
+	} {
+		path := filepath.Join(dir, file.name)
+		count, err := writeFile(path, func(encode func(any) error) error {
+			return file.write(ctx, tx, from, to, encode)
+		})
+		if !errors.Is(err, os.ErrExist) {
+			created = append(created, path)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("export %s: %w", file.name, err)
+		}
+		rst.Records[file.name] = count
+	}
+	data, err := json.MarshalIndent(rst, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("encode manifest: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, ManifestFile), data, 0o600); err != nil {
+		return nil, fmt.Errorf("write manifest: %w", err)
+	}
+	return rst, nil
+}
+
+// writeFile creates the file and passes an encoder for records to write.
+// Returns the number of written records.
+func writeFile(path string, write func(encode func(any) error) error) (int, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o600)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+	var (
+		buf   = bufio.NewWriter(f)
+		enc   = json.NewEncoder(buf)
+		count int
+	)
+	if err := write(func(record any) error {
+		count++
+		return enc.Encode(record)
+	}); err != nil {
+		return 0, err
+	}
+	if err := buf.Flush(); err != nil {
+		return 0, err
+	}
+	return count, f.Close()
+}
+
+func encodeHex(buf []byte) string {
+	return hex.EncodeToString(buf)
+}
+
+func writeLayers(ctx context.Context, db sql.Executor, from, to types.LayerID, encode func(any) error) error {
+	for lid := from; !lid.After(to); lid = lid.Add(1) {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		record := Layer{Layer: lid.Uint32()}
+		applied, err := layers.GetApplied(db, lid)
+		switch {
+		case errors.Is(err, sql.ErrNotFound):
+		case err != nil:
+			return err
+		case applied != types.EmptyBlockID:
+			record.AppliedBlock = encodeHex(applied[:])
+		}
+		if hash, err := layers.GetStateHash(db, lid); err == nil {
+			record.StateHash = encodeHex(hash[:])
+		} else if !errors.Is(err, sql.ErrNotFound) {
+			return err
+		}
+		if hash, err := layers.GetAggregatedHash(db, lid); err == nil {
+			record.MeshHash = encodeHex(hash[:])
+		} else if !errors.Is(err, sql.ErrNotFound) {
+			return err
+		}
+		if err := encode(record); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeBlocks(ctx context.Context, db sql.Executor, from, to types.LayerID, encode func(any) error) error {
+	for lid := from; !lid.After(to); lid = lid.Add(1) {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		blks, err := blocks.Layer(db, lid)
+		if err != nil {
+			return err
+		}
+		for _, block := range blks {
+			id := block.ID()
+			record := Block{
+				ID:           encodeHex(id[:]),
+				Layer:        block.LayerIndex.Uint32(),
+				TickHeight:   block.TickHeight,
+				Rewards:      make([]BlockReward, 0, len(block.Rewards)),
+				Transactions: make([]string, 0, len(block.TxIDs)),
+			}
+			valid, err := blocks.IsValid(db, id)
+			switch {
+			case errors.Is(err, blocks.ErrValidityNotDecided):
+			case err != nil:
+				return err
+			default:
+				record.Valid = &valid
+			}
+			for _, reward := range block.Rewards {
+				record.Rewards = append(record.Rewards, BlockReward{
+					Atx:         encodeHex(reward.AtxID[:]),
+					WeightNum:   reward.Weight.Num,
+					WeightDenom: reward.Weight.Denom,
+				})
+			}
+			for _, tid := range block.TxIDs {
+				record.Transactions = append(record.Transactions, encodeHex(tid[:]))
+			}
+			if err := encode(record); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func writeTransactions(ctx context.Context, db sql.Executor, from, to types.LayerID, encode func(any) error) error {
+	var ierr error
+	if err := transactions.IterateResults(db, transactions.ResultsFilter{Start: &from, End: &to},
+		func(tx *types.TransactionWithResult) bool {
+			if ierr = ctx.Err(); ierr != nil {
+				return false
+			}
+			record := Transaction{
+				ID:        encodeHex(tx.ID[:]),
+				Layer:     tx.Layer.Uint32(),
+				Block:     encodeHex(tx.Block[:]),
+				Status:    tx.Status.String(),
+				Message:   tx.Message,
+				Gas:       tx.Gas,
+				Fee:       tx.Fee,
+				Addresses: make([]string, 0, len(tx.Addresses)),
+				Raw:       encodeHex(tx.Raw),
+			}
+			if tx.TxHeader != nil {
+				record.Principal = tx.Principal.String()
+				record.Template = tx.TemplateAddress.String()
+				record.Method = tx.Method
+				record.Nonce = tx.Nonce
+				record.MaxGas = tx.MaxGas
+				record.GasPrice = tx.GasPrice
+				record.MaxSpend = tx.MaxSpend
+			}
+			for _, addr := range tx.Addresses {
+				record.Addresses = append(record.Addresses, addr.String())
+			}
+			ierr = encode(record)
+			return ierr == nil
+		}); err != nil {
+		return err
+	}
+	return ierr
+}
+
+func writeRewards(ctx context.Context, db sql.Executor, from, to types.LayerID, encode func(any) error) error {
+	var ierr error
+	if err := rewards.IterateLayers(db, from, to, func(reward *types.Reward) bool {
+		if ierr = ctx.Err(); ierr != nil {
+			return false
+		}
+		ierr = encode(Reward{
+			Layer:       reward.Layer.Uint32(),
+			Coinbase:    reward.Coinbase.String(),
+			TotalReward: reward.TotalReward,
+			LayerReward: reward.LayerReward,
+		})
+		return ierr == nil
+	}); err != nil {
+		return err
+	}
+	return ierr
+}
+
+func writeAtxs(ctx context.Context, db sql.Executor, from, to types.LayerID, encode func(any) error) error {
+	for epoch := from.GetEpoch(); epoch <= to.GetEpoch(); epoch++ {
+		ids, err := atxs.GetIDsByEpoch(db, epoch)
+		if err != nil {
+			return err
+		}
+		for _, id := range ids {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			atx, err := atxs.Get(db, id)
+			if err != nil {
+				return err
+			}
+			if err := encode(Atx{
+				ID:                encodeHex(id[:]),
+				NodeID:            encodeHex(atx.SmesherID[:]),
+				PublishEpoch:      atx.PublishEpoch.Uint32(),
+				Sequence:          atx.Sequence,
+				PrevAtx:           encodeHex(atx.PrevATXID[:]),
+				PositioningAtx:    encodeHex(atx.PositioningATX[:]),
+				Coinbase:          atx.Coinbase.String(),
+				NumUnits:          atx.NumUnits,
+				EffectiveNumUnits: atx.EffectiveNumUnits(),
+				BaseTickHeight:    atx.BaseTickHeight(),
+				TickCount:         atx.TickCount(),
+				Weight:            atx.GetWeight(),
+			}); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func writeMalfeasance(ctx context.Context, db sql.Executor, from, to types.LayerID, encode func(any) error) error {
+	ids, err := identities.GetMalicious(db)
+	if err != nil {
+		return err
+	}
+	for _, id := range ids {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		proof, err := identities.GetMalfeasanceProof(db, id)
+		if err != nil {
+			return err
+		}
+		if proof.Layer.Before(from) || proof.Layer.After(to) {
+			continue
+		}
+		blob, err := identities.GetMalfeasanceBlob(db, id.Bytes())
+		if err != nil {
+			return err
+		}
+		if err := encode(Malfeasance{
+			NodeID:   encodeHex(id[:]),
+			Layer:    proof.Layer.Uint32(),
+			Type:     proof.Proof.Type,
+			Received: proof.Received().UTC(),
+			Proof:    encodeHex(blob),
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
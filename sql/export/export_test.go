@@ -0,0 +1,242 @@
+package export
+
+import (
+	"bufio"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/spacemeshos/go-spacemesh/codec"
+	"github.com/spacemeshos/go-spacemesh/common/types"
+	"github.com/spacemeshos/go-spacemesh/sql"
+	"github.com/spacemeshos/go-spacemesh/sql/atxs"
+	"github.com/spacemeshos/go-spacemesh/sql/blocks"
+	"github.com/spacemeshos/go-spacemesh/sql/identities"
+	"github.com/spacemeshos/go-spacemesh/sql/layers"
+	"github.com/spacemeshos/go-spacemesh/sql/rewards"
+	"github.com/spacemeshos/go-spacemesh/sql/transactions"
+)
+
+const layersPerEpoch = 4
+
+func TestMain(m *testing.M) {
+	types.SetLayersPerEpoch(layersPerEpoch)
+	os.Exit(m.Run())
+}
+
+func readRecords[T any](tb testing.TB, path string) []T {
+	tb.Helper()
+	f, err := os.Open(path)
+	require.NoError(tb, err)
+	defer f.Close()
+	var rst []T
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var record T
+		require.NoError(tb, json.Unmarshal(scanner.Bytes(), &record))
+		rst = append(rst, record)
+	}
+	require.NoError(tb, scanner.Err())
+	return rst
+}
+
+func addAtx(tb testing.TB, db sql.Executor, epoch types.EpochID, nodeID types.NodeID) *types.VerifiedActivationTx {
+	tb.Helper()
+	atx := &types.ActivationTx{
+		InnerActivationTx: types.InnerActivationTx{
+			NIPostChallenge: types.NIPostChallenge{PublishEpoch: epoch},
+			Coinbase:        types.Address{1},
+			NumUnits:        2,
+		},
+		SmesherID: nodeID,
+	}
+	atx.SetEffectiveNumUnits(atx.NumUnits)
+	atx.SetReceived(time.Now())
+	vatx, err := atx.Verify(10, 5)
+	require.NoError(tb, err)
+	require.NoError(tb, atxs.Add(db, vatx))
+	return vatx
+}
+
+func addMalfeasance(tb testing.TB, db sql.Executor, nodeID types.NodeID, lid types.LayerID) []byte {
+	tb.Helper()
+	var ballotProof types.BallotProof
+	for i := 0; i < 2; i++ {
+		ballotProof.Messages[i] = types.BallotProofMsg{
+			InnerMsg:  types.BallotMetadata{Layer: lid, MsgHash: types.RandomHash()},
+			Signature: types.RandomEdSignature(),
+			SmesherID: nodeID,
+		}
+	}
+	data, err := codec.Encode(&types.MalfeasanceProof{
+		Layer: lid,
+		Proof: types.Proof{Type: types.MultipleBallots, Data: &ballotProof},
+	})
+	require.NoError(tb, err)
+	require.NoError(tb, identities.SetMalicious(db, nodeID, data, time.Now()))
+	return data
+}
+
+func TestExport(t *testing.T) {
+	db := sql.InMemory()
+	block := types.NewExistingBlock(types.BlockID{1}, types.InnerBlock{
+		LayerIndex: 5,
+		TickHeight: 100,
+		Rewards:    []types.AnyReward{{AtxID: types.ATXID{1}, Weight: types.RatNum{Num: 1, Denom: 2}}},
+		TxIDs:      []types.TransactionID{{1}},
+	})
+	require.NoError(t, blocks.Add(db, block))
+	require.NoError(t, blocks.SetValid(db, block.ID()))
+	for lid := types.LayerID(1); lid <= 6; lid++ {
+		bid := types.EmptyBlockID
+		if lid == block.LayerIndex {
+			bid = block.ID()
+		}
+		require.NoError(t, layers.SetApplied(db, lid, bid))
+		require.NoError(t, layers.UpdateStateHash(db, lid, types.Hash32{byte(lid)}))
+		require.NoError(t, layers.SetMeshHash(db, lid, types.Hash32{byte(lid), 1}))
+		require.NoError(t, rewards.Add(db, &types.Reward{
+			Layer: lid, Coinbase: types.Address{1}, TotalReward: 10, LayerReward: 5,
+		}))
+	}
+
+	principal := types.GenerateAddress([]byte{1})
+	tx := &types.Transaction{
+		RawTx:    types.NewRawTx([]byte{1, 2, 3}),
+		TxHeader: &types.TxHeader{Principal: principal, Nonce: 7, MaxGas: 100, GasPrice: 1},
+	}
+	require.NoError(t, transactions.Add(db, tx, time.Now()))
+	require.NoError(t, db.WithTx(context.Background(), func(dbtx *sql.Tx) error {
+		return transactions.AddResult(dbtx, tx.ID, &types.TransactionResult{
+			Layer:     block.LayerIndex,
+			Block:     block.ID(),
+			Gas:       50,
+			Fee:       50,
+			Addresses: []types.Address{principal},
+		})
+	}))
+
+	// epochs 0 and 1 are exported for layers [3, 5]
+	inRange := []*types.VerifiedActivationTx{
+		addAtx(t, db, 0, types.NodeID{1}),
+		addAtx(t, db, 1, types.NodeID{2}),
+	}
+	addAtx(t, db, 2, types.NodeID{3})
+
+	proof := addMalfeasance(t, db, types.NodeID{1}, 4)
+	addMalfeasance(t, db, types.NodeID{2}, 10)
+
+	dir := t.TempDir()
+	manifest, err := Export(context.Background(), db, dir, 3, 5)
+	require.NoError(t, err)
+	require.Equal(t, Version, manifest.Version)
+	require.Equal(t, types.LayerID(3), manifest.From)
+	require.Equal(t, types.LayerID(5), manifest.To)
+	require.Equal(t, map[string]int{
+		LayersFile:       3,
+		BlocksFile:       1,
+		TransactionsFile: 1,
+		RewardsFile:      3,
+		AtxsFile:         2,
+		MalfeasanceFile:  1,
+	}, manifest.Records)
+
+	lrs := readRecords[Layer](t, filepath.Join(dir, LayersFile))
+	require.Equal(t, Layer{
+		Layer:     3,
+		StateHash: hex.EncodeToString(types.Hash32{3}.Bytes()),
+		MeshHash:  hex.EncodeToString(types.Hash32{3, 1}.Bytes()),
+	}, lrs[0])
+	bid := block.ID()
+	require.Equal(t, hex.EncodeToString(bid[:]), lrs[2].AppliedBlock)
+
+	blks := readRecords[Block](t, filepath.Join(dir, BlocksFile))
+	require.Equal(t, hex.EncodeToString(bid[:]), blks[0].ID)
+	require.Equal(t, uint32(5), blks[0].Layer)
+	require.Equal(t, uint64(100), blks[0].TickHeight)
+	require.NotNil(t, blks[0].Valid)
+	require.True(t, *blks[0].Valid)
+	require.Equal(t, []BlockReward{{
+		Atx: hex.EncodeToString(types.ATXID{1}.Bytes()), WeightNum: 1, WeightDenom: 2,
+	}}, blks[0].Rewards)
+	require.Equal(t, []string{hex.EncodeToString(types.TransactionID{1}.Bytes())}, blks[0].Transactions)
+
+	txs := readRecords[Transaction](t, filepath.Join(dir, TransactionsFile))
+	require.Equal(t, Transaction{
+		ID:        hex.EncodeToString(tx.ID.Bytes()),
+		Layer:     5,
+		Block:     hex.EncodeToString(bid[:]),
+		Principal: principal.String(),
+		Template:  types.Address{}.String(),
+		Nonce:     7,
+		MaxGas:    100,
+		GasPrice:  1,
+		Status:    types.TransactionSuccess.String(),
+		Gas:       50,
+		Fee:       50,
+		Addresses: []string{principal.String()},
+		Raw:       hex.EncodeToString(tx.Raw),
+	}, txs[0])
+
+	rwds := readRecords[Reward](t, filepath.Join(dir, RewardsFile))
+	require.Equal(t, Reward{
+		Layer: 3, Coinbase: types.Address{1}.String(), TotalReward: 10, LayerReward: 5,
+	}, rwds[0])
+
+	atxRecords := readRecords[Atx](t, filepath.Join(dir, AtxsFile))
+	for i, atx := range inRange {
+		require.Equal(t, hex.EncodeToString(atx.ID().Bytes()), atxRecords[i].ID)
+		require.Equal(t, hex.EncodeToString(atx.SmesherID.Bytes()), atxRecords[i].NodeID)
+		require.Equal(t, atx.PublishEpoch.Uint32(), atxRecords[i].PublishEpoch)
+		require.Equal(t, uint32(2), atxRecords[i].NumUnits)
+		require.Equal(t, uint64(10), atxRecords[i].BaseTickHeight)
+		require.Equal(t, uint64(5), atxRecords[i].TickCount)
+		require.Equal(t, atx.GetWeight(), atxRecords[i].Weight)
+	}
+
+	mal := readRecords[Malfeasance](t, filepath.Join(dir, MalfeasanceFile))
+	require.Equal(t, hex.EncodeToString(types.NodeID{1}.Bytes()), mal[0].NodeID)
+	require.Equal(t, uint32(4), mal[0].Layer)
+	require.Equal(t, types.MultipleBallots, mal[0].Type)
+	require.Equal(t, hex.EncodeToString(proof), mal[0].Proof)
+
+	_, err = Export(context.Background(), db, dir, 3, 5)
+	require.ErrorContains(t, err, "already exists")
+}
+
+func TestExportRange(t *testing.T) {
+	db := sql.InMemory()
+	require.NoError(t, layers.SetApplied(db, 2, types.EmptyBlockID))
+
+	_, err := Export(context.Background(), db, t.TempDir(), 3, 2)
+	require.ErrorContains(t, err, "invalid range")
+
+	_, err = Export(context.Background(), db, t.TempDir(), 3, 10)
+	require.ErrorContains(t, err, "not applied")
+
+	manifest, err := Export(context.Background(), db, t.TempDir(), 1, 10)
+	require.NoError(t, err)
+	require.Equal(t, types.LayerID(2), manifest.To)
+	require.Equal(t, 2, manifest.Records[LayersFile])
+}
+
+func TestExportCleanup(t *testing.T) {
+	db := sql.InMemory()
+	require.NoError(t, layers.SetApplied(db, 2, types.EmptyBlockID))
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, BlocksFile), []byte("{}"), 0o600))
+	_, err := Export(context.Background(), db, dir, 1, 2)
+	require.ErrorIs(t, err, os.ErrExist)
+
+	// files written by the failed export are removed, existing files are kept
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	require.Equal(t, BlocksFile, entries[0].Name())
+}
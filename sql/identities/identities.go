@@ -90,6 +90,27 @@ func GetMalfeasanceBlob(db sql.Executor, nodeID []byte) ([]byte, error) {
 	return proof, nil
 }
 
+// GetMaliciousAfter returns up to limit malicious identities that are greater than after,
+// in ascending order.
+func GetMaliciousAfter(db sql.Executor, after types.NodeID, limit int) ([]types.NodeID, error) {
+	var result []types.NodeID
+	_, err := db.Exec("select pubkey from identities where proof is not null and pubkey > ?1 order by pubkey limit ?2;",
+		func(stmt *sql.Statement) {
+			stmt.BindBytes(1, after.Bytes())
+			stmt.BindInt64(2, int64(limit))
+		},
+		func(stmt *sql.Statement) bool {
+			var nid types.NodeID
+			stmt.ColumnBytes(0, nid[:])
+			result = append(result, nid)
+			return true
+		})
+	if err != nil {
+		return nil, fmt.Errorf("get malicious identities after %s: %w", after, err)
+	}
+	return result, nil
+}
+
 func GetMalicious(db sql.Executor) ([]types.NodeID, error) {
 	var (
 		result []types.NodeID
@@ -69,4 +69,14 @@ func Test_GetMalicious(t *testing.T) {
 	got, err = GetMalicious(db)
 	require.NoError(t, err)
 	require.Equal(t, bad, got)
+
+	got, err = GetMaliciousAfter(db, types.NodeID{}, 5)
+	require.NoError(t, err)
+	require.Equal(t, bad[:5], got)
+	got, err = GetMaliciousAfter(db, got[len(got)-1], 10)
+	require.NoError(t, err)
+	require.Equal(t, bad[5:], got)
+	got, err = GetMaliciousAfter(db, got[len(got)-1], 10)
+	require.NoError(t, err)
+	require.Empty(t, got)
 }
@@ -119,6 +119,21 @@ func GetStateHash(db sql.Executor, lid types.LayerID) (rst types.Hash32, err err
 	return rst, err
 }
 
+// GetLatestStateHashLayer returns the latest layer with the state hash.
+func GetLatestStateHashLayer(db sql.Executor) (lid types.LayerID, err error) {
+	if rows, err := db.Exec("select id from layers where state_hash is not null order by id desc limit 1;",
+		nil,
+		func(stmt *sql.Statement) bool {
+			lid = types.LayerID(uint32(stmt.ColumnInt64(0)))
+			return false
+		}); err != nil {
+		return 0, fmt.Errorf("failed to load latest layer with state hash %w", err)
+	} else if rows == 0 {
+		return 0, fmt.Errorf("%w: state hash doesnt exist", sql.ErrNotFound)
+	}
+	return lid, nil
+}
+
 // GetApplied for the applied block for layer.
 func GetApplied(db sql.Executor, lid types.LayerID) (rst types.BlockID, err error) {
 	if rows, err := db.Exec("select applied_block from layers where id = ?1;",
@@ -243,3 +258,29 @@ func GetAggHashes(db sql.Executor, from, to types.LayerID, by uint32) ([]types.H
 	}
 	return hashes, nil
 }
+
+// SetPruned records that mesh data before the layer was pruned.
+func SetPruned(db sql.Executor, lid types.LayerID) error {
+	if _, err := db.Exec(`insert into pruning (id, layer) values (1, ?1)
+		on conflict (id) do update set layer = ?1;`,
+		func(stmt *sql.Statement) {
+			stmt.BindInt64(1, int64(lid))
+		}, nil); err != nil {
+		return fmt.Errorf("set pruned %s: %w", lid, err)
+	}
+	return nil
+}
+
+// GetPruned returns the layer before which mesh data was pruned.
+// Zero is returned if data was never pruned.
+func GetPruned(db sql.Executor) (types.LayerID, error) {
+	var lid types.LayerID
+	if _, err := db.Exec("select layer from pruning where id = 1;", nil,
+		func(stmt *sql.Statement) bool {
+			lid = types.LayerID(uint32(stmt.ColumnInt64(0)))
+			return true
+		}); err != nil {
+		return lid, fmt.Errorf("get pruned: %w", err)
+	}
+	return lid, nil
+}
@@ -96,6 +96,25 @@ func TestStateHash(t *testing.T) {
 	require.Equal(t, hashes[0], latest)
 }
 
+func TestLatestStateHashLayer(t *testing.T) {
+	db := sql.InMemory()
+	_, err := GetLatestStateHashLayer(db)
+	require.ErrorIs(t, err, sql.ErrNotFound)
+
+	require.NoError(t, SetMeshHash(db, 12, types.Hash32{12}))
+	for _, lid := range []types.LayerID{9, 11, 10} {
+		require.NoError(t, UpdateStateHash(db, lid, types.Hash32{1}))
+	}
+	lid, err := GetLatestStateHashLayer(db)
+	require.NoError(t, err)
+	require.Equal(t, types.LayerID(11), lid)
+
+	require.NoError(t, UnsetAppliedFrom(db, 10))
+	lid, err = GetLatestStateHashLayer(db)
+	require.NoError(t, err)
+	require.Equal(t, types.LayerID(9), lid)
+}
+
 func TestSetHashes(t *testing.T) {
 	db := sql.InMemory()
 	_, err := GetAggregatedHash(db, types.LayerID(11))
@@ -197,3 +216,16 @@ func TestGetAggHashes(t *testing.T) {
 		})
 	}
 }
+
+func TestPruned(t *testing.T) {
+	db := sql.InMemory()
+	lid, err := GetPruned(db)
+	require.NoError(t, err)
+	require.Equal(t, types.LayerID(0), lid)
+	for _, layer := range []types.LayerID{10, 20} {
+		require.NoError(t, SetPruned(db, layer))
+		lid, err = GetPruned(db)
+		require.NoError(t, err)
+		require.Equal(t, layer, lid)
+	}
+}
@@ -3,7 +3,9 @@ package sql
 import (
 	"bufio"
 	"bytes"
+	"crypto/sha256"
 	"embed"
+	"errors"
 	"fmt"
 	"io/fs"
 	"sort"
@@ -14,18 +16,40 @@ import (
 //go:embed migrations/*.sql
 var embedded embed.FS
 
+var (
+	// ErrNewerVersion is returned if the database was migrated by a newer release.
+	ErrNewerVersion = errors.New("database: schema version is newer than supported")
+	// ErrChecksumMismatch is returned if the applied migration doesn't match the embedded one.
+	ErrChecksumMismatch = errors.New("database: migration checksum mismatch")
+)
+
+const downSuffix = ".down.sql"
+
 type migration struct {
-	order   int
-	name    string
-	content *bufio.Scanner
+	order int
+	name  string
+	up    []byte
+	// down reverts up. nil if migration can't be rolled back.
+	down []byte
+}
+
+func (m *migration) checksum() []byte {
+	sum := sha256.Sum256(m.up)
+	return sum[:]
 }
 
 // Migrations is interface for migrations provider.
 type Migrations func(Executor) error
 
-func embeddedMigrations(db Executor) error {
-	var migrations []migration
-	fs.WalkDir(embedded, "migrations", func(path string, d fs.DirEntry, err error) error {
+// loadMigrations reads embedded migrations sorted by order.
+//
+// Migration is a file named NNNN_name.sql, optional NNNN_name.down.sql reverts it.
+// Migration 0 creates the table with applied migrations, and is applied to the databases
+// of any version that don't have it.
+func loadMigrations() ([]*migration, error) {
+	byOrder := map[int]*migration{}
+	downs := map[int][]byte{}
+	err := fs.WalkDir(embedded, "migrations", func(path string, d fs.DirEntry, err error) error {
 		if err != nil {
 			return fmt.Errorf("walkdir %s: %w", path, err)
 		}
@@ -40,50 +64,249 @@ func embeddedMigrations(db Executor) error {
 		if err != nil {
 			return fmt.Errorf("invalid migration %s: %w", d.Name(), err)
 		}
-		f, err := embedded.Open(path)
+		content, err := embedded.ReadFile(path)
 		if err != nil {
 			return fmt.Errorf("readfile %s: %w", path, err)
 		}
-		scanner := bufio.NewScanner(f)
-		scanner.Split(func(data []byte, atEOF bool) (advance int, token []byte, err error) {
-			if i := bytes.Index(data, []byte(";")); i >= 0 {
-				return i + 1, data[0 : i+1], nil
-			}
-			return 0, nil, nil
-		})
-		migrations = append(migrations, migration{
-			order:   order,
-			name:    d.Name(),
-			content: scanner,
-		})
+		if strings.HasSuffix(d.Name(), downSuffix) {
+			downs[order] = content
+			return nil
+		}
+		if _, exists := byOrder[order]; exists {
+			return fmt.Errorf("duplicate migration %d", order)
+		}
+		byOrder[order] = &migration{order: order, name: d.Name(), up: content}
 		return nil
 	})
+	if err != nil {
+		return nil, err
+	}
+	for order, down := range downs {
+		m, exists := byOrder[order]
+		if !exists {
+			return nil, fmt.Errorf("down migration %d without up migration", order)
+		}
+		m.down = down
+	}
+	migrations := make([]*migration, 0, len(byOrder))
+	for _, m := range byOrder {
+		migrations = append(migrations, m)
+	}
 	sort.Slice(migrations, func(i, j int) bool {
 		return migrations[i].order < migrations[j].order
 	})
+	return migrations, nil
+}
 
-	var current int
+func embeddedMigrations(db Executor) error {
+	migrations, err := loadMigrations()
+	if err != nil {
+		return err
+	}
+	return migrate(db, migrations)
+}
 
-	if _, err := db.Exec("PRAGMA user_version;", nil, func(stmt *Statement) bool {
-		current = stmt.ColumnInt(0)
-		return true
-	}); err != nil {
-		return fmt.Errorf("read user_version %w", err)
+func latest(migrations []*migration) int {
+	if len(migrations) == 0 {
+		return 0
 	}
+	return migrations[len(migrations)-1].order
+}
 
+// migrate verifies checksums of applied migrations and applies pending ones.
+func migrate(db Executor, migrations []*migration) error {
+	current, err := Version(db)
+	if err != nil {
+		return err
+	}
+	if supported := latest(migrations); current > supported {
+		return fmt.Errorf("%w: database version %d, supported %d. rollback with a newer release first",
+			ErrNewerVersion, current, supported)
+	}
+	if err := bootstrap(db, migrations); err != nil {
+		return err
+	}
+	applied, err := checksums(db)
+	if err != nil {
+		return err
+	}
 	for _, m := range migrations {
-		if m.order <= current {
+		if m.order > current {
+			if err := execScript(db, m.up); err != nil {
+				return fmt.Errorf("apply %s: %w", m.name, err)
+			}
+			if err := record(db, m); err != nil {
+				return err
+			}
+			if err := setVersion(db, m.order); err != nil {
+				return err
+			}
 			continue
 		}
-		for m.content.Scan() {
-			if _, err := db.Exec(m.content.Text(), nil, nil); err != nil {
-				return fmt.Errorf("exec %s: %w", m.content.Text(), err)
+		checksum, exists := applied[m.order]
+		if !exists {
+			// applied before checksums were recorded
+			if err := record(db, m); err != nil {
+				return err
 			}
+		} else if !bytes.Equal(checksum, m.checksum()) {
+			return fmt.Errorf("%w: %s", ErrChecksumMismatch, m.name)
 		}
-		// binding values in pragma statement is not allowed
-		if _, err := db.Exec(fmt.Sprintf("PRAGMA user_version = %d;", m.order), nil, nil); err != nil {
-			return fmt.Errorf("update user_version to %d: %w", m.order, err)
+	}
+	return nil
+}
+
+// Rollback reverts embedded migrations applied after the target version.
+//
+// It fails without changes if any of the migrations can't be rolled back.
+// The caller is expected to run it in a transaction.
+func Rollback(db Executor, target int) error {
+	migrations, err := loadMigrations()
+	if err != nil {
+		return err
+	}
+	return rollback(db, migrations, target)
+}
+
+func rollback(db Executor, migrations []*migration, target int) error {
+	if target < 0 {
+		return fmt.Errorf("invalid target version %d", target)
+	}
+	current, err := Version(db)
+	if err != nil {
+		return err
+	}
+	if supported := latest(migrations); current > supported {
+		return fmt.Errorf("%w: database version %d, supported %d", ErrNewerVersion, current, supported)
+	}
+	if err := bootstrap(db, migrations); err != nil {
+		return err
+	}
+	var revert []int
+	for i := len(migrations) - 1; i >= 0; i-- {
+		m := migrations[i]
+		if m.order <= target {
+			break
+		}
+		if m.order > current {
+			continue
+		}
+		if m.down == nil {
+			return fmt.Errorf("migration %s can't be rolled back", m.name)
+		}
+		revert = append(revert, i)
+	}
+	for _, i := range revert {
+		m := migrations[i]
+		if err := execScript(db, m.down); err != nil {
+			return fmt.Errorf("rollback %s: %w", m.name, err)
+		}
+		if _, err := db.Exec("delete from migrations where version = ?1;", func(stmt *Statement) {
+			stmt.BindInt64(1, int64(m.order))
+		}, nil); err != nil {
+			return fmt.Errorf("delete migration %d: %w", m.order, err)
+		}
+		previous := 0
+		if i > 0 {
+			previous = migrations[i-1].order
+		}
+		if err := setVersion(db, previous); err != nil {
+			return err
 		}
 	}
 	return nil
 }
+
+func execScript(db Executor, content []byte) error {
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	scanner.Split(func(data []byte, atEOF bool) (advance int, token []byte, err error) {
+		if i := bytes.Index(data, []byte(";")); i >= 0 {
+			return i + 1, data[0 : i+1], nil
+		}
+		return 0, nil, nil
+	})
+	for scanner.Scan() {
+		if _, err := db.Exec(scanner.Text(), nil, nil); err != nil {
+			return fmt.Errorf("exec %s: %w", scanner.Text(), err)
+		}
+	}
+	return scanner.Err()
+}
+
+// bootstrap applies migration 0 if the table with applied migrations doesn't exist.
+// Unlike other migrations it doesn't depend on user_version, as it was introduced after them.
+func bootstrap(db Executor, migrations []*migration) error {
+	if len(migrations) == 0 || migrations[0].order != 0 {
+		return errors.New("migration 0 is missing")
+	}
+	exists := false
+	if _, err := db.Exec("select 1 from sqlite_master where type = 'table' and name = 'migrations';",
+		nil, func(*Statement) bool {
+			exists = true
+			return false
+		}); err != nil {
+		return fmt.Errorf("check migrations table: %w", err)
+	}
+	if exists {
+		return nil
+	}
+	m := migrations[0]
+	if err := execScript(db, m.up); err != nil {
+		return fmt.Errorf("apply %s: %w", m.name, err)
+	}
+	return record(db, m)
+}
+
+func checksums(db Executor) (map[int][]byte, error) {
+	rst := map[int][]byte{}
+	if _, err := db.Exec("select version, checksum from migrations;", nil, func(stmt *Statement) bool {
+		checksum := make([]byte, stmt.ColumnLen(1))
+		stmt.ColumnBytes(1, checksum)
+		rst[stmt.ColumnInt(0)] = checksum
+		return true
+	}); err != nil {
+		return nil, fmt.Errorf("read migrations: %w", err)
+	}
+	return rst, nil
+}
+
+func record(db Executor, m *migration) error {
+	if _, err := db.Exec(`insert into migrations (version, name, checksum) values (?1, ?2, ?3)
+	on conflict (version) do update set name = ?2, checksum = ?3;`, func(stmt *Statement) {
+		stmt.BindInt64(1, int64(m.order))
+		stmt.BindText(2, m.name)
+		stmt.BindBytes(3, m.checksum())
+	}, nil); err != nil {
+		return fmt.Errorf("record migration %s: %w", m.name, err)
+	}
+	return nil
+}
+
+func setVersion(db Executor, version int) error {
+	// binding values in pragma statement is not allowed
+	if _, err := db.Exec(fmt.Sprintf("PRAGMA user_version = %d;", version), nil, nil); err != nil {
+		return fmt.Errorf("update user_version to %d: %w", version, err)
+	}
+	return nil
+}
+
+// Version returns the order of the last migration applied to the database.
+func Version(db Executor) (int, error) {
+	var version int
+	if _, err := db.Exec("PRAGMA user_version;", nil, func(stmt *Statement) bool {
+		version = stmt.ColumnInt(0)
+		return true
+	}); err != nil {
+		return 0, fmt.Errorf("read user_version %w", err)
+	}
+	return version, nil
+}
+
+// SupportedVersion returns the version of the latest embedded migration.
+func SupportedVersion() (int, error) {
+	migrations, err := loadMigrations()
+	if err != nil {
+		return 0, err
+	}
+	return latest(migrations), nil
+}
@@ -0,0 +1,6 @@
+CREATE TABLE migrations
+(
+    version  INT PRIMARY KEY,
+    name     TEXT NOT NULL,
+    checksum BLOB NOT NULL
+);
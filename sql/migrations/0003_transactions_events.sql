@@ -0,0 +1 @@
+ALTER TABLE transactions ADD events BLOB;
@@ -0,0 +1,3 @@
+DROP TABLE pruned_ids;
+DROP INDEX proposal_transactions_by_layer;
+DROP TABLE pruning;
@@ -0,0 +1,12 @@
+CREATE TABLE pruning
+(
+    id    INTEGER PRIMARY KEY CHECK (id = 1),
+    layer INT NOT NULL
+);
+CREATE INDEX proposal_transactions_by_layer ON proposal_transactions (layer);
+CREATE TABLE pruned_ids
+(
+    id    CHAR(20) PRIMARY KEY,
+    layer INT NOT NULL
+) WITHOUT ROWID;
+CREATE INDEX pruned_ids_by_layer ON pruned_ids (layer);
@@ -0,0 +1,6 @@
+DROP TABLE indexer_spawns;
+DROP TABLE indexer_smesher_epoch_rewards;
+DROP TABLE indexer_coinbase_epoch_rewards;
+DROP TABLE indexer_rewards;
+DROP TABLE indexer_activity;
+DROP TABLE indexer_layers;
@@ -0,0 +1,55 @@
+CREATE TABLE indexer_layers
+(
+    layer INT PRIMARY KEY,
+    block CHAR(20) NOT NULL
+) WITHOUT ROWID;
+
+CREATE TABLE indexer_activity
+(
+    address CHAR(24),
+    layer   INT NOT NULL,
+    tid     CHAR(32),
+    PRIMARY KEY (address, layer, tid)
+) WITHOUT ROWID;
+CREATE INDEX indexer_activity_by_layer ON indexer_activity (layer);
+
+CREATE TABLE indexer_rewards
+(
+    smesher      CHAR(32),
+    layer        INT NOT NULL,
+    coinbase     CHAR(24) NOT NULL,
+    total_reward UNSIGNED LONG INT NOT NULL,
+    layer_reward UNSIGNED LONG INT NOT NULL,
+    PRIMARY KEY (smesher, layer)
+) WITHOUT ROWID;
+CREATE INDEX indexer_rewards_by_layer ON indexer_rewards (layer);
+
+CREATE TABLE indexer_coinbase_epoch_rewards
+(
+    coinbase     CHAR(24),
+    epoch        INT NOT NULL,
+    total_reward UNSIGNED LONG INT NOT NULL,
+    layer_reward UNSIGNED LONG INT NOT NULL,
+    count        INT NOT NULL,
+    PRIMARY KEY (coinbase, epoch)
+) WITHOUT ROWID;
+
+CREATE TABLE indexer_smesher_epoch_rewards
+(
+    smesher      CHAR(32),
+    epoch        INT NOT NULL,
+    total_reward UNSIGNED LONG INT NOT NULL,
+    layer_reward UNSIGNED LONG INT NOT NULL,
+    count        INT NOT NULL,
+    PRIMARY KEY (smesher, epoch)
+) WITHOUT ROWID;
+
+CREATE TABLE indexer_spawns
+(
+    account CHAR(24) PRIMARY KEY,
+    spawner CHAR(24) NOT NULL,
+    tid     CHAR(32) NOT NULL,
+    layer   INT NOT NULL
+) WITHOUT ROWID;
+CREATE INDEX indexer_spawns_by_spawner ON indexer_spawns (spawner, layer);
+CREATE INDEX indexer_spawns_by_layer ON indexer_spawns (layer);
@@ -1,6 +1,7 @@
 package sql
 
 import (
+	"path/filepath"
 	"testing"
 
 	"github.com/stretchr/testify/require"
@@ -15,5 +16,108 @@ func TestMigrationsAppliedOnce(t *testing.T) {
 		return true
 	})
 	require.NoError(t, err)
-	require.Equal(t, version, 2)
+	require.Equal(t, version, 5)
+
+	supported, err := SupportedVersion()
+	require.NoError(t, err)
+	require.Equal(t, version, supported)
+}
+
+func TestMigrationsChecksum(t *testing.T) {
+	db := InMemory()
+	migrations, err := loadMigrations()
+	require.NoError(t, err)
+	require.NoError(t, migrate(db, migrations))
+
+	_, err = db.Exec("delete from migrations where version = 2;", nil, nil)
+	require.NoError(t, err)
+	require.NoError(t, migrate(db, migrations), "checksums are recorded for old databases")
+	recorded, err := checksums(db)
+	require.NoError(t, err)
+	require.Len(t, recorded, len(migrations))
+	for _, m := range migrations {
+		require.Equal(t, m.checksum(), recorded[m.order])
+	}
+
+	migrations[1].up = append([]byte("-- changed\n"), migrations[1].up...)
+	require.ErrorIs(t, migrate(db, migrations), ErrChecksumMismatch)
+}
+
+func TestMigrationsNewerVersion(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.sql")
+	db, err := Open("file:" + path)
+	require.NoError(t, err)
+	require.NoError(t, setVersion(db, 100))
+	require.NoError(t, db.Close())
+
+	_, err = Open("file:" + path)
+	require.ErrorIs(t, err, ErrNewerVersion)
+
+	db, err = Open("file:"+path, WithMigrations(nil))
+	require.NoError(t, err)
+	defer db.Close()
+	require.ErrorIs(t, Rollback(db, 1), ErrNewerVersion)
+}
+
+func TestRollback(t *testing.T) {
+	db := InMemory()
+
+	require.NoError(t, Rollback(db, 2))
+	version, err := Version(db)
+	require.NoError(t, err)
+	require.Equal(t, 2, version)
+	_, err = db.Exec("select count(*) from pruning;", nil, nil)
+	require.Error(t, err)
+	_, err = db.Exec("select events from transactions;", nil, nil)
+	require.Error(t, err)
+	recorded, err := checksums(db)
+	require.NoError(t, err)
+	require.Len(t, recorded, 3, "migrations 0, 1 and 2")
+
+	require.ErrorContains(t, Rollback(db, 0), "can't be rolled back")
+	version, err = Version(db)
+	require.NoError(t, err)
+	require.Equal(t, 2, version)
+
+	require.NoError(t, embeddedMigrations(db))
+	version, err = Version(db)
+	require.NoError(t, err)
+	require.Equal(t, 5, version)
+	_, err = db.Exec("select count(*) from pruning;", nil, nil)
+	require.NoError(t, err)
+}
+
+func TestRollbackWithoutChecksums(t *testing.T) {
+	db := InMemory()
+	_, err := db.Exec("drop table migrations;", nil, nil)
+	require.NoError(t, err)
+
+	require.NoError(t, Rollback(db, 3))
+	version, err := Version(db)
+	require.NoError(t, err)
+	require.Equal(t, 3, version)
+}
+
+func TestMigrationsBootstrap(t *testing.T) {
+	db := InMemory()
+	migrations, err := loadMigrations()
+	require.NoError(t, err)
+	require.Equal(t, 0, migrations[0].order)
+
+	// database migrated before migrations table was introduced
+	_, err = db.Exec("drop table migrations;", nil, nil)
+	require.NoError(t, err)
+	require.NoError(t, migrate(db, migrations))
+	recorded, err := checksums(db)
+	require.NoError(t, err)
+	require.Len(t, recorded, len(migrations))
+	require.Equal(t, migrations[0].checksum(), recorded[0])
+
+	// table is not recreated
+	_, err = db.Exec("insert into migrations (version, name, checksum) values (100, 'test', x'00');", nil, nil)
+	require.NoError(t, err)
+	require.NoError(t, bootstrap(db, migrations))
+	recorded, err = checksums(db)
+	require.NoError(t, err)
+	require.Contains(t, recorded, 100)
 }
@@ -185,3 +185,43 @@ func decodeProposal(stmt *sql.Statement) (*types.Proposal, error) {
 	proposal.SetID(proposalID)
 	return proposal, nil
 }
+
+// DeleteBefore deletes proposals before the layer.
+// Ids of deleted proposals are kept, so that they can be told apart from unknown ones with IsPruned.
+func DeleteBefore(db sql.Executor, lid types.LayerID) error {
+	enc := func(stmt *sql.Statement) {
+		stmt.BindInt64(1, int64(lid))
+	}
+	if _, err := db.Exec(`insert into pruned_ids (id, layer) select id, layer from proposals where layer < ?1
+		on conflict do nothing;`, enc, nil); err != nil {
+		return fmt.Errorf("record pruned proposals before %s: %w", lid, err)
+	}
+	if _, err := db.Exec("delete from proposals where layer < ?1;", enc, nil); err != nil {
+		return fmt.Errorf("delete proposals before %s: %w", lid, err)
+	}
+	return nil
+}
+
+// DeletePrunedBefore deletes ids of pruned proposals before the layer.
+// IsPruned returns false for such proposals.
+func DeletePrunedBefore(db sql.Executor, lid types.LayerID) error {
+	if _, err := db.Exec("delete from pruned_ids where layer < ?1;",
+		func(stmt *sql.Statement) {
+			stmt.BindInt64(1, int64(lid))
+		}, nil); err != nil {
+		return fmt.Errorf("delete pruned proposals before %s: %w", lid, err)
+	}
+	return nil
+}
+
+// IsPruned returns true if the proposal was deleted by DeleteBefore.
+func IsPruned(db sql.Executor, id types.ProposalID) (bool, error) {
+	rows, err := db.Exec("select 1 from pruned_ids where id = ?1;",
+		func(stmt *sql.Statement) {
+			stmt.BindBytes(1, id.Bytes())
+		}, nil)
+	if err != nil {
+		return false, fmt.Errorf("is pruned proposal %s: %w", id, err)
+	}
+	return rows > 0, nil
+}
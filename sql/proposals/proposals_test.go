@@ -84,3 +84,36 @@ func TestGet(t *testing.T) {
 	require.NoError(t, err)
 	require.EqualValues(t, proposal, got)
 }
+
+func TestDeleteBefore(t *testing.T) {
+	db := sql.InMemory()
+	var ids []types.ProposalID
+	for i := 1; i <= 3; i++ {
+		ballot := types.NewExistingBallot(types.BallotID{byte(i)}, types.RandomEdSignature(), types.RandomNodeID(), types.LayerID(i))
+		require.NoError(t, ballots.Add(db, &ballot))
+		proposal := &types.Proposal{
+			InnerProposal: types.InnerProposal{Ballot: ballot},
+			Signature:     types.RandomEdSignature(),
+		}
+		proposal.SetID(types.ProposalID{byte(i)})
+		require.NoError(t, Add(db, proposal))
+		ids = append(ids, proposal.ID())
+	}
+
+	require.NoError(t, DeleteBefore(db, types.LayerID(3)))
+	for i, id := range ids {
+		has, err := Has(db, id)
+		require.NoError(t, err)
+		require.Equal(t, i == 2, has)
+		pruned, err := IsPruned(db, id)
+		require.NoError(t, err)
+		require.Equal(t, i != 2, pruned)
+	}
+
+	require.NoError(t, DeletePrunedBefore(db, types.LayerID(2)))
+	for i, id := range ids {
+		pruned, err := IsPruned(db, id)
+		require.NoError(t, err)
+		require.Equal(t, i == 1, pruned)
+	}
+}
@@ -54,3 +54,25 @@ func List(db sql.Executor, coinbase types.Address) (rst []*types.Reward, err err
 		})
 	return
 }
+
+// IterateLayers calls fn with rewards in layers [from, to], ordered by layer and coinbase.
+// Iteration stops if fn returns false.
+func IterateLayers(db sql.Executor, from, to types.LayerID, fn func(*types.Reward) bool) error {
+	if _, err := db.Exec(`select coinbase, layer, total_reward, layer_reward from rewards
+		where layer between ?1 and ?2 order by layer, coinbase;`,
+		func(stmt *sql.Statement) {
+			stmt.BindInt64(1, int64(from.Uint32()))
+			stmt.BindInt64(2, int64(to.Uint32()))
+		}, func(stmt *sql.Statement) bool {
+			reward := &types.Reward{
+				Layer:       types.LayerID(uint32(stmt.ColumnInt64(1))),
+				TotalReward: uint64(stmt.ColumnInt64(2)),
+				LayerReward: uint64(stmt.ColumnInt64(3)),
+			}
+			stmt.ColumnBytes(0, reward.Coinbase[:])
+			return fn(reward)
+		}); err != nil {
+		return fmt.Errorf("rewards in layers [%v, %v]: %w", from, to, err)
+	}
+	return nil
+}
@@ -94,3 +94,32 @@ func TestRewards(t *testing.T) {
 	require.Equal(t, part, got[0].TotalReward)
 	require.Equal(t, lyrReward, got[0].LayerReward)
 }
+
+func TestIterateLayers(t *testing.T) {
+	db := sql.InMemory()
+	for _, reward := range []types.Reward{
+		{Layer: 1, Coinbase: types.Address{2}, TotalReward: 1, LayerReward: 1},
+		{Layer: 1, Coinbase: types.Address{1}, TotalReward: 2, LayerReward: 1},
+		{Layer: 2, Coinbase: types.Address{1}, TotalReward: 3, LayerReward: 1},
+		{Layer: 3, Coinbase: types.Address{1}, TotalReward: 4, LayerReward: 1},
+	} {
+		require.NoError(t, Add(db, &reward))
+	}
+	var got []types.Reward
+	require.NoError(t, IterateLayers(db, 1, 2, func(reward *types.Reward) bool {
+		got = append(got, *reward)
+		return true
+	}))
+	require.Equal(t, []types.Reward{
+		{Layer: 1, Coinbase: types.Address{1}, TotalReward: 2, LayerReward: 1},
+		{Layer: 1, Coinbase: types.Address{2}, TotalReward: 1, LayerReward: 1},
+		{Layer: 2, Coinbase: types.Address{1}, TotalReward: 3, LayerReward: 1},
+	}, got)
+
+	got = nil
+	require.NoError(t, IterateLayers(db, 1, 3, func(reward *types.Reward) bool {
+		got = append(got, *reward)
+		return false
+	}))
+	require.Len(t, got, 1)
+}
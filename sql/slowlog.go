@@ -0,0 +1,245 @@
+package sql
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-llsqlite/llsqlite"
+)
+
+const (
+	// slowLogSize is a number of recent slow queries that are kept.
+	slowLogSize = 256
+	// slowStatsSize limits number of distinct queries that are aggregated.
+	slowStatsSize = 1024
+)
+
+// pkgDir is a directory of this package, its frames are skipped when looking for the caller.
+var pkgDir = func() string {
+	_, file, _, _ := runtime.Caller(0)
+	return filepath.Dir(file)
+}()
+
+// SlowQuery is an execution of the query that took longer than the threshold.
+type SlowQuery struct {
+	Query string `json:"query"`
+	// Params summarizes parameters of the query. Bound values are not available from the driver,
+	// therefore only the number and the names of parameters are recorded.
+	Params   string        `json:"params"`
+	Caller   string        `json:"caller"`
+	Duration time.Duration `json:"duration"`
+	Time     time.Time     `json:"time"`
+}
+
+// QueryStats aggregates slow executions of the query.
+type QueryStats struct {
+	Query string        `json:"query"`
+	Count int           `json:"count"`
+	Total time.Duration `json:"total"`
+	Max   time.Duration `json:"max"`
+	// Plan is the output of EXPLAIN QUERY PLAN, updated by ExplainSlowQueries.
+	Plan string `json:"plan"`
+}
+
+func newSlowLog(threshold time.Duration) *slowLog {
+	return &slowLog{
+		threshold: threshold,
+		entries:   make([]SlowQuery, 0, slowLogSize),
+		stats:     map[string]*QueryStats{},
+	}
+}
+
+type slowLog struct {
+	threshold time.Duration
+
+	mu sync.Mutex
+	// entries is a ring buffer, next is the position of the oldest entry once it is full.
+	entries []SlowQuery
+	next    int
+	stats   map[string]*QueryStats
+}
+
+func (l *slowLog) add(query SlowQuery) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if len(l.entries) < cap(l.entries) {
+		l.entries = append(l.entries, query)
+	} else {
+		l.entries[l.next] = query
+		l.next = (l.next + 1) % len(l.entries)
+	}
+	stats, exists := l.stats[query.Query]
+	if !exists {
+		if len(l.stats) >= slowStatsSize {
+			return
+		}
+		stats = &QueryStats{Query: query.Query}
+		l.stats[query.Query] = stats
+	}
+	stats.Count++
+	stats.Total += query.Duration
+	if query.Duration > stats.Max {
+		stats.Max = query.Duration
+	}
+}
+
+// recent returns slow queries, newest first.
+func (l *slowLog) recent() []SlowQuery {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	rst := make([]SlowQuery, 0, len(l.entries))
+	for i := len(l.entries) - 1; i >= 0; i-- {
+		rst = append(rst, l.entries[(l.next+i)%len(l.entries)])
+	}
+	return rst
+}
+
+// top returns up to n queries with the largest total duration.
+func (l *slowLog) top(n int) []QueryStats {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	rst := make([]QueryStats, 0, len(l.stats))
+	for _, stats := range l.stats {
+		rst = append(rst, *stats)
+	}
+	sort.Slice(rst, func(i, j int) bool {
+		return rst[i].Total > rst[j].Total
+	})
+	if len(rst) > n {
+		rst = rst[:n]
+	}
+	return rst
+}
+
+func (l *slowLog) setPlan(query, plan string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if stats, exists := l.stats[query]; exists {
+		stats.Plan = plan
+	}
+}
+
+// observe records latency of the query, and adds it to the slow log if it is above the threshold.
+func (db *Database) observe(conn *sqlite.Conn, query string, duration time.Duration) {
+	if db.latency != nil {
+		db.latency.WithLabelValues(query).Observe(float64(duration))
+	}
+	if db.slow != nil && duration >= db.slow.threshold {
+		db.slow.add(SlowQuery{
+			Query:    query,
+			Params:   paramsSummary(conn, query),
+			Caller:   caller(),
+			Duration: duration,
+			Time:     time.Now(),
+		})
+	}
+}
+
+func paramsSummary(conn *sqlite.Conn, query string) string {
+	// prepared statement is cached by the connection
+	stmt, err := conn.Prepare(query)
+	if err != nil {
+		return ""
+	}
+	count := stmt.BindParamCount()
+	names := make([]string, 0, count)
+	for i := 1; i <= count; i++ {
+		if name := stmt.BindParamName(i); name != "" {
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		return fmt.Sprintf("%d params", count)
+	}
+	return fmt.Sprintf("%d params: %s", count, strings.Join(names, " "))
+}
+
+// caller returns the first function on the stack outside of this package.
+func caller() string {
+	pcs := make([]uintptr, 16)
+	frames := runtime.CallersFrames(pcs[:runtime.Callers(2, pcs)])
+	for {
+		frame, more := frames.Next()
+		if filepath.Dir(frame.File) != pkgDir || strings.HasSuffix(frame.File, "_test.go") {
+			return fmt.Sprintf("%s (%s:%d)", frame.Function, frame.File, frame.Line)
+		}
+		if !more {
+			return ""
+		}
+	}
+}
+
+// SlowQueries returns recent queries that took longer than the threshold, newest first.
+// Returns nil if slow query log is not enabled.
+func (db *Database) SlowQueries() []SlowQuery {
+	if db.slow == nil {
+		return nil
+	}
+	return db.slow.recent()
+}
+
+// TopSlowQueries returns up to n queries with the largest total duration of slow executions.
+func (db *Database) TopSlowQueries(n int) []QueryStats {
+	if db.slow == nil {
+		return nil
+	}
+	return db.slow.top(n)
+}
+
+// ExplainSlowQueries updates query plans for the top n slow queries and returns them.
+// If the query can't be explained (for example, schema changes) the error is recorded instead of the plan.
+func (db *Database) ExplainSlowQueries(n int) []QueryStats {
+	top := db.TopSlowQueries(n)
+	for i := range top {
+		plan, err := db.Explain(top[i].Query)
+		if err != nil {
+			plan = err.Error()
+		}
+		top[i].Plan = plan
+		db.slow.setPlan(top[i].Query, plan)
+	}
+	return top
+}
+
+// Explain returns the output of EXPLAIN QUERY PLAN for the query, one step per line.
+// Parameters are not bound, and the query is not executed.
+// https://www.sqlite.org/eqp.html
+func (db *Database) Explain(query string) (string, error) {
+	conn := db.pool.Get(context.Background())
+	if conn == nil {
+		return "", ErrNoConnection
+	}
+	defer db.pool.Put(conn)
+	// explained queries are not cached with the rest of prepared statements
+	stmt, _, err := conn.PrepareTransient("EXPLAIN QUERY PLAN " + query)
+	if err != nil {
+		return "", fmt.Errorf("explain %s: %w", query, err)
+	}
+	defer stmt.Finalize()
+	var (
+		plan  strings.Builder
+		depth = map[int64]int{}
+	)
+	for {
+		row, err := stmt.Step()
+		if err != nil {
+			return "", fmt.Errorf("explain %s: %w", query, err)
+		}
+		if !row {
+			return plan.String(), nil
+		}
+		id, parent := stmt.ColumnInt64(0), stmt.ColumnInt64(1)
+		depth[id] = depth[parent] + 1
+		if plan.Len() > 0 {
+			plan.WriteByte('\n')
+		}
+		plan.WriteString(strings.Repeat("  ", depth[id]-1))
+		plan.WriteString(stmt.ColumnText(3))
+	}
+}
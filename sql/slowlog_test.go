@@ -0,0 +1,95 @@
+package sql
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSlowQueryLog(t *testing.T) {
+	db := InMemory(
+		WithMigrations(testTables),
+		WithSlowQueryLog(time.Nanosecond),
+	)
+	insert := "insert into testing1(id, field) values (?1, ?2)"
+	for _, key := range []string{"a", "b", "c"} {
+		_, err := db.Exec(insert, func(stmt *Statement) {
+			stmt.BindText(1, key)
+			stmt.BindInt64(2, 1)
+		}, nil)
+		require.NoError(t, err)
+	}
+	query := "select id from testing1 where field = @field"
+	require.NoError(t, db.WithTx(context.Background(), func(tx *Tx) error {
+		_, err := tx.Exec(query, func(stmt *Statement) {
+			stmt.SetInt64("@field", 1)
+		}, nil)
+		return err
+	}))
+
+	// migrations are logged too
+	recent := db.SlowQueries()
+	require.Len(t, recent, 5)
+	require.Equal(t, query, recent[0].Query)
+	require.Equal(t, "1 params: @field", recent[0].Params)
+	require.Contains(t, recent[0].Caller, "TestSlowQueryLog")
+	require.Equal(t, insert, recent[1].Query)
+	require.Equal(t, "2 params: ?1 ?2", recent[1].Params)
+	require.Contains(t, recent[1].Caller, "slowlog_test.go")
+
+	top := db.TopSlowQueries(10)
+	require.Len(t, top, 3)
+	counts := map[string]int{}
+	for _, stats := range top {
+		counts[stats.Query] = stats.Count
+		require.GreaterOrEqual(t, stats.Total, stats.Max)
+	}
+	require.Equal(t, 3, counts[insert])
+	require.Equal(t, 1, counts[query])
+	require.Len(t, db.TopSlowQueries(1), 1)
+
+	explained := db.ExplainSlowQueries(10)
+	require.Len(t, explained, 3)
+	for _, stats := range explained {
+		if stats.Query == query {
+			require.Contains(t, stats.Plan, "SCAN testing1")
+		}
+	}
+	for _, stats := range db.TopSlowQueries(10) {
+		if stats.Query == query {
+			require.Contains(t, stats.Plan, "SCAN testing1")
+		}
+	}
+}
+
+func TestSlowQueryLogDisabled(t *testing.T) {
+	db := InMemory(WithMigrations(testTables))
+	_, err := db.Exec("select 1", nil, nil)
+	require.NoError(t, err)
+	require.Empty(t, db.SlowQueries())
+	require.Empty(t, db.TopSlowQueries(10))
+}
+
+func TestSlowQueryLogRing(t *testing.T) {
+	log := newSlowLog(time.Nanosecond)
+	for i := 0; i < slowLogSize+10; i++ {
+		log.add(SlowQuery{Query: "query", Duration: time.Duration(i)})
+	}
+	recent := log.recent()
+	require.Len(t, recent, slowLogSize)
+	require.Equal(t, time.Duration(slowLogSize+9), recent[0].Duration)
+	require.Equal(t, time.Duration(10), recent[len(recent)-1].Duration)
+	require.Equal(t, slowLogSize+10, log.top(1)[0].Count)
+}
+
+func TestExplain(t *testing.T) {
+	db := InMemory(WithMigrations(testTables))
+	plan, err := db.Explain("select field from testing1 where id = ?1")
+	require.NoError(t, err)
+	require.Contains(t, plan, "SEARCH testing1 USING INDEX")
+
+	_, err = db.Explain("select from")
+	require.Error(t, err)
+}
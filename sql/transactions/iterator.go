@@ -20,7 +20,7 @@ type ResultsFilter struct {
 func (f *ResultsFilter) query() string {
 	var q strings.Builder
 	q.WriteString(`
-		select distinct id, tx, header, result 
+		select distinct id, tx, header, result, events 
 		from transactions
 		left join transactions_results_addresses on id=tid
 		where result is not null
@@ -88,6 +88,14 @@ func IterateResults(db sql.Executor, filter ResultsFilter, fn func(*types.Transa
 		if ierr != nil {
 			return false
 		}
+		if stmt.ColumnLen(4) > 0 {
+			buf := make([]byte, stmt.ColumnLen(4))
+			stmt.ColumnBytes(4, buf)
+			tx.Events, ierr = codec.DecodeSlice[types.TransactionEvent](buf)
+			if ierr != nil {
+				return false
+			}
+		}
 		return fn(&tx)
 	})
 	if err == nil {
@@ -69,6 +69,9 @@ func TestIterateResults(t *testing.T) {
 
 			require.NoError(t, Add(dtx, &tx.Transaction, time.Time{}))
 			require.NoError(t, AddResult(dtx, tx.ID, &tx.TransactionResult))
+			if len(tx.Events) > 0 {
+				require.NoError(t, AddEvents(dtx, tx.ID, tx.Events))
+			}
 			txs[i] = *tx
 		}
 		return nil
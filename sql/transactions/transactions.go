@@ -72,6 +72,17 @@ func HasProposalTX(db sql.Executor, pid types.ProposalID, tid types.TransactionI
 	return rows > 0, nil
 }
 
+// DeleteProposalTxsBefore deletes associations between transactions and proposals before the layer.
+func DeleteProposalTxsBefore(db sql.Executor, lid types.LayerID) error {
+	if _, err := db.Exec("delete from proposal_transactions where layer < ?1;",
+		func(stmt *sql.Statement) {
+			stmt.BindInt64(1, int64(lid))
+		}, nil); err != nil {
+		return fmt.Errorf("delete proposal txs before %s: %w", lid, err)
+	}
+	return nil
+}
+
 // AddToBlock associates a transaction with a block.
 func AddToBlock(db sql.Executor, tid types.TransactionID, lid types.LayerID, bid types.BlockID) error {
 	if _, err := db.Exec(`
@@ -129,7 +140,7 @@ func UndoLayers(db *sql.Tx, from types.LayerID) error {
 		return fmt.Errorf("delete addresses mapping %w", err)
 	}
 	_, err = db.Exec(`update transactions 
-		set layer = null, block = null, result = null 
+		set layer = null, block = null, result = null, events = null 
 		where layer >= ?1`,
 		func(stmt *sql.Statement) {
 			stmt.BindInt64(1, int64(from))
@@ -340,6 +351,29 @@ func AddResult(db *sql.Tx, id types.TransactionID, rst *types.TransactionResult)
 	return nil
 }
 
+// AddEvents adds events emitted during execution of the transaction.
+func AddEvents(db sql.Executor, id types.TransactionID, events []types.TransactionEvent) error {
+	buf, err := codec.EncodeSlice(events)
+	if err != nil {
+		return fmt.Errorf("encode %w", err)
+	}
+	if rows, err := db.Exec(`update transactions set events = ?2 
+		where id = ?1 and result is not null returning id;`,
+		func(stmt *sql.Statement) {
+			stmt.BindBytes(1, id[:])
+			stmt.BindBytes(2, buf)
+		},
+		func(stmt *sql.Statement) bool {
+			return false
+		},
+	); err != nil {
+		return fmt.Errorf("insert events for %s: %w", id, err)
+	} else if rows == 0 {
+		return fmt.Errorf("invalid state for %s", id)
+	}
+	return nil
+}
+
 // TransactionInProposal returns lowest layer of the proposal where tx is included after the specified layer.
 func TransactionInProposal(db sql.Executor, id types.TransactionID, after types.LayerID) (types.LayerID, error) {
 	var rst types.LayerID
@@ -154,6 +154,22 @@ func TestAddToProposal(t *testing.T) {
 	require.False(t, has)
 }
 
+func TestDeleteProposalTxsBefore(t *testing.T) {
+	db := sql.InMemory()
+	tid := types.TransactionID{1}
+	for _, lid := range []types.LayerID{9, 10} {
+		require.NoError(t, transactions.AddToProposal(db, tid, lid, types.ProposalID{byte(lid)}))
+	}
+	require.NoError(t, transactions.DeleteProposalTxsBefore(db, 10))
+
+	has, err := transactions.HasProposalTX(db, types.ProposalID{9}, tid)
+	require.NoError(t, err)
+	require.False(t, has)
+	has, err = transactions.HasProposalTX(db, types.ProposalID{10}, tid)
+	require.NoError(t, err)
+	require.True(t, has)
+}
+
 func TestAddToBlock(t *testing.T) {
 	db := sql.InMemory()
 
@@ -502,3 +518,40 @@ func TestTransactionInBlock(t *testing.T) {
 	_, _, err = transactions.TransactionInBlock(db, tid, lids[2])
 	require.ErrorIs(t, err, sql.ErrNotFound)
 }
+
+func TestAddEvents(t *testing.T) {
+	db := sql.InMemory()
+	signer, err := signing.NewEdSigner()
+	require.NoError(t, err)
+	tx := createTX(t, signer, types.Address{1}, 1, 191, 1)
+	lid := types.LayerID(10)
+	events := []types.TransactionEvent{
+		{Type: types.EventTransfer, From: tx.Principal, To: types.Address{1}, Amount: 191},
+	}
+	require.NoError(t, transactions.Add(db, tx, time.Now()))
+	require.Error(t, transactions.AddEvents(db, tx.ID, events), "result is not added yet")
+
+	require.NoError(t, db.WithTx(context.Background(), func(dtx *sql.Tx) error {
+		require.NoError(t, transactions.AddResult(dtx, tx.ID, &types.TransactionResult{Layer: lid}))
+		return transactions.AddEvents(dtx, tx.ID, events)
+	}))
+	iterate := func() []types.TransactionEvent {
+		var rst []types.TransactionEvent
+		require.NoError(t, transactions.IterateResults(db, transactions.ResultsFilter{TID: &tx.ID},
+			func(tx *types.TransactionWithResult) bool {
+				rst = tx.Events
+				return true
+			}))
+		return rst
+	}
+	require.Equal(t, events, iterate())
+
+	require.NoError(t, db.WithTx(context.Background(), func(dtx *sql.Tx) error {
+		return transactions.UndoLayers(dtx, lid)
+	}))
+	require.Empty(t, iterate())
+	require.NoError(t, db.WithTx(context.Background(), func(dtx *sql.Tx) error {
+		return transactions.AddResult(dtx, tx.ID, &types.TransactionResult{Layer: lid})
+	}))
+	require.Empty(t, iterate())
+}
@@ -83,7 +83,7 @@ func (d *DataFetch) PollMaliciousProofs(ctx context.Context) error {
 		},
 		ch: make(chan peerResult[fetch.MaliciousIDs], len(peers)),
 	}
-	okFunc := func(data []byte, peer p2p.Peer) {
+	okFunc := func(data *fetch.MaliciousIDs, peer p2p.Peer) {
 		d.receiveMaliciousIDs(ctx, req, peer, data, nil)
 	}
 	errFunc := func(err error, peer p2p.Peer) {
@@ -124,10 +124,10 @@ func (d *DataFetch) PollMaliciousProofs(ctx context.Context) error {
 	}
 }
 
-// PollLayerData polls all peers for data in the specified layer.
+// PollLayerData polls all peers that are not banned for data in the specified layer.
 func (d *DataFetch) PollLayerData(ctx context.Context, lid types.LayerID, peers ...p2p.Peer) error {
 	if len(peers) == 0 {
-		peers = d.fetcher.GetPeers()
+		peers = d.fetcher.RankedPeers()
 	}
 	if len(peers) == 0 {
 		return errNoPeers
@@ -142,7 +142,7 @@ func (d *DataFetch) PollLayerData(ctx context.Context, lid types.LayerID, peers
 		},
 		ch: make(chan peerResult[fetch.LayerData], len(peers)),
 	}
-	okFunc := func(data []byte, peer p2p.Peer) {
+	okFunc := func(data *fetch.LayerData, peer p2p.Peer) {
 		d.receiveData(ctx, req, peer, data, nil)
 	}
 	errFunc := func(err error, peer p2p.Peer) {
@@ -185,19 +185,12 @@ func (d *DataFetch) PollLayerData(ctx context.Context, lid types.LayerID, peers
 	}
 }
 
-func (d *DataFetch) receiveMaliciousIDs(ctx context.Context, req *maliciousIDRequest, peer p2p.Peer, data []byte, peerErr error) {
+func (d *DataFetch) receiveMaliciousIDs(ctx context.Context, req *maliciousIDRequest, peer p2p.Peer, data *fetch.MaliciousIDs, peerErr error) {
 	logger := d.logger.WithContext(ctx).WithFields(req.lid, log.Stringer("peer", peer))
 	logger.Debug("received layer data from peer")
-	var (
-		result = peerResult[fetch.MaliciousIDs]{peer: peer, err: peerErr}
-		malIDs fetch.MaliciousIDs
-	)
+	result := peerResult[fetch.MaliciousIDs]{peer: peer, data: data, err: peerErr}
 	if peerErr != nil {
 		logger.With().Debug("received peer error for layer data", req.lid, log.Err(peerErr))
-	} else if result.err = codec.Decode(data, &malIDs); result.err != nil {
-		logger.With().Debug("error converting bytes to LayerData", log.Err(result.err))
-	} else {
-		result.data = &malIDs
 	}
 	select {
 	case req.ch <- result:
@@ -206,19 +199,13 @@ func (d *DataFetch) receiveMaliciousIDs(ctx context.Context, req *maliciousIDReq
 	}
 }
 
-func (d *DataFetch) receiveData(ctx context.Context, req *dataRequest, peer p2p.Peer, data []byte, peerErr error) {
+func (d *DataFetch) receiveData(ctx context.Context, req *dataRequest, peer p2p.Peer, data *fetch.LayerData, peerErr error) {
 	logger := d.logger.WithContext(ctx).WithFields(req.lid, log.Stringer("peer", peer))
 	logger.Debug("received layer data from peer")
-	var (
-		result = peerResult[fetch.LayerData]{peer: peer, err: peerErr}
-		ld     fetch.LayerData
-	)
+	result := peerResult[fetch.LayerData]{peer: peer, data: data, err: peerErr}
 	if peerErr != nil {
 		logger.With().Debug("received peer error for layer data", req.lid, log.Err(peerErr))
-	} else if result.err = codec.Decode(data, &ld); result.err != nil {
-		logger.With().Debug("error converting bytes to LayerData", log.Err(result.err))
 	} else {
-		result.data = &ld
 		registerLayerHashes(d.fetcher, peer, result.data)
 	}
 	select {
@@ -302,9 +289,9 @@ func fetchLayerData(ctx context.Context, logger log.Log, fetcher fetcher, req *d
 	}
 }
 
-// PollLayerOpinions polls all peers for opinions in the specified layer.
+// PollLayerOpinions polls all peers that are not banned for opinions in the specified layer.
 func (d *DataFetch) PollLayerOpinions(ctx context.Context, lid types.LayerID) ([]*fetch.LayerOpinion, error) {
-	peers := d.fetcher.GetPeers()
+	peers := d.fetcher.RankedPeers()
 	if len(peers) == 0 {
 		return nil, errNoPeers
 	}
@@ -366,6 +353,7 @@ func (d *DataFetch) receiveOpinions(ctx context.Context, req *opinionRequest, pe
 		logger.With().Debug("received peer error for layer opinions", log.Err(peerErr))
 	} else if result.err = codec.Decode(data, &lo); result.err != nil {
 		logger.With().Debug("error converting bytes to LayerOpinion", log.Err(result.err))
+		d.fetcher.ReportInvalid(peer)
 	} else {
 		lo.SetPeer(peer)
 		result.data = &lo
@@ -44,15 +44,13 @@ const (
 	numMalicious = 11
 )
 
-func generateMaliciousIDs(t *testing.T) ([]types.NodeID, []byte) {
+func generateMaliciousIDs(t *testing.T) ([]types.NodeID, *fetch.MaliciousIDs) {
 	t.Helper()
 	var malicious fetch.MaliciousIDs
 	for i := 0; i < numMalicious; i++ {
 		malicious.NodeIDs = append(malicious.NodeIDs, types.RandomNodeID())
 	}
-	data, err := codec.Encode(&malicious)
-	require.NoError(t, err)
-	return malicious.NodeIDs, data
+	return malicious.NodeIDs, &malicious
 }
 
 func generateLayerOpinions(t *testing.T) []byte {
@@ -66,27 +64,21 @@ func generateLayerOpinions(t *testing.T) []byte {
 	return data
 }
 
-func generateLayerContent(t *testing.T) []byte {
+func generateLayerContent(t *testing.T) *fetch.LayerData {
 	t.Helper()
 	ballotIDs := make([]types.BallotID, 0, numBallots)
 	for i := 0; i < numBallots; i++ {
 		ballotIDs = append(ballotIDs, types.RandomBallotID())
 	}
-	lb := fetch.LayerData{
+	return &fetch.LayerData{
 		Ballots: ballotIDs,
 	}
-	out, err := codec.Encode(&lb)
-	require.NoError(t, err)
-	return out
 }
 
-func generateEmptyLayer(t *testing.T) []byte {
-	lb := fetch.LayerData{
+func generateEmptyLayer() *fetch.LayerData {
+	return &fetch.LayerData{
 		Ballots: []types.BallotID{},
 	}
-	out, err := codec.Encode(&lb)
-	require.NoError(t, err)
-	return out
 }
 
 func GenPeers(num int) []p2p.Peer {
@@ -105,7 +97,7 @@ func TestDataFetch_PollMaliciousIDs(t *testing.T) {
 		td := newTestDataFetch(t)
 		td.mFetcher.EXPECT().GetPeers().Return(peers)
 		td.mFetcher.EXPECT().GetMaliciousIDs(gomock.Any(), peers, gomock.Any(), gomock.Any()).DoAndReturn(
-			func(_ context.Context, _ []p2p.Peer, okCB func([]byte, p2p.Peer), errCB func(error, p2p.Peer)) error {
+			func(_ context.Context, _ []p2p.Peer, okCB func(*fetch.MaliciousIDs, p2p.Peer), errCB func(error, p2p.Peer)) error {
 				for _, peer := range peers {
 					ids, data := generateMaliciousIDs(t)
 					for _, id := range ids {
@@ -144,9 +136,9 @@ func TestDataFetch_PollLayerData(t *testing.T) {
 	errUnknown := errors.New("unknown")
 	newTestDataFetchWithMocks := func(*testing.T) *testDataFetch {
 		td := newTestDataFetch(t)
-		td.mFetcher.EXPECT().GetPeers().Return(peers)
+		td.mFetcher.EXPECT().RankedPeers().Return(peers)
 		td.mFetcher.EXPECT().GetLayerData(gomock.Any(), peers, layerID, gomock.Any(), gomock.Any()).DoAndReturn(
-			func(_ context.Context, _ []p2p.Peer, _ types.LayerID, okCB func([]byte, p2p.Peer), errCB func(error, p2p.Peer)) error {
+			func(_ context.Context, _ []p2p.Peer, _ types.LayerID, okCB func(*fetch.LayerData, p2p.Peer), errCB func(error, p2p.Peer)) error {
 				for _, peer := range peers {
 					td.mFetcher.EXPECT().RegisterPeerHashes(peer, gomock.Any())
 					okCB(generateLayerContent(t), peer)
@@ -183,9 +175,9 @@ func TestDataFetch_PollLayerData_PeerErrors(t *testing.T) {
 	t.Run("only one peer has data", func(t *testing.T) {
 		t.Parallel()
 		td := newTestDataFetch(t)
-		td.mFetcher.EXPECT().GetPeers().Return(peers)
+		td.mFetcher.EXPECT().RankedPeers().Return(peers)
 		td.mFetcher.EXPECT().GetLayerData(gomock.Any(), peers, layerID, gomock.Any(), gomock.Any()).DoAndReturn(
-			func(_ context.Context, _ []p2p.Peer, _ types.LayerID, okCB func([]byte, p2p.Peer), errCB func(error, p2p.Peer)) error {
+			func(_ context.Context, _ []p2p.Peer, _ types.LayerID, okCB func(*fetch.LayerData, p2p.Peer), errCB func(error, p2p.Peer)) error {
 				td.mFetcher.EXPECT().RegisterPeerHashes(peers[0], gomock.Any())
 				okCB(generateLayerContent(t), peers[0])
 				for i := 1; i < numPeers; i++ {
@@ -200,10 +192,10 @@ func TestDataFetch_PollLayerData_PeerErrors(t *testing.T) {
 	t.Run("only one peer has empty layer", func(t *testing.T) {
 		t.Parallel()
 		td := newTestDataFetch(t)
-		td.mFetcher.EXPECT().GetPeers().Return(peers)
+		td.mFetcher.EXPECT().RankedPeers().Return(peers)
 		td.mFetcher.EXPECT().GetLayerData(gomock.Any(), peers, layerID, gomock.Any(), gomock.Any()).DoAndReturn(
-			func(_ context.Context, _ []p2p.Peer, _ types.LayerID, okCB func([]byte, p2p.Peer), errCB func(error, p2p.Peer)) error {
-				okCB(generateEmptyLayer(t), peers[0])
+			func(_ context.Context, _ []p2p.Peer, _ types.LayerID, okCB func(*fetch.LayerData, p2p.Peer), errCB func(error, p2p.Peer)) error {
+				okCB(generateEmptyLayer(), peers[0])
 				for i := 1; i < numPeers; i++ {
 					errCB(errors.New("not available"), peers[i])
 				}
@@ -244,7 +236,7 @@ func TestDataFetch_PollLayerOpinions(t *testing.T) {
 			t.Parallel()
 
 			td := newTestDataFetch(t)
-			td.mFetcher.EXPECT().GetPeers().Return(peers)
+			td.mFetcher.EXPECT().RankedPeers().Return(peers)
 			td.mFetcher.EXPECT().GetLayerOpinions(gomock.Any(), peers, lid, gomock.Any(), gomock.Any()).DoAndReturn(
 				func(_ context.Context, _ []p2p.Peer, _ types.LayerID, okCB func([]byte, p2p.Peer), errCB func(error, p2p.Peer)) error {
 					for i, peer := range peers {
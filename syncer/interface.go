@@ -36,8 +36,8 @@ type fetchLogic interface {
 
 // fetcher is the interface to the low-level fetching.
 type fetcher interface {
-	GetMaliciousIDs(context.Context, []p2p.Peer, func([]byte, p2p.Peer), func(error, p2p.Peer)) error
-	GetLayerData(context.Context, []p2p.Peer, types.LayerID, func([]byte, p2p.Peer), func(error, p2p.Peer)) error
+	GetMaliciousIDs(context.Context, []p2p.Peer, func(*fetch.MaliciousIDs, p2p.Peer), func(error, p2p.Peer)) error
+	GetLayerData(context.Context, []p2p.Peer, types.LayerID, func(*fetch.LayerData, p2p.Peer), func(error, p2p.Peer)) error
 	GetLayerOpinions(context.Context, []p2p.Peer, types.LayerID, func([]byte, p2p.Peer), func(error, p2p.Peer)) error
 
 	GetMalfeasanceProofs(context.Context, []types.NodeID) error
@@ -47,6 +47,8 @@ type fetcher interface {
 	RegisterPeerHashes(peer p2p.Peer, hashes []types.Hash32)
 
 	GetPeers() []p2p.Peer
+	RankedPeers() []p2p.Peer
+	ReportInvalid(p2p.Peer)
 	PeerEpochInfo(context.Context, p2p.Peer, types.EpochID) (*fetch.EpochData, error)
 	PeerMeshHashes(context.Context, p2p.Peer, *fetch.MeshHashRequest) (*fetch.MeshHashes, error)
 }
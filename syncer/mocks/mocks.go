@@ -204,7 +204,7 @@ func (mr *MockfetchLogicMockRecorder) GetEpochATXs(arg0, arg1 interface{}) *gomo
 }
 
 // GetLayerData mocks base method.
-func (m *MockfetchLogic) GetLayerData(arg0 context.Context, arg1 []p2p.Peer, arg2 types.LayerID, arg3 func([]byte, p2p.Peer), arg4 func(error, p2p.Peer)) error {
+func (m *MockfetchLogic) GetLayerData(arg0 context.Context, arg1 []p2p.Peer, arg2 types.LayerID, arg3 func(*fetch.LayerData, p2p.Peer), arg4 func(error, p2p.Peer)) error {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "GetLayerData", arg0, arg1, arg2, arg3, arg4)
 	ret0, _ := ret[0].(error)
@@ -246,7 +246,7 @@ func (mr *MockfetchLogicMockRecorder) GetMalfeasanceProofs(arg0, arg1 interface{
 }
 
 // GetMaliciousIDs mocks base method.
-func (m *MockfetchLogic) GetMaliciousIDs(arg0 context.Context, arg1 []p2p.Peer, arg2 func([]byte, p2p.Peer), arg3 func(error, p2p.Peer)) error {
+func (m *MockfetchLogic) GetMaliciousIDs(arg0 context.Context, arg1 []p2p.Peer, arg2 func(*fetch.MaliciousIDs, p2p.Peer), arg3 func(error, p2p.Peer)) error {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "GetMaliciousIDs", arg0, arg1, arg2, arg3)
 	ret0, _ := ret[0].(error)
@@ -351,6 +351,20 @@ func (mr *MockfetchLogicMockRecorder) PollMaliciousProofs(ctx interface{}) *gomo
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PollMaliciousProofs", reflect.TypeOf((*MockfetchLogic)(nil).PollMaliciousProofs), ctx)
 }
 
+// RankedPeers mocks base method.
+func (m *MockfetchLogic) RankedPeers() []p2p.Peer {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RankedPeers")
+	ret0, _ := ret[0].([]p2p.Peer)
+	return ret0
+}
+
+// RankedPeers indicates an expected call of RankedPeers.
+func (mr *MockfetchLogicMockRecorder) RankedPeers() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RankedPeers", reflect.TypeOf((*MockfetchLogic)(nil).RankedPeers))
+}
+
 // RegisterPeerHashes mocks base method.
 func (m *MockfetchLogic) RegisterPeerHashes(peer p2p.Peer, hashes []types.Hash32) {
 	m.ctrl.T.Helper()
@@ -363,6 +377,18 @@ func (mr *MockfetchLogicMockRecorder) RegisterPeerHashes(peer, hashes interface{
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RegisterPeerHashes", reflect.TypeOf((*MockfetchLogic)(nil).RegisterPeerHashes), peer, hashes)
 }
 
+// ReportInvalid mocks base method.
+func (m *MockfetchLogic) ReportInvalid(arg0 p2p.Peer) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "ReportInvalid", arg0)
+}
+
+// ReportInvalid indicates an expected call of ReportInvalid.
+func (mr *MockfetchLogicMockRecorder) ReportInvalid(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReportInvalid", reflect.TypeOf((*MockfetchLogic)(nil).ReportInvalid), arg0)
+}
+
 // Mockfetcher is a mock of fetcher interface.
 type Mockfetcher struct {
 	ctrl     *gomock.Controller
@@ -429,7 +455,7 @@ func (mr *MockfetcherMockRecorder) GetBlocks(arg0, arg1 interface{}) *gomock.Cal
 }
 
 // GetLayerData mocks base method.
-func (m *Mockfetcher) GetLayerData(arg0 context.Context, arg1 []p2p.Peer, arg2 types.LayerID, arg3 func([]byte, p2p.Peer), arg4 func(error, p2p.Peer)) error {
+func (m *Mockfetcher) GetLayerData(arg0 context.Context, arg1 []p2p.Peer, arg2 types.LayerID, arg3 func(*fetch.LayerData, p2p.Peer), arg4 func(error, p2p.Peer)) error {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "GetLayerData", arg0, arg1, arg2, arg3, arg4)
 	ret0, _ := ret[0].(error)
@@ -471,7 +497,7 @@ func (mr *MockfetcherMockRecorder) GetMalfeasanceProofs(arg0, arg1 interface{})
 }
 
 // GetMaliciousIDs mocks base method.
-func (m *Mockfetcher) GetMaliciousIDs(arg0 context.Context, arg1 []p2p.Peer, arg2 func([]byte, p2p.Peer), arg3 func(error, p2p.Peer)) error {
+func (m *Mockfetcher) GetMaliciousIDs(arg0 context.Context, arg1 []p2p.Peer, arg2 func(*fetch.MaliciousIDs, p2p.Peer), arg3 func(error, p2p.Peer)) error {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "GetMaliciousIDs", arg0, arg1, arg2, arg3)
 	ret0, _ := ret[0].(error)
@@ -528,6 +554,20 @@ func (mr *MockfetcherMockRecorder) PeerMeshHashes(arg0, arg1, arg2 interface{})
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PeerMeshHashes", reflect.TypeOf((*Mockfetcher)(nil).PeerMeshHashes), arg0, arg1, arg2)
 }
 
+// RankedPeers mocks base method.
+func (m *Mockfetcher) RankedPeers() []p2p.Peer {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RankedPeers")
+	ret0, _ := ret[0].([]p2p.Peer)
+	return ret0
+}
+
+// RankedPeers indicates an expected call of RankedPeers.
+func (mr *MockfetcherMockRecorder) RankedPeers() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RankedPeers", reflect.TypeOf((*Mockfetcher)(nil).RankedPeers))
+}
+
 // RegisterPeerHashes mocks base method.
 func (m *Mockfetcher) RegisterPeerHashes(peer p2p.Peer, hashes []types.Hash32) {
 	m.ctrl.T.Helper()
@@ -540,6 +580,18 @@ func (mr *MockfetcherMockRecorder) RegisterPeerHashes(peer, hashes interface{})
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RegisterPeerHashes", reflect.TypeOf((*Mockfetcher)(nil).RegisterPeerHashes), peer, hashes)
 }
 
+// ReportInvalid mocks base method.
+func (m *Mockfetcher) ReportInvalid(arg0 p2p.Peer) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "ReportInvalid", arg0)
+}
+
+// ReportInvalid indicates an expected call of ReportInvalid.
+func (mr *MockfetcherMockRecorder) ReportInvalid(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReportInvalid", reflect.TypeOf((*Mockfetcher)(nil).ReportInvalid), arg0)
+}
+
 // MocklayerPatrol is a mock of layerPatrol interface.
 type MocklayerPatrol struct {
 	ctrl     *gomock.Controller
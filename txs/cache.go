@@ -72,7 +72,21 @@ type accountCache struct {
 	// https://github.com/spacemeshos/go-spacemesh/issues/3668
 	moreInDB bool
 
-	cachedTXs map[types.TransactionID]*NanoTX // shared with the cache instance
+	maxTXs int
+	policy EvictionPolicy
+
+	cachedTXs   map[types.TransactionID]*NanoTX // shared with the cache instance
+	cachedBytes *int                            // shared with the cache instance
+}
+
+func (ac *accountCache) cache(ntx *NanoTX) {
+	ac.cachedTXs[ntx.ID] = ntx
+	*ac.cachedBytes += ntx.Size
+}
+
+func (ac *accountCache) uncache(ntx *NanoTX) {
+	delete(ac.cachedTXs, ntx.ID)
+	*ac.cachedBytes -= ntx.Size
 }
 
 func (ac *accountCache) nextNonce() uint64 {
@@ -90,7 +104,7 @@ func (ac *accountCache) availBalance() uint64 {
 }
 
 func (ac *accountCache) precheck(logger log.Log, ntx *NanoTX) (*list.Element, *candidate, error) {
-	if ac.txsByNonce.Len() >= maxTXsPerAcct {
+	if ac.txsByNonce.Len() >= ac.maxTXs {
 		ac.moreInDB = true
 		return nil, nil, errTooManyNonce
 	}
@@ -142,15 +156,30 @@ func (ac *accountCache) accept(logger log.Log, ntx *NanoTX, blockSeed []byte) er
 		if !ntx.Better(prevCand.best, blockSeed) {
 			return nil
 		}
+		if len(blockSeed) == 0 && !ac.policy.Replace(prevCand.best, ntx) {
+			logger.With().Debug("fee is not high enough to replace transaction",
+				log.Stringer("candidate", ntx.ID),
+				log.Stringer("current", prevCand.id()),
+				log.Uint64("nonce", ntx.Nonce),
+				log.Uint64("fee", ntx.Fee()),
+				log.Uint64("current_fee", prevCand.best.Fee()))
+			return nil
+		}
 		added = prev
 		replaced = prevCand.best
-		delete(ac.cachedTXs, prevCand.best.ID)
+		ac.uncache(prevCand.best)
 		prevCand.best = ntx
 		prevCand.postBalance = cand.postBalance
 	}
-	ac.cachedTXs[ntx.ID] = ntx
+	ac.cache(ntx)
 
 	if replaced != nil {
+		events.ReportEvicted(events.EventEvicted{
+			ID:        replaced.ID,
+			Principal: replaced.Principal,
+			Nonce:     replaced.Nonce,
+			Reason:    events.EvictedReplaced,
+		})
 		logger.With().Debug("better transaction replaced for nonce",
 			log.Stringer("better", ntx.ID),
 			log.Stringer("replaced", replaced.ID),
@@ -186,7 +215,7 @@ func (ac *accountCache) accept(logger log.Log, ntx *NanoTX, blockSeed []byte) er
 		rm := next
 		next = next.Next()
 		removed := ac.txsByNonce.Remove(rm).(*candidate)
-		delete(ac.cachedTXs, removed.id())
+		ac.uncache(removed.best)
 		logger.With().Debug("tx made infeasible by new/better transaction",
 			removed.id(),
 			log.Uint64("nonce", removed.nonce()),
@@ -195,6 +224,50 @@ func (ac *accountCache) accept(logger log.Log, ntx *NanoTX, blockSeed []byte) er
 	return nil
 }
 
+// evict removes candidates selected by the predicate and recomputes balances for the remaining ones.
+// Candidates with higher nonces than the evicted one can't be applied, and are evicted as well.
+// It returns the number of candidates selected by the predicate and the number of those evicted after them.
+func (ac *accountCache) evict(
+	logger log.Log,
+	reason events.EvictionReason,
+	predicate func(*candidate) bool,
+) (evicted, dropped int) {
+	balance := ac.startBalance
+	for e := ac.txsByNonce.Front(); e != nil; {
+		next := e.Next()
+		cand := e.Value.(*candidate)
+		if evicted > 0 || predicate(cand) {
+			ac.txsByNonce.Remove(e)
+			ac.uncache(cand.best)
+			current := reason
+			if evicted > 0 {
+				current = events.EvictedNonceGap
+				dropped++
+				// transactions that are still valid are loaded back once the gap is filled
+				ac.moreInDB = true
+			} else {
+				evicted++
+			}
+			events.ReportEvicted(events.EventEvicted{
+				ID:        cand.id(),
+				Principal: ac.addr,
+				Nonce:     cand.nonce(),
+				Reason:    current,
+			})
+			logger.With().Debug("evicted transaction from mempool",
+				cand.id(),
+				ac.addr,
+				log.Uint64("nonce", cand.nonce()),
+				log.Stringer("reason", current))
+		} else {
+			balance -= cand.maxSpending()
+			cand.postBalance = balance
+		}
+		e = next
+	}
+	return evicted, dropped
+}
+
 func nonceMarshaller(any any) log.ArrayMarshaler {
 	return log.ArrayMarshalerFunc(func(encoder log.ArrayEncoder) error {
 		var allNonce []uint64
@@ -227,6 +300,8 @@ func (ac *accountCache) addBatch(logger log.Log, nonce2TXs map[uint64][]*NanoTX,
 		balance     = ac.availBalance()
 		sortedNonce = make([]uint64, 0, len(nonce2TXs))
 		added       = make([]uint64, 0, len(nonce2TXs))
+		// skipped is true if there are nonces after the expired one
+		skipped bool
 	)
 	for nonce := range nonce2TXs {
 		if nonce < nextNonce {
@@ -235,6 +310,27 @@ func (ac *accountCache) addBatch(logger log.Log, nonce2TXs map[uint64][]*NanoTX,
 		sortedNonce = append(sortedNonce, nonce)
 	}
 	sort.Slice(sortedNonce, func(i, j int) bool { return sortedNonce[i] < sortedNonce[j] })
+	if len(blockSeed) == 0 {
+		// expired transactions are never loaded back into the mempool, and loading stops
+		// at the first expired nonce, as higher nonces can't be applied until it is replaced.
+		// transactions are expired only in the mempool and never when the cache
+		// is used to build a block.
+		now := time.Now()
+		for i, nonce := range sortedNonce {
+			alive := nonce2TXs[nonce][:0]
+			for _, ntx := range nonce2TXs[nonce] {
+				if !ac.policy.Expired(ntx, now) {
+					alive = append(alive, ntx)
+				}
+			}
+			if len(alive) == 0 {
+				skipped = i+1 < len(sortedNonce)
+				sortedNonce = sortedNonce[:i]
+				break
+			}
+			nonce2TXs[nonce] = alive
+		}
+	}
 	for _, nonce := range sortedNonce {
 		best := findBest(nonce2TXs[nonce], balance, blockSeed)
 		if best == nil {
@@ -259,7 +355,7 @@ func (ac *accountCache) addBatch(logger log.Log, nonce2TXs map[uint64][]*NanoTX,
 		balance = ac.availBalance()
 	}
 
-	ac.moreInDB = len(sortedNonce) > len(added)
+	ac.moreInDB = skipped || len(sortedNonce) > len(added)
 	if len(added) > 0 {
 		logger.With().Debug("added batch to account pool", log.Array("batch", nonceMarshaller(added)))
 	} else {
@@ -353,7 +449,7 @@ func (ac *accountCache) addPendingFromNonce(logger log.Log, db *sql.Database, no
 // find the first nonce without a layer.
 // a nonce with a valid layer indicates that it's already packed in a proposal/block.
 func (ac *accountCache) getMempool(logger log.Log) []*NanoTX {
-	bests := make([]*NanoTX, 0, maxTXsPerAcct)
+	bests := make([]*NanoTX, 0, ac.txsByNonce.Len())
 	offset := 0
 	found := false
 	for e := ac.txsByNonce.Front(); e != nil; e = e.Next() {
@@ -394,7 +490,7 @@ func (ac *accountCache) resetAfterApply(logger log.Log, db *sql.Database, nextNo
 	logger = logger.WithFields(ac.addr)
 	logger.With().Debug("resetting to nonce", log.Uint64("nonce", nextNonce))
 	for e := ac.txsByNonce.Front(); e != nil; e = e.Next() {
-		delete(ac.cachedTXs, e.Value.(*candidate).id())
+		ac.uncache(e.Value.(*candidate).best)
 	}
 	ac.txsByNonce = list.New()
 	ac.startNonce = nextNonce
@@ -408,22 +504,53 @@ func (ac *accountCache) shouldEvict() bool {
 
 type stateFunc func(types.Address) (uint64, uint64)
 
+// CacheOpt for configuring the cache.
+type CacheOpt func(*Cache)
+
+// WithMempoolConfig defines the limits of the mempool.
+func WithMempoolConfig(cfg MempoolConfig) CacheOpt {
+	return func(c *Cache) {
+		c.cfg = cfg
+	}
+}
+
+// WithEvictionPolicy overwrites the policy created from the mempool config.
+func WithEvictionPolicy(policy EvictionPolicy) CacheOpt {
+	return func(c *Cache) {
+		c.policy = policy
+	}
+}
+
 type Cache struct {
 	logger log.Log
 	stateF stateFunc
+	cfg    MempoolConfig
+	policy EvictionPolicy
 
-	mu        sync.Mutex
-	pending   map[types.Address]*accountCache
-	cachedTXs map[types.TransactionID]*NanoTX // shared with accountCache instances
+	mu          sync.Mutex
+	pending     map[types.Address]*accountCache
+	cachedTXs   map[types.TransactionID]*NanoTX // shared with accountCache instances
+	cachedBytes int                             // total size of cachedTXs
 }
 
-func NewCache(s stateFunc, logger log.Log) *Cache {
-	return &Cache{
+func NewCache(s stateFunc, logger log.Log, opts ...CacheOpt) *Cache {
+	c := &Cache{
 		logger:    logger,
 		stateF:    s,
+		cfg:       DefaultMempoolConfig(),
 		pending:   make(map[types.Address]*accountCache),
 		cachedTXs: make(map[types.TransactionID]*NanoTX),
 	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	if c.cfg.MaxTXsPerAccount == 0 {
+		c.cfg.MaxTXsPerAccount = maxTXsPerAcct
+	}
+	if c.policy == nil {
+		c.policy = NewDefaultPolicy(c.cfg)
+	}
+	return c
 }
 
 func groupTXsByPrincipal(logger log.Log, mtxs []*types.MeshTransaction) map[types.Address]map[uint64][]*NanoTX {
@@ -478,7 +605,13 @@ func (c *Cache) buildFromScratch(db *sql.Database) error {
 		mtx.LayerID = nextLayer
 		mtx.BlockID = nextBlock
 	}
-	return c.BuildFromTXs(rst, nil)
+	if err := c.BuildFromTXs(rst, nil); err != nil {
+		return err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.evict(c.logger, time.Now())
+	return nil
 }
 
 // BuildFromTXs builds the cache from the provided transactions.
@@ -524,7 +657,10 @@ func (c *Cache) createAcctIfNotPresent(addr types.Address) {
 			startNonce:   nextNonce,
 			startBalance: balance,
 			txsByNonce:   list.New(),
+			maxTXs:       c.cfg.MaxTXsPerAccount,
+			policy:       c.policy,
 			cachedTXs:    c.cachedTXs,
+			cachedBytes:  &c.cachedBytes,
 		}
 	}
 }
@@ -567,6 +703,7 @@ func (c *Cache) Add(ctx context.Context, db *sql.Database, tx *types.Transaction
 	if acceptable(err) {
 		err = nil
 		mempoolTxCount.WithLabelValues(accepted).Inc()
+		c.evictOverLimit(logger)
 	}
 	if err == nil || mustPersist {
 		if dbErr := transactions.Add(db, tx, received); dbErr != nil {
@@ -648,6 +785,7 @@ func (c *Cache) applyEmptyLayer(db *sql.Database, lid types.LayerID) error {
 			ntx.UpdateLayer(nbid, nlid)
 		}
 	}
+	c.evict(c.logger, time.Now())
 	return nil
 }
 
@@ -684,6 +822,11 @@ func (c *Cache) ApplyLayer(
 			if err != nil {
 				return fmt.Errorf("add result tx=%s nonce=%d %w", rst.ID, rst.Nonce, err)
 			}
+			if len(rst.Events) > 0 {
+				if err := transactions.AddEvents(dbtx, rst.ID, rst.Events); err != nil {
+					return fmt.Errorf("add events tx=%s nonce=%d %w", rst.ID, rst.Nonce, err)
+				}
+			}
 		}
 		return nil
 	}); err != nil {
@@ -757,9 +900,66 @@ func (c *Cache) ApplyLayer(
 		}
 		acctResetDuration.Observe(float64(time.Since(t2)))
 	}
+	c.evict(logger, time.Now())
 	return nil
 }
 
+// evict removes expired transactions and keeps the mempool within the limits.
+func (c *Cache) evict(logger log.Log, now time.Time) {
+	for addr, ac := range c.pending {
+		n, dropped := ac.evict(logger, events.EvictedExpired, func(cand *candidate) bool {
+			return c.policy.Expired(cand.best, now)
+		})
+		if n == 0 {
+			continue
+		}
+		mempoolTxCount.WithLabelValues(expired).Add(float64(n))
+		mempoolTxCount.WithLabelValues(nonceGap).Add(float64(dropped))
+		if ac.shouldEvict() {
+			delete(c.pending, addr)
+		}
+	}
+	c.evictOverLimit(logger)
+}
+
+func (c *Cache) overLimit() bool {
+	return (c.cfg.MaxTXs > 0 && len(c.cachedTXs) > c.cfg.MaxTXs) ||
+		(c.cfg.MaxBytes > 0 && c.cachedBytes > c.cfg.MaxBytes)
+}
+
+// evictOverLimit evicts transactions with the highest nonce of the account, selected by the policy,
+// until the mempool is within limits. Transactions included into proposals or blocks are not evicted.
+func (c *Cache) evictOverLimit(logger log.Log) {
+	for c.overLimit() {
+		var (
+			victim *accountCache
+			worst  *candidate
+		)
+		for _, ac := range c.pending {
+			if ac.txsByNonce.Len() == 0 {
+				continue
+			}
+			last := ac.txsByNonce.Back().Value.(*candidate)
+			if last.layer() != 0 {
+				continue
+			}
+			if worst == nil || c.policy.Less(last.best, worst.best) {
+				victim, worst = ac, last
+			}
+		}
+		if victim == nil {
+			return
+		}
+		victim.evict(logger, events.EvictedPoolFull, func(cand *candidate) bool {
+			return cand == worst
+		})
+		mempoolTxCount.WithLabelValues(evicted).Inc()
+		if victim.shouldEvict() {
+			delete(c.pending, victim.addr)
+		}
+	}
+}
+
 func (c *Cache) RevertToLayer(db *sql.Database, revertTo types.LayerID) error {
 	if err := undoLayers(db, revertTo.Add(1)); err != nil {
 		return err
@@ -786,6 +986,12 @@ func (c *Cache) GetProjection(addr types.Address) (uint64, uint64) {
 
 // GetMempool returns all the transactions that eligible for a proposal/block.
 func (c *Cache) GetMempool(logger log.Log) map[types.Address][]*NanoTX {
+	return c.getMempool(logger, false)
+}
+
+// getMempool returns transactions in the mempool. If copied is true the transactions are copied,
+// and can be used after the cache updates them.
+func (c *Cache) getMempool(logger log.Log, copied bool) map[types.Address][]*NanoTX {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
@@ -793,13 +999,29 @@ func (c *Cache) GetMempool(logger log.Log) map[types.Address][]*NanoTX {
 	logger.With().Debug("cache has pending accounts", log.Int("num_acct", len(c.pending)))
 	for addr, accCache := range c.pending {
 		txs := accCache.getMempool(logger.WithFields(addr))
-		if len(txs) > 0 {
-			all[addr] = txs
+		if len(txs) == 0 {
+			continue
+		}
+		if copied {
+			for i, ntx := range txs {
+				cp := *ntx
+				txs[i] = &cp
+			}
 		}
+		all[addr] = txs
 	}
 	return all
 }
 
+// mempoolCopy is a conStateCache that returns copies of the transactions in the mempool.
+type mempoolCopy struct {
+	cache *Cache
+}
+
+func (m mempoolCopy) GetMempool(logger log.Log) map[types.Address][]*NanoTX {
+	return m.cache.getMempool(logger, true)
+}
+
 // checkApplyOrder returns an error if layers were not applied in order.
 func checkApplyOrder(logger log.Log, db *sql.Database, toApply types.LayerID) error {
 	lastApplied, err := layers.GetLastApplied(db)
@@ -20,12 +20,14 @@ import (
 type CSConfig struct {
 	BlockGasLimit     uint64
 	NumTXsPerProposal int
+	Mempool           MempoolConfig
 }
 
 func defaultCSConfig() CSConfig {
 	return CSConfig{
 		BlockGasLimit:     math.MaxUint64,
 		NumTXsPerProposal: 100,
+		Mempool:           DefaultMempoolConfig(),
 	}
 }
 
@@ -39,6 +41,13 @@ func WithCSConfig(cfg CSConfig) ConservativeStateOpt {
 	}
 }
 
+// WithMempoolPolicy overwrites the eviction policy created from the mempool config.
+func WithMempoolPolicy(policy EvictionPolicy) ConservativeStateOpt {
+	return func(cs *ConservativeState) {
+		cs.policy = policy
+	}
+}
+
 // WithLogger defines logger for conservative state.
 func WithLogger(logger log.Log) ConservativeStateOpt {
 	return func(cs *ConservativeState) {
@@ -53,6 +62,7 @@ type ConservativeState struct {
 
 	logger log.Log
 	cfg    CSConfig
+	policy EvictionPolicy
 	db     *sql.Database
 	cache  *Cache
 }
@@ -68,7 +78,11 @@ func NewConservativeState(state vmState, db *sql.Database, opts ...ConservativeS
 	for _, opt := range opts {
 		opt(cs)
 	}
-	cs.cache = NewCache(cs.getState, cs.logger)
+	cacheOpts := []CacheOpt{WithMempoolConfig(cs.cfg.Mempool)}
+	if cs.policy != nil {
+		cacheOpts = append(cacheOpts, WithEvictionPolicy(cs.policy))
+	}
+	cs.cache = NewCache(cs.getState, cs.logger, cacheOpts...)
 	return cs
 }
 
@@ -147,6 +161,42 @@ func (cs *ConservativeState) GetProjection(addr types.Address) (uint64, uint64)
 	return cs.cache.GetProjection(addr)
 }
 
+// PendingTX is a transaction in the mempool.
+type PendingTX struct {
+	// NanoTX is a copy of the transaction in the mempool, it is not updated by the mempool.
+	NanoTX
+	// Position of the transaction in the order it would be selected into a proposal
+	// if the gas limit is not reached.
+	Position int
+}
+
+// Projection is an account nonce and balance after applying all pending transactions.
+type Projection struct {
+	Nonce, Balance uint64
+}
+
+// GetPendingTXs returns transactions in the mempool in the order they would be selected into a proposal.
+// If principal is not empty only transactions of that principal are returned.
+func (cs *ConservativeState) GetPendingTXs(principal types.Address) ([]PendingTX, map[types.Address]Projection) {
+	mi := newMempoolIterator(cs.logger, mempoolCopy{cache: cs.cache}, math.MaxUint64)
+	ordered, _ := mi.PopAll()
+	var (
+		rst         []PendingTX
+		projections = map[types.Address]Projection{}
+	)
+	for i, ntx := range ordered {
+		if !principal.IsEmpty() && ntx.Principal != principal {
+			continue
+		}
+		rst = append(rst, PendingTX{NanoTX: *ntx, Position: i})
+		if _, exist := projections[ntx.Principal]; !exist {
+			nonce, balance := cs.cache.GetProjection(ntx.Principal)
+			projections[ntx.Principal] = Projection{Nonce: nonce, Balance: balance}
+		}
+	}
+	return rst, projections
+}
+
 // LinkTXsWithProposal associates the transactions to a proposal.
 func (cs *ConservativeState) LinkTXsWithProposal(lid types.LayerID, pid types.ProposalID, tids []types.TransactionID) error {
 	return cs.cache.LinkTXsWithProposal(cs.db, lid, pid, tids)
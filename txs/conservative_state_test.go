@@ -345,6 +345,64 @@ func TestGetProjection(t *testing.T) {
 	require.EqualValues(t, defaultBalance-2*(defaultAmount+defaultFee*defaultGas), balance)
 }
 
+func TestGetPendingTXs(t *testing.T) {
+	tcs := createConservativeState(t)
+	var (
+		addrs []types.Address
+		txs   []*types.Transaction
+	)
+	for i := 0; i < 2; i++ {
+		signer, err := signing.NewEdSigner()
+		require.NoError(t, err)
+		addr := types.GenerateAddress(signer.PublicKey().Bytes())
+		tcs.mvm.EXPECT().GetBalance(addr).Return(defaultBalance, nil).Times(1)
+		tcs.mvm.EXPECT().GetNonce(addr).Return(nonce, nil).Times(1)
+		addrs = append(addrs, addr)
+		// second principal pays higher fee for the first transaction
+		// and lower fee for the second.
+		fees := []uint64{defaultFee, defaultFee - 1}
+		if i == 1 {
+			fees = []uint64{defaultFee + 1, defaultFee - 2}
+		}
+		for j, fee := range fees {
+			tx := newTx(t, nonce+uint64(j), defaultAmount, fee, signer)
+			require.NoError(t, tcs.AddToCache(context.Background(), tx, time.Now()))
+			txs = append(txs, tx)
+		}
+	}
+
+	pending, projections := tcs.GetPendingTXs(types.Address{})
+	require.Len(t, pending, 4)
+	for i, expected := range []*types.Transaction{txs[2], txs[0], txs[1], txs[3]} {
+		require.Equal(t, expected.ID, pending[i].ID)
+		require.Equal(t, i, pending[i].Position)
+	}
+	require.Len(t, projections, 2)
+	for i, addr := range addrs {
+		require.Equal(t, nonce+2, projections[addr].Nonce)
+		require.Equal(t, defaultBalance-txs[2*i].Spending()-txs[2*i+1].Spending(), projections[addr].Balance)
+	}
+
+	pending, projections = tcs.GetPendingTXs(addrs[0])
+	require.Len(t, pending, 2)
+	require.Equal(t, txs[0].ID, pending[0].ID)
+	require.Equal(t, 1, pending[0].Position)
+	require.Equal(t, txs[1].ID, pending[1].ID)
+	require.Equal(t, 2, pending[1].Position)
+	require.Len(t, projections, 1)
+	require.Contains(t, projections, addrs[0])
+
+	pending, projections = tcs.GetPendingTXs(types.GenerateAddress([]byte{1}))
+	require.Empty(t, pending)
+	require.Empty(t, projections)
+
+	// returned transactions are copies that are not updated by the mempool
+	pending, _ = tcs.GetPendingTXs(types.Address{})
+	require.Equal(t, txs[2].ID, pending[0].ID)
+	require.NoError(t, tcs.LinkTXsWithProposal(types.LayerID(10), types.ProposalID{1}, []types.TransactionID{txs[2].ID}))
+	require.Zero(t, pending[0].Layer)
+}
+
 func TestAddToCache(t *testing.T) {
 	tcs := createConservativeState(t)
 	signer, err := signing.NewEdSigner()
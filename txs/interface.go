@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"github.com/spacemeshos/go-spacemesh/common/types"
+	vm "github.com/spacemeshos/go-spacemesh/genvm"
 	"github.com/spacemeshos/go-spacemesh/log"
 	"github.com/spacemeshos/go-spacemesh/system"
 )
@@ -27,6 +28,12 @@ type vmState interface {
 	GetAllAccounts() ([]*types.Account, error)
 	GetBalance(types.Address) (uint64, error)
 	GetNonce(types.Address) (types.Nonce, error)
+	GetAccount(types.Address, types.LayerID) (types.Account, error)
+	GetAccountHistory(types.Address, types.LayerID, int) ([]*types.Account, error)
+	GetAccountsRoot(types.LayerID) (types.LayerID, types.Hash32, error)
+	GetAccountProof(types.Address, types.LayerID) (*vm.AccountProof, error)
+	Simulate(types.LayerID, types.RawTx, bool) (*types.TransactionWithResult, error)
+	EstimateGas([]byte) (*vm.GasEstimate, error)
 }
 
 type conStateCache interface {
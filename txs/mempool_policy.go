@@ -0,0 +1,78 @@
+package txs
+
+import (
+	"bytes"
+	"time"
+)
+
+// MempoolConfig defines limits and eviction rules for the mempool.
+type MempoolConfig struct {
+	// MaxTXs is the maximal number of transactions in the mempool. Zero disables the limit.
+	MaxTXs int `mapstructure:"max-txs"`
+	// MaxBytes is the maximal total size of transactions in the mempool. Zero disables the limit.
+	MaxBytes int `mapstructure:"max-bytes"`
+	// MaxTXsPerAccount is the maximal number of pending nonces for a single principal.
+	// Transactions with higher nonces are kept only in the database.
+	MaxTXsPerAccount int `mapstructure:"max-txs-per-account"`
+	// MinFeeBump is the percent by which fee must be increased to replace
+	// transaction with the same nonce.
+	MinFeeBump uint64 `mapstructure:"min-fee-bump"`
+	// TTL of the transaction that was never included into a proposal or a block.
+	// Transactions with higher nonces of the same principal are evicted together with
+	// the expired one, until its nonce is filled by a new transaction. Zero disables expiry.
+	TTL time.Duration `mapstructure:"ttl"`
+}
+
+// DefaultMempoolConfig returns default configuration for the mempool.
+func DefaultMempoolConfig() MempoolConfig {
+	return MempoolConfig{
+		MaxTXs:           100_000,
+		MaxBytes:         128 << 20,
+		MaxTXsPerAccount: maxTXsPerAcct,
+		MinFeeBump:       10,
+	}
+}
+
+// EvictionPolicy decides which transactions are replaced or evicted from the mempool.
+type EvictionPolicy interface {
+	// Replace is consulted when a new transaction is better than the current one for the same nonce.
+	// It returns false if the transaction should not be replaced.
+	Replace(current, candidate *NanoTX) bool
+	// Expired returns true if transaction should be evicted from the mempool.
+	Expired(ntx *NanoTX, now time.Time) bool
+	// Less returns true if transaction a should be evicted before b when the mempool is full.
+	Less(a, b *NanoTX) bool
+}
+
+// DefaultPolicy evicts transactions according to the MempoolConfig.
+type DefaultPolicy struct {
+	cfg MempoolConfig
+}
+
+// NewDefaultPolicy returns eviction policy that follows config.
+func NewDefaultPolicy(cfg MempoolConfig) *DefaultPolicy {
+	return &DefaultPolicy{cfg: cfg}
+}
+
+// Replace requires the fee of the candidate to be higher by at least MinFeeBump percent.
+func (p *DefaultPolicy) Replace(current, candidate *NanoTX) bool {
+	fee := current.Fee()
+	// fee * MinFeeBump / 100 without overflow for large fees
+	return candidate.Fee()-fee >= fee/100*p.cfg.MinFeeBump+fee%100*p.cfg.MinFeeBump/100
+}
+
+// Expired returns true if transaction was not included into a proposal or a block during TTL.
+func (p *DefaultPolicy) Expired(ntx *NanoTX, now time.Time) bool {
+	return p.cfg.TTL > 0 && ntx.Layer == 0 && now.Sub(ntx.Received) > p.cfg.TTL
+}
+
+// Less evicts transactions with lower gas price first, and the most recent among equal ones.
+func (p *DefaultPolicy) Less(a, b *NanoTX) bool {
+	if a.GasPrice != b.GasPrice {
+		return a.GasPrice < b.GasPrice
+	}
+	if !a.Received.Equal(b.Received) {
+		return a.Received.After(b.Received)
+	}
+	return bytes.Compare(a.ID[:], b.ID[:]) > 0
+}
@@ -0,0 +1,230 @@
+package txs
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/spacemeshos/go-spacemesh/common/types"
+	"github.com/spacemeshos/go-spacemesh/events"
+	"github.com/spacemeshos/go-spacemesh/log/logtest"
+	"github.com/spacemeshos/go-spacemesh/sql"
+	"github.com/spacemeshos/go-spacemesh/sql/layers"
+	"github.com/spacemeshos/go-spacemesh/sql/transactions"
+)
+
+func createCacheWithConfig(t *testing.T, numAccounts int, cfg MempoolConfig) (*testCache, []*testAcct) {
+	t.Helper()
+	accounts := createState(t, numAccounts)
+	var all []*testAcct
+	for _, ta := range accounts {
+		all = append(all, ta)
+	}
+	return &testCache{
+		Cache: NewCache(getStateFunc(accounts), logtest.New(t), WithMempoolConfig(cfg)),
+		db:    sql.InMemory(),
+	}, all
+}
+
+func newMeshTXWithFee(t *testing.T, ta *testAcct, nonce, fee uint64, received time.Time) *types.MeshTransaction {
+	t.Helper()
+	return &types.MeshTransaction{
+		Transaction: *newTx(t, nonce, defaultAmount, fee, ta.signer),
+		Received:    received,
+	}
+}
+
+func subscribeEvicted(t *testing.T) <-chan events.EventEvicted {
+	t.Helper()
+	events.InitializeReporter()
+	t.Cleanup(events.CloseEventReporter)
+	sub, err := events.Subscribe[events.EventEvicted](events.WithBuffer(100))
+	require.NoError(t, err)
+	t.Cleanup(sub.Close)
+	return sub.Out()
+}
+
+func requireEvicted(t *testing.T, out <-chan events.EventEvicted, id types.TransactionID, reason events.EvictionReason) {
+	t.Helper()
+	select {
+	case ev := <-out:
+		require.Equal(t, id, ev.ID)
+		require.Equal(t, reason, ev.Reason)
+	case <-time.After(time.Second):
+		require.FailNow(t, "timed out waiting for eviction event")
+	}
+}
+
+func TestDefaultPolicy_Replace(t *testing.T) {
+	tx := func(price uint64) *NanoTX {
+		return &NanoTX{TxHeader: types.TxHeader{MaxGas: defaultGas, GasPrice: price}}
+	}
+	for _, tc := range []struct {
+		desc             string
+		bump             uint64
+		current, replace uint64
+		expect           bool
+	}{
+		{desc: "no bump", bump: 0, current: 10, replace: 10, expect: true},
+		{desc: "below bump", bump: 10, current: 10, replace: 10, expect: false},
+		{desc: "exact bump", bump: 10, current: 10, replace: 11, expect: true},
+		{desc: "above bump", bump: 50, current: 10, replace: 20, expect: true},
+		{desc: "large fee", bump: 10, current: 1 << 50, replace: 1<<50 + 1<<47, expect: true},
+	} {
+		tc := tc
+		t.Run(tc.desc, func(t *testing.T) {
+			policy := NewDefaultPolicy(MempoolConfig{MinFeeBump: tc.bump})
+			require.Equal(t, tc.expect, policy.Replace(tx(tc.current), tx(tc.replace)))
+		})
+	}
+}
+
+func TestDefaultPolicy_Expired(t *testing.T) {
+	now := time.Now()
+	policy := NewDefaultPolicy(MempoolConfig{TTL: time.Minute})
+	require.False(t, policy.Expired(&NanoTX{Received: now.Add(-time.Second)}, now))
+	require.True(t, policy.Expired(&NanoTX{Received: now.Add(-2 * time.Minute)}, now))
+	require.False(t, policy.Expired(&NanoTX{Received: now.Add(-2 * time.Minute), Layer: 10}, now))
+
+	disabled := NewDefaultPolicy(MempoolConfig{})
+	require.False(t, disabled.Expired(&NanoTX{Received: now.Add(-time.Hour)}, now))
+}
+
+func TestCache_ReplaceRequiresFeeBump(t *testing.T) {
+	evicted := subscribeEvicted(t)
+	tc, accounts := createCacheWithConfig(t, 1, MempoolConfig{MinFeeBump: 50})
+	ta := accounts[0]
+
+	first := newMeshTXWithFee(t, ta, ta.nonce, 4, time.Now())
+	require.NoError(t, tc.Add(context.Background(), tc.db, &first.Transaction, first.Received, false))
+
+	cheap := newMeshTXWithFee(t, ta, ta.nonce, 5, time.Now())
+	require.NoError(t, tc.Add(context.Background(), tc.db, &cheap.Transaction, cheap.Received, false))
+	checkTX(t, tc.Cache, first.ID, 0, types.EmptyBlockID)
+	checkNoTX(t, tc.Cache, cheap.ID)
+
+	better := newMeshTXWithFee(t, ta, ta.nonce, 6, time.Now())
+	require.NoError(t, tc.Add(context.Background(), tc.db, &better.Transaction, better.Received, false))
+	checkTX(t, tc.Cache, better.ID, 0, types.EmptyBlockID)
+	checkNoTX(t, tc.Cache, first.ID)
+	requireEvicted(t, evicted, first.ID, events.EvictedReplaced)
+	checkTXStateFromDB(t, tc.db, []*types.MeshTransaction{first, cheap, better}, types.MEMPOOL)
+}
+
+func TestCache_ExpiredNotLoaded(t *testing.T) {
+	tc, accounts := createCacheWithConfig(t, 1, MempoolConfig{TTL: time.Minute})
+	ta := accounts[0]
+	now := time.Now()
+	alive := newMeshTXWithFee(t, ta, ta.nonce, defaultFee, now)
+	expired := newMeshTXWithFee(t, ta, ta.nonce+1, defaultFee, now.Add(-2*time.Minute))
+	next := newMeshTXWithFee(t, ta, ta.nonce+2, defaultFee, now)
+	saveTXs(t, tc.db, []*types.MeshTransaction{alive, expired, next})
+
+	require.NoError(t, tc.buildFromScratch(tc.db))
+	checkTX(t, tc.Cache, alive.ID, 0, types.EmptyBlockID)
+	checkNoTX(t, tc.Cache, expired.ID)
+	// loading stops at the first expired nonce
+	checkNoTX(t, tc.Cache, next.ID)
+	require.True(t, tc.MoreInDB(ta.principal))
+
+	// once the gap is filled the rest is loaded
+	replacement := newMeshTXWithFee(t, ta, ta.nonce+1, defaultFee+1, now)
+	saveTXs(t, tc.db, []*types.MeshTransaction{replacement})
+	require.NoError(t, tc.buildFromScratch(tc.db))
+	checkTX(t, tc.Cache, replacement.ID, 0, types.EmptyBlockID)
+	checkTX(t, tc.Cache, next.ID, 0, types.EmptyBlockID)
+	require.False(t, tc.MoreInDB(ta.principal))
+}
+
+func TestCache_ExpiredEvictedAfterApply(t *testing.T) {
+	evicted := subscribeEvicted(t)
+	tc, accounts := createCacheWithConfig(t, 1, MempoolConfig{TTL: time.Minute})
+	ta := accounts[0]
+	now := time.Now()
+	included := newMeshTXWithFee(t, ta, ta.nonce, defaultFee, now.Add(-2*time.Minute))
+	old := newMeshTXWithFee(t, ta, ta.nonce+1, defaultFee, now.Add(-2*time.Minute))
+	fresh := newMeshTXWithFee(t, ta, ta.nonce+2, defaultFee, now)
+	for _, mtx := range []*types.MeshTransaction{included, old, fresh} {
+		require.NoError(t, tc.Add(context.Background(), tc.db, &mtx.Transaction, mtx.Received, false))
+	}
+	lid := types.LayerID(10)
+	require.NoError(t, tc.LinkTXsWithProposal(tc.db, lid.Add(1), types.ProposalID{1}, []types.TransactionID{included.ID}))
+
+	require.NoError(t, layers.SetApplied(tc.db, lid.Sub(1), types.RandomBlockID()))
+	require.NoError(t, tc.ApplyLayer(context.Background(), tc.db, lid, types.EmptyBlockID, nil, nil))
+	checkTX(t, tc.Cache, included.ID, lid.Add(1), types.EmptyBlockID)
+	checkNoTX(t, tc.Cache, old.ID)
+	// higher nonce can't be applied after the gap
+	checkNoTX(t, tc.Cache, fresh.ID)
+	requireEvicted(t, evicted, old.ID, events.EvictedExpired)
+	requireEvicted(t, evicted, fresh.ID, events.EvictedNonceGap)
+	require.True(t, tc.MoreInDB(ta.principal))
+
+	// balance of the evicted transactions is available again
+	checkProjection(t, tc.Cache, ta.principal, ta.nonce+1, ta.balance-included.Spending())
+}
+
+func TestCache_EvictOverLimit(t *testing.T) {
+	evicted := subscribeEvicted(t)
+	tc, accounts := createCacheWithConfig(t, 2, MempoolConfig{MaxTXs: 3})
+	first, second := accounts[0], accounts[1]
+
+	now := time.Now()
+	mtxs := []*types.MeshTransaction{
+		newMeshTXWithFee(t, first, first.nonce, 10, now),
+		newMeshTXWithFee(t, first, first.nonce+1, 10, now),
+		newMeshTXWithFee(t, second, second.nonce, 5, now),
+	}
+	for _, mtx := range mtxs {
+		require.NoError(t, tc.Add(context.Background(), tc.db, &mtx.Transaction, mtx.Received, false))
+	}
+	checkMempoolSize(t, tc.Cache, 3)
+
+	// tail of the first account has higher gas price, second account is evicted
+	last := newMeshTXWithFee(t, first, first.nonce+2, 7, now)
+	require.NoError(t, tc.Add(context.Background(), tc.db, &last.Transaction, last.Received, false))
+	checkMempoolSize(t, tc.Cache, 3)
+	checkNoTX(t, tc.Cache, mtxs[2].ID)
+	requireEvicted(t, evicted, mtxs[2].ID, events.EvictedPoolFull)
+
+	// transaction with the lowest gas price is evicted right away
+	cheap := newMeshTXWithFee(t, second, second.nonce, 1, now)
+	require.NoError(t, tc.Add(context.Background(), tc.db, &cheap.Transaction, cheap.Received, false))
+	checkMempoolSize(t, tc.Cache, 3)
+	checkNoTX(t, tc.Cache, cheap.ID)
+	requireEvicted(t, evicted, cheap.ID, events.EvictedPoolFull)
+
+	// evicted transactions are still persisted
+	checkTXStateFromDB(t, tc.db, append(mtxs, last, cheap), types.MEMPOOL)
+}
+
+func TestCache_EvictOverBytesLimit(t *testing.T) {
+	tc, accounts := createCacheWithConfig(t, 1, MempoolConfig{})
+	ta := accounts[0]
+	mtxs := genTXs(t, ta.signer, ta.nonce, ta.nonce+3, time.Now())
+	tc.cfg.MaxBytes = 2 * len(mtxs[0].Raw)
+	for _, mtx := range mtxs {
+		require.NoError(t, tc.Add(context.Background(), tc.db, &mtx.Transaction, mtx.Received, false))
+	}
+	checkMempoolSize(t, tc.Cache, 2)
+	checkTX(t, tc.Cache, mtxs[0].ID, 0, types.EmptyBlockID)
+	checkTX(t, tc.Cache, mtxs[1].ID, 0, types.EmptyBlockID)
+	require.Equal(t, tc.cfg.MaxBytes, tc.cachedBytes)
+}
+
+func TestCache_MaxTXsPerAccount(t *testing.T) {
+	tc, accounts := createCacheWithConfig(t, 1, MempoolConfig{MaxTXsPerAccount: 2})
+	ta := accounts[0]
+	mtxs := genTXs(t, ta.signer, ta.nonce, ta.nonce+2, time.Now())
+	for _, mtx := range mtxs {
+		require.NoError(t, tc.Add(context.Background(), tc.db, &mtx.Transaction, mtx.Received, false))
+	}
+	checkMempoolSize(t, tc.Cache, 2)
+	checkNoTX(t, tc.Cache, mtxs[2].ID)
+	require.True(t, tc.MoreInDB(ta.principal))
+	has, err := transactions.Has(tc.db, mtxs[2].ID)
+	require.NoError(t, err)
+	require.True(t, has)
+}
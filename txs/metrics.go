@@ -24,6 +24,9 @@ const (
 	mempool         = "mempool"
 	balanceTooSmall = "balance"
 	tooManyNonce    = "too_many"
+	evicted         = "evicted"
+	expired         = "expired"
+	nonceGap        = "nonce_gap"
 	accepted        = "ok"
 )
 
@@ -13,6 +13,8 @@ import (
 type NanoTX struct {
 	types.TxHeader
 	ID types.TransactionID
+	// Size of the raw transaction.
+	Size int
 
 	Received time.Time
 
@@ -25,6 +27,7 @@ func NewNanoTX(mtx *types.MeshTransaction) *NanoTX {
 	return &NanoTX{
 		ID:       mtx.ID,
 		TxHeader: *mtx.TxHeader,
+		Size:     len(mtx.Raw),
 		Received: mtx.Received,
 		Block:    mtx.BlockID,
 		Layer:    mtx.LayerID,
@@ -11,6 +11,7 @@ import (
 
 	gomock "github.com/golang/mock/gomock"
 	types "github.com/spacemeshos/go-spacemesh/common/types"
+	genvm "github.com/spacemeshos/go-spacemesh/genvm"
 	log "github.com/spacemeshos/go-spacemesh/log"
 	system "github.com/spacemeshos/go-spacemesh/system"
 )
@@ -133,6 +134,82 @@ func (m *MockvmState) EXPECT() *MockvmStateMockRecorder {
 	return m.recorder
 }
 
+// EstimateGas mocks base method.
+func (m *MockvmState) EstimateGas(arg0 []byte) (*genvm.GasEstimate, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "EstimateGas", arg0)
+	ret0, _ := ret[0].(*genvm.GasEstimate)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// EstimateGas indicates an expected call of EstimateGas.
+func (mr *MockvmStateMockRecorder) EstimateGas(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EstimateGas", reflect.TypeOf((*MockvmState)(nil).EstimateGas), arg0)
+}
+
+// GetAccount mocks base method.
+func (m *MockvmState) GetAccount(arg0 types.Address, arg1 types.LayerID) (types.Account, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAccount", arg0, arg1)
+	ret0, _ := ret[0].(types.Account)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAccount indicates an expected call of GetAccount.
+func (mr *MockvmStateMockRecorder) GetAccount(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAccount", reflect.TypeOf((*MockvmState)(nil).GetAccount), arg0, arg1)
+}
+
+// GetAccountHistory mocks base method.
+func (m *MockvmState) GetAccountHistory(arg0 types.Address, arg1 types.LayerID, arg2 int) ([]*types.Account, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAccountHistory", arg0, arg1, arg2)
+	ret0, _ := ret[0].([]*types.Account)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAccountHistory indicates an expected call of GetAccountHistory.
+func (mr *MockvmStateMockRecorder) GetAccountHistory(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAccountHistory", reflect.TypeOf((*MockvmState)(nil).GetAccountHistory), arg0, arg1, arg2)
+}
+
+// GetAccountProof mocks base method.
+func (m *MockvmState) GetAccountProof(arg0 types.Address, arg1 types.LayerID) (*genvm.AccountProof, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAccountProof", arg0, arg1)
+	ret0, _ := ret[0].(*genvm.AccountProof)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAccountProof indicates an expected call of GetAccountProof.
+func (mr *MockvmStateMockRecorder) GetAccountProof(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAccountProof", reflect.TypeOf((*MockvmState)(nil).GetAccountProof), arg0, arg1)
+}
+
+// GetAccountsRoot mocks base method.
+func (m *MockvmState) GetAccountsRoot(arg0 types.LayerID) (types.LayerID, types.Hash32, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAccountsRoot", arg0)
+	ret0, _ := ret[0].(types.LayerID)
+	ret1, _ := ret[1].(types.Hash32)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// GetAccountsRoot indicates an expected call of GetAccountsRoot.
+func (mr *MockvmStateMockRecorder) GetAccountsRoot(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAccountsRoot", reflect.TypeOf((*MockvmState)(nil).GetAccountsRoot), arg0)
+}
+
 // GetAllAccounts mocks base method.
 func (m *MockvmState) GetAllAccounts() ([]*types.Account, error) {
 	m.ctrl.T.Helper()
@@ -223,6 +300,21 @@ func (mr *MockvmStateMockRecorder) GetStateRoot() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetStateRoot", reflect.TypeOf((*MockvmState)(nil).GetStateRoot))
 }
 
+// Simulate mocks base method.
+func (m *MockvmState) Simulate(arg0 types.LayerID, arg1 types.RawTx, arg2 bool) (*types.TransactionWithResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Simulate", arg0, arg1, arg2)
+	ret0, _ := ret[0].(*types.TransactionWithResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Simulate indicates an expected call of Simulate.
+func (mr *MockvmStateMockRecorder) Simulate(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Simulate", reflect.TypeOf((*MockvmState)(nil).Simulate), arg0, arg1, arg2)
+}
+
 // Validation mocks base method.
 func (m *MockvmState) Validation(arg0 types.RawTx) system.ValidationRequest {
 	m.ctrl.T.Helper()